@@ -21,12 +21,16 @@ import (
 	"net/url"
 	"strings"
 
+	dockerterm "github.com/docker/docker/pkg/term"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 	"golang.org/x/net/context"
 	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 )
 
+// defaultDetachKeys is the default key sequence for detaching from a container.
+const defaultDetachKeys = "ctrl-p,ctrl-q"
+
 var runtimeAttachCommand = cli.Command{
 	Name:                   "attach",
 	Usage:                  "Attach to a running container",
@@ -42,6 +46,15 @@ var runtimeAttachCommand = cli.Command{
 			Name:  "stdin,i",
 			Usage: "Keep STDIN open",
 		},
+		cli.BoolFlag{
+			Name:  "no-stdin",
+			Usage: "Do not attach STDIN, even with --stdin",
+		},
+		cli.StringFlag{
+			Name:  "detach-keys",
+			Value: defaultDetachKeys,
+			Usage: "Key sequence for detaching from the container when a TTY is allocated",
+		},
 	},
 	Action: func(context *cli.Context) error {
 		id := context.Args().First()
@@ -52,13 +65,22 @@ var runtimeAttachCommand = cli.Command{
 		if err := getRuntimeClient(context); err != nil {
 			return err
 		}
+		id, err := resolveContainerID(runtimeClient, id)
+		if err != nil {
+			return err
+		}
 
+		detachKeys, err := dockerterm.ToBytes(context.String("detach-keys"))
+		if err != nil {
+			return fmt.Errorf("invalid detach keys %q: %v", context.String("detach-keys"), err)
+		}
 		var opts = attachOptions{
-			id:    id,
-			tty:   context.Bool("tty"),
-			stdin: context.Bool("stdin"),
+			id:         id,
+			tty:        context.Bool("tty"),
+			stdin:      attachStdin(context),
+			detachKeys: detachKeys,
 		}
-		err := Attach(runtimeClient, opts)
+		err = Attach(runtimeClient, opts)
 		if err != nil {
 			return fmt.Errorf("attaching running container failed: %v", err)
 
@@ -69,6 +91,12 @@ var runtimeAttachCommand = cli.Command{
 	After: closeConnection,
 }
 
+// attachStdin returns whether STDIN is attached: with --stdin, unless
+// --no-stdin is set.
+func attachStdin(context *cli.Context) bool {
+	return context.Bool("stdin") && !context.Bool("no-stdin")
+}
+
 // Attach sends an AttachRequest to server, and parses the returned AttachResponse
 func Attach(client pb.RuntimeServiceClient, opts attachOptions) error {
 	if opts.id == "" {
@@ -98,5 +126,5 @@ func Attach(client pb.RuntimeServiceClient, opts attachOptions) error {
 		return err
 	}
 	logrus.Debugf("Attach URL: %v", URL)
-	return stream(opts.stdin, opts.tty, URL)
+	return stream(opts.stdin, opts.tty, opts.detachKeys, URL)
 }
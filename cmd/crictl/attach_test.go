@@ -0,0 +1,54 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestAttachStdin(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected bool
+	}{
+		{nil, false},
+		{[]string{"-i"}, true},
+		{[]string{"--stdin"}, true},
+		{[]string{"--no-stdin"}, false},
+		{[]string{"-i", "--no-stdin"}, false},
+	}
+	for _, tc := range testCases {
+		var actual bool
+		command := runtimeAttachCommand
+		command.Action = func(context *cli.Context) error {
+			actual = attachStdin(context)
+			return nil
+		}
+		command.After = nil
+		app := cli.NewApp()
+		app.Commands = []cli.Command{command}
+		args := append(append([]string{"crictl", "attach"}, tc.args...), "id")
+		if err := app.Run(args); err != nil {
+			t.Fatalf("failed to run %v: %v", args, err)
+		}
+		if actual != tc.expected {
+			t.Errorf("expected stdin %v for %v; actual stdin is %v", tc.expected, tc.args, actual)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+	"golang.org/x/net/context"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// selectFlags select the containers or pods of the stop and remove commands
+// instead of giving their IDs.
+var selectFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "all, a",
+		Usage: "Select all the containers or pods",
+	},
+	cli.StringFlag{
+		Name:  "state",
+		Usage: "Select the containers or pods in the given state",
+	},
+	cli.StringSliceFlag{
+		Name:  "label",
+		Usage: "Select the containers or pods with the key=value label",
+	},
+	cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "Print the IDs of the containers or pods instead of acting on them",
+	},
+}
+
+// selectOptions are the options of selectFlags.
+type selectOptions struct {
+	all    bool
+	state  string
+	labels map[string]string
+	dryRun bool
+}
+
+// isSet returns whether any container or pod is selected by the options.
+func (o selectOptions) isSet() bool {
+	return o.all || o.state != "" || len(o.labels) > 0
+}
+
+// parseSelectOptions parses the selectFlags of the command.
+func parseSelectOptions(context *cli.Context) (selectOptions, error) {
+	labels, err := parseLabelStringSlice(context.StringSlice("label"))
+	if err != nil {
+		return selectOptions{}, err
+	}
+	opts := selectOptions{
+		all:    context.Bool("all"),
+		state:  context.String("state"),
+		labels: labels,
+		dryRun: context.Bool("dry-run"),
+	}
+	if opts.isSet() && context.NArg() > 0 {
+		return selectOptions{}, fmt.Errorf("IDs can't be given along with --all, --state or --label")
+	}
+	return opts, nil
+}
+
+// parseContainerState parses the container state of the --state flag.
+func parseContainerState(state string) (pb.ContainerState, error) {
+	switch strings.ToLower(state) {
+	case "created":
+		return pb.ContainerState_CONTAINER_CREATED, nil
+	case "running":
+		return pb.ContainerState_CONTAINER_RUNNING, nil
+	case "exited":
+		return pb.ContainerState_CONTAINER_EXITED, nil
+	case "unknown":
+		return pb.ContainerState_CONTAINER_UNKNOWN, nil
+	default:
+		return pb.ContainerState_CONTAINER_UNKNOWN, fmt.Errorf("--state should be one of created, running, exited or unknown")
+	}
+}
+
+// parsePodSandboxState parses the pod state of the --state flag.
+func parsePodSandboxState(state string) (pb.PodSandboxState, error) {
+	switch strings.ToLower(state) {
+	case "ready":
+		return pb.PodSandboxState_SANDBOX_READY, nil
+	case "notready":
+		return pb.PodSandboxState_SANDBOX_NOTREADY, nil
+	default:
+		return pb.PodSandboxState_SANDBOX_NOTREADY, fmt.Errorf("--state should be ready or notready")
+	}
+}
+
+// selectContainerIDs returns the resolved IDs given as arguments of the command, or
+// the IDs of the containers selected by opts. Containers in defaultState are
+// selected when neither --all nor --state is set, unless defaultState is
+// empty.
+func selectContainerIDs(client pb.RuntimeServiceClient, cliContext *cli.Context, opts selectOptions, defaultState string) ([]string, error) {
+	if !opts.isSet() {
+		return resolveContainerIDs(client, cliContext.Args())
+	}
+	filter := &pb.ContainerFilter{LabelSelector: opts.labels}
+	state := opts.state
+	if state == "" && !opts.all {
+		state = defaultState
+	}
+	if state != "" {
+		s, err := parseContainerState(state)
+		if err != nil {
+			return nil, err
+		}
+		filter.State = &pb.ContainerStateValue{State: s}
+	}
+	request := &pb.ListContainersRequest{Filter: filter}
+	logrus.Debugf("ListContainerRequest: %v", request)
+	r, err := client.ListContainers(context.Background(), request)
+	logrus.Debugf("ListContainerResponse: %v", r)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, c := range r.GetContainers() {
+		ids = append(ids, c.Id)
+	}
+	return ids, nil
+}
+
+// selectPodSandboxIDs returns the IDs given as arguments of the command, or
+// the IDs of the pods selected by opts. Pods in defaultState are selected
+// when neither --all nor --state is set, unless defaultState is empty.
+func selectPodSandboxIDs(client pb.RuntimeServiceClient, cliContext *cli.Context, opts selectOptions, defaultState string) ([]string, error) {
+	if !opts.isSet() {
+		return resolvePodSandboxIDs(client, cliContext.Args())
+	}
+	filter := &pb.PodSandboxFilter{LabelSelector: opts.labels}
+	state := opts.state
+	if state == "" && !opts.all {
+		state = defaultState
+	}
+	if state != "" {
+		s, err := parsePodSandboxState(state)
+		if err != nil {
+			return nil, err
+		}
+		filter.State = &pb.PodSandboxStateValue{State: s}
+	}
+	request := &pb.ListPodSandboxRequest{Filter: filter}
+	logrus.Debugf("ListPodSandboxRequest: %v", request)
+	r, err := client.ListPodSandbox(context.Background(), request)
+	logrus.Debugf("ListPodSandboxResponse: %v", r)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, p := range r.GetItems() {
+		ids = append(ids, p.Id)
+	}
+	return ids, nil
+}
+
+// forEachID calls fn with each of the ids, or prints them in dry run mode.
+// It goes on after failures and returns all the errors.
+func forEachID(ids []string, dryRun bool, fn func(id string) error) error {
+	var errs []error
+	for _, id := range ids {
+		if dryRun {
+			fmt.Println(id)
+			continue
+		}
+		if err := fn(id); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
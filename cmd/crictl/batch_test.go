@@ -0,0 +1,135 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+func TestParseContainerState(t *testing.T) {
+	testCases := []struct {
+		state     string
+		expected  pb.ContainerState
+		expectErr bool
+	}{
+		{state: "created", expected: pb.ContainerState_CONTAINER_CREATED},
+		{state: "Running", expected: pb.ContainerState_CONTAINER_RUNNING},
+		{state: "EXITED", expected: pb.ContainerState_CONTAINER_EXITED},
+		{state: "unknown", expected: pb.ContainerState_CONTAINER_UNKNOWN},
+		{state: "ready", expectErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.state, func(t *testing.T) {
+			state, err := parseContainerState(tc.state)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", state)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if state != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, state)
+			}
+		})
+	}
+}
+
+func TestParsePodSandboxState(t *testing.T) {
+	testCases := []struct {
+		state     string
+		expected  pb.PodSandboxState
+		expectErr bool
+	}{
+		{state: "ready", expected: pb.PodSandboxState_SANDBOX_READY},
+		{state: "NotReady", expected: pb.PodSandboxState_SANDBOX_NOTREADY},
+		{state: "running", expectErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.state, func(t *testing.T) {
+			state, err := parsePodSandboxState(tc.state)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", state)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if state != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, state)
+			}
+		})
+	}
+}
+
+func TestForEachID(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		dryRun     bool
+		failing    map[string]bool
+		called     []string
+		errorCount int
+	}{
+		{
+			desc:   "all succeed",
+			called: []string{"a", "b", "c"},
+		},
+		{
+			desc:       "failures don't stop the others",
+			failing:    map[string]bool{"a": true, "c": true},
+			called:     []string{"a", "b", "c"},
+			errorCount: 2,
+		},
+		{
+			desc:   "dry run",
+			dryRun: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var called []string
+			err := forEachID([]string{"a", "b", "c"}, tc.dryRun, func(id string) error {
+				called = append(called, id)
+				if tc.failing[id] {
+					return fmt.Errorf("%s failed", id)
+				}
+				return nil
+			})
+			if !reflect.DeepEqual(called, tc.called) {
+				t.Errorf("expected calls for %v, got %v", tc.called, called)
+			}
+			if tc.errorCount == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			agg, ok := err.(interface{ Errors() []error })
+			if !ok || len(agg.Errors()) != tc.errorCount {
+				t.Errorf("expected %d errors, got %v", tc.errorCount, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,365 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	dockerterm "github.com/docker/docker/pkg/term"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+	"golang.org/x/net/context"
+	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/output"
+	"github.com/kubernetes-sigs/cri-tools/pkg/remote"
+)
+
+// defaultCheckImage is the image pulled by the check command.
+const defaultCheckImage = "busybox:latest"
+
+// checkStatus is the result of a check of the check command.
+type checkStatus string
+
+const (
+	checkOK   checkStatus = "OK"
+	checkWarn checkStatus = "WARN"
+	checkFail checkStatus = "FAIL"
+)
+
+// color returns the ANSI color code of the status.
+func (s checkStatus) color() string {
+	switch s {
+	case checkOK:
+		return "\x1b[32m"
+	case checkWarn:
+		return "\x1b[33m"
+	default:
+		return "\x1b[31m"
+	}
+}
+
+// checkResult is a row of the diagnosis table.
+type checkResult struct {
+	name   string
+	status checkStatus
+	detail string
+}
+
+var checkCommand = cli.Command{
+	Name:  "check",
+	Usage: "Diagnose the connection to the container runtime and its configuration",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "image",
+			Value: defaultCheckImage,
+			Usage: "Image pulled to check the runtime can pull images",
+		},
+		cli.BoolFlag{
+			Name:  "skip-pull",
+			Usage: "Do not check that the image can be pulled",
+		},
+		cli.BoolFlag{
+			Name:  "no-color",
+			Usage: "Do not color the status, the status is only colored on terminals",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		results := runChecks(context)
+		color := !context.Bool("no-color") && dockerterm.IsTerminal(os.Stdout.Fd())
+		if err := printCheckResults(results, color); err != nil {
+			return err
+		}
+		failed := 0
+		for _, r := range results {
+			if r.status == checkFail {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d checks failed", failed, len(results))
+		}
+		return nil
+	},
+	After: closeConnection,
+}
+
+// runChecks runs the checks in order. The checks needing the runtime fail
+// when it can't be reached.
+func runChecks(cliContext *cli.Context) []checkResult {
+	var results []checkResult
+	var status checkStatus
+	var detail string
+	add := func(name string, status checkStatus, detail string) {
+		results = append(results, checkResult{name: name, status: status, detail: detail})
+	}
+
+	endpoint, err := getRuntimeEndpoint()
+	if err != nil {
+		add("runtime endpoint", checkFail, err.Error())
+		return results
+	}
+	status, detail = checkSocketPermissions(endpoint)
+	add("socket permissions", status, detail)
+	if err := getRuntimeClient(cliContext); err != nil {
+		add("runtime endpoint", checkFail, fmt.Sprintf("%s: %v", endpoint, err))
+		return results
+	}
+	version, err := checkVersion(runtimeClient)
+	if err != nil {
+		add("runtime endpoint", checkFail, fmt.Sprintf("%s: %v", endpoint, err))
+		return results
+	}
+	add("runtime endpoint", checkOK, fmt.Sprintf("%s: %s %s", endpoint, version.RuntimeName, version.RuntimeVersion))
+	status, detail = checkAPIVersion(version.RuntimeApiVersion)
+	add("API version", status, detail)
+
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	r, err := runtimeClient.Status(ctx, &pb.StatusRequest{Verbose: true})
+	if err != nil {
+		add("runtime status", checkFail, err.Error())
+	} else {
+		status, detail = checkRuntimeConditions(r.GetStatus())
+		add("runtime status", status, detail)
+		status, detail = checkCgroupDriver(r.GetInfo())
+		add("cgroup driver", status, detail)
+	}
+	status, detail = checkStreaming(runtimeClient)
+	add("streaming endpoint", status, detail)
+
+	if err := getImageClient(cliContext); err != nil {
+		add("image endpoint", checkFail, err.Error())
+		return results
+	}
+	if ImageEndpoint != endpoint {
+		status, detail = checkImageEndpoint(imageClient)
+		add("image endpoint", status, detail)
+		if status == checkFail {
+			return results
+		}
+	}
+	if cliContext.Bool("skip-pull") {
+		add("image pull", checkWarn, "skipped")
+		return results
+	}
+	status, detail = checkImagePull(imageClient, cliContext.String("image"))
+	add("image pull", status, detail)
+	return results
+}
+
+// checkVersion returns the version of the runtime.
+func checkVersion(client pb.RuntimeServiceClient) (*pb.VersionResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	return client.Version(ctx, &pb.VersionRequest{Version: criClientVersion})
+}
+
+// checkImageEndpoint checks the image endpoint, when it isn't the runtime
+// endpoint.
+func checkImageEndpoint(client pb.ImageServiceClient) (checkStatus, string) {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	if _, err := client.ImageFsInfo(ctx, &pb.ImageFsInfoRequest{}); err != nil {
+		return checkFail, fmt.Sprintf("%s: %v", ImageEndpoint, err)
+	}
+	return checkOK, ImageEndpoint
+}
+
+// checkImagePull pulls image. The pull isn't bound by the connection
+// timeout.
+func checkImagePull(client pb.ImageServiceClient, image string) (checkStatus, string) {
+	request := &pb.PullImageRequest{Image: &pb.ImageSpec{Image: image}}
+	logrus.Debugf("PullImageRequest: %v", request)
+	r, err := client.PullImage(context.Background(), request)
+	logrus.Debugf("PullImageResponse: %v", r)
+	if err != nil {
+		return checkFail, fmt.Sprintf("%s: %v", image, err)
+	}
+	return checkOK, fmt.Sprintf("%s: %s", image, r.ImageRef)
+}
+
+// checkAPIVersion checks the CRI API version of the runtime is one crictl
+// negotiates. Runtimes reporting other versions, such as dockershim which
+// reports "0.1.0", only get a warning since the calls themselves may work.
+func checkAPIVersion(version string) (checkStatus, string) {
+	if version != remote.APIVersionV1 && version != remote.APIVersionV1alpha2 {
+		return checkWarn, fmt.Sprintf("runtime reports %q, crictl supports %q and %q", version, remote.APIVersionV1, remote.APIVersionV1alpha2)
+	}
+	return checkOK, version
+}
+
+// checkRuntimeConditions checks the RuntimeReady and NetworkReady conditions
+// of the runtime.
+func checkRuntimeConditions(status *pb.RuntimeStatus) (checkStatus, string) {
+	var notReady []string
+	for _, c := range status.GetConditions() {
+		if !c.Status {
+			notReady = append(notReady, fmt.Sprintf("%s is false (%s: %s)", c.Type, c.Reason, c.Message))
+		}
+	}
+	if len(notReady) > 0 {
+		return checkFail, strings.Join(notReady, ", ")
+	}
+	return checkOK, "all conditions are true"
+}
+
+// cgroupDriverKeys are the lowercase keys of the cgroup driver in the verbose
+// status info of the runtimes.
+var cgroupDriverKeys = []string{"cgroupdriver", "cgroup_driver", "cgroupmanager", "cgroup_manager"}
+
+// systemdCgroupKeys are the lowercase keys of the boolean enabling the
+// systemd cgroup driver in the verbose status info of the runtimes.
+var systemdCgroupKeys = []string{"systemdcgroup", "systemd_cgroup"}
+
+// checkCgroupDriver looks for the cgroup driver in the verbose status info of
+// the runtime. Runtimes which don't report it only get a warning.
+func checkCgroupDriver(info map[string]string) (checkStatus, string) {
+	for _, k := range getSortedKeys(info) {
+		var value interface{}
+		if err := json.Unmarshal([]byte(info[k]), &value); err != nil {
+			continue
+		}
+		if driver, ok := findCgroupDriver(value); ok {
+			return checkOK, driver
+		}
+	}
+	return checkWarn, "not reported by the runtime, check it matches the kubelet --cgroup-driver"
+}
+
+// findCgroupDriver looks for the cgroup driver in a decoded info value.
+func findCgroupDriver(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			field, key := v[k], strings.ToLower(k)
+			for _, driverKey := range cgroupDriverKeys {
+				if s, ok := field.(string); ok && key == driverKey && s != "" {
+					return s, true
+				}
+			}
+			for _, systemdKey := range systemdCgroupKeys {
+				if b, ok := field.(bool); ok && key == systemdKey {
+					if b {
+						return "systemd", true
+					}
+					return "cgroupfs", true
+				}
+			}
+		}
+		for _, k := range keys {
+			if driver, ok := findCgroupDriver(v[k]); ok {
+				return driver, true
+			}
+		}
+	case []interface{}:
+		for _, field := range v {
+			if driver, ok := findCgroupDriver(field); ok {
+				return driver, true
+			}
+		}
+	}
+	return "", false
+}
+
+// checkStreaming requests the exec URL of a running container and checks
+// the streaming server can be connected to. The command is never run. The
+// check is skipped if no container is running.
+func checkStreaming(client pb.RuntimeServiceClient) (checkStatus, string) {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	containers, err := client.ListContainers(ctx, &pb.ListContainersRequest{
+		Filter: &pb.ContainerFilter{State: &pb.ContainerStateValue{State: pb.ContainerState_CONTAINER_RUNNING}},
+	})
+	if err != nil {
+		return checkFail, fmt.Sprintf("failed to list containers: %v", err)
+	}
+	if len(containers.GetContainers()) == 0 {
+		return checkWarn, "skipped, no container is running"
+	}
+	request := &pb.ExecRequest{
+		ContainerId: containers.Containers[0].Id,
+		Cmd:         []string{"true"},
+		Stdout:      true,
+	}
+	logrus.Debugf("ExecRequest: %v", request)
+	r, err := client.Exec(ctx, request)
+	logrus.Debugf("ExecResponse: %v", r)
+	if err != nil {
+		return checkFail, fmt.Sprintf("failed to get exec URL: %v", err)
+	}
+	address, err := streamingAddress(r.Url)
+	if err != nil {
+		return checkFail, err.Error()
+	}
+	conn, err := net.DialTimeout("tcp", address, Timeout)
+	if err != nil {
+		return checkFail, fmt.Sprintf("failed to connect to %s: %v", address, err)
+	}
+	conn.Close()
+	return checkOK, address
+}
+
+// streamingAddress returns the host:port address of a streaming URL.
+func streamingAddress(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid streaming URL %q: %v", rawURL, err)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("streaming URL %q has no host", rawURL)
+	}
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+	port := "80"
+	if u.Scheme == "https" {
+		port = "443"
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
+
+// printCheckResults prints the diagnosis table, with colored status if
+// color is set.
+func printCheckResults(results []checkResult, color bool) error {
+	table := output.NewTable(
+		output.Column{Name: "check", Header: "CHECK"},
+		output.Column{Name: "status", Header: "STATUS"},
+		output.Column{Name: "detail", Header: "DETAIL"},
+	)
+	for _, r := range results {
+		status := string(r.status)
+		if color {
+			// All the color codes have the same length, which keeps the
+			// columns aligned.
+			status = r.status.color() + status + "\x1b[0m"
+		}
+		table.AddRow(r.name, status, r.detail)
+	}
+	return table.Write(os.Stdout, output.Options{})
+}
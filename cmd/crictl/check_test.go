@@ -0,0 +1,97 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+)
+
+func TestCheckCgroupDriver(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		info   map[string]string
+		status checkStatus
+		driver string
+	}{
+		{
+			"cgroup manager string",
+			map[string]string{"config": `{"cgroup_manager":"systemd"}`},
+			checkOK,
+			"systemd",
+		},
+		{
+			"nested systemd cgroup boolean",
+			map[string]string{"config": `{"containerd":{"runtimes":[{"systemdCgroup":false}]}}`},
+			checkOK,
+			"cgroupfs",
+		},
+		{
+			"no cgroup driver",
+			map[string]string{"golang": "go1.10", "config": `{"sandboxImage":"pause"}`},
+			checkWarn,
+			"",
+		},
+		{
+			"no info",
+			nil,
+			checkWarn,
+			"",
+		},
+	}
+	for _, tc := range testCases {
+		status, detail := checkCgroupDriver(tc.info)
+		if status != tc.status {
+			t.Errorf("%s: expected status %s, got %s: %s", tc.desc, tc.status, status, detail)
+		}
+		if tc.status == checkOK && detail != tc.driver {
+			t.Errorf("%s: expected driver %q, got %q", tc.desc, tc.driver, detail)
+		}
+	}
+}
+
+func TestStreamingAddress(t *testing.T) {
+	testCases := []struct {
+		url     string
+		address string
+		isErr   bool
+	}{
+		{"http://127.0.0.1:10010/exec/abc", "127.0.0.1:10010", false},
+		{"https://node.example.com/exec/abc", "node.example.com:443", false},
+		{"http://[::1]/exec/abc", "[::1]:80", false},
+		{"/exec/abc", "", true},
+	}
+	for _, tc := range testCases {
+		address, err := streamingAddress(tc.url)
+		if (err != nil) != tc.isErr {
+			t.Errorf("%q: expected error %v, got %v", tc.url, tc.isErr, err)
+		}
+		if address != tc.address {
+			t.Errorf("%q: expected address %q, got %q", tc.url, tc.address, address)
+		}
+	}
+}
+
+func TestCheckAPIVersion(t *testing.T) {
+	for _, version := range []string{"v1", "v1alpha2"} {
+		if status, detail := checkAPIVersion(version); status != checkOK {
+			t.Errorf("expected %s to be compatible: %s", version, detail)
+		}
+	}
+	if status, _ := checkAPIVersion("0.1.0"); status != checkWarn {
+		t.Errorf("expected a warning for 0.1.0, got %s", status)
+	}
+}
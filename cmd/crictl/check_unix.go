@@ -0,0 +1,47 @@
+// +build !windows
+
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// checkSocketPermissions checks the socket of a unix endpoint exists and can
+// be written by the user running crictl.
+func checkSocketPermissions(endpoint string) (checkStatus, string) {
+	address, _, err := GetAddressAndDialer(endpoint)
+	if err != nil {
+		return checkFail, err.Error()
+	}
+	info, err := os.Stat(address)
+	if err != nil {
+		return checkFail, err.Error()
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return checkFail, fmt.Sprintf("%s is not a socket", address)
+	}
+	// W_OK, the syscall package doesn't define it.
+	const writable = 0x2
+	if err := syscall.Access(address, writable); err != nil {
+		return checkFail, fmt.Sprintf("%s is not writable by uid %d: %v", address, os.Getuid(), err)
+	}
+	return checkOK, fmt.Sprintf("%s (%s)", address, info.Mode().Perm())
+}
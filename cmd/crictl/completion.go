@@ -18,49 +18,210 @@ package main
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/kubernetes-sigs/cri-tools/pkg/output"
 	"github.com/urfave/cli"
+	"golang.org/x/net/context"
+	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 )
 
-var bashCompletionTemplate = `_cli_bash_autocomplete() {
-    local cur opts base
+// Kinds of IDs completed by querying the runtime.
+const (
+	completeContainers = "containers"
+	completePods       = "pods"
+	completeImages     = "images"
+)
+
+// idCompletions maps the commands taking IDs to the kind of their IDs.
+var idCompletions = map[string]string{
+	"attach":       completeContainers,
+	"exec":         completeContainers,
+	"inspect":      completeContainers,
+	"logs":         completeContainers,
+	"rm":           completeContainers,
+	"start":        completeContainers,
+	"stop":         completeContainers,
+	"top":          completeContainers,
+	"update":       completeContainers,
+	"create":       completePods,
+	"inspectp":     completePods,
+	"port-forward": completePods,
+	"rmp":          completePods,
+	"stopp":        completePods,
+	"inspecti":     completeImages,
+	"rmi":          completeImages,
+}
+
+var bashCompletionTemplate = `_crictl() {
+    local cur kind
     COMPREPLY=()
     cur="${COMP_WORDS[COMP_CWORD]}"
-    opts="%s"
-    COMPREPLY=( $(compgen -W "${opts}" -- ${cur}) )
+    if [[ ${COMP_CWORD} -eq 1 ]]; then
+        COMPREPLY=( $(compgen -W "%s" -- "${cur}") )
+        return 0
+    fi
+    case "${COMP_WORDS[1]}" in
+%s
+    esac
+    if [[ -n "${kind}" && "${cur}" != -* ]]; then
+        COMPREPLY=( $(compgen -W "$(crictl __complete ${kind} 2>/dev/null)" -- "${cur}") )
+    fi
     return 0
 }
 
-complete -F _cli_bash_autocomplete crictl`
+complete -F _crictl crictl`
+
+// zshCompletionTemplate uses the bash completion through bashcompinit.
+var zshCompletionTemplate = `autoload -U +X compinit && compinit
+autoload -U +X bashcompinit && bashcompinit
+%s`
+
+var fishCompletionTemplate = `complete -c crictl -f
+complete -c crictl -n '__fish_use_subcommand' -a '%s'
+%s`
 
 var completionCommand = cli.Command{
-	Name:  "completion",
-	Usage: "Output bash shell completion code",
-	Description: `Output bash shell completion code.
+	Name:      "completion",
+	Usage:     "Output shell completion code",
+	ArgsUsage: "[bash|zsh|fish]",
+	Description: `Output shell completion code for bash (default), zsh or fish.
+
+The IDs of containers, pods and images are completed by querying the runtime.
 
 Examples:
 
     # Installing bash completion on Linux
     source <(crictl completion)
+
+    # Installing zsh completion
+    source <(crictl completion zsh)
+
+    # Installing fish completion
+    crictl completion fish | source
 	`,
 	Action: func(c *cli.Context) error {
-		subcommands := []string{}
-		for _, command := range c.App.Commands {
-			if command.Hidden {
-				continue
+		shell := c.Args().First()
+		if shell == "" {
+			shell = "bash"
+		}
+		code, err := completionCode(shell, c.App)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.App.Writer, code)
+		return nil
+	},
+}
+
+var completeIDsCommand = cli.Command{
+	Name:      "__complete",
+	Usage:     "List the IDs completed by the shell completion",
+	ArgsUsage: completeContainers + "|" + completePods + "|" + completeImages,
+	Hidden:    true,
+	Action: func(c *cli.Context) error {
+		var ids []string
+		var err error
+		switch kind := c.Args().First(); kind {
+		case completeContainers:
+			if err = getRuntimeClient(c); err != nil {
+				return err
 			}
-			for _, name := range command.Names() {
-				subcommands = append(subcommands, name)
+			ids, err = listContainerIDs(runtimeClient)
+			ids = truncateIDs(ids)
+		case completePods:
+			if err = getRuntimeClient(c); err != nil {
+				return err
 			}
+			ids, err = listPodSandboxIDs(runtimeClient)
+			ids = truncateIDs(ids)
+		case completeImages:
+			if err = getImageClient(c); err != nil {
+				return err
+			}
+			ids, err = listImageCompletions(imageClient)
+		default:
+			return fmt.Errorf("unknown kind %q", kind)
 		}
-
-		for _, flag := range c.App.Flags {
-			// only includes full flag name.
-			subcommands = append(subcommands, "--"+strings.Split(flag.GetName(), ",")[0])
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			fmt.Fprintln(c.App.Writer, id)
 		}
-
-		fmt.Fprintln(c.App.Writer, fmt.Sprintf(bashCompletionTemplate, strings.Join(subcommands, "\n")))
 		return nil
 	},
+	After: closeConnection,
+}
+
+// completionCode returns the completion code of app for shell.
+func completionCode(shell string, app *cli.App) (string, error) {
+	var subcommands []string
+	for _, command := range app.Commands {
+		if command.Hidden {
+			continue
+		}
+		subcommands = append(subcommands, command.Names()...)
+	}
+	for _, flag := range app.Flags {
+		// only includes full flag name.
+		subcommands = append(subcommands, "--"+strings.Split(flag.GetName(), ",")[0])
+	}
+
+	commandsByKind := make(map[string][]string)
+	for command, kind := range idCompletions {
+		commandsByKind[kind] = append(commandsByKind[kind], command)
+	}
+	var kinds []string
+	for kind, commands := range commandsByKind {
+		sort.Strings(commands)
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	switch shell {
+	case "bash", "zsh":
+		var cases []string
+		for _, kind := range kinds {
+			cases = append(cases, fmt.Sprintf("        %s) kind=%s ;;", strings.Join(commandsByKind[kind], "|"), kind))
+		}
+		code := fmt.Sprintf(bashCompletionTemplate, strings.Join(subcommands, " "), strings.Join(cases, "\n"))
+		if shell == "zsh" {
+			code = fmt.Sprintf(zshCompletionTemplate, code)
+		}
+		return code, nil
+	case "fish":
+		var lines []string
+		for _, kind := range kinds {
+			lines = append(lines, fmt.Sprintf("complete -c crictl -n '__fish_seen_subcommand_from %s' -a '(crictl __complete %s 2>/dev/null)'",
+				strings.Join(commandsByKind[kind], " "), kind))
+		}
+		return fmt.Sprintf(fishCompletionTemplate, strings.Join(subcommands, " "), strings.Join(lines, "\n")), nil
+	default:
+		return "", fmt.Errorf("unsupported shell %q, should be one of bash, zsh or fish", shell)
+	}
+}
+
+// listImageCompletions returns the tags and IDs of the images.
+func listImageCompletions(client pb.ImageServiceClient) ([]string, error) {
+	r, err := client.ListImages(context.Background(), &pb.ListImagesRequest{})
+	if err != nil {
+		return nil, err
+	}
+	var refs []string
+	for _, image := range r.GetImages() {
+		refs = append(refs, image.RepoTags...)
+		refs = append(refs, output.TruncateID(image.Id, imageIDPrefix, false))
+	}
+	return refs, nil
+}
+
+// truncateIDs returns the truncated form of ids.
+func truncateIDs(ids []string) []string {
+	truncated := make([]string, 0, len(ids))
+	for _, id := range ids {
+		truncated = append(truncated, output.TruncateID(id, "", false))
+	}
+	return truncated
 }
@@ -20,9 +20,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 
-	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 	"gopkg.in/yaml.v2"
 )
@@ -30,10 +31,55 @@ import (
 // Config is the internal representation of the yaml that determines how
 // the app start
 type Config struct {
-	RuntimeEndpoint string `yaml:"runtime-endpoint"`
-	ImageEndpoint   string `yaml:"image-endpoint"`
-	Timeout         int    `yaml:"timeout"`
-	Debug           bool   `yaml:"debug"`
+	RuntimeEndpoint endpointList `yaml:"runtime-endpoint"`
+	ImageEndpoint   string       `yaml:"image-endpoint"`
+	Timeout         int          `yaml:"timeout"`
+	Debug           bool         `yaml:"debug"`
+}
+
+// endpointList is a list of endpoints, written as a single string in the
+// config file when it holds at most one endpoint.
+type endpointList []string
+
+// UnmarshalYAML accepts either a single endpoint or a list of endpoints.
+func (l *endpointList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var endpoint string
+	if err := unmarshal(&endpoint); err == nil {
+		*l = parseEndpointList(endpoint)
+		return nil
+	}
+	var endpoints []string
+	if err := unmarshal(&endpoints); err != nil {
+		return err
+	}
+	*l = endpoints
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (l endpointList) MarshalYAML() (interface{}, error) {
+	switch len(l) {
+	case 0:
+		return "", nil
+	case 1:
+		return l[0], nil
+	}
+	return []string(l), nil
+}
+
+func (l endpointList) String() string {
+	return strings.Join(l, ",")
+}
+
+// parseEndpointList parses a comma separated list of endpoints.
+func parseEndpointList(s string) endpointList {
+	var l endpointList
+	for _, endpoint := range strings.Split(s, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			l = append(l, endpoint)
+		}
+	}
+	return l
 }
 
 // ReadConfig reads from a file with the given name and returns a config or
@@ -51,12 +97,136 @@ func ReadConfig(filepath string) (*Config, error) {
 	return &config, err
 }
 
-func writeConfig(c *Config, filepath string) error {
+// writeConfig atomically replaces the config file with c: the config is
+// written to a temporary file in the same directory and then renamed.
+func writeConfig(c *Config, configFile string) error {
 	data, err := yaml.Marshal(c)
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(filepath, data, 0644)
+	dir := filepath.Dir(configFile)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(dir, "."+filepath.Base(configFile))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), configFile)
+}
+
+// loadConfig reads the config file, returning an empty config if it does
+// not exist yet.
+func loadConfig(filepath string) (*Config, error) {
+	config, err := ReadConfig(filepath)
+	if os.IsNotExist(err) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config file: %v", err)
+	}
+	return config, nil
+}
+
+// configOptionNames are the options managed by the config command.
+var configOptionNames = []string{"runtime-endpoint", "image-endpoint", "timeout", "debug"}
+
+func getConfigOption(c *Config, name string) (string, error) {
+	switch name {
+	case "runtime-endpoint":
+		return c.RuntimeEndpoint.String(), nil
+	case "image-endpoint":
+		return c.ImageEndpoint, nil
+	case "timeout":
+		return strconv.Itoa(c.Timeout), nil
+	case "debug":
+		return strconv.FormatBool(c.Debug), nil
+	}
+	return "", unknownConfigOptionError(name)
+}
+
+func setConfigOption(c *Config, name, value string) error {
+	switch name {
+	case "runtime-endpoint":
+		endpoints := parseEndpointList(value)
+		if len(endpoints) == 0 {
+			return fmt.Errorf("runtime-endpoint should not be empty, use unset to remove it")
+		}
+		for _, endpoint := range endpoints {
+			if _, _, err := GetAddressAndDialer(endpoint); err != nil {
+				return fmt.Errorf("invalid runtime-endpoint %q: %v", endpoint, err)
+			}
+		}
+		c.RuntimeEndpoint = endpoints
+	case "image-endpoint":
+		if _, _, err := GetAddressAndDialer(value); err != nil {
+			return fmt.Errorf("invalid image-endpoint %q: %v", value, err)
+		}
+		c.ImageEndpoint = value
+	case "timeout":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("timeout should be a non-negative number of seconds, got %q", value)
+		}
+		c.Timeout = n
+	case "debug":
+		debug, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("use true|false for debug, got %q", value)
+		}
+		c.Debug = debug
+	default:
+		return unknownConfigOptionError(name)
+	}
+	return nil
+}
+
+func unsetConfigOption(c *Config, name string) error {
+	switch name {
+	case "runtime-endpoint":
+		c.RuntimeEndpoint = nil
+	case "image-endpoint":
+		c.ImageEndpoint = ""
+	case "timeout":
+		c.Timeout = 0
+	case "debug":
+		c.Debug = false
+	default:
+		return unknownConfigOptionError(name)
+	}
+	return nil
+}
+
+func unknownConfigOptionError(name string) error {
+	return fmt.Errorf("no option named %q, should be one of %s", name, strings.Join(configOptionNames, ", "))
+}
+
+// updateConfig loads the config file, applies update and writes it back.
+func updateConfig(context *cli.Context, update func(*Config) error) error {
+	configFile := context.GlobalString("config")
+	config, err := loadConfig(configFile)
+	if err != nil {
+		return err
+	}
+	if err := update(config); err != nil {
+		return err
+	}
+	return writeConfig(config, configFile)
 }
 
 var configCommand = cli.Command{
@@ -65,68 +235,104 @@ var configCommand = cli.Command{
 	ArgsUsage:              "[<options>]",
 	SkipArgReorder:         true,
 	UseShortOptionHandling: true,
+	Subcommands: []cli.Command{
+		configGetCommand,
+		configSetCommand,
+		configUnsetCommand,
+		configViewCommand,
+	},
 	Flags: []cli.Flag{
 		cli.StringFlag{
-			Name:  "get",
-			Usage: "get value: name",
+			Name:   "get",
+			Usage:  "get value: name",
+			Hidden: true,
 		},
 	},
+	// Action keeps supporting the "--get NAME" and "NAME VALUE" forms used
+	// before the subcommands were added.
 	Action: func(context *cli.Context) error {
-		configFile := context.GlobalString("config")
-		if _, err := os.Stat(configFile); err != nil {
-			if err := writeConfig(nil, configFile); err != nil {
-				return err
-			}
-		}
-		// Get config from file.
-		config, err := ReadConfig(configFile)
-		if err != nil {
-			return fmt.Errorf("Failed to load config file: %v", err)
-		}
 		if context.IsSet("get") {
-			get := context.String("get")
-			switch get {
-			case "runtime-endpoint":
-				fmt.Println(config.RuntimeEndpoint)
-			case "image-endpoint":
-				fmt.Println(config.ImageEndpoint)
-			case "timeout":
-				fmt.Println(config.Timeout)
-			case "debug":
-				fmt.Println(config.Debug)
-			default:
-				logrus.Fatalf("No section named %s", get)
-			}
-			return nil
+			return printConfigOption(context, context.String("get"))
 		}
-		key := context.Args().First()
-		if key == "" {
+		name := context.Args().First()
+		if name == "" {
 			return cli.ShowSubcommandHelp(context)
 		}
 		value := context.Args().Get(1)
-		switch key {
-		case "runtime-endpoint":
-			config.RuntimeEndpoint = value
-		case "image-endpoint":
-			config.ImageEndpoint = value
-		case "timeout":
-			n, err := strconv.Atoi(value)
-			if err != nil {
-				logrus.Fatal(err)
-			}
-			config.Timeout = n
-		case "debug":
-			var debug bool
-			if value == "true" {
-				debug = true
-			} else {
-				logrus.Fatal("use true|false for debug")
-			}
-			config.Debug = debug
-		default:
-			logrus.Fatalf("No section named %s", key)
+		return updateConfig(context, func(c *Config) error {
+			return setConfigOption(c, name, value)
+		})
+	},
+}
+
+var configGetCommand = cli.Command{
+	Name:      "get",
+	Usage:     "Print the value of an option",
+	ArgsUsage: "NAME",
+	Action: func(context *cli.Context) error {
+		if context.NArg() != 1 {
+			return cli.ShowSubcommandHelp(context)
 		}
+		return printConfigOption(context, context.Args().First())
+	},
+}
 
-		return writeConfig(config, configFile)
+var configSetCommand = cli.Command{
+	Name:      "set",
+	Usage:     "Set the value of an option",
+	ArgsUsage: "NAME VALUE",
+	// Do not parse negative values as flags.
+	SkipFlagParsing: true,
+	Action: func(context *cli.Context) error {
+		if context.NArg() != 2 {
+			return cli.ShowSubcommandHelp(context)
+		}
+		return updateConfig(context, func(c *Config) error {
+			return setConfigOption(c, context.Args().Get(0), context.Args().Get(1))
+		})
+	},
+}
+
+var configUnsetCommand = cli.Command{
+	Name:      "unset",
+	Usage:     "Reset an option to its default value",
+	ArgsUsage: "NAME",
+	Action: func(context *cli.Context) error {
+		if context.NArg() != 1 {
+			return cli.ShowSubcommandHelp(context)
+		}
+		return updateConfig(context, func(c *Config) error {
+			return unsetConfigOption(c, context.Args().First())
+		})
+	},
+}
+
+var configViewCommand = cli.Command{
+	Name:  "view",
+	Usage: "Print the config file",
+	Action: func(context *cli.Context) error {
+		config, err := loadConfig(context.GlobalString("config"))
+		if err != nil {
+			return err
+		}
+		data, err := yaml.Marshal(config)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(data))
+		return nil
 	},
 }
+
+func printConfigOption(context *cli.Context, name string) error {
+	config, err := loadConfig(context.GlobalString("config"))
+	if err != nil {
+		return err
+	}
+	value, err := getConfigOption(config, name)
+	if err != nil {
+		return err
+	}
+	fmt.Println(value)
+	return nil
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestConfigRuntimeEndpoint(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		data      string
+		endpoints endpointList
+	}{
+		{"empty endpoint", "runtime-endpoint: \"\"\n", nil},
+		{"single endpoint", "runtime-endpoint: unix:///var/run/a.sock\n", endpointList{"unix:///var/run/a.sock"}},
+		{"endpoint list", "runtime-endpoint:\n- unix:///var/run/a.sock\n- unix:///var/run/b.sock\n", endpointList{"unix:///var/run/a.sock", "unix:///var/run/b.sock"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := Config{}
+			if err := yaml.Unmarshal([]byte(tc.data), &config); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(config.RuntimeEndpoint, tc.endpoints) {
+				t.Errorf("expected endpoints %v, got %v", tc.endpoints, config.RuntimeEndpoint)
+			}
+
+			data, err := yaml.Marshal(&config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			roundTrip := Config{}
+			if err := yaml.Unmarshal(data, &roundTrip); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(roundTrip.RuntimeEndpoint, tc.endpoints) {
+				t.Errorf("expected endpoints %v after round trip, got %v", tc.endpoints, roundTrip.RuntimeEndpoint)
+			}
+		})
+	}
+}
+
+func TestSetConfigOption(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		name        string
+		value       string
+		expected    string
+		expectError bool
+	}{
+		{"runtime endpoint list", "runtime-endpoint", "unix:///a.sock, unix:///b.sock", "unix:///a.sock,unix:///b.sock", false},
+		{"empty runtime endpoint", "runtime-endpoint", "", "", true},
+		{"image endpoint", "image-endpoint", "unix:///a.sock", "unix:///a.sock", false},
+		{"timeout", "timeout", "5", "5", false},
+		{"negative timeout", "timeout", "-1", "", true},
+		{"debug", "debug", "true", "true", false},
+		{"invalid debug", "debug", "yes", "", true},
+		{"unknown option", "foo", "bar", "", true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := &Config{}
+			err := setConfigOption(config, tc.name, tc.value)
+			if (err != nil) != tc.expectError {
+				t.Fatalf("expected error: %v; actual error is %v", tc.expectError, err)
+			}
+			if err != nil {
+				return
+			}
+			value, err := getConfigOption(config, tc.name)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if value != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, value)
+			}
+			if err := unsetConfigOption(config, tc.name); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(config, &Config{}) {
+				t.Errorf("expected empty config after unset, got %+v", config)
+			}
+		})
+	}
+}
+
+func TestWriteConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crictl-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	configFile := filepath.Join(dir, "crictl", "crictl.yaml")
+	config := &Config{RuntimeEndpoint: endpointList{"unix:///a.sock"}, Timeout: 5, Debug: true}
+	if err := writeConfig(config, configFile); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	loaded, err := loadConfig(configFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, config) {
+		t.Errorf("expected %+v, got %+v", config, loaded)
+	}
+	files, err := ioutil.ReadDir(filepath.Dir(configFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("expected only the config file to be left, got %d files", len(files))
+	}
+}
@@ -22,11 +22,10 @@ import (
 	"log"
 	"os"
 	"sort"
-	"strings"
-	"text/tabwriter"
 	"time"
 
 	units "github.com/docker/go-units"
+	"github.com/kubernetes-sigs/cri-tools/pkg/output"
 	godigest "github.com/opencontainers/go-digest"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
@@ -43,18 +42,18 @@ func (a containerByCreated) Less(i, j int) bool {
 }
 
 type createOptions struct {
-	// configPath is path to the config for container
-	configPath string
 	// podID of the container
 	podID string
-	// podConfig is path to the config for sandbox
-	podConfig string
+	// config of the container
+	config *pb.ContainerConfig
+	// podConfig is the config of the sandbox
+	podConfig *pb.PodSandboxConfig
 }
 
 var createContainerCommand = cli.Command{
 	Name:      "create",
 	Usage:     "Create a new container",
-	ArgsUsage: "POD container-config.[json|yaml] pod-config.[json|yaml]",
+	ArgsUsage: "POD container-config.[json|yaml]|- pod-config.[json|yaml]|-",
 	Flags:     []cli.Flag{},
 
 	Action: func(context *cli.Context) error {
@@ -66,16 +65,62 @@ var createContainerCommand = cli.Command{
 			return err
 		}
 
+		config, podConfig, err := loadCreateConfigs(context.Args().Get(1), context.Args().Get(2))
+		if err != nil {
+			return err
+		}
+		podID, err := resolvePodSandboxID(runtimeClient, context.Args().Get(0))
+		if err != nil {
+			return err
+		}
 		opts := createOptions{
-			podID:      context.Args().Get(0),
-			configPath: context.Args().Get(1),
-			podConfig:  context.Args().Get(2),
+			podID:     podID,
+			config:    config,
+			podConfig: podConfig,
 		}
 
-		err := CreateContainer(runtimeClient, opts)
+		containerID, err := CreateContainer(runtimeClient, opts)
 		if err != nil {
 			return fmt.Errorf("Creating container failed: %v", err)
 		}
+		fmt.Println(containerID)
+		return nil
+	},
+}
+
+var runContainerCommand = cli.Command{
+	Name:      "run",
+	Usage:     "Run a new container inside a new pod",
+	ArgsUsage: "container-config.[json|yaml]|- pod-config.[json|yaml]|-",
+	Action: func(context *cli.Context) error {
+		if len(context.Args()) != 2 {
+			return cli.ShowSubcommandHelp(context)
+		}
+
+		if err := getRuntimeClient(context); err != nil {
+			return err
+		}
+
+		config, podConfig, err := loadCreateConfigs(context.Args().Get(0), context.Args().Get(1))
+		if err != nil {
+			return err
+		}
+
+		podID, err := RunPodSandbox(runtimeClient, podConfig)
+		if err != nil {
+			return fmt.Errorf("run pod sandbox failed: %v", err)
+		}
+		containerID, err := CreateContainer(runtimeClient, createOptions{
+			podID:     podID,
+			config:    config,
+			podConfig: podConfig,
+		})
+		if err != nil {
+			return fmt.Errorf("Creating container in pod sandbox %q failed: %v", podID, err)
+		}
+		if err := StartContainer(runtimeClient, containerID); err != nil {
+			return fmt.Errorf("Starting the container %q failed: %v", containerID, err)
+		}
 		return nil
 	},
 }
@@ -92,8 +137,11 @@ var startContainerCommand = cli.Command{
 			return err
 		}
 
-		for i := 0; i < context.NArg(); i++ {
-			containerID := context.Args().Get(i)
+		containerIDs, err := resolveContainerIDs(runtimeClient, context.Args())
+		if err != nil {
+			return err
+		}
+		for _, containerID := range containerIDs {
 			err := StartContainer(runtimeClient, containerID)
 			if err != nil {
 				return fmt.Errorf("Starting the container %q failed: %v", containerID, err)
@@ -150,8 +198,11 @@ var updateContainerCommand = cli.Command{
 			MemoryLimitInBytes: context.Int64("memory"),
 		}
 
-		for i := 0; i < context.NArg(); i++ {
-			containerID := context.Args().Get(i)
+		containerIDs, err := resolveContainerIDs(runtimeClient, context.Args())
+		if err != nil {
+			return err
+		}
+		for _, containerID := range containerIDs {
 			err := UpdateContainerResources(runtimeClient, containerID, options)
 			if err != nil {
 				return fmt.Errorf("Updating container resources for %q failed: %v", containerID, err)
@@ -167,29 +218,36 @@ var stopContainerCommand = cli.Command{
 	ArgsUsage:              "CONTAINER-ID [CONTAINER-ID...]",
 	SkipArgReorder:         true,
 	UseShortOptionHandling: true,
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		cli.Int64Flag{
 			Name:  "timeout, t",
 			Value: 10,
 			Usage: "Seconds to wait to kill the container after a graceful stop is requested",
 		},
-	},
+	}, selectFlags...),
 	Action: func(context *cli.Context) error {
-		if context.NArg() == 0 {
+		opts, err := parseSelectOptions(context)
+		if err != nil {
+			return err
+		}
+		if context.NArg() == 0 && !opts.isSet() {
 			return cli.ShowSubcommandHelp(context)
 		}
 		if err := getRuntimeClient(context); err != nil {
 			return err
 		}
 
-		for i := 0; i < context.NArg(); i++ {
-			containerID := context.Args().Get(i)
+		ids, err := selectContainerIDs(runtimeClient, context, opts, "running")
+		if err != nil {
+			return err
+		}
+		return forEachID(ids, opts.dryRun, func(containerID string) error {
 			err := StopContainer(runtimeClient, containerID, context.Int64("timeout"))
 			if err != nil {
 				return fmt.Errorf("Stopping the container %q failed: %v", containerID, err)
 			}
-		}
-		return nil
+			return nil
+		})
 	},
 }
 
@@ -197,22 +255,40 @@ var removeContainerCommand = cli.Command{
 	Name:      "rm",
 	Usage:     "Remove one or more containers",
 	ArgsUsage: "CONTAINER-ID [CONTAINER-ID...]",
+	Flags: append([]cli.Flag{
+		cli.BoolFlag{
+			Name:  "force, f",
+			Usage: "Stop the running containers before removing them",
+		},
+	}, selectFlags...),
 	Action: func(context *cli.Context) error {
-		if context.NArg() == 0 {
+		opts, err := parseSelectOptions(context)
+		if err != nil {
+			return err
+		}
+		if context.NArg() == 0 && !opts.isSet() {
 			return cli.ShowSubcommandHelp(context)
 		}
 		if err := getRuntimeClient(context); err != nil {
 			return err
 		}
 
-		for i := 0; i < context.NArg(); i++ {
-			containerID := context.Args().Get(i)
+		ids, err := selectContainerIDs(runtimeClient, context, opts, "")
+		if err != nil {
+			return err
+		}
+		return forEachID(ids, opts.dryRun, func(containerID string) error {
+			if context.Bool("force") {
+				if err := stopRunningContainer(runtimeClient, containerID); err != nil {
+					return fmt.Errorf("Stopping the container %q failed: %v", containerID, err)
+				}
+			}
 			err := RemoveContainer(runtimeClient, containerID)
 			if err != nil {
 				return fmt.Errorf("Removing the container %q failed: %v", containerID, err)
 			}
-		}
-		return nil
+			return nil
+		})
 	},
 }
 
@@ -223,7 +299,7 @@ var containerStatusCommand = cli.Command{
 	Flags: []cli.Flag{
 		cli.StringFlag{
 			Name:  "output, o",
-			Usage: "Output format, One of: json|yaml|table",
+			Usage: "Output format, One of: json|yaml|table|go-template=TEMPLATE|jsonpath=TEMPLATE",
 		},
 		cli.BoolFlag{
 			Name:  "quiet, q",
@@ -238,8 +314,11 @@ var containerStatusCommand = cli.Command{
 			return err
 		}
 
-		for i := 0; i < context.NArg(); i++ {
-			containerID := context.Args().Get(i)
+		containerIDs, err := resolveContainerIDs(runtimeClient, context.Args())
+		if err != nil {
+			return err
+		}
+		for _, containerID := range containerIDs {
 			err := ContainerStatus(runtimeClient, containerID, context.String("output"), context.Bool("quiet"))
 			if err != nil {
 				return fmt.Errorf("Getting the status of the container %q failed: %v", containerID, err)
@@ -284,7 +363,7 @@ var listContainersCommand = cli.Command{
 		},
 		cli.StringFlag{
 			Name:  "output, o",
-			Usage: "Output format, One of: json|yaml|table",
+			Usage: "Output format, One of: json|yaml|table|go-template=TEMPLATE|jsonpath=TEMPLATE",
 		},
 		cli.BoolFlag{
 			Name:  "all, a",
@@ -302,6 +381,10 @@ var listContainersCommand = cli.Command{
 			Name:  "no-trunc",
 			Usage: "Show output without truncating the ID",
 		},
+		cli.StringFlag{
+			Name:  "columns",
+			Usage: "Comma separated `COLUMNS` shown in table output",
+		},
 	},
 	Action: func(context *cli.Context) error {
 		var err error
@@ -320,6 +403,7 @@ var listContainersCommand = cli.Command{
 			latest:  context.Bool("latest"),
 			last:    context.Int("last"),
 			noTrunc: context.Bool("no-trunc"),
+			columns: output.ParseColumns(context.String("columns")),
 		}
 		opts.labels, err = parseLabelStringSlice(context.StringSlice("label"))
 		if err != nil {
@@ -335,32 +419,19 @@ var listContainersCommand = cli.Command{
 
 // CreateContainer sends a CreateContainerRequest to the server, and parses
 // the returned CreateContainerResponse.
-func CreateContainer(client pb.RuntimeServiceClient, opts createOptions) error {
-	config, err := loadContainerConfig(opts.configPath)
-	if err != nil {
-		return err
-	}
-	var podConfig *pb.PodSandboxConfig
-	if opts.podConfig != "" {
-		podConfig, err = loadPodSandboxConfig(opts.podConfig)
-		if err != nil {
-			return err
-		}
-	}
-
+func CreateContainer(client pb.RuntimeServiceClient, opts createOptions) (string, error) {
 	request := &pb.CreateContainerRequest{
 		PodSandboxId:  opts.podID,
-		Config:        config,
-		SandboxConfig: podConfig,
+		Config:        opts.config,
+		SandboxConfig: opts.podConfig,
 	}
 	logrus.Debugf("CreateContainerRequest: %v", request)
 	r, err := client.CreateContainer(context.Background(), request)
 	logrus.Debugf("CreateContainerResponse: %v", r)
 	if err != nil {
-		return err
+		return "", err
 	}
-	fmt.Println(r.ContainerId)
-	return nil
+	return r.ContainerId, nil
 }
 
 // StartContainer sends a StartContainerRequest to the server, and parses
@@ -447,6 +518,22 @@ func StopContainer(client pb.RuntimeServiceClient, ID string, timeout int64) err
 	return nil
 }
 
+// stopRunningContainer stops the container without grace period if it is
+// running.
+func stopRunningContainer(client pb.RuntimeServiceClient, ID string) error {
+	request := &pb.ContainerStatusRequest{ContainerId: ID}
+	logrus.Debugf("ContainerStatusRequest: %v", request)
+	r, err := client.ContainerStatus(context.Background(), request)
+	logrus.Debugf("ContainerStatusResponse: %v", r)
+	if err != nil {
+		return err
+	}
+	if r.GetStatus().GetState() != pb.ContainerState_CONTAINER_RUNNING {
+		return nil
+	}
+	return StopContainer(client, ID, 0)
+}
+
 // RemoveContainer sends a RemoveContainerRequest to the server, and parses
 // the returned RemoveContainerResponse.
 func RemoveContainer(client pb.RuntimeServiceClient, ID string) error {
@@ -469,7 +556,7 @@ func RemoveContainer(client pb.RuntimeServiceClient, ID string) error {
 // marshalContainerStatus converts container status into string and converts
 // the timestamps into readable format.
 func marshalContainerStatus(cs *pb.ContainerStatus) (string, error) {
-	statusStr, err := protobufObjectToJSON(cs)
+	statusStr, err := output.ProtobufToJSON(cs)
 	if err != nil {
 		return "", err
 	}
@@ -486,10 +573,10 @@ func marshalContainerStatus(cs *pb.ContainerStatus) (string, error) {
 
 // ContainerStatus sends a ContainerStatusRequest to the server, and parses
 // the returned ContainerStatusResponse.
-func ContainerStatus(client pb.RuntimeServiceClient, ID, output string, quiet bool) error {
+func ContainerStatus(client pb.RuntimeServiceClient, ID, format string, quiet bool) error {
 	verbose := !(quiet)
-	if output == "" { // default to json output
-		output = "json"
+	if format == "" { // default to json output
+		format = "json"
 	}
 	if ID == "" {
 		return fmt.Errorf("ID cannot be empty")
@@ -510,12 +597,15 @@ func ContainerStatus(client pb.RuntimeServiceClient, ID, output string, quiet bo
 		return err
 	}
 
-	switch output {
+	if output.IsTemplate(format) {
+		return outputStatusInfo(status, r.Info, format)
+	}
+	switch format {
 	case "json", "yaml":
-		return outputStatusInfo(status, r.Info, output)
+		return outputStatusInfo(status, r.Info, format)
 	case "table": // table output is after this switch block
 	default:
-		return fmt.Errorf("output option cannot be %s", output)
+		return fmt.Errorf("output option cannot be %s", format)
 	}
 
 	// output in table format
@@ -543,7 +633,7 @@ func ContainerStatus(client pb.RuntimeServiceClient, ID, output string, quiet bo
 		fmt.Printf("Exit Code: %v\n", r.Status.ExitCode)
 	}
 	if verbose {
-		fmt.Printf("Info: %v\n", r.GetInfo())
+		return printInfo(r.GetInfo())
 	}
 
 	return nil
@@ -565,23 +655,12 @@ func ListContainers(client pb.RuntimeServiceClient, opts listOptions) error {
 		filter.State = st
 	}
 	if opts.state != "" {
-		st.State = pb.ContainerState_CONTAINER_UNKNOWN
-		switch strings.ToLower(opts.state) {
-		case "created":
-			st.State = pb.ContainerState_CONTAINER_CREATED
-			filter.State = st
-		case "running":
-			st.State = pb.ContainerState_CONTAINER_RUNNING
-			filter.State = st
-		case "exited":
-			st.State = pb.ContainerState_CONTAINER_EXITED
-			filter.State = st
-		case "unknown":
-			st.State = pb.ContainerState_CONTAINER_UNKNOWN
-			filter.State = st
-		default:
-			log.Fatalf("--state should be one of created, running, exited or unknown")
+		state, err := parseContainerState(opts.state)
+		if err != nil {
+			log.Fatal(err)
 		}
+		st.State = state
+		filter.State = st
 	}
 	if opts.latest || opts.last > 0 {
 		// Do not filter by state if latest/last is specified.
@@ -601,76 +680,76 @@ func ListContainers(client pb.RuntimeServiceClient, opts listOptions) error {
 	}
 	r.Containers = getContainersList(r.GetContainers(), opts)
 
-	switch opts.output {
-	case "json":
-		return outputProtobufObjAsJSON(r)
-	case "yaml":
-		return outputProtobufObjAsYAML(r)
-	case "table", "":
-	// continue; output will be generated after the switch block ends.
-	default:
-		return fmt.Errorf("unsupported output format %q", opts.output)
+	if opts.verbose && !opts.quiet && isTableOutput(opts.output) {
+		for _, c := range r.Containers {
+			printContainerVerbose(c)
+		}
+		return nil
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
-	if !opts.verbose && !opts.quiet {
-		fmt.Fprintln(w, "CONTAINER ID\tIMAGE\tCREATED\tSTATE\tNAME\tATTEMPT\tPOD ID")
-	}
+	outputOpts := opts.outputOptions()
+	table := output.NewTable(
+		output.Column{Name: output.IDColumn, Header: "CONTAINER ID"},
+		output.Column{Name: "image", Header: "IMAGE"},
+		output.Column{Name: "created", Header: "CREATED"},
+		output.Column{Name: "state", Header: "STATE"},
+		output.Column{Name: "name", Header: "NAME"},
+		output.Column{Name: "attempt", Header: "ATTEMPT"},
+		output.Column{Name: "pod", Header: "POD ID"},
+	)
 	for _, c := range r.Containers {
-		if opts.quiet {
-			fmt.Printf("%s\n", c.Id)
-			continue
-		}
-
 		createdAt := time.Unix(0, c.CreatedAt)
 		ctm := units.HumanDuration(time.Now().UTC().Sub(createdAt)) + " ago"
-		if !opts.verbose {
-			id := c.Id
-			image := c.Image.Image
-			if !opts.noTrunc {
-				id = getTruncatedID(id, "")
-
-				// Now c.Image.Image is imageID in kubelet.
-				if digest, err := godigest.Parse(image); err == nil {
-					image = getTruncatedID(digest.String(), string(digest.Algorithm())+":")
-				}
+		image := c.Image.Image
+		if !outputOpts.NoTrunc {
+			// Now c.Image.Image is imageID in kubelet.
+			if digest, err := godigest.Parse(image); err == nil {
+				image = output.TruncateID(digest.String(), string(digest.Algorithm())+":", false)
 			}
-			PodID := getTruncatedID(c.PodSandboxId, "")
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n",
-				id, image, ctm, convertContainerState(c.State), c.Metadata.Name, c.Metadata.Attempt, PodID)
-			continue
 		}
+		table.AddRow(
+			output.TruncateID(c.Id, "", outputOpts.NoTrunc),
+			image,
+			ctm,
+			convertContainerState(c.State),
+			c.Metadata.Name,
+			fmt.Sprintf("%d", c.Metadata.Attempt),
+			output.TruncateID(c.PodSandboxId, "", outputOpts.NoTrunc),
+		)
+	}
+	return output.Write(os.Stdout, r, table, outputOpts)
+}
 
-		fmt.Printf("ID: %s\n", c.Id)
-		fmt.Printf("PodID: %s\n", c.PodSandboxId)
-		if c.Metadata != nil {
-			if c.Metadata.Name != "" {
-				fmt.Printf("Name: %s\n", c.Metadata.Name)
-			}
-			fmt.Printf("Attempt: %v\n", c.Metadata.Attempt)
-		}
-		fmt.Printf("State: %s\n", convertContainerState(c.State))
-		if c.Image != nil {
-			fmt.Printf("Image: %s\n", c.Image.Image)
+// printContainerVerbose prints the verbose information of a container.
+func printContainerVerbose(c *pb.Container) {
+	createdAt := time.Unix(0, c.CreatedAt)
+	ctm := units.HumanDuration(time.Now().UTC().Sub(createdAt)) + " ago"
+	fmt.Printf("ID: %s\n", c.Id)
+	fmt.Printf("PodID: %s\n", c.PodSandboxId)
+	if c.Metadata != nil {
+		if c.Metadata.Name != "" {
+			fmt.Printf("Name: %s\n", c.Metadata.Name)
 		}
-		fmt.Printf("Created: %v\n", ctm)
-		if c.Labels != nil {
-			fmt.Println("Labels:")
-			for _, k := range getSortedKeys(c.Labels) {
-				fmt.Printf("\t%s -> %s\n", k, c.Labels[k])
-			}
+		fmt.Printf("Attempt: %v\n", c.Metadata.Attempt)
+	}
+	fmt.Printf("State: %s\n", convertContainerState(c.State))
+	if c.Image != nil {
+		fmt.Printf("Image: %s\n", c.Image.Image)
+	}
+	fmt.Printf("Created: %v\n", ctm)
+	if c.Labels != nil {
+		fmt.Println("Labels:")
+		for _, k := range getSortedKeys(c.Labels) {
+			fmt.Printf("\t%s -> %s\n", k, c.Labels[k])
 		}
-		if c.Annotations != nil {
-			fmt.Println("Annotations:")
-			for _, k := range getSortedKeys(c.Annotations) {
-				fmt.Printf("\t%s -> %s\n", k, c.Annotations[k])
-			}
+	}
+	if c.Annotations != nil {
+		fmt.Println("Annotations:")
+		for _, k := range getSortedKeys(c.Annotations) {
+			fmt.Printf("\t%s -> %s\n", k, c.Annotations[k])
 		}
-		fmt.Println()
 	}
-
-	w.Flush()
-	return nil
+	fmt.Println()
 }
 
 func convertContainerState(state pb.ContainerState) string {
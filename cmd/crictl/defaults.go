@@ -0,0 +1,78 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/pborman/uuid"
+	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+const (
+	// defaultPodNamespace is the namespace of pods which don't set one.
+	defaultPodNamespace = "default"
+	// defaultPodLogsRoot is the directory of the pod log directories, as
+	// used by the kubelet.
+	defaultPodLogsRoot = "/var/log/pods"
+)
+
+// setPodSandboxConfigDefaults fills in the fields of a partial pod config.
+// The UID defaults to one derived from the namespace and name of the pod, so
+// that the same config can be given to runp and create.
+func setPodSandboxConfigDefaults(config *pb.PodSandboxConfig) error {
+	if config.Metadata == nil || config.Metadata.Name == "" {
+		return fmt.Errorf("pod config must set metadata.name")
+	}
+	if config.Metadata.Namespace == "" {
+		config.Metadata.Namespace = defaultPodNamespace
+	}
+	if config.Metadata.Uid == "" {
+		config.Metadata.Uid = podUID(config.Metadata.Namespace, config.Metadata.Name)
+	}
+	if config.LogDirectory == "" {
+		config.LogDirectory = filepath.Join(defaultPodLogsRoot, config.Metadata.Uid)
+	}
+	if config.Linux == nil {
+		config.Linux = &pb.LinuxPodSandboxConfig{}
+	}
+	return nil
+}
+
+// setContainerConfigDefaults fills in the fields of a partial container
+// config.
+func setContainerConfigDefaults(config *pb.ContainerConfig) error {
+	if config.Metadata == nil || config.Metadata.Name == "" {
+		return fmt.Errorf("container config must set metadata.name")
+	}
+	if config.Image == nil || config.Image.Image == "" {
+		return fmt.Errorf("container config must set image.image")
+	}
+	if config.LogPath == "" {
+		config.LogPath = filepath.Join(config.Metadata.Name, fmt.Sprintf("%d.log", config.Metadata.Attempt))
+	}
+	if config.Linux == nil {
+		config.Linux = &pb.LinuxContainerConfig{}
+	}
+	return nil
+}
+
+// podUID returns the UID of the pod name in namespace.
+func podUID(namespace, name string) string {
+	return uuid.NewSHA1(uuid.NameSpace_URL, []byte(namespace+"/"+name)).String()
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+func TestSetPodSandboxConfigDefaults(t *testing.T) {
+	uid := podUID("default", "nginx")
+	testCases := []struct {
+		desc      string
+		config    *pb.PodSandboxConfig
+		expected  *pb.PodSandboxConfig
+		expectErr bool
+	}{
+		{
+			desc: "partial config",
+			config: &pb.PodSandboxConfig{
+				Metadata: &pb.PodSandboxMetadata{Name: "nginx"},
+			},
+			expected: &pb.PodSandboxConfig{
+				Metadata:     &pb.PodSandboxMetadata{Name: "nginx", Namespace: "default", Uid: uid},
+				LogDirectory: "/var/log/pods/" + uid,
+				Linux:        &pb.LinuxPodSandboxConfig{},
+			},
+		},
+		{
+			desc: "complete config",
+			config: &pb.PodSandboxConfig{
+				Metadata:     &pb.PodSandboxMetadata{Name: "nginx", Namespace: "web", Uid: "1234", Attempt: 2},
+				LogDirectory: "/tmp",
+				Linux:        &pb.LinuxPodSandboxConfig{CgroupParent: "/pods"},
+			},
+			expected: &pb.PodSandboxConfig{
+				Metadata:     &pb.PodSandboxMetadata{Name: "nginx", Namespace: "web", Uid: "1234", Attempt: 2},
+				LogDirectory: "/tmp",
+				Linux:        &pb.LinuxPodSandboxConfig{CgroupParent: "/pods"},
+			},
+		},
+		{
+			desc:      "missing name",
+			config:    &pb.PodSandboxConfig{Metadata: &pb.PodSandboxMetadata{Namespace: "web"}},
+			expectErr: true,
+		},
+		{
+			desc:      "missing metadata",
+			config:    &pb.PodSandboxConfig{},
+			expectErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := setPodSandboxConfigDefaults(tc.config)
+			if tc.expectErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tc.config, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, tc.config)
+			}
+		})
+	}
+}
+
+func TestSetContainerConfigDefaults(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		config    *pb.ContainerConfig
+		expected  *pb.ContainerConfig
+		expectErr bool
+	}{
+		{
+			desc: "partial config",
+			config: &pb.ContainerConfig{
+				Metadata: &pb.ContainerMetadata{Name: "busybox", Attempt: 1},
+				Image:    &pb.ImageSpec{Image: "busybox"},
+			},
+			expected: &pb.ContainerConfig{
+				Metadata: &pb.ContainerMetadata{Name: "busybox", Attempt: 1},
+				Image:    &pb.ImageSpec{Image: "busybox"},
+				LogPath:  "busybox/1.log",
+				Linux:    &pb.LinuxContainerConfig{},
+			},
+		},
+		{
+			desc: "log path is kept",
+			config: &pb.ContainerConfig{
+				Metadata: &pb.ContainerMetadata{Name: "busybox"},
+				Image:    &pb.ImageSpec{Image: "busybox"},
+				LogPath:  "busybox.log",
+				Linux:    &pb.LinuxContainerConfig{},
+			},
+			expected: &pb.ContainerConfig{
+				Metadata: &pb.ContainerMetadata{Name: "busybox"},
+				Image:    &pb.ImageSpec{Image: "busybox"},
+				LogPath:  "busybox.log",
+				Linux:    &pb.LinuxContainerConfig{},
+			},
+		},
+		{
+			desc:      "missing name",
+			config:    &pb.ContainerConfig{Image: &pb.ImageSpec{Image: "busybox"}},
+			expectErr: true,
+		},
+		{
+			desc:      "missing image",
+			config:    &pb.ContainerConfig{Metadata: &pb.ContainerMetadata{Name: "busybox"}},
+			expectErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := setContainerConfigDefaults(tc.config)
+			if tc.expectErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tc.config, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, tc.config)
+			}
+		})
+	}
+}
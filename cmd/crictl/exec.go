@@ -18,17 +18,21 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"net/url"
 	"strings"
+	"sync"
 
 	dockerterm "github.com/docker/docker/pkg/term"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 	"golang.org/x/net/context"
-	restclient "k8s.io/client-go/rest"
 	remoteclient "k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
 	"k8s.io/kubernetes/pkg/kubectl/util/term"
 	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/streaming"
 )
 
 const (
@@ -70,22 +74,33 @@ var runtimeExecCommand = cli.Command{
 			return err
 		}
 
+		id, err := resolveContainerID(runtimeClient, context.Args().First())
+		if err != nil {
+			return err
+		}
 		var opts = execOptions{
-			id:      context.Args().First(),
+			id:      id,
 			timeout: context.Int64("timeout"),
 			tty:     context.Bool("tty"),
 			stdin:   context.Bool("interactive"),
 			cmd:     context.Args()[1:],
 		}
 		if context.Bool("sync") {
-			err := ExecSync(runtimeClient, opts)
+			exitCode, err := ExecSync(runtimeClient, opts)
 			if err != nil {
 				return fmt.Errorf("execing command in container synchronously failed: %v", err)
 			}
+			if exitCode != 0 {
+				return cli.NewExitError("", exitCode)
+			}
 			return nil
 		}
-		err := Exec(runtimeClient, opts)
+		err = Exec(runtimeClient, opts)
 		if err != nil {
+			// Exit with the exit code of the remote command.
+			if exitErr, ok := err.(utilexec.ExitError); ok && exitErr.Exited() {
+				return cli.NewExitError("", exitErr.ExitStatus())
+			}
 			return fmt.Errorf("execing command in container failed: %v", err)
 		}
 		return nil
@@ -94,8 +109,8 @@ var runtimeExecCommand = cli.Command{
 }
 
 // ExecSync sends an ExecSyncRequest to the server, and parses
-// the returned ExecSyncResponse.
-func ExecSync(client pb.RuntimeServiceClient, opts execOptions) error {
+// the returned ExecSyncResponse. It returns the exit code of the command.
+func ExecSync(client pb.RuntimeServiceClient, opts execOptions) (int, error) {
 	request := &pb.ExecSyncRequest{
 		ContainerId: opts.id,
 		Cmd:         opts.cmd,
@@ -105,7 +120,7 @@ func ExecSync(client pb.RuntimeServiceClient, opts execOptions) error {
 	r, err := client.ExecSync(context.Background(), request)
 	logrus.Debugf("ExecSyncResponse: %v", r)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	fmt.Println(string(r.Stdout))
 	fmt.Println(string(r.Stderr))
@@ -113,7 +128,7 @@ func ExecSync(client pb.RuntimeServiceClient, opts execOptions) error {
 		fmt.Printf("Exit code: %v\n", r.ExitCode)
 	}
 
-	return nil
+	return int(r.ExitCode), nil
 }
 
 // Exec sends an ExecRequest to server, and parses the returned ExecResponse
@@ -145,11 +160,14 @@ func Exec(client pb.RuntimeServiceClient, opts execOptions) error {
 	}
 
 	logrus.Debugf("Exec URL: %v", URL)
-	return stream(opts.stdin, opts.tty, URL)
+	return stream(opts.stdin, opts.tty, nil, URL)
 }
 
-func stream(in, tty bool, url *url.URL) error {
-	executor, err := remoteclient.NewSPDYExecutor(&restclient.Config{TLSClientConfig: restclient.TLSClientConfig{Insecure: true}}, "POST", url)
+// stream streams the IO of url to the local terminal. When a TTY is used and
+// detachKeys is not empty, reading detachKeys from stdin detaches from the
+// stream.
+func stream(in, tty bool, detachKeys []byte, url *url.URL) error {
+	executor, err := streaming.NewExecutor(url, StreamingProtocol)
 	if err != nil {
 		return err
 	}
@@ -178,6 +196,45 @@ func stream(in, tty bool, url *url.URL) error {
 	if !t.IsTerminalIn() {
 		return fmt.Errorf("input is not a terminal")
 	}
+	var detached chan struct{}
+	if len(detachKeys) > 0 {
+		detached = make(chan struct{})
+		streamOptions.Stdin = &detachReader{
+			r:        dockerterm.NewEscapeProxy(stdin, detachKeys),
+			detached: detached,
+		}
+	}
 	streamOptions.TerminalSizeQueue = t.MonitorSize(t.GetSize())
-	return t.Safe(func() error { return executor.Stream(streamOptions) })
+	return t.Safe(func() error {
+		if detached == nil {
+			return executor.Stream(streamOptions)
+		}
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- executor.Stream(streamOptions)
+		}()
+		select {
+		case err := <-errCh:
+			return err
+		case <-detached:
+			logrus.Debugf("Detached from %v", url)
+			return nil
+		}
+	})
+}
+
+// detachReader closes detached once the escape proxy it wraps reads the
+// detach key sequence.
+type detachReader struct {
+	r        io.Reader
+	detached chan struct{}
+	once     sync.Once
+}
+
+func (d *detachReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	if _, ok := err.(dockerterm.EscapeError); ok {
+		d.once.Do(func() { close(d.detached) })
+	}
+	return n, err
 }
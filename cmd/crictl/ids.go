@@ -0,0 +1,188 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// imageIDPrefix is the digest algorithm prefix of image IDs, which can be
+// omitted from short image IDs.
+const imageIDPrefix = "sha256:"
+
+// matchIDPrefix returns the ID of ids which starts with prefix. An exact
+// match is always returned, and an empty string if no ID matches. It fails if
+// several IDs match.
+func matchIDPrefix(ids []string, prefix string) (string, error) {
+	var matches []string
+	for _, id := range ids {
+		if id == prefix {
+			return id, nil
+		}
+		if strings.HasPrefix(id, prefix) {
+			matches = append(matches, id)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", nil
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("ID %q is ambiguous, it matches %s", prefix, strings.Join(matches, ", "))
+	}
+}
+
+// resolveID returns the full ID matching the short ID id among ids, or id
+// itself if none matches, to let the runtime resolve or reject it.
+func resolveID(ids []string, id string) (string, error) {
+	match, err := matchIDPrefix(ids, id)
+	if err != nil {
+		return "", err
+	}
+	if match == "" {
+		return id, nil
+	}
+	if match != id {
+		logrus.Debugf("Resolved ID %q to %q", id, match)
+	}
+	return match, nil
+}
+
+// resolveContainerID returns the full ID of the container with the short ID id.
+func resolveContainerID(client pb.RuntimeServiceClient, id string) (string, error) {
+	ids, err := listContainerIDs(client)
+	if err != nil {
+		return "", err
+	}
+	return resolveID(ids, id)
+}
+
+// resolvePodSandboxID returns the full ID of the pod with the short ID id.
+func resolvePodSandboxID(client pb.RuntimeServiceClient, id string) (string, error) {
+	ids, err := listPodSandboxIDs(client)
+	if err != nil {
+		return "", err
+	}
+	return resolveID(ids, id)
+}
+
+// resolveImageID returns the full ID of the image with the short ID ref,
+// with or without the sha256: prefix. Image references which are not short
+// IDs are returned as is.
+func resolveImageID(client pb.ImageServiceClient, ref string) (string, error) {
+	request := &pb.ListImagesRequest{}
+	logrus.Debugf("ListImagesRequest: %v", request)
+	r, err := client.ListImages(context.Background(), request)
+	logrus.Debugf("ListImagesResponse: %v", r)
+	if err != nil {
+		return "", err
+	}
+	var ids []string
+	for _, image := range r.GetImages() {
+		for _, tag := range image.RepoTags {
+			if tag == ref {
+				return ref, nil
+			}
+		}
+		ids = append(ids, strings.TrimPrefix(image.Id, imageIDPrefix))
+	}
+	match, err := matchIDPrefix(ids, strings.TrimPrefix(ref, imageIDPrefix))
+	if err != nil || match == "" {
+		return ref, err
+	}
+	for _, image := range r.GetImages() {
+		if strings.TrimPrefix(image.Id, imageIDPrefix) == match {
+			return image.Id, nil
+		}
+	}
+	return ref, nil
+}
+
+// resolveContainerIDs resolves the short IDs of containers in ids.
+func resolveContainerIDs(client pb.RuntimeServiceClient, ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return ids, nil
+	}
+	all, err := listContainerIDs(client)
+	if err != nil {
+		return nil, err
+	}
+	return resolveIDs(all, ids)
+}
+
+// resolvePodSandboxIDs resolves the short IDs of pods in ids.
+func resolvePodSandboxIDs(client pb.RuntimeServiceClient, ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return ids, nil
+	}
+	all, err := listPodSandboxIDs(client)
+	if err != nil {
+		return nil, err
+	}
+	return resolveIDs(all, ids)
+}
+
+// resolveIDs resolves each of the short IDs among all the IDs.
+func resolveIDs(all, ids []string) ([]string, error) {
+	var resolved []string
+	for _, id := range ids {
+		r, err := resolveID(all, id)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, r)
+	}
+	return resolved, nil
+}
+
+// listContainerIDs returns the IDs of all the containers.
+func listContainerIDs(client pb.RuntimeServiceClient) ([]string, error) {
+	request := &pb.ListContainersRequest{}
+	logrus.Debugf("ListContainerRequest: %v", request)
+	r, err := client.ListContainers(context.Background(), request)
+	logrus.Debugf("ListContainerResponse: %v", r)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, c := range r.GetContainers() {
+		ids = append(ids, c.Id)
+	}
+	return ids, nil
+}
+
+// listPodSandboxIDs returns the IDs of all the pods.
+func listPodSandboxIDs(client pb.RuntimeServiceClient) ([]string, error) {
+	request := &pb.ListPodSandboxRequest{}
+	logrus.Debugf("ListPodSandboxRequest: %v", request)
+	r, err := client.ListPodSandbox(context.Background(), request)
+	logrus.Debugf("ListPodSandboxResponse: %v", r)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, p := range r.GetItems() {
+		ids = append(ids, p.Id)
+	}
+	return ids, nil
+}
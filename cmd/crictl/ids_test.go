@@ -0,0 +1,86 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchIDPrefix(t *testing.T) {
+	ids := []string{"abc123", "abd456", "abc"}
+	testCases := []struct {
+		name      string
+		prefix    string
+		expected  string
+		expectErr bool
+	}{
+		{name: "exact match", prefix: "abc", expected: "abc"},
+		{name: "unique prefix", prefix: "abd", expected: "abd456"},
+		{name: "full ID", prefix: "abc123", expected: "abc123"},
+		{name: "no match", prefix: "xyz", expected: ""},
+		{name: "ambiguous prefix", prefix: "ab", expectErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			match, err := matchIDPrefix(ids, tc.prefix)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error for %q, got %q", tc.prefix, match)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if match != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, match)
+			}
+		})
+	}
+}
+
+func TestResolveIDs(t *testing.T) {
+	all := []string{"0123456789", "abcdef", "abd"}
+	testCases := []struct {
+		name      string
+		ids       []string
+		expected  []string
+		expectErr bool
+	}{
+		{name: "short IDs", ids: []string{"012", "abc"}, expected: []string{"0123456789", "abcdef"}},
+		{name: "unknown ID", ids: []string{"xyz"}, expected: []string{"xyz"}},
+		{name: "ambiguous ID", ids: []string{"012", "ab"}, expectErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resolved, err := resolveIDs(all, tc.ids)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", resolved)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(resolved, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, resolved)
+			}
+		})
+	}
+}
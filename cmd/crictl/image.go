@@ -17,20 +17,28 @@ limitations under the License.
 package main
 
 import (
+	"bufio"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 	"strings"
-	"text/tabwriter"
+	"sync"
+	"time"
 
 	units "github.com/docker/go-units"
+	"github.com/kubernetes-sigs/cri-tools/pkg/output"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 	"golang.org/x/net/context"
 	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 )
 
+// defaultPullParallelism is the default number of images pulled concurrently.
+const defaultPullParallelism = 3
+
 type imageByRef []*pb.Image
 
 func (a imageByRef) Len() int      { return len(a) }
@@ -47,7 +55,7 @@ func (a imageByRef) Less(i, j int) bool {
 
 var pullImageCommand = cli.Command{
 	Name:                   "pull",
-	Usage:                  "Pull an image from a registry",
+	Usage:                  "Pull one or more images from a registry",
 	SkipArgReorder:         true,
 	UseShortOptionHandling: true,
 	Flags: []cli.Flag{
@@ -56,16 +64,29 @@ var pullImageCommand = cli.Command{
 			Value: "",
 			Usage: "Use `USERNAME[:PASSWORD]` for accessing the registry",
 		},
+		cli.StringFlag{
+			Name:  "auth",
+			Value: "",
+			Usage: "Use `AUTH_STRING` for accessing the registry. AUTH_STRING is a base64 encoded 'USERNAME[:PASSWORD]'",
+		},
 		cli.StringFlag{
 			Name:  "pod-config",
 			Value: "",
 			Usage: "Use `pod-config.[json|yaml]` to override the the pull context",
 		},
+		cli.DurationFlag{
+			Name:  "pull-timeout",
+			Usage: "Maximum time to wait for pulling each image, 0 means no timeout",
+		},
+		cli.IntFlag{
+			Name:  "parallel",
+			Value: defaultPullParallelism,
+			Usage: "Maximum number of images pulled concurrently",
+		},
 	},
-	ArgsUsage: "NAME[:TAG|@DIGEST]",
+	ArgsUsage: "NAME[:TAG|@DIGEST] [NAME[:TAG|@DIGEST]...]",
 	Action: func(context *cli.Context) error {
-		imageName := context.Args().First()
-		if imageName == "" {
+		if context.NArg() == 0 {
 			return cli.ShowSubcommandHelp(context)
 		}
 
@@ -73,28 +94,59 @@ var pullImageCommand = cli.Command{
 			return err
 		}
 
-		var auth *pb.AuthConfig
-		if context.IsSet("creds") {
-			var err error
-			auth, err = getAuth(context.String("creds"))
-			if err != nil {
-				return err
-			}
+		auth, err := getAuth(context.String("creds"), context.String("auth"))
+		if err != nil {
+			return err
 		}
 		var sandbox *pb.PodSandboxConfig
 		if context.IsSet("pod-config") {
-			var err error
 			sandbox, err = loadPodSandboxConfig(context.String("pod-config"))
 			if err != nil {
 				return fmt.Errorf("load podSandboxConfig failed: %v", err)
 			}
 		}
 
-		r, err := PullImageWithSandbox(imageClient, imageName, auth, sandbox)
-		if err != nil {
-			return fmt.Errorf("pulling image failed: %v", err)
+		parallel := context.Int("parallel")
+		if parallel < 1 {
+			return fmt.Errorf("parallel should be at least 1, got %d", parallel)
+		}
+		timeout := context.Duration("pull-timeout")
+
+		var (
+			wg     sync.WaitGroup
+			mu     sync.Mutex
+			failed []string
+			errs   = make(map[string]error)
+		)
+		sem := make(chan struct{}, parallel)
+		for _, imageName := range context.Args() {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(imageName string) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				r, err := PullImageWithSandbox(imageClient, imageName, auth, sandbox, timeout)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					failed = append(failed, imageName)
+					errs[imageName] = err
+					return
+				}
+				fmt.Printf("Image is up to date for %s\n", r.ImageRef)
+			}(imageName)
+		}
+		wg.Wait()
+
+		if context.NArg() == 1 && len(failed) == 1 {
+			return fmt.Errorf("pulling image failed: %v", errs[failed[0]])
+		}
+		for _, imageName := range failed {
+			logrus.Errorf("pulling image %q failed: %v", imageName, errs[imageName])
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("pulling images failed: %s", strings.Join(failed, ", "))
 		}
-		fmt.Printf("Image is up to date for %s\n", r.ImageRef)
 		return nil
 	},
 }
@@ -116,7 +168,7 @@ var listImageCommand = cli.Command{
 		},
 		cli.StringFlag{
 			Name:  "output, o",
-			Usage: "Output format, One of: json|yaml|table",
+			Usage: "Output format, One of: json|yaml|table|go-template=TEMPLATE|jsonpath=TEMPLATE",
 		},
 		cli.BoolFlag{
 			Name:  "digests",
@@ -126,82 +178,99 @@ var listImageCommand = cli.Command{
 			Name:  "no-trunc",
 			Usage: "Show output without truncating the ID",
 		},
+		cli.BoolFlag{
+			Name:  "dangling",
+			Usage: "Only show images not used by any container",
+		},
+		cli.StringFlag{
+			Name:  "columns",
+			Usage: "Comma separated `COLUMNS` shown in table output",
+		},
 	},
 	Action: func(context *cli.Context) error {
-		if err := getImageClient(context); err != nil {
-			return err
-		}
+		var r *pb.ListImagesResponse
+		if context.Bool("dangling") {
+			if context.NArg() != 0 {
+				return fmt.Errorf("image name can't be specified with --dangling")
+			}
+			images, err := listDanglingImages(context)
+			if err != nil {
+				return err
+			}
+			r = &pb.ListImagesResponse{Images: images}
+		} else {
+			if err := getImageClient(context); err != nil {
+				return err
+			}
 
-		r, err := ListImages(imageClient, context.Args().First())
-		if err != nil {
-			return fmt.Errorf("listing images failed: %v", err)
+			var err error
+			r, err = ListImages(imageClient, context.Args().First())
+			if err != nil {
+				return fmt.Errorf("listing images failed: %v", err)
+			}
 		}
 		sort.Sort(imageByRef(r.Images))
 
-		switch context.String("output") {
-		case "json":
-			return outputProtobufObjAsJSON(r)
-		case "yaml":
-			return outputProtobufObjAsYAML(r)
-		}
-
-		// output in table format by default.
-		w := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
-		verbose := context.Bool("verbose")
-		showDigest := context.Bool("digests")
-		quiet := context.Bool("quiet")
-		noTrunc := context.Bool("no-trunc")
-		if !verbose && !quiet {
-			if showDigest {
-				fmt.Fprintln(w, "IMAGE\tTAG\tDIGEST\tIMAGE ID\tSIZE")
-			} else {
-				fmt.Fprintln(w, "IMAGE\tTAG\tIMAGE ID\tSIZE")
+		if context.Bool("verbose") && !context.Bool("quiet") && isTableOutput(context.String("output")) {
+			for _, image := range r.Images {
+				printImageVerbose(image)
 			}
+			return nil
+		}
+
+		outputOpts := output.Options{
+			Format:  context.String("output"),
+			Columns: output.ParseColumns(context.String("columns")),
+			NoTrunc: context.Bool("no-trunc") || context.Bool("quiet"),
+			Quiet:   context.Bool("quiet"),
+		}
+		if len(outputOpts.Columns) == 0 && !context.Bool("digests") {
+			outputOpts.Columns = []string{"image", "tag", "id", "size"}
 		}
+		table := output.NewTable(
+			output.Column{Name: "image", Header: "IMAGE"},
+			output.Column{Name: "tag", Header: "TAG"},
+			output.Column{Name: "digest", Header: "DIGEST"},
+			output.Column{Name: output.IDColumn, Header: "IMAGE ID"},
+			output.Column{Name: "size", Header: "SIZE"},
+		)
 		for _, image := range r.Images {
-			if quiet {
-				fmt.Printf("%s\n", image.Id)
-				continue
-			}
-			if !verbose {
-				imageName, repoDigest := normalizeRepoDigest(image.RepoDigests)
-				repoTagPairs := normalizeRepoTagPair(image.RepoTags, imageName)
-				size := units.HumanSizeWithPrecision(float64(image.GetSize_()), 3)
-				id := image.Id
-				if !noTrunc {
-					id = getTruncatedID(id, "sha256:")
-				}
-				for _, repoTagPair := range repoTagPairs {
-					if showDigest {
-						fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", repoTagPair[0], repoTagPair[1], repoDigest, id, size)
-					} else {
-						fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", repoTagPair[0], repoTagPair[1], id, size)
-					}
-				}
-				continue
-			}
-			fmt.Printf("ID: %s\n", image.Id)
-			for _, tag := range image.RepoTags {
-				fmt.Printf("RepoTags: %s\n", tag)
-			}
-			for _, digest := range image.RepoDigests {
-				fmt.Printf("RepoDigests: %s\n", digest)
-			}
-			if image.Size_ != 0 {
-				fmt.Printf("Size: %d\n", image.Size_)
-			}
-			if image.Uid != nil {
-				fmt.Printf("Uid: %v\n", image.Uid)
+			imageName, repoDigest := normalizeRepoDigest(image.RepoDigests)
+			repoTagPairs := normalizeRepoTagPair(image.RepoTags, imageName)
+			size := units.HumanSizeWithPrecision(float64(image.GetSize_()), 3)
+			id := output.TruncateID(image.Id, "sha256:", outputOpts.NoTrunc)
+			if outputOpts.Quiet {
+				// Only list each image once.
+				repoTagPairs = repoTagPairs[:1]
 			}
-			if image.Username != "" {
-				fmt.Printf("Username: %v\n\n", image.Username)
+			for _, repoTagPair := range repoTagPairs {
+				table.AddRow(repoTagPair[0], repoTagPair[1], repoDigest, id, size)
 			}
 		}
-		w.Flush()
-		return nil
+		return output.Write(os.Stdout, r, table, outputOpts)
 	},
 }
 
+// printImageVerbose prints the verbose information of an image.
+func printImageVerbose(image *pb.Image) {
+	fmt.Printf("ID: %s\n", image.Id)
+	for _, tag := range image.RepoTags {
+		fmt.Printf("RepoTags: %s\n", tag)
+	}
+	for _, digest := range image.RepoDigests {
+		fmt.Printf("RepoDigests: %s\n", digest)
+	}
+	if image.Size_ != 0 {
+		fmt.Printf("Size: %d\n", image.Size_)
+	}
+	if image.Uid != nil {
+		fmt.Printf("Uid: %v\n", image.Uid)
+	}
+	if image.Username != "" {
+		fmt.Printf("Username: %v\n\n", image.Username)
+	}
+}
+
 var imageStatusCommand = cli.Command{
 	Name:                   "inspecti",
 	Usage:                  "Return the status of one or more images",
@@ -211,7 +280,7 @@ var imageStatusCommand = cli.Command{
 	Flags: []cli.Flag{
 		cli.StringFlag{
 			Name:  "output, o",
-			Usage: "Output format, One of: json|yaml|table",
+			Usage: "Output format, One of: json|yaml|table|go-template=TEMPLATE|jsonpath=TEMPLATE",
 		},
 		cli.BoolFlag{
 			Name:  "quiet, q",
@@ -226,12 +295,15 @@ var imageStatusCommand = cli.Command{
 			return err
 		}
 		verbose := !(context.Bool("quiet"))
-		output := context.String("output")
-		if output == "" { // default to json output
-			output = "json"
+		format := context.String("output")
+		if format == "" { // default to json output
+			format = "json"
 		}
 		for i := 0; i < context.NArg(); i++ {
-			id := context.Args().Get(i)
+			id, err := resolveImageID(imageClient, context.Args().Get(i))
+			if err != nil {
+				return err
+			}
 
 			r, err := ImageStatus(imageClient, id, verbose)
 			if err != nil {
@@ -242,19 +314,25 @@ var imageStatusCommand = cli.Command{
 				return fmt.Errorf("no such image %q present", id)
 			}
 
-			status, err := protobufObjectToJSON(r.Image)
+			status, err := output.ProtobufToJSON(r.Image)
 			if err != nil {
 				return fmt.Errorf("failed to marshal status to json for %q: %v", id, err)
 			}
-			switch output {
+			if output.IsTemplate(format) {
+				if err := outputStatusInfo(status, r.Info, format); err != nil {
+					return fmt.Errorf("failed to output status for %q: %v", id, err)
+				}
+				continue
+			}
+			switch format {
 			case "json", "yaml":
-				if err := outputStatusInfo(status, r.Info, output); err != nil {
+				if err := outputStatusInfo(status, r.Info, format); err != nil {
 					return fmt.Errorf("failed to output status for %q: %v", id, err)
 				}
 				continue
 			case "table": // table output is after this switch block
 			default:
-				return fmt.Errorf("output option cannot be %s", output)
+				return fmt.Errorf("output option cannot be %s", format)
 			}
 
 			// otherwise output in table format
@@ -268,7 +346,9 @@ var imageStatusCommand = cli.Command{
 			size := units.HumanSizeWithPrecision(float64(image.GetSize_()), 3)
 			fmt.Printf("Size: %s\n", size)
 			if verbose {
-				fmt.Printf("Info: %v\n", r.GetInfo())
+				if err := printInfo(r.GetInfo()); err != nil {
+					return fmt.Errorf("failed to output info for %q: %v", id, err)
+				}
 			}
 		}
 
@@ -280,7 +360,23 @@ var removeImageCommand = cli.Command{
 	Name:      "rmi",
 	Usage:     "Remove one or more images",
 	ArgsUsage: "IMAGE-ID [IMAGE-ID...]",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "prune",
+			Usage: "Remove all images not used by any container",
+		},
+		cli.BoolFlag{
+			Name:  "force, f",
+			Usage: "Do not prompt for confirmation when pruning images",
+		},
+	},
 	Action: func(context *cli.Context) error {
+		if context.Bool("prune") {
+			if context.NArg() != 0 {
+				return fmt.Errorf("image IDs can't be specified with --prune")
+			}
+			return pruneImages(context, context.Bool("force"))
+		}
 		if context.NArg() == 0 {
 			return cli.ShowSubcommandHelp(context)
 		}
@@ -288,7 +384,10 @@ var removeImageCommand = cli.Command{
 			return err
 		}
 		for i := 0; i < context.NArg(); i++ {
-			id := context.Args().Get(i)
+			id, err := resolveImageID(imageClient, context.Args().Get(i))
+			if err != nil {
+				return err
+			}
 
 			var verbose = false
 			status, err := ImageStatus(imageClient, id, verbose)
@@ -311,6 +410,98 @@ var removeImageCommand = cli.Command{
 	},
 }
 
+// pruneImages removes the images not used by any container, asking for
+// confirmation unless force is set.
+func pruneImages(context *cli.Context, force bool) error {
+	images, err := listDanglingImages(context)
+	if err != nil {
+		return err
+	}
+	if len(images) == 0 {
+		fmt.Println("No unused images to remove")
+		return nil
+	}
+
+	if !force {
+		fmt.Printf("This will remove %d image(s) not used by any container. Continue? [y/N] ", len(images))
+		answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read confirmation: %v", err)
+		}
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			return nil
+		}
+	}
+
+	for _, image := range images {
+		if _, err := RemoveImage(imageClient, image.Id); err != nil {
+			return fmt.Errorf("error of removing image %q: %v", image.Id, err)
+		}
+		if len(image.RepoTags) == 0 {
+			fmt.Printf("Deleted: %s\n", image.Id)
+		}
+		for _, repoTag := range image.RepoTags {
+			fmt.Printf("Deleted: %s\n", repoTag)
+		}
+	}
+	return nil
+}
+
+// listDanglingImages returns the images not used by any container.
+func listDanglingImages(context *cli.Context) ([]*pb.Image, error) {
+	if err := getRuntimeClient(context); err != nil {
+		return nil, err
+	}
+	containers, err := listAllContainers(runtimeClient)
+	if err != nil {
+		return nil, fmt.Errorf("listing containers failed: %v", err)
+	}
+	if err := getImageClient(context); err != nil {
+		return nil, err
+	}
+	r, err := ListImages(imageClient, "")
+	if err != nil {
+		return nil, fmt.Errorf("listing images failed: %v", err)
+	}
+	return unusedImages(r.Images, containers), nil
+}
+
+// listAllContainers returns the containers in all states.
+func listAllContainers(client pb.RuntimeServiceClient) ([]*pb.Container, error) {
+	request := &pb.ListContainersRequest{}
+	logrus.Debugf("ListContainerRequest: %v", request)
+	r, err := client.ListContainers(context.Background(), request)
+	logrus.Debugf("ListContainerResponse: %v", r)
+	if err != nil {
+		return nil, err
+	}
+	return r.Containers, nil
+}
+
+// unusedImages returns the images which are not referenced by any of the
+// containers, either by ID, tag or digest.
+func unusedImages(images []*pb.Image, containers []*pb.Container) []*pb.Image {
+	used := make(map[string]bool)
+	for _, c := range containers {
+		used[c.ImageRef] = true
+		if c.Image != nil {
+			used[c.Image.Image] = true
+		}
+	}
+
+	var unused []*pb.Image
+	for _, image := range images {
+		inUse := used[image.Id]
+		for _, ref := range append(append([]string{}, image.RepoTags...), image.RepoDigests...) {
+			inUse = inUse || used[ref]
+		}
+		if !inUse {
+			unused = append(unused, image)
+		}
+	}
+	return unused
+}
+
 func parseCreds(creds string) (string, string, error) {
 	if creds == "" {
 		return "", "", errors.New("credentials can't be empty")
@@ -325,15 +516,35 @@ func parseCreds(creds string) (string, string, error) {
 	return up[0], up[1], nil
 }
 
-func getAuth(creds string) (*pb.AuthConfig, error) {
-	username, password, err := parseCreds(creds)
-	if err != nil {
-		return nil, err
+// getAuth returns the auth config for the --creds or --auth flag, or nil if
+// neither is set.
+func getAuth(creds string, auth string) (*pb.AuthConfig, error) {
+	if creds != "" && auth != "" {
+		return nil, errors.New("both `--creds` and `--auth` are specified")
+	}
+	if creds != "" {
+		username, password, err := parseCreds(creds)
+		if err != nil {
+			return nil, err
+		}
+		return &pb.AuthConfig{
+			Username: username,
+			Password: password,
+		}, nil
+	}
+	if auth != "" {
+		decoded, err := base64.StdEncoding.DecodeString(auth)
+		if err != nil {
+			return nil, fmt.Errorf("invalid auth string: %v", err)
+		}
+		if _, _, err := parseCreds(string(decoded)); err != nil {
+			return nil, fmt.Errorf("invalid auth string: %v", err)
+		}
+		return &pb.AuthConfig{
+			Auth: auth,
+		}, nil
 	}
-	return &pb.AuthConfig{
-		Username: username,
-		Password: password,
-	}, nil
+	return nil, nil
 }
 
 // Ideally repo tag should always be image:tag.
@@ -368,12 +579,12 @@ func normalizeRepoDigest(repoDigests []string) (string, string) {
 // PullImage sends a PullImageRequest to the server, and parses
 // the returned PullImageResponse.
 func PullImage(client pb.ImageServiceClient, image string, auth *pb.AuthConfig) (resp *pb.PullImageResponse, err error) {
-	return PullImageWithSandbox(client, image, auth, nil)
+	return PullImageWithSandbox(client, image, auth, nil, 0)
 }
 
 // PullImageWithSandbox sends a PullImageRequest to the server, and parses
-// the returned PullImageResponse.
-func PullImageWithSandbox(client pb.ImageServiceClient, image string, auth *pb.AuthConfig, sandbox *pb.PodSandboxConfig) (resp *pb.PullImageResponse, err error) {
+// the returned PullImageResponse. A zero timeout means no timeout.
+func PullImageWithSandbox(client pb.ImageServiceClient, image string, auth *pb.AuthConfig, sandbox *pb.PodSandboxConfig, timeout time.Duration) (resp *pb.PullImageResponse, err error) {
 	request := &pb.PullImageRequest{
 		Image: &pb.ImageSpec{
 			Image: image,
@@ -385,8 +596,14 @@ func PullImageWithSandbox(client pb.ImageServiceClient, image string, auth *pb.A
 	if sandbox != nil {
 		request.SandboxConfig = sandbox
 	}
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 	logrus.Debugf("PullImageRequest: %v", request)
-	resp, err = client.PullImage(context.Background(), request)
+	resp, err = client.PullImage(ctx, request)
 	logrus.Debugf("PullImageResponse: %v", resp)
 	return
 }
@@ -0,0 +1,123 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+func TestGetAuth(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		creds       string
+		auth        string
+		username    string
+		password    string
+		expectError bool
+	}{
+		{
+			desc: "no credentials should return nil",
+		},
+		{
+			desc:     "creds should set username and password",
+			creds:    "user:pass",
+			username: "user",
+			password: "pass",
+		},
+		{
+			desc: "auth should be kept encoded",
+			auth: "dXNlcjpwYXNz",
+		},
+		{
+			desc:        "invalid base64 auth should fail",
+			auth:        "not base64!",
+			expectError: true,
+		},
+		{
+			desc:        "creds and auth together should fail",
+			creds:       "user:pass",
+			auth:        "dXNlcjpwYXNz",
+			expectError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			auth, err := getAuth(tc.creds, tc.auth)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error; actual result is %v", auth)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.creds == "" && tc.auth == "" {
+				if auth != nil {
+					t.Errorf("expected nil auth; actual result is %v", auth)
+				}
+				return
+			}
+			if auth.Username != tc.username || auth.Password != tc.password || auth.Auth != tc.auth {
+				t.Errorf("unexpected auth config %v", auth)
+			}
+		})
+	}
+}
+
+func TestUnusedImages(t *testing.T) {
+	images := []*pb.Image{
+		{Id: "sha256:1", RepoTags: []string{"busybox:latest"}},
+		{Id: "sha256:2", RepoDigests: []string{"nginx@sha256:abc"}},
+		{Id: "sha256:3"},
+		{Id: "sha256:4", RepoTags: []string{"redis:latest"}},
+	}
+	testCases := []struct {
+		desc       string
+		containers []*pb.Container
+		expected   []string
+	}{
+		{
+			"all images should be unused without containers",
+			nil,
+			[]string{"sha256:1", "sha256:2", "sha256:3", "sha256:4"},
+		},
+		{
+			"images should be matched by tag, digest and ID",
+			[]*pb.Container{
+				{Image: &pb.ImageSpec{Image: "busybox:latest"}},
+				{Image: &pb.ImageSpec{Image: "nginx@sha256:abc"}},
+				{ImageRef: "sha256:3"},
+			},
+			[]string{"sha256:4"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var ids []string
+			for _, image := range unusedImages(images, tc.containers) {
+				ids = append(ids, image.Id)
+			}
+			if strings.Join(ids, ",") != strings.Join(tc.expected, ",") {
+				t.Errorf("expected %v; actual result is %v", tc.expected, ids)
+			}
+		})
+	}
+}
@@ -23,6 +23,8 @@ import (
 	"github.com/urfave/cli"
 	"golang.org/x/net/context"
 	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/output"
 )
 
 var runtimeStatusCommand = cli.Command{
@@ -59,7 +61,7 @@ func Info(cliContext *cli.Context, client pb.RuntimeServiceClient) error {
 		return err
 	}
 
-	status, err := protobufObjectToJSON(r.Status)
+	status, err := output.ProtobufToJSON(r.Status)
 	if err != nil {
 		return err
 	}
@@ -69,6 +69,17 @@ var logsCommand = cli.Command{
 		if containerID == "" {
 			return fmt.Errorf("ID cannot be empty")
 		}
+		containers, err := runtimeService.ListContainers(nil)
+		if err != nil {
+			return err
+		}
+		var ids []string
+		for _, c := range containers {
+			ids = append(ids, c.Id)
+		}
+		if containerID, err = resolveID(ids, containerID); err != nil {
+			return err
+		}
 		tailLines := context.Int64("tail")
 		limitBytes := context.Int64("limit-bytes")
 		since, err := parseTimestamp(context.String("since"))
@@ -21,14 +21,18 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
+	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"k8s.io/kubernetes/pkg/kubelet/apis/cri"
-	"k8s.io/kubernetes/pkg/kubelet/remote"
+	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 
+	"github.com/kubernetes-sigs/cri-tools/pkg/remote"
+	"github.com/kubernetes-sigs/cri-tools/pkg/streaming"
 	"github.com/kubernetes-sigs/cri-tools/pkg/version"
 )
 
@@ -37,7 +41,9 @@ const (
 )
 
 var (
-	// RuntimeEndpoint is CRI server runtime endpoint
+	// RuntimeEndpoints are the CRI server runtime endpoints, tried in order
+	RuntimeEndpoints []string
+	// RuntimeEndpoint is the runtime endpoint selected from RuntimeEndpoints
 	RuntimeEndpoint string
 	// ImageEndpoint is CRI server image endpoint, default same as runtime endpoint
 	ImageEndpoint string
@@ -45,47 +51,94 @@ var (
 	Timeout time.Duration
 	// Debug enable debug output
 	Debug bool
+	// StreamingProtocol is the protocol used by exec and attach
+	StreamingProtocol streaming.Protocol
+
+	// connections are the connections to the CRI endpoints used by the command
+	connections = remote.NewManager(remote.Options{})
 )
 
-func getRuntimeClientConnection(context *cli.Context) (*grpc.ClientConn, error) {
-	if RuntimeEndpoint == "" {
-		return nil, fmt.Errorf("--runtime-endpoint is not set")
+// getRuntimeEndpoint returns the runtime endpoint to connect to. When
+// several endpoints are configured, they are tried in order and the first
+// one answering Version() is used for the rest of the command.
+func getRuntimeEndpoint() (string, error) {
+	if RuntimeEndpoint != "" {
+		return RuntimeEndpoint, nil
+	}
+	switch len(RuntimeEndpoints) {
+	case 0:
+		return "", fmt.Errorf("--runtime-endpoint is not set")
+	case 1:
+		RuntimeEndpoint = RuntimeEndpoints[0]
+		return RuntimeEndpoint, nil
 	}
 
-	addr, dialer, err := GetAddressAndDialer(RuntimeEndpoint)
+	var errs []string
+	for _, endpoint := range RuntimeEndpoints {
+		if err := probeRuntimeEndpoint(endpoint); err != nil {
+			logrus.Debugf("Runtime endpoint %q is not available: %v", endpoint, err)
+			errs = append(errs, fmt.Sprintf("%s: %v", endpoint, err))
+			continue
+		}
+		logrus.Infof("Using runtime endpoint %q", endpoint)
+		RuntimeEndpoint = endpoint
+		return RuntimeEndpoint, nil
+	}
+	return "", fmt.Errorf("no runtime endpoint is available: %s", strings.Join(errs, "; "))
+}
+
+// probeRuntimeEndpoint checks that the runtime at endpoint answers Version().
+// The connection is kept to be reused by the command.
+func probeRuntimeEndpoint(endpoint string) error {
+	conn, err := dialEndpoint(endpoint)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	conn, err := grpc.Dial(addr, grpc.WithInsecure(), grpc.WithTimeout(Timeout), grpc.WithDialer(dialer))
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	_, err = pb.NewRuntimeServiceClient(conn).Version(ctx, &pb.VersionRequest{})
+	return err
+}
+
+// dialEndpoint returns the connection to endpoint, shared by the runtime and
+// image clients of the command.
+func dialEndpoint(endpoint string) (*grpc.ClientConn, error) {
+	conn, err := connections.Dial(endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect: %v", err)
 	}
 	return conn, nil
 }
 
+func getRuntimeClientConnection(context *cli.Context) (*grpc.ClientConn, error) {
+	endpoint, err := getRuntimeEndpoint()
+	if err != nil {
+		return nil, err
+	}
+	return dialEndpoint(endpoint)
+}
+
 func getImageClientConnection(context *cli.Context) (*grpc.ClientConn, error) {
 	if ImageEndpoint == "" {
-		if RuntimeEndpoint == "" {
+		if len(RuntimeEndpoints) == 0 {
 			return nil, fmt.Errorf("--image-endpoint is not set")
 		}
-		ImageEndpoint = RuntimeEndpoint
+		endpoint, err := getRuntimeEndpoint()
+		if err != nil {
+			return nil, err
+		}
+		ImageEndpoint = endpoint
 	}
+	return dialEndpoint(ImageEndpoint)
+}
 
-	addr, dialer, err := GetAddressAndDialer(ImageEndpoint)
+func getRuntimeService(context *cli.Context) (cri.RuntimeService, error) {
+	endpoint, err := getRuntimeEndpoint()
 	if err != nil {
 		return nil, err
 	}
-
-	conn, err := grpc.Dial(addr, grpc.WithInsecure(), grpc.WithTimeout(Timeout), grpc.WithDialer(dialer))
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect: %v", err)
-	}
-	return conn, nil
-}
-
-func getRuntimeService(context *cli.Context) (cri.RuntimeService, error) {
-	return remote.NewRemoteRuntimeService(RuntimeEndpoint, Timeout)
+	return connections.RuntimeService(endpoint, Timeout)
 }
 
 func main() {
@@ -112,6 +165,7 @@ func main() {
 		listContainersCommand,
 		pullImageCommand,
 		runPodCommand,
+		runContainerCommand,
 		removeContainerCommand,
 		removeImageCommand,
 		removePodCommand,
@@ -122,8 +176,11 @@ func main() {
 		stopPodCommand,
 		updateContainerCommand,
 		configCommand,
+		checkCommand,
 		statsCommand,
+		topCommand,
 		completionCommand,
+		completeIDsCommand,
 	}
 
 	app.Flags = []cli.Flag{
@@ -133,11 +190,10 @@ func main() {
 			Value:  defaultConfigPath,
 			Usage:  "Location of the client config file",
 		},
-		cli.StringFlag{
+		cli.StringSliceFlag{
 			Name:   "runtime-endpoint, r",
 			EnvVar: "CONTAINER_RUNTIME_ENDPOINT",
-			Value:  defaultRuntimeEndpoint,
-			Usage:  "Endpoint of CRI container runtime service",
+			Usage:  fmt.Sprintf("Endpoint of CRI container runtime service (default: %q). Can be repeated, the first endpoint that responds is used", defaultRuntimeEndpoint),
 		},
 		cli.StringFlag{
 			Name:   "image-endpoint, i",
@@ -153,6 +209,11 @@ func main() {
 			Name:  "debug, D",
 			Usage: "Enable debug mode",
 		},
+		cli.StringFlag{
+			Name:  "streaming-protocol",
+			Value: string(streaming.ProtocolAuto),
+			Usage: "Protocol used by exec and attach: auto, spdy or websocket. auto falls back to websocket if the runtime does not serve spdy",
+		},
 	}
 
 	app.Before = func(context *cli.Context) error {
@@ -161,15 +222,17 @@ func main() {
 		if _, err := os.Stat(configFile); err == nil {
 			isUseConfig = true
 		} else {
-			if context.IsSet("config") || !os.IsNotExist(err) {
+			isConfigCommand := context.Args().First() == configCommand.Name
+			if (context.IsSet("config") && !isConfigCommand) || !os.IsNotExist(err) {
 				// note: the absence of default config file is normal case
-				// when user have not setted it in cli
+				// when user have not setted it in cli, and the config
+				// command creates it
 				logrus.Fatalf("Falied to load config file: %v", err)
 			}
 		}
 
 		if !isUseConfig {
-			RuntimeEndpoint = context.GlobalString("runtime-endpoint")
+			RuntimeEndpoints = context.GlobalStringSlice("runtime-endpoint")
 			ImageEndpoint = context.GlobalString("image-endpoint")
 			Timeout = context.GlobalDuration("timeout")
 			Debug = context.GlobalBool("debug")
@@ -182,11 +245,11 @@ func main() {
 
 			// Command line flags overrides config file.
 			if context.IsSet("runtime-endpoint") {
-				RuntimeEndpoint = context.String("runtime-endpoint")
-			} else if config.RuntimeEndpoint != "" {
-				RuntimeEndpoint = config.RuntimeEndpoint
+				RuntimeEndpoints = context.GlobalStringSlice("runtime-endpoint")
+			} else if len(config.RuntimeEndpoint) > 0 {
+				RuntimeEndpoints = config.RuntimeEndpoint
 			} else {
-				RuntimeEndpoint = context.GlobalString("runtime-endpoint")
+				RuntimeEndpoints = context.GlobalStringSlice("runtime-endpoint")
 			}
 			if context.IsSet("image-endpoint") {
 				ImageEndpoint = context.String("image-endpoint")
@@ -209,6 +272,16 @@ func main() {
 			}
 		}
 
+		if len(RuntimeEndpoints) == 0 {
+			RuntimeEndpoints = []string{defaultRuntimeEndpoint}
+		}
+
+		protocol, err := streaming.ParseProtocol(context.GlobalString("streaming-protocol"))
+		if err != nil {
+			return err
+		}
+		StreamingProtocol = protocol
+
 		if Debug {
 			logrus.SetLevel(logrus.DebugLevel)
 		}
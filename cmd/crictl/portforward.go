@@ -22,7 +22,9 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
 
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
@@ -36,7 +38,7 @@ import (
 var runtimePortForwardCommand = cli.Command{
 	Name:      "port-forward",
 	Usage:     "Forward local port to a pod",
-	ArgsUsage: "POD-ID [LOCAL_PORT:]REMOTE_PORT",
+	ArgsUsage: "POD-ID [LOCAL_PORT:]REMOTE_PORT [[LOCAL_PORT:]REMOTE_PORT...]",
 	Action: func(context *cli.Context) error {
 		args := context.Args()
 		if len(args) < 2 {
@@ -47,11 +49,18 @@ var runtimePortForwardCommand = cli.Command{
 			return err
 		}
 
+		id, err := resolvePodSandboxID(runtimeClient, args[0])
+		if err != nil {
+			return err
+		}
 		var opts = portforwardOptions{
-			id:    args[0],
+			id:    id,
 			ports: args[1:],
 		}
-		err := PortForward(runtimeClient, opts)
+		if err := validatePorts(opts.ports); err != nil {
+			return err
+		}
+		err = PortForward(runtimeClient, opts)
 		if err != nil {
 			return fmt.Errorf("port forward failed: %v", err)
 
@@ -87,7 +96,7 @@ func PortForward(client pb.RuntimeServiceClient, opts portforwardOptions) error
 		return err
 	}
 	logrus.Debugf("PortForward URL: %v", URL)
-	transport, upgrader, err := spdy.RoundTripperFor(&restclient.Config{})
+	transport, upgrader, err := spdy.RoundTripperFor(&restclient.Config{TLSClientConfig: restclient.TLSClientConfig{Insecure: true}})
 	if err != nil {
 		return err
 	}
@@ -97,7 +106,7 @@ func PortForward(client pb.RuntimeServiceClient, opts portforwardOptions) error
 	readyChan := make(chan struct{})
 
 	signals := make(chan os.Signal, 1)
-	signal.Notify(signals, os.Interrupt)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
 	defer signal.Stop(signals)
 
 	go func() {
@@ -113,3 +122,23 @@ func PortForward(client pb.RuntimeServiceClient, opts portforwardOptions) error
 	}
 	return pf.ForwardPorts()
 }
+
+// validatePorts checks that ports are in the [LOCAL_PORT:]REMOTE_PORT format.
+func validatePorts(ports []string) error {
+	for _, port := range ports {
+		parts := strings.Split(port, ":")
+		if len(parts) > 2 {
+			return fmt.Errorf("invalid port format %q, should be [LOCAL_PORT:]REMOTE_PORT", port)
+		}
+		for i, p := range parts {
+			// The local port may be empty to select a random port.
+			if p == "" && i == 0 && len(parts) == 2 {
+				continue
+			}
+			if n, err := strconv.ParseUint(p, 10, 16); err != nil || n == 0 {
+				return fmt.Errorf("invalid port %q in %q", p, port)
+			}
+		}
+	}
+	return nil
+}
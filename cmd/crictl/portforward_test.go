@@ -0,0 +1,45 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+)
+
+func TestValidatePorts(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		ports       []string
+		expectError bool
+	}{
+		{"remote port should be valid", []string{"8080"}, false},
+		{"local and remote ports should be valid", []string{"8080:80", "9090:90"}, false},
+		{"empty local port should be valid", []string{":80"}, false},
+		{"zero port should be invalid", []string{"0"}, true},
+		{"too many colons should be invalid", []string{"1:2:3"}, true},
+		{"non numeric port should be invalid", []string{"http"}, true},
+		{"out of range port should be invalid", []string{"8080:70000"}, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := validatePorts(tc.ports)
+			if (err != nil) != tc.expectError {
+				t.Errorf("expected error: %v; actual error is %v", tc.expectError, err)
+			}
+		})
+	}
+}
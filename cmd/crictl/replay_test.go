@@ -0,0 +1,102 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework/replay"
+	"github.com/kubernetes-sigs/cri-tools/pkg/remote"
+)
+
+// replayRuntime serves the calls recorded in testdata/replay.jsonl and
+// returns a runtime client connected to it.
+func replayRuntime(t *testing.T) (pb.RuntimeServiceClient, func()) {
+	server, err := replay.Load(filepath.Join("testdata", "replay.jsonl"))
+	if err != nil {
+		t.Fatalf("failed to load the trace: %v", err)
+	}
+	dir, err := ioutil.TempDir("", "crictl-replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "replay.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("failed to listen on %q: %v", path, err)
+	}
+	go server.Serve(l)
+	m := remote.NewManager(remote.Options{})
+	conn, err := m.Dial("unix://" + path)
+	if err != nil {
+		t.Fatalf("failed to dial %q: %v", path, err)
+	}
+	return pb.NewRuntimeServiceClient(conn), func() {
+		m.Close()
+		server.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+// captureStdout returns what f prints on stdout.
+func captureStdout(t *testing.T, f func() error) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	err = f()
+	os.Stdout = stdout
+	w.Close()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestReplayedOutput(t *testing.T) {
+	client, cleanup := replayRuntime(t)
+	defer cleanup()
+
+	out := captureStdout(t, func() error { return Version(client, criClientVersion) })
+	expected := "Version:  0.1.0\nRuntimeName:  pouch\nRuntimeVersion:  1.0.0\nRuntimeApiVersion:  v1alpha2\n"
+	if out != expected {
+		t.Errorf("expected version output:\n%s\ngot:\n%s", expected, out)
+	}
+
+	out = captureStdout(t, func() error {
+		return ListPodSandboxes(client, listOptions{columns: []string{"id", "state", "name", "namespace", "attempt"}})
+	})
+	expected = `POD ID              STATE               NAME                NAMESPACE           ATTEMPT
+f84dd361f8dc5       Ready               nginx-sandbox       default             1
+3e025dd50a72d       NotReady            busybox-sandbox     kube-system         0
+`
+	if out != expected {
+		t.Errorf("expected pods output:\n%s\ngot:\n%s", expected, out)
+	}
+}
@@ -23,11 +23,10 @@ import (
 	"os"
 	"regexp"
 	"sort"
-	"strings"
-	"text/tabwriter"
 	"time"
 
 	units "github.com/docker/go-units"
+	"github.com/kubernetes-sigs/cri-tools/pkg/output"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 	"golang.org/x/net/context"
@@ -46,7 +45,7 @@ func (a sandboxByCreated) Less(i, j int) bool {
 var runPodCommand = cli.Command{
 	Name:      "runp",
 	Usage:     "Run a new pod",
-	ArgsUsage: "pod-config.[json|yaml]",
+	ArgsUsage: "pod-config.[json|yaml]|-",
 	Action: func(context *cli.Context) error {
 		sandboxSpec := context.Args().First()
 		if sandboxSpec == "" {
@@ -61,12 +60,16 @@ var runPodCommand = cli.Command{
 		if err != nil {
 			return fmt.Errorf("load podSandboxConfig failed: %v", err)
 		}
+		if err := setPodSandboxConfigDefaults(podSandboxConfig); err != nil {
+			return err
+		}
 
 		// Test RuntimeServiceClient.RunPodSandbox
-		err = RunPodSandbox(runtimeClient, podSandboxConfig)
+		podID, err := RunPodSandbox(runtimeClient, podSandboxConfig)
 		if err != nil {
 			return fmt.Errorf("run pod sandbox failed: %v", err)
 		}
+		fmt.Println(podID)
 		return nil
 	},
 }
@@ -75,21 +78,29 @@ var stopPodCommand = cli.Command{
 	Name:      "stopp",
 	Usage:     "Stop one or more running pods",
 	ArgsUsage: "POD-ID [POD-ID...]",
+	Flags:     selectFlags,
 	Action: func(context *cli.Context) error {
-		if context.NArg() == 0 {
+		opts, err := parseSelectOptions(context)
+		if err != nil {
+			return err
+		}
+		if context.NArg() == 0 && !opts.isSet() {
 			return cli.ShowSubcommandHelp(context)
 		}
 		if err := getRuntimeClient(context); err != nil {
 			return err
 		}
-		for i := 0; i < context.NArg(); i++ {
-			id := context.Args().Get(i)
+		ids, err := selectPodSandboxIDs(runtimeClient, context, opts, "ready")
+		if err != nil {
+			return err
+		}
+		return forEachID(ids, opts.dryRun, func(id string) error {
 			err := StopPodSandbox(runtimeClient, id)
 			if err != nil {
 				return fmt.Errorf("stopping the pod sandbox %q failed: %v", id, err)
 			}
-		}
-		return nil
+			return nil
+		})
 	},
 }
 
@@ -97,21 +108,39 @@ var removePodCommand = cli.Command{
 	Name:      "rmp",
 	Usage:     "Remove one or more pods",
 	ArgsUsage: "POD-ID [POD-ID...]",
+	Flags: append([]cli.Flag{
+		cli.BoolFlag{
+			Name:  "force, f",
+			Usage: "Stop the pods before removing them",
+		},
+	}, selectFlags...),
 	Action: func(context *cli.Context) error {
-		if context.NArg() == 0 {
+		opts, err := parseSelectOptions(context)
+		if err != nil {
+			return err
+		}
+		if context.NArg() == 0 && !opts.isSet() {
 			return cli.ShowSubcommandHelp(context)
 		}
 		if err := getRuntimeClient(context); err != nil {
 			return err
 		}
-		for i := 0; i < context.NArg(); i++ {
-			id := context.Args().Get(i)
+		ids, err := selectPodSandboxIDs(runtimeClient, context, opts, "")
+		if err != nil {
+			return err
+		}
+		return forEachID(ids, opts.dryRun, func(id string) error {
+			if context.Bool("force") {
+				if err := StopPodSandbox(runtimeClient, id); err != nil {
+					return fmt.Errorf("stopping the pod sandbox %q failed: %v", id, err)
+				}
+			}
 			err := RemovePodSandbox(runtimeClient, id)
 			if err != nil {
 				return fmt.Errorf("removing the pod sandbox %q failed: %v", id, err)
 			}
-		}
-		return nil
+			return nil
+		})
 	},
 }
 
@@ -124,7 +153,7 @@ var podStatusCommand = cli.Command{
 	Flags: []cli.Flag{
 		cli.StringFlag{
 			Name:  "output, o",
-			Usage: "Output format, One of: json|yaml|table",
+			Usage: "Output format, One of: json|yaml|table|go-template=TEMPLATE|jsonpath=TEMPLATE",
 		},
 		cli.BoolFlag{
 			Name:  "quiet, q",
@@ -138,9 +167,11 @@ var podStatusCommand = cli.Command{
 		if err := getRuntimeClient(context); err != nil {
 			return err
 		}
-		for i := 0; i < context.NArg(); i++ {
-			id := context.Args().Get(i)
-
+		ids, err := resolvePodSandboxIDs(runtimeClient, context.Args())
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
 			err := PodSandboxStatus(runtimeClient, id, context.String("output"), context.Bool("quiet"))
 			if err != nil {
 				return fmt.Errorf("getting the pod sandbox status for %q failed: %v", id, err)
@@ -190,7 +221,7 @@ var listPodCommand = cli.Command{
 		},
 		cli.StringFlag{
 			Name:  "output, o",
-			Usage: "Output format, One of: json|yaml|table",
+			Usage: "Output format, One of: json|yaml|table|go-template=TEMPLATE|jsonpath=TEMPLATE",
 		},
 		cli.BoolFlag{
 			Name:  "latest, l",
@@ -204,6 +235,10 @@ var listPodCommand = cli.Command{
 			Name:  "no-trunc",
 			Usage: "Show output without truncating the ID",
 		},
+		cli.StringFlag{
+			Name:  "columns",
+			Usage: "Comma separated `COLUMNS` shown in table output",
+		},
 	},
 	Action: func(context *cli.Context) error {
 		var err error
@@ -220,6 +255,7 @@ var listPodCommand = cli.Command{
 			latest:             context.Bool("latest"),
 			last:               context.Int("last"),
 			noTrunc:            context.Bool("no-trunc"),
+			columns:            output.ParseColumns(context.String("columns")),
 			podNameRegexp:      context.String("name"),
 			podNamespaceRegexp: context.String("namespace"),
 		}
@@ -236,16 +272,15 @@ var listPodCommand = cli.Command{
 
 // RunPodSandbox sends a RunPodSandboxRequest to the server, and parses
 // the returned RunPodSandboxResponse.
-func RunPodSandbox(client pb.RuntimeServiceClient, config *pb.PodSandboxConfig) error {
+func RunPodSandbox(client pb.RuntimeServiceClient, config *pb.PodSandboxConfig) (string, error) {
 	request := &pb.RunPodSandboxRequest{Config: config}
 	logrus.Debugf("RunPodSandboxRequest: %v", request)
 	r, err := client.RunPodSandbox(context.Background(), request)
 	logrus.Debugf("RunPodSandboxResponse: %v", r)
 	if err != nil {
-		return err
+		return "", err
 	}
-	fmt.Println(r.PodSandboxId)
-	return nil
+	return r.PodSandboxId, nil
 }
 
 // StopPodSandbox sends a StopPodSandboxRequest to the server, and parses
@@ -286,7 +321,7 @@ func RemovePodSandbox(client pb.RuntimeServiceClient, ID string) error {
 // marshalPodSandboxStatus converts pod sandbox status into string and converts
 // the timestamps into readable format.
 func marshalPodSandboxStatus(ps *pb.PodSandboxStatus) (string, error) {
-	statusStr, err := protobufObjectToJSON(ps)
+	statusStr, err := output.ProtobufToJSON(ps)
 	if err != nil {
 		return "", err
 	}
@@ -301,10 +336,10 @@ func marshalPodSandboxStatus(ps *pb.PodSandboxStatus) (string, error) {
 
 // PodSandboxStatus sends a PodSandboxStatusRequest to the server, and parses
 // the returned PodSandboxStatusResponse.
-func PodSandboxStatus(client pb.RuntimeServiceClient, ID, output string, quiet bool) error {
+func PodSandboxStatus(client pb.RuntimeServiceClient, ID, format string, quiet bool) error {
 	verbose := !(quiet)
-	if output == "" { // default to json output
-		output = "json"
+	if format == "" { // default to json output
+		format = "json"
 	}
 	if ID == "" {
 		return fmt.Errorf("ID cannot be empty")
@@ -325,12 +360,15 @@ func PodSandboxStatus(client pb.RuntimeServiceClient, ID, output string, quiet b
 	if err != nil {
 		return err
 	}
-	switch output {
+	if output.IsTemplate(format) {
+		return outputStatusInfo(status, r.Info, format)
+	}
+	switch format {
 	case "json", "yaml":
-		return outputStatusInfo(status, r.Info, output)
+		return outputStatusInfo(status, r.Info, format)
 	case "table": // table output is after this switch block
 	default:
-		return fmt.Errorf("output option cannot be %s", output)
+		return fmt.Errorf("output option cannot be %s", format)
 	}
 
 	// output in table format by default.
@@ -367,7 +405,7 @@ func PodSandboxStatus(client pb.RuntimeServiceClient, ID, output string, quiet b
 		}
 	}
 	if verbose {
-		fmt.Printf("Info: %v\n", r.GetInfo())
+		return printInfo(r.GetInfo())
 	}
 
 	return nil
@@ -393,18 +431,11 @@ func ListPodSandboxes(client pb.RuntimeServiceClient, opts listOptions) error {
 		filter.Id = opts.id
 	}
 	if opts.state != "" {
-		st := &pb.PodSandboxStateValue{}
-		st.State = pb.PodSandboxState_SANDBOX_NOTREADY
-		switch strings.ToLower(opts.state) {
-		case "ready":
-			st.State = pb.PodSandboxState_SANDBOX_READY
-			filter.State = st
-		case "notready":
-			st.State = pb.PodSandboxState_SANDBOX_NOTREADY
-			filter.State = st
-		default:
-			log.Fatalf("--state should be ready or notready")
+		state, err := parsePodSandboxState(opts.state)
+		if err != nil {
+			log.Fatal(err)
 		}
+		filter.State = &pb.PodSandboxStateValue{State: state}
 	}
 	if opts.labels != nil {
 		filter.LabelSelector = opts.labels
@@ -420,81 +451,70 @@ func ListPodSandboxes(client pb.RuntimeServiceClient, opts listOptions) error {
 	}
 	r.Items = getSandboxesList(r.GetItems(), opts)
 
-	switch opts.output {
-	case "json":
-		return outputProtobufObjAsJSON(r)
-	case "yaml":
-		return outputProtobufObjAsJSON(r)
-	case "table", "":
-	// continue; output will be generated after the switch block ends.
-	default:
-		return fmt.Errorf("unsupported output format %q", opts.output)
+	if opts.verbose && !opts.quiet && isTableOutput(opts.output) {
+		for _, pod := range r.Items {
+			printPodSandboxVerbose(pod)
+		}
+		return nil
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
-	if !opts.verbose && !opts.quiet {
-		fmt.Fprintln(w, "POD ID\tCREATED\tSTATE\tNAME\tNAMESPACE\tATTEMPT")
-	}
+	outputOpts := opts.outputOptions()
+	table := output.NewTable(
+		output.Column{Name: output.IDColumn, Header: "POD ID"},
+		output.Column{Name: "created", Header: "CREATED"},
+		output.Column{Name: "state", Header: "STATE"},
+		output.Column{Name: "name", Header: "NAME"},
+		output.Column{Name: "namespace", Header: "NAMESPACE"},
+		output.Column{Name: "attempt", Header: "ATTEMPT"},
+	)
 	for _, pod := range r.Items {
-		// Filter by pod name/namespace regular expressions.
-		if !podMatchesRegex(opts.podNameRegexp, pod.Labels[kubePodNameLabel]) {
-			continue
-		}
-		if !podMatchesRegex(opts.podNamespaceRegexp, pod.Labels[kubePodNamespaceLabel]) {
-			continue
-		}
+		createdAt := time.Unix(0, pod.CreatedAt)
+		ctm := units.HumanDuration(time.Now().UTC().Sub(createdAt)) + " ago"
+		table.AddRow(
+			output.TruncateID(pod.Id, "", outputOpts.NoTrunc),
+			ctm,
+			convertPodState(pod.State),
+			pod.Metadata.Name,
+			pod.Metadata.Namespace,
+			fmt.Sprintf("%d", pod.Metadata.Attempt),
+		)
+	}
+	return output.Write(os.Stdout, r, table, outputOpts)
+}
 
-		if opts.quiet {
-			fmt.Printf("%s\n", pod.Id)
-			continue
+// printPodSandboxVerbose prints the verbose information of a pod sandbox.
+func printPodSandboxVerbose(pod *pb.PodSandbox) {
+	fmt.Printf("ID: %s\n", pod.Id)
+	if pod.Metadata != nil {
+		if pod.Metadata.Name != "" {
+			fmt.Printf("Name: %s\n", pod.Metadata.Name)
 		}
-		if !opts.verbose {
-			createdAt := time.Unix(0, pod.CreatedAt)
-			ctm := units.HumanDuration(time.Now().UTC().Sub(createdAt)) + " ago"
-			id := pod.Id
-			if !opts.noTrunc {
-				id = getTruncatedID(id, "")
-			}
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\n",
-				id, ctm, convertPodState(pod.State), pod.Metadata.Name, pod.Metadata.Namespace, pod.Metadata.Attempt)
-			continue
+		if pod.Metadata.Uid != "" {
+			fmt.Printf("UID: %s\n", pod.Metadata.Uid)
 		}
-
-		fmt.Printf("ID: %s\n", pod.Id)
-		if pod.Metadata != nil {
-			if pod.Metadata.Name != "" {
-				fmt.Printf("Name: %s\n", pod.Metadata.Name)
-			}
-			if pod.Metadata.Uid != "" {
-				fmt.Printf("UID: %s\n", pod.Metadata.Uid)
-			}
-			if pod.Metadata.Namespace != "" {
-				fmt.Printf("Namespace: %s\n", pod.Metadata.Namespace)
-			}
-			if pod.Metadata.Attempt != 0 {
-				fmt.Printf("Attempt: %v\n", pod.Metadata.Attempt)
-			}
+		if pod.Metadata.Namespace != "" {
+			fmt.Printf("Namespace: %s\n", pod.Metadata.Namespace)
 		}
-		fmt.Printf("Status: %s\n", convertPodState(pod.State))
-		ctm := time.Unix(0, pod.CreatedAt)
-		fmt.Printf("Created: %v\n", ctm)
-		if pod.Labels != nil {
-			fmt.Println("Labels:")
-			for _, k := range getSortedKeys(pod.Labels) {
-				fmt.Printf("\t%s -> %s\n", k, pod.Labels[k])
-			}
+		if pod.Metadata.Attempt != 0 {
+			fmt.Printf("Attempt: %v\n", pod.Metadata.Attempt)
 		}
-		if pod.Annotations != nil {
-			fmt.Println("Annotations:")
-			for _, k := range getSortedKeys(pod.Annotations) {
-				fmt.Printf("\t%s -> %s\n", k, pod.Annotations[k])
-			}
+	}
+	fmt.Printf("Status: %s\n", convertPodState(pod.State))
+	ctm := time.Unix(0, pod.CreatedAt)
+	fmt.Printf("Created: %v\n", ctm)
+	if pod.Labels != nil {
+		fmt.Println("Labels:")
+		for _, k := range getSortedKeys(pod.Labels) {
+			fmt.Printf("\t%s -> %s\n", k, pod.Labels[k])
 		}
-		fmt.Println()
 	}
-
-	w.Flush()
-	return nil
+	if pod.Annotations != nil {
+		fmt.Println("Annotations:")
+		for _, k := range getSortedKeys(pod.Annotations) {
+			fmt.Printf("\t%s -> %s\n", k, pod.Annotations[k])
+		}
+	}
+	fmt.Println()
 }
 
 func convertPodState(state pb.PodSandboxState) string {
@@ -510,6 +530,16 @@ func convertPodState(state pb.PodSandboxState) string {
 }
 
 func getSandboxesList(sandboxesList []*pb.PodSandbox, opts listOptions) []*pb.PodSandbox {
+	// Filter by pod name/namespace regular expressions.
+	var filtered []*pb.PodSandbox
+	for _, pod := range sandboxesList {
+		if podMatchesRegex(opts.podNameRegexp, pod.Labels[kubePodNameLabel]) &&
+			podMatchesRegex(opts.podNamespaceRegexp, pod.Labels[kubePodNamespaceLabel]) {
+			filtered = append(filtered, pod)
+		}
+	}
+	sandboxesList = filtered
+
 	sort.Sort(sandboxByCreated(sandboxesList))
 	n := len(sandboxesList)
 	if opts.latest {
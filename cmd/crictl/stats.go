@@ -20,10 +20,10 @@ import (
 	"fmt"
 	"os"
 	"sort"
-	"text/tabwriter"
 	"time"
 
 	units "github.com/docker/go-units"
+	"github.com/kubernetes-sigs/cri-tools/pkg/output"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 	"golang.org/x/net/context"
@@ -43,6 +43,12 @@ type statsOptions struct {
 	labels map[string]string
 	// output format
 	output string
+	// only display container IDs
+	quiet bool
+	// out with truncating the id
+	noTrunc bool
+	// columns shown in table output
+	columns []string
 }
 
 var statsCommand = cli.Command{
@@ -72,7 +78,19 @@ var statsCommand = cli.Command{
 		},
 		cli.StringFlag{
 			Name:  "output, o",
-			Usage: "Output format, One of: json|yaml|table",
+			Usage: "Output format, One of: json|yaml|table|go-template=TEMPLATE|jsonpath=TEMPLATE",
+		},
+		cli.BoolFlag{
+			Name:  "quiet, q",
+			Usage: "Only display container IDs",
+		},
+		cli.BoolFlag{
+			Name:  "no-trunc",
+			Usage: "Show output without truncating the ID",
+		},
+		cli.StringFlag{
+			Name:  "columns",
+			Usage: "Comma separated `COLUMNS` shown in table output",
 		},
 		cli.IntFlag{
 			Name:  "seconds, s",
@@ -87,11 +105,14 @@ var statsCommand = cli.Command{
 		}
 
 		opts := statsOptions{
-			all:    context.Bool("all"),
-			id:     context.String("id"),
-			podID:  context.String("pod"),
-			sample: time.Duration(context.Int("seconds")) * time.Second,
-			output: context.String("output"),
+			all:     context.Bool("all"),
+			id:      context.String("id"),
+			podID:   context.String("pod"),
+			sample:  time.Duration(context.Int("seconds")) * time.Second,
+			output:  context.String("output"),
+			quiet:   context.Bool("quiet"),
+			noTrunc: context.Bool("no-trunc"),
+			columns: output.ParseColumns(context.String("columns")),
 		}
 		opts.labels, err = parseLabelStringSlice(context.StringSlice("label"))
 		if err != nil {
@@ -137,11 +158,14 @@ func ContainerStats(client pb.RuntimeServiceClient, opts statsOptions) error {
 	}
 	sort.Sort(containerStatsByID(r.Stats))
 
-	switch opts.output {
-	case "json":
-		return outputProtobufObjAsJSON(r)
-	case "yaml":
-		return outputProtobufObjAsYAML(r)
+	outputOpts := output.Options{
+		Format:  opts.output,
+		Columns: opts.columns,
+		NoTrunc: opts.noTrunc || opts.quiet,
+		Quiet:   opts.quiet,
+	}
+	if !isTableOutput(opts.output) {
+		return output.Write(os.Stdout, r, nil, outputOpts)
 	}
 	oldStats := make(map[string]*pb.ContainerStats)
 	for _, s := range r.GetStats() {
@@ -158,11 +182,15 @@ func ContainerStats(client pb.RuntimeServiceClient, opts statsOptions) error {
 	}
 	sort.Sort(containerStatsByID(r.Stats))
 
-	w := tabwriter.NewWriter(os.Stdout, 20, 1, 3, ' ', 0)
-	// Use `+` to work around go vet bug
-	fmt.Fprintln(w, "CONTAINER\tCPU %"+"\tMEM\tDISK\tINODES")
+	table := output.NewTable(
+		output.Column{Name: output.IDColumn, Header: "CONTAINER"},
+		output.Column{Name: "cpu", Header: "CPU %"},
+		output.Column{Name: "mem", Header: "MEM"},
+		output.Column{Name: "disk", Header: "DISK"},
+		output.Column{Name: "inodes", Header: "INODES"},
+	)
 	for _, s := range r.GetStats() {
-		id := getTruncatedID(s.Attributes.Id, "")
+		id := output.TruncateID(s.Attributes.Id, "", outputOpts.NoTrunc)
 		cpu := s.GetCpu().GetUsageCoreNanoSeconds().GetValue()
 		mem := s.GetMemory().GetWorkingSetBytes().GetValue()
 		disk := s.GetWritableLayer().GetUsedBytes().GetValue()
@@ -185,9 +213,7 @@ func ContainerStats(client pb.RuntimeServiceClient, opts statsOptions) error {
 			}
 			cpuPerc = float64(cpu-old.GetCpu().GetUsageCoreNanoSeconds().GetValue()) / float64(duration) * 100
 		}
-		fmt.Fprintf(w, "%s\t%.2f\t%s\t%s\t%d\n", id, cpuPerc, units.HumanSize(float64(mem)), units.HumanSize(float64(disk)), inodes)
+		table.AddRow(id, fmt.Sprintf("%.2f", cpuPerc), units.HumanSize(float64(mem)), units.HumanSize(float64(disk)), fmt.Sprintf("%d", inodes))
 	}
-
-	w.Flush()
-	return nil
+	return table.Write(os.Stdout, outputOpts)
 }
@@ -0,0 +1,138 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/output"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+	"golang.org/x/net/context"
+	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// psCommands list the commands tried in turn to list the processes of a
+// container. They all print the PID, USER and COMMAND columns.
+var psCommands = [][]string{
+	// procps
+	{"ps", "-eo", "pid,user,args"},
+	// busybox, which lists all processes and doesn't accept -e
+	{"ps", "-o", "pid,user,args"},
+}
+
+// process is a process running in a container.
+type process struct {
+	pid     string
+	user    string
+	command string
+}
+
+var topCommand = cli.Command{
+	Name:      "top",
+	Usage:     "Display the running processes of a container",
+	ArgsUsage: "CONTAINER-ID",
+	Flags: []cli.Flag{
+		cli.Int64Flag{
+			Name:  "timeout",
+			Value: 10,
+			Usage: "Timeout in seconds of the process listing",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if len(context.Args()) != 1 {
+			return cli.ShowSubcommandHelp(context)
+		}
+
+		if err := getRuntimeClient(context); err != nil {
+			return err
+		}
+
+		id, err := resolveContainerID(runtimeClient, context.Args().First())
+		if err != nil {
+			return err
+		}
+		processes, err := ContainerTop(runtimeClient, id, context.Int64("timeout"))
+		if err != nil {
+			return fmt.Errorf("listing the processes of container failed: %v", err)
+		}
+		table := output.NewTable(
+			output.Column{Name: "pid", Header: "PID"},
+			output.Column{Name: "user", Header: "USER"},
+			output.Column{Name: "command", Header: "COMMAND"},
+		)
+		for _, p := range processes {
+			table.AddRow(p.pid, p.user, p.command)
+		}
+		return table.Write(os.Stdout, output.Options{})
+	},
+	After: closeConnection,
+}
+
+// ContainerTop lists the processes of a container by running ps in it with
+// ExecSync.
+func ContainerTop(client pb.RuntimeServiceClient, id string, timeout int64) ([]process, error) {
+	var lastErr error
+	for _, cmd := range psCommands {
+		request := &pb.ExecSyncRequest{
+			ContainerId: id,
+			Cmd:         cmd,
+			Timeout:     timeout,
+		}
+		logrus.Debugf("ExecSyncRequest: %v", request)
+		r, err := client.ExecSync(context.Background(), request)
+		logrus.Debugf("ExecSyncResponse: %v", r)
+		if err != nil {
+			// The container is not running or the runtime failed,
+			// another ps command won't help.
+			return nil, err
+		}
+		if r.ExitCode != 0 {
+			lastErr = fmt.Errorf("%q exited with code %d: %s", strings.Join(cmd, " "), r.ExitCode, strings.TrimSpace(string(r.Stderr)))
+			continue
+		}
+		return parsePsOutput(string(r.Stdout))
+	}
+	return nil, lastErr
+}
+
+// parsePsOutput parses the output of ps with the PID, USER and COMMAND
+// columns, skipping the header line.
+func parsePsOutput(out string) ([]process, error) {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) == 0 || !strings.HasPrefix(strings.TrimSpace(lines[0]), "PID") {
+		return nil, fmt.Errorf("unexpected ps output %q", out)
+	}
+	var processes []process
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("unexpected ps output line %q", line)
+		}
+		processes = append(processes, process{
+			pid:     fields[0],
+			user:    fields[1],
+			command: strings.Join(fields[2:], " "),
+		})
+	}
+	return processes, nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePsOutput(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		out       string
+		processes []process
+		expectErr bool
+	}{
+		{
+			desc: "procps output",
+			out: `  PID USER     COMMAND
+    1 root     nginx: master process nginx -g daemon off;
+    6 nginx    nginx: worker process
+`,
+			processes: []process{
+				{pid: "1", user: "root", command: "nginx: master process nginx -g daemon off;"},
+				{pid: "6", user: "nginx", command: "nginx: worker process"},
+			},
+		},
+		{
+			desc: "busybox output",
+			out: `PID   USER     COMMAND
+    1 root     top
+    7 root     ps -o pid,user,args
+`,
+			processes: []process{
+				{pid: "1", user: "root", command: "top"},
+				{pid: "7", user: "root", command: "ps -o pid,user,args"},
+			},
+		},
+		{
+			desc: "process without command",
+			out:  "PID USER COMMAND\n 1 root\n",
+			processes: []process{
+				{pid: "1", user: "root"},
+			},
+		},
+		{
+			desc:      "missing header",
+			out:       "ps: unrecognized option\n",
+			expectErr: true,
+		},
+		{
+			desc:      "truncated line",
+			out:       "PID USER COMMAND\n1\n",
+			expectErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			processes, err := parsePsOutput(tc.out)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", processes)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(processes, tc.processes) {
+				t.Errorf("expected %v, got %v", tc.processes, processes)
+			}
+		})
+	}
+}
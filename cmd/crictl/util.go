@@ -20,28 +20,25 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"reflect"
 	"sort"
 	"strings"
 
 	"github.com/ghodss/yaml"
-	"github.com/golang/protobuf/jsonpb"
-	"github.com/golang/protobuf/proto"
+	"github.com/kubernetes-sigs/cri-tools/pkg/output"
 	"github.com/urfave/cli"
-	"google.golang.org/grpc"
 	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
 	pb "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 )
 
-const (
-	// truncatedImageIDLen is the truncated length of imageID
-	truncatedIDLen = 13
-)
+// stdinConfigPath is the path of config files read from stdin.
+const stdinConfigPath = "-"
 
 var runtimeClient pb.RuntimeServiceClient
 var imageClient pb.ImageServiceClient
-var conn *grpc.ClientConn
 
 type listOptions struct {
 	// id of container or sandbox
@@ -70,6 +67,19 @@ type listOptions struct {
 	last int
 	// out with truncating the id
 	noTrunc bool
+	// columns shown in table output
+	columns []string
+}
+
+// outputOptions returns the output options of the list command. IDs are
+// never truncated in quiet mode.
+func (o listOptions) outputOptions() output.Options {
+	return output.Options{
+		Format:  o.output,
+		Columns: o.columns,
+		NoTrunc: o.noTrunc || o.quiet,
+		Quiet:   o.quiet,
+	}
 }
 
 type execOptions struct {
@@ -91,6 +101,8 @@ type attachOptions struct {
 	tty bool
 	// Whether pass Stdin to container
 	stdin bool
+	// Key sequence for detaching from the container
+	detachKeys []byte
 }
 
 type portforwardOptions struct {
@@ -111,7 +123,7 @@ func getSortedKeys(m map[string]string) []string {
 }
 
 func loadContainerConfig(path string) (*pb.ContainerConfig, error) {
-	f, err := openFile(path)
+	f, err := openConfig(path)
 	if err != nil {
 		return nil, err
 	}
@@ -125,7 +137,7 @@ func loadContainerConfig(path string) (*pb.ContainerConfig, error) {
 }
 
 func loadPodSandboxConfig(path string) (*pb.PodSandboxConfig, error) {
-	f, err := openFile(path)
+	f, err := openConfig(path)
 	if err != nil {
 		return nil, err
 	}
@@ -138,6 +150,39 @@ func loadPodSandboxConfig(path string) (*pb.PodSandboxConfig, error) {
 	return &config, nil
 }
 
+// loadCreateConfigs loads the container and pod configs used to create a
+// container, and fills in their defaults. At most one of them can be read
+// from stdin.
+func loadCreateConfigs(containerPath, podPath string) (*pb.ContainerConfig, *pb.PodSandboxConfig, error) {
+	if containerPath == stdinConfigPath && podPath == stdinConfigPath {
+		return nil, nil, fmt.Errorf("container and pod configs can't both be read from stdin")
+	}
+	config, err := loadContainerConfig(containerPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load containerConfig failed: %v", err)
+	}
+	if err := setContainerConfigDefaults(config); err != nil {
+		return nil, nil, err
+	}
+	podConfig, err := loadPodSandboxConfig(podPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load podSandboxConfig failed: %v", err)
+	}
+	if err := setPodSandboxConfigDefaults(podConfig); err != nil {
+		return nil, nil, err
+	}
+	return config, podConfig, nil
+}
+
+// openConfig opens the config file at path, or stdin if path is
+// stdinConfigPath.
+func openConfig(path string) (io.ReadCloser, error) {
+	if path == stdinConfigPath {
+		return ioutil.NopCloser(os.Stdin), nil
+	}
+	return openFile(path)
+}
+
 func openFile(path string) (*os.File, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -151,8 +196,7 @@ func openFile(path string) (*os.File, error) {
 
 func getRuntimeClient(context *cli.Context) error {
 	// Set up a connection to the server.
-	var err error
-	conn, err = getRuntimeClientConnection(context)
+	conn, err := getRuntimeClientConnection(context)
 	if err != nil {
 		return fmt.Errorf("failed to connect: %v", err)
 	}
@@ -162,8 +206,7 @@ func getRuntimeClient(context *cli.Context) error {
 
 func getImageClient(context *cli.Context) error {
 	// Set up a connection to the server.
-	var err error
-	conn, err = getImageClientConnection(context)
+	conn, err := getImageClientConnection(context)
 	if err != nil {
 		return fmt.Errorf("failed to connect: %v", err)
 	}
@@ -172,67 +215,15 @@ func getImageClient(context *cli.Context) error {
 }
 
 func closeConnection(context *cli.Context) error {
-	if conn == nil {
-		return nil
-	}
-
-	return conn.Close()
+	return connections.Close()
 }
 
-func protobufObjectToJSON(obj proto.Message) (string, error) {
-	jsonpbMarshaler := jsonpb.Marshaler{EmitDefaults: true, Indent: "  "}
-	marshaledJSON, err := jsonpbMarshaler.MarshalToString(obj)
-	if err != nil {
-		return "", err
-	}
-	return marshaledJSON, nil
-}
-
-func outputProtobufObjAsJSON(obj proto.Message) error {
-	marshaledJSON, err := protobufObjectToJSON(obj)
-	if err != nil {
-		return err
-	}
-
-	fmt.Println(marshaledJSON)
-	return nil
-}
-
-func outputProtobufObjAsYAML(obj proto.Message) error {
-	marshaledJSON, err := protobufObjectToJSON(obj)
-	if err != nil {
-		return err
-	}
-	marshaledYAML, err := yaml.JSONToYAML([]byte(marshaledJSON))
+func outputStatusInfo(status string, info map[string]string, format string) error {
+	jsonInfo, err := statusInfoJSON(status, info)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println(string(marshaledYAML))
-	return nil
-}
-
-func outputStatusInfo(status string, info map[string]string, format string) error {
-	// Sort all keys
-	var keys []string
-	for k := range info {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
-	jsonInfo := "{" + "\"status\":" + status + ","
-	for _, k := range keys {
-		var res interface{}
-		// We attempt to convert key into JSON if possible else use it directly
-		if err := json.Unmarshal([]byte(info[k]), res); err != nil {
-			jsonInfo += "\"" + k + "\"" + ":" + "\"" + info[k] + "\","
-		} else {
-			jsonInfo += "\"" + k + "\"" + ":" + info[k] + ","
-		}
-	}
-	jsonInfo = jsonInfo[:len(jsonInfo)-1]
-	jsonInfo += "}"
-
 	switch format {
 	case "yaml":
 		yamlInfo, err := yaml.JSONToYAML([]byte(jsonInfo))
@@ -247,11 +238,65 @@ func outputStatusInfo(status string, info map[string]string, format string) erro
 		}
 		fmt.Println(output.String())
 	default:
+		if output.IsTemplate(format) {
+			return output.WriteTemplate(os.Stdout, jsonInfo, format)
+		}
 		fmt.Printf("Don't support %q format\n", format)
 	}
 	return nil
 }
 
+// decodeInfoValue decodes a value of the verbose info map returned by the
+// runtime. Values are usually JSON, such as the OCI spec of a container, and
+// are kept as raw JSON so that their numbers and key order are unchanged.
+// They are returned as is otherwise.
+func decodeInfoValue(value string) interface{} {
+	if !json.Valid([]byte(value)) {
+		return value
+	}
+	return json.RawMessage(value)
+}
+
+// statusInfoJSON returns a JSON object with the status JSON followed by the
+// decoded info values, sorted by key.
+func statusInfoJSON(status string, info map[string]string) (string, error) {
+	jsonInfo := "{" + "\"status\":" + status
+	for _, k := range getSortedKeys(info) {
+		key, err := json.Marshal(k)
+		if err != nil {
+			return "", err
+		}
+		value, err := json.Marshal(decodeInfoValue(info[k]))
+		if err != nil {
+			return "", err
+		}
+		jsonInfo += "," + string(key) + ":" + string(value)
+	}
+	jsonInfo += "}"
+	return jsonInfo, nil
+}
+
+// printInfo prints the decoded verbose info map in table output.
+func printInfo(info map[string]string) error {
+	if len(info) == 0 {
+		return nil
+	}
+	fmt.Println("Info:")
+	for _, k := range getSortedKeys(info) {
+		value, err := json.MarshalIndent(decodeInfoValue(info[k]), "\t", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\t%s -> %s\n", k, value)
+	}
+	return nil
+}
+
+// isTableOutput returns whether format is the table output format.
+func isTableOutput(format string) bool {
+	return format == "table" || format == ""
+}
+
 func parseLabelStringSlice(ss []string) (map[string]string, error) {
 	labels := make(map[string]string)
 	for _, s := range ss {
@@ -300,11 +345,3 @@ func jsonFieldFromTag(tag reflect.StructTag) string {
 	}
 	return field
 }
-
-func getTruncatedID(id, prefix string) string {
-	id = strings.TrimPrefix(id, prefix)
-	if len(id) > truncatedIDLen {
-		id = id[:truncatedIDLen]
-	}
-	return id
-}
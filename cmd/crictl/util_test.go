@@ -0,0 +1,68 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+)
+
+func TestStatusInfoJSON(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		info     map[string]string
+		expected string
+	}{
+		{
+			desc:     "no info",
+			expected: `{"status":{"id":"1"}}`,
+		},
+		{
+			desc: "JSON values are decoded",
+			info: map[string]string{
+				"info": `{"pid":42,"runtimeSpec":{"ociVersion":"1.0.0"}}`,
+				"pid":  "42",
+			},
+			expected: `{"status":{"id":"1"},"info":{"pid":42,"runtimeSpec":{"ociVersion":"1.0.0"}},"pid":42}`,
+		},
+		{
+			desc: "JSON values keep their numbers and key order",
+			info: map[string]string{
+				"info": `{"uid": 18446744073709551615, "b": 1, "a": 2}`,
+			},
+			expected: `{"status":{"id":"1"},"info":{"uid":18446744073709551615,"b":1,"a":2}}`,
+		},
+		{
+			desc: "other values are kept as strings",
+			info: map[string]string{
+				"sandboxID": "abc",
+				"message":   `invalid "spec"`,
+			},
+			expected: `{"status":{"id":"1"},"message":"invalid \"spec\"","sandboxID":"abc"}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			jsonInfo, err := statusInfoJSON(`{"id":"1"}`, tc.info)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if jsonInfo != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, jsonInfo)
+			}
+		})
+	}
+}
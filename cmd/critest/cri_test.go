@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"math/rand"
@@ -28,6 +29,7 @@ import (
 	"time"
 
 	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/config"
 	"github.com/onsi/ginkgo/reporters"
 	"github.com/onsi/gomega"
 
@@ -42,6 +44,15 @@ const (
 	parallelFlag  = "parallel"
 	benchmarkFlag = "benchmark"
 	versionFlag   = "version"
+	focusAreaFlag = "focus-area"
+	skipAreaFlag  = "skip-area"
+	listFlag      = "list"
+	rerunFlag     = "rerun-failed"
+
+	// soakFocus focuses on the soak test.
+	soakFocus = `\[Soak\]`
+	// densityFocus focuses on the density test.
+	densityFocus = `\[Density\]`
 )
 
 var (
@@ -50,6 +61,10 @@ var (
 	isBenchMark = flag.Bool(benchmarkFlag, false, "Run benchmarks instead of validation tests")
 	parallel    = flag.Int(parallelFlag, 1, "The number of parallel test nodes to run (default 1)")
 	version     = flag.Bool(versionFlag, false, "Display version of critest")
+	focusAreas  = flag.String(focusAreaFlag, "", fmt.Sprintf("Comma separated areas to run, among %s", strings.Join(framework.Areas(), ", ")))
+	list        = flag.Bool(listFlag, false, "Print the specs which would run, with their conformance status and required capabilities, as JSON instead of running them")
+	rerunFailed = flag.Bool(rerunFlag, false, "Only run the specs which failed in the last run recorded in -last-run-dir")
+	skipAreas   = flag.String(skipAreaFlag, "", fmt.Sprintf("Comma separated areas to skip, among %s", strings.Join(framework.Areas(), ", ")))
 )
 
 func init() {
@@ -59,7 +74,7 @@ func init() {
 
 // runTestSuite runs cri validation tests and benchmark tests.
 func runTestSuite(t *testing.T) {
-	gomega.RegisterFailHandler(ginkgo.Fail)
+	gomega.RegisterFailHandler(framework.FailWithArtifacts)
 
 	reporter := []ginkgo.Reporter{}
 	if framework.TestContext.ReportDir != "" {
@@ -68,9 +83,100 @@ func runTestSuite(t *testing.T) {
 		}
 
 		reporter = append(reporter, reporters.NewJUnitReporter(path.Join(framework.TestContext.ReportDir, fmt.Sprintf("junit_%v.xml", framework.TestContext.ReportPrefix))))
+		prefix := framework.TestContext.ReportPrefix
+		if config.GinkgoConfig.ParallelTotal > 1 {
+			prefix += fmt.Sprintf("%02d", config.GinkgoConfig.ParallelNode)
+		}
+		reporter = append(reporter, framework.NewSuiteReporter(framework.TestContext.ReportDir, prefix))
+	}
+	if recordsLastRun() {
+		reporter = append(reporter, framework.NewLastRunReporter(framework.TestContext.LastRunDir, config.GinkgoConfig.ParallelNode))
+	}
+	if framework.TestContext.MetricsAddress != "" {
+		if err := framework.StartMetricsServer(); err != nil {
+			t.Fatalf("Failed to start the metrics server: %v", err)
+		}
+	}
+	var benchmarkReporter *framework.BenchmarkReporter
+	if *isBenchMark && (framework.TestContext.BenchmarkResults != "" || framework.TestContext.BenchmarkBaseline != "") {
+		benchmarkReporter = framework.NewBenchmarkReporter()
+		reporter = append(reporter, benchmarkReporter)
+	}
+
+	if framework.TestContext.FlakeAttempts > 1 {
+		config.GinkgoConfig.FlakeAttempts = framework.TestContext.FlakeAttempts
+	}
+	if attempts := config.GinkgoConfig.FlakeAttempts; attempts > 1 {
+		for i, r := range reporter {
+			reporter[i] = framework.NewFlakeReporter(r, attempts)
+		}
 	}
 
 	ginkgo.RunSpecsWithDefaultAndCustomReporters(t, "CRI validation", reporter)
+
+	if benchmarkReporter != nil {
+		checkBenchmarkResults(t, benchmarkReporter.Results())
+	}
+}
+
+// checkBenchmarkResults writes the benchmark results and compares them to
+// the baseline.
+func checkBenchmarkResults(t *testing.T, results []framework.BenchmarkResult) {
+	if path := framework.TestContext.BenchmarkResults; path != "" {
+		if err := framework.WriteBenchmarkResults(path, results); err != nil {
+			t.Errorf("Failed to write the benchmark results: %v", err)
+		}
+	}
+	if framework.TestContext.BenchmarkBaseline == "" {
+		return
+	}
+	baseline, err := framework.LoadBenchmarkResults(framework.TestContext.BenchmarkBaseline)
+	if err != nil {
+		t.Fatalf("Failed to load the benchmark baseline: %v", err)
+	}
+	maxRegression, err := framework.ParseRegression(framework.TestContext.BenchmarkMaxRegression)
+	if err != nil {
+		t.Fatalf("Invalid -max-regression: %v", err)
+	}
+	for _, r := range framework.CompareBenchmarkResults(baseline, results, maxRegression) {
+		t.Errorf("Benchmark regression: %v", r)
+	}
+}
+
+// checkBenchmarkFlags checks the flags comparing the benchmarks to a
+// baseline, before running them.
+func checkBenchmarkFlags() error {
+	if framework.TestContext.BenchmarkResults == "" && framework.TestContext.BenchmarkBaseline == "" {
+		return nil
+	}
+	if !*isBenchMark {
+		return fmt.Errorf("-benchmark-results and -baseline are only supported in benchmark mode")
+	}
+	if *parallel > 1 {
+		return fmt.Errorf("-benchmark-results and -baseline can't be used with -%s", parallelFlag)
+	}
+	if framework.TestContext.BenchmarkBaseline != "" {
+		if _, err := framework.LoadBenchmarkResults(framework.TestContext.BenchmarkBaseline); err != nil {
+			return err
+		}
+	}
+	_, err := framework.ParseRegression(framework.TestContext.BenchmarkMaxRegression)
+	return err
+}
+
+// listSpecs prints the specs which would run as JSON.
+func listSpecs(t *testing.T) {
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	config.GinkgoConfig.DryRun = true
+
+	reporter := framework.NewListReporter()
+	ginkgo.RunSpecsWithCustomReporters(t, "CRI validation", []ginkgo.Reporter{reporter})
+
+	data, err := json.MarshalIndent(reporter.Specs(), "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to marshal specs: %v", err)
+	}
+	fmt.Println(string(data))
 }
 
 func generateTempTestName() string {
@@ -103,11 +209,16 @@ func runParallelTestSuite(t *testing.T) {
 			ginkgoArgs = append(ginkgoArgs, fmt.Sprintf("-%s=%s", flagName, f.Value.String()))
 			return
 		}
-		if f.Name == parallelFlag || f.Name == benchmarkFlag {
+		if f.Name == parallelFlag || f.Name == benchmarkFlag || f.Name == focusAreaFlag || f.Name == skipAreaFlag || f.Name == rerunFlag {
 			return
 		}
 		testArgs = append(testArgs, fmt.Sprintf("-%s=%s", f.Name, f.Value.String()))
 	})
+	// Parallel test nodes are not given --benchmark, so they are told not to
+	// record their results with an empty last run directory.
+	if !recordsLastRun() {
+		testArgs = append(testArgs, "-last-run-dir=")
+	}
 	var args []string
 	args = append(args, ginkgoArgs...)
 	args = append(args, tempFileName, "--")
@@ -122,19 +233,180 @@ func runParallelTestSuite(t *testing.T) {
 	}
 }
 
+// applyAreas translates --focus-area and --skip-area into the ginkgo focus
+// and skip regular expressions. Focusing on the benchmark area is the same
+// as --benchmark, and other focused areas then select their benchmarks.
+func applyAreas() error {
+	focus, err := framework.ParseAreas(*focusAreas)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, name := range focus {
+		if name == framework.AreaBenchmark {
+			*isBenchMark = true
+			continue
+		}
+		names = append(names, name)
+	}
+	if regexp := framework.AreasRegexp(names); regexp != "" {
+		if flag.Lookup("ginkgo.focus").Value.String() != "" {
+			return fmt.Errorf("--%s can't be used with -ginkgo.focus", focusAreaFlag)
+		}
+		if *isBenchMark {
+			regexp += ".*benchmark"
+		}
+		flag.Set("ginkgo.focus", regexp)
+	} else if *isBenchMark {
+		flag.Set("ginkgo.focus", "benchmark")
+	}
+
+	skip, err := framework.ParseAreas(*skipAreas)
+	if err != nil {
+		return err
+	}
+	if regexp := framework.AreasRegexp(skip); regexp != "" {
+		if userSkip := flag.Lookup("ginkgo.skip").Value.String(); userSkip != "" {
+			regexp = "(" + userSkip + ")|" + regexp
+		}
+		flag.Set("ginkgo.skip", regexp)
+	}
+	return nil
+}
+
+// applySoak focuses on the soak test in soak mode.
+func applySoak() error {
+	if !framework.TestContext.Soak {
+		return nil
+	}
+	if *isBenchMark {
+		return fmt.Errorf("-soak can't be used with -%s", benchmarkFlag)
+	}
+	if *focusAreas != "" || *skipAreas != "" {
+		return fmt.Errorf("-soak can't be used with -%s or -%s", focusAreaFlag, skipAreaFlag)
+	}
+	if _, err := framework.ParseSoakWeights(framework.TestContext.SoakWeights); err != nil {
+		return err
+	}
+	focus := flag.Lookup("ginkgo.focus").Value.String()
+	if focus != "" && focus != soakFocus {
+		return fmt.Errorf("-soak can't be used with -ginkgo.focus")
+	}
+	return flag.Set("ginkgo.focus", soakFocus)
+}
+
+// applyDensity focuses on the density test in density mode.
+func applyDensity() error {
+	if !framework.TestContext.Density {
+		return nil
+	}
+	if *isBenchMark || framework.TestContext.Soak {
+		return fmt.Errorf("-density can't be used with -%s or -soak", benchmarkFlag)
+	}
+	if *focusAreas != "" || *skipAreas != "" {
+		return fmt.Errorf("-density can't be used with -%s or -%s", focusAreaFlag, skipAreaFlag)
+	}
+	if framework.TestContext.MaxPods < 1 {
+		return fmt.Errorf("-max-pods should be positive")
+	}
+	focus := flag.Lookup("ginkgo.focus").Value.String()
+	if focus != "" && focus != densityFocus {
+		return fmt.Errorf("-density can't be used with -ginkgo.focus")
+	}
+	return flag.Set("ginkgo.focus", densityFocus)
+}
+
+// recordsLastRun returns whether the run records its results for
+// --rerun-failed: only the validation runs do, not the benchmark, soak and
+// density runs, whose specs --rerun-failed can't rerun.
+func recordsLastRun() bool {
+	return framework.TestContext.LastRunDir != "" && !*isBenchMark && !framework.TestContext.Soak && !framework.TestContext.Density
+}
+
+// applyRerunFailed focuses on the specs which failed in the last run with
+// --rerun-failed, and clears the results of the last run before a new run
+// records its own. It returns false if there is no failed spec to rerun.
+func applyRerunFailed() (bool, error) {
+	// Parallel test nodes are not given --rerun-failed, their parent
+	// focused on the failed specs and cleared the last run.
+	if *list || config.GinkgoConfig.ParallelTotal > 1 {
+		return true, nil
+	}
+	if *rerunFailed {
+		if *isBenchMark || framework.TestContext.Soak || framework.TestContext.Density {
+			return false, fmt.Errorf("--%s can't be used with --%s, -soak or -density", rerunFlag, benchmarkFlag)
+		}
+		if *focusAreas != "" || *skipAreas != "" || flag.Lookup("ginkgo.focus").Value.String() != "" {
+			return false, fmt.Errorf("--%s can't be used with --%s, --%s or -ginkgo.focus", rerunFlag, focusAreaFlag, skipAreaFlag)
+		}
+		failed, err := framework.LastRunFailures(framework.TestContext.LastRunDir)
+		if err != nil {
+			return false, err
+		}
+		if len(failed) == 0 {
+			return false, nil
+		}
+		fmt.Printf("Rerunning %d failed spec(s)\n", len(failed))
+		flag.Set("ginkgo.focus", framework.RerunFocus(failed))
+	}
+	if !recordsLastRun() {
+		return true, nil
+	}
+	return true, framework.ClearLastRun(framework.TestContext.LastRunDir)
+}
+
 func TestCRISuite(t *testing.T) {
 	if *version {
 		fmt.Printf("critest version: %s\n", versionconst.Version)
 		return
 	}
 
-	if *isBenchMark {
-		flag.Set("ginkgo.focus", "benchmark")
-	} else {
+	// The extension suites are loaded by each parallel test node, and by
+	// their parent to check them.
+	if dir := framework.TestContext.ExtraSuitesDir; dir != "" {
+		if err := framework.LoadExtraSuites(dir); err != nil {
+			t.Fatalf("Failed to load the extra suites: %v", err)
+		}
+	}
+	if err := applyAreas(); err != nil {
+		t.Fatalf("Invalid areas: %v", err)
+	}
+	if err := applySoak(); err != nil {
+		t.Fatalf("Invalid soak mode: %v", err)
+	}
+	if err := applyDensity(); err != nil {
+		t.Fatalf("Invalid density mode: %v", err)
+	}
+	if rerun, err := applyRerunFailed(); err != nil {
+		t.Fatalf("Failed to rerun the failed specs: %v", err)
+	} else if !rerun {
+		fmt.Println("No failed spec to rerun")
+		return
+	}
+	if err := checkBenchmarkFlags(); err != nil {
+		t.Fatalf("Invalid benchmark results: %v", err)
+	}
+	// The faults disrupt the runtime under the specs of the other parallel
+	// test nodes.
+	if framework.TestContext.ChaosFaults != "" && *parallel > 1 {
+		t.Fatalf("-chaos-faults can't be used with -%s", parallelFlag)
+	}
+	if profile := framework.TestContext.RuntimeProfile; profile != "" {
+		if _, err := framework.LookupRuntimeProfile(profile); err != nil {
+			t.Fatalf("Invalid -runtime-profile: %v", err)
+		}
+	}
+	// Parallel test nodes are not given --benchmark, their parent checked it.
+	if framework.TestContext.MetricsAddress != "" && !*isBenchMark && !framework.TestContext.Soak && !framework.TestContext.Density && config.GinkgoConfig.ParallelTotal == 1 {
+		t.Fatalf("-metrics-address is only supported in benchmark, soak and density modes")
+	}
+	if !*isBenchMark {
 		// Skip benchamark measurements for validation tests.
 		flag.Set("ginkgo.skipMeasurements", "true")
 	}
-	if *parallel > 1 {
+	if *list {
+		listSpecs(t)
+	} else if *parallel > 1 {
 		runParallelTestSuite(t)
 	} else {
 		runTestSuite(t)
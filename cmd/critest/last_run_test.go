@@ -0,0 +1,64 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+)
+
+func TestBenchmarkRunKeepsLastRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "last-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	reporter := framework.NewLastRunReporter(dir, 1)
+	reporter.SpecSuiteWillBegin(config.GinkgoConfigType{}, &types.SuiteSummary{SuiteDescription: "CRI validation"})
+	reporter.SpecDidComplete(&types.SpecSummary{
+		ComponentTexts: []string{"[Top Level]", "[k8s.io] Pod", "failed spec"},
+		State:          types.SpecStateFailed,
+	})
+	reporter.SpecSuiteDidEnd(&types.SuiteSummary{RunTime: time.Second})
+
+	defer func(benchmark bool, lastRunDir string) {
+		*isBenchMark = benchmark
+		framework.TestContext.LastRunDir = lastRunDir
+	}(*isBenchMark, framework.TestContext.LastRunDir)
+	*isBenchMark = true
+	framework.TestContext.LastRunDir = dir
+
+	if recordsLastRun() {
+		t.Errorf("expected a benchmark run not to record its results")
+	}
+	if _, err := applyRerunFailed(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	failed, err := framework.LastRunFailures(dir)
+	if err != nil {
+		t.Fatalf("expected the last run to be kept: %v", err)
+	}
+	if len(failed) != 1 {
+		t.Errorf("expected the failed spec of the last run; actual failed specs are %v", failed)
+	}
+}
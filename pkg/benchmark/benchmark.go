@@ -39,7 +39,7 @@ import (
 // generated in this directory.
 func TestPerformance(t *testing.T) {
 	rand.Seed(time.Now().UTC().UnixNano())
-	RegisterFailHandler(Fail)
+	RegisterFailHandler(framework.FailWithArtifacts)
 	r := []Reporter{}
 	reportDir := framework.TestContext.ReportDir
 	if reportDir != "" {
@@ -0,0 +1,128 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmark
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = framework.KubeDescribe("Density", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	var rc internalapi.RuntimeService
+	var ic internalapi.ImageManagerService
+	var podIDs []string
+
+	BeforeEach(func() {
+		if !framework.TestContext.Density {
+			Skip("density mode is not enabled, use -density")
+		}
+		rc = f.CRIClient.CRIRuntimeClient
+		ic = f.CRIClient.CRIImageClient
+		podIDs = nil
+	})
+
+	AfterEach(func() {
+		By(fmt.Sprintf("remove the %d PodSandboxes", len(podIDs)))
+		for _, podID := range podIDs {
+			rc.StopPodSandbox(podID)
+			rc.RemovePodSandbox(podID)
+		}
+	})
+
+	It("should create pods until a failure or -max-pods [Density]", func() {
+		maxPods := framework.TestContext.MaxPods
+		framework.PullImageIfNotPresent(ic, framework.DefaultContainerImage)
+		image := framework.ResolveImage(framework.DefaultContainerImage)
+
+		By(fmt.Sprintf("create up to %d PodSandboxes with a running container", maxPods))
+		stats := framework.NewDensityStats(maxPods)
+		for i := 0; i < maxPods; i++ {
+			latencies, operation, err := densityPod(rc, image, &podIDs)
+			if err != nil {
+				framework.Logf("Density test stopped after %d pods, %s failed: %v", i, operation, err)
+				stats.Fail(operation, err)
+				break
+			}
+			stats.Record(latencies)
+		}
+
+		results := stats.Results()
+		framework.Logf("Density results:\n%s", strings.Join(results.Summary(), "\n"))
+		if dir := framework.TestContext.ReportDir; dir != "" {
+			path := filepath.Join(dir, framework.TestContext.ReportPrefix+"density.json")
+			framework.ExpectNoError(framework.WriteDensityResults(path, results), "failed to write the density results")
+		}
+		Expect(results.Pods).NotTo(BeZero(), "runtime should run at least a pod: %s", results.Failure)
+	})
+})
+
+// densityPod runs a pod sandbox with a running container, appending the
+// pod to podIDs for removal, and returns the latencies of the operations, or
+// the operation which failed and its error.
+func densityPod(rc internalapi.RuntimeService, image string, podIDs *[]string) (map[string]time.Duration, string, error) {
+	latencies := make(map[string]time.Duration)
+	timed := func(operation string, call func() error) error {
+		begin := time.Now()
+		err := call()
+		latencies[operation] = time.Since(begin)
+		return err
+	}
+
+	podConfig := &runtimeapi.PodSandboxConfig{
+		Metadata: framework.BuildPodSandboxMetadata("density-pod-"+framework.NewUUID(), framework.DefaultUIDPrefix+framework.NewUUID(),
+			framework.DefaultNamespacePrefix+framework.NewUUID(), framework.DefaultAttempt),
+		Linux: &runtimeapi.LinuxPodSandboxConfig{},
+	}
+	var podID, containerID string
+	if err := timed(framework.DensityOperations[0], func() (err error) {
+		podID, err = rc.RunPodSandbox(podConfig)
+		return err
+	}); err != nil {
+		return nil, framework.DensityOperations[0], err
+	}
+	*podIDs = append(*podIDs, podID)
+
+	containerConfig := &runtimeapi.ContainerConfig{
+		Metadata: framework.BuildContainerMetadata("density-container-"+framework.NewUUID(), framework.DefaultAttempt),
+		Image:    &runtimeapi.ImageSpec{Image: image},
+		Command:  []string{"top"},
+		Linux:    &runtimeapi.LinuxContainerConfig{},
+	}
+	if err := timed(framework.DensityOperations[1], func() (err error) {
+		containerID, err = rc.CreateContainer(podID, containerConfig, podConfig)
+		return err
+	}); err != nil {
+		return nil, framework.DensityOperations[1], err
+	}
+	if err := timed(framework.DensityOperations[2], func() error {
+		return rc.StartContainer(containerID)
+	}); err != nil {
+		return nil, framework.DensityOperations[2], err
+	}
+	return latencies, "", nil
+}
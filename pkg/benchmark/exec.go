@@ -0,0 +1,130 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmark
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	// defaultExecSyncTimeout is the timeout of a single ExecSync call.
+	defaultExecSyncTimeout = 5 * time.Second
+
+	// execSyncOperationTimes is the number of samples taken for ExecSync benchmarks.
+	execSyncOperationTimes int = 5
+)
+
+var _ = framework.KubeDescribe("ExecSync", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	var rc internalapi.RuntimeService
+	var ic internalapi.ImageManagerService
+
+	BeforeEach(func() {
+		rc = f.CRIClient.CRIRuntimeClient
+		ic = f.CRIClient.CRIImageClient
+	})
+
+	Context("benchmark about ExecSync", func() {
+		var podID string
+		var podConfig *runtimeapi.PodSandboxConfig
+		var containerID string
+
+		BeforeEach(func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+			containerID = framework.CreateDefaultContainer(rc, ic, podID, podConfig, "Container-for-execSync-benchmark-")
+			err := rc.StartContainer(containerID)
+			framework.ExpectNoError(err, "failed to start Container: %v", err)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+		})
+
+		Measure("benchmark about serial ExecSync", func(b Benchmarker) {
+			number := framework.TestContext.ExecSyncNumber
+
+			operation := b.Time("serial ExecSync", func() {
+				for i := 0; i < number; i++ {
+					execSyncAndCheck(rc, containerID)
+				}
+			})
+
+			recordExecSyncResult(b, "serial", operation, number)
+		}, execSyncOperationTimes)
+
+		Measure("benchmark about concurrent ExecSync", func(b Benchmarker) {
+			number := framework.TestContext.ExecSyncNumber
+			concurrency := framework.TestContext.ExecSyncConcurrency
+			if concurrency < 1 {
+				concurrency = 1
+			}
+
+			operation := b.Time("concurrent ExecSync", func() {
+				var wg sync.WaitGroup
+				jobs := make(chan struct{}, number)
+				for i := 0; i < number; i++ {
+					jobs <- struct{}{}
+				}
+				close(jobs)
+
+				for i := 0; i < concurrency; i++ {
+					wg.Add(1)
+					go func() {
+						defer GinkgoRecover()
+						defer wg.Done()
+						for range jobs {
+							execSyncAndCheck(rc, containerID)
+						}
+					}()
+				}
+				wg.Wait()
+			})
+
+			recordExecSyncResult(b, "concurrent", operation, number)
+		}, execSyncOperationTimes)
+	})
+})
+
+// execSyncAndCheck runs a trivial command in the container and makes sure it succeeds.
+func execSyncAndCheck(rc internalapi.RuntimeService, containerID string) {
+	stdout, _, err := rc.ExecSync(containerID, []string{"echo", "-n", "ok"}, defaultExecSyncTimeout)
+	framework.ExpectNoError(err, "failed to execSync in container %q: %v", containerID, err)
+	Expect(string(stdout)).To(Equal("ok"), "The stdout of execSync should be ok")
+}
+
+// recordExecSyncResult records the per-exec latency and throughput of an ExecSync run.
+func recordExecSyncResult(b Benchmarker, mode string, operation time.Duration, number int) {
+	if number == 0 {
+		return
+	}
+	latency := operation.Seconds() * 1000 / float64(number)
+	b.RecordValue(mode+" ExecSync latency (ms/exec)", latency)
+	b.RecordValue(mode+" ExecSync throughput (execs/s)", float64(number)/operation.Seconds())
+	Expect(latency).Should(BeNumerically("<", 2000), "a single ExecSync shouldn't take too long.")
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmark
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	// logOperationTimes is the number of samples taken for the container
+	// log benchmark.
+	logOperationTimes int = 3
+
+	// logExitPollInterval is the interval between checks of the state of
+	// the logging container, short enough not to skew the throughput.
+	logExitPollInterval = 100 * time.Millisecond
+)
+
+var _ = framework.KubeDescribe("Container", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	var rc internalapi.RuntimeService
+	var ic internalapi.ImageManagerService
+
+	BeforeEach(func() {
+		rc = f.CRIClient.CRIRuntimeClient
+		ic = f.CRIClient.CRIImageClient
+	})
+
+	Context("benchmark about container log", func() {
+		var podID, logDir string
+		var podConfig *runtimeapi.PodSandboxConfig
+
+		BeforeEach(func() {
+			var err error
+			logDir, err = ioutil.TempDir("", "critest-log-benchmark")
+			framework.ExpectNoError(err, "failed to create log directory: %v", err)
+
+			podSandboxName := "PodSandbox-for-log-benchmark-" + framework.NewUUID()
+			uid := framework.DefaultUIDPrefix + framework.NewUUID()
+			namespace := framework.DefaultNamespacePrefix + framework.NewUUID()
+			podConfig = &runtimeapi.PodSandboxConfig{
+				Metadata:     framework.BuildPodSandboxMetadata(podSandboxName, uid, namespace, framework.DefaultAttempt),
+				LogDirectory: logDir,
+				Linux:        &runtimeapi.LinuxPodSandboxConfig{},
+			}
+			podID = framework.RunPodSandbox(rc, podConfig)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+			os.RemoveAll(logDir)
+		})
+
+		Measure("benchmark about high-volume container log", func(b Benchmarker) {
+			lineSize := framework.TestContext.LogLineSize
+			lines := framework.TestContext.LogSize * 1024 * 1024 / lineSize
+			command, err := framework.LogSequenceCommand(lines, lineSize)
+			framework.ExpectNoError(err, "invalid log benchmark settings: %v", err)
+
+			By("create a container writing sequence-numbered lines")
+			containerName := "Container-for-log-benchmark-" + framework.NewUUID()
+			logPath := containerName + ".log"
+			containerID := framework.CreateContainer(rc, ic, &runtimeapi.ContainerConfig{
+				Metadata: framework.BuildContainerMetadata(containerName, framework.DefaultAttempt),
+				Image:    &runtimeapi.ImageSpec{Image: framework.DefaultContainerImage},
+				Command:  command,
+				LogPath:  logPath,
+				Linux:    &runtimeapi.LinuxContainerConfig{},
+			}, podID, podConfig)
+
+			operation := b.Time("write container log", func() {
+				By("start the container and wait for it to exit")
+				err = rc.StartContainer(containerID)
+				framework.ExpectNoError(err, "failed to start Container: %v", err)
+				Eventually(func() runtimeapi.ContainerState {
+					status, err := rc.ContainerStatus(containerID)
+					framework.ExpectNoError(err, "failed to get Container status: %v", err)
+					return status.State
+				}, framework.TestContext.StateTimeout, logExitPollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_EXITED))
+			})
+			size := float64(lines*lineSize) / (1024 * 1024)
+			b.RecordValue("container log throughput (MB/s)", size/operation.Seconds())
+
+			By("check all lines are in the container log")
+			var report framework.LogSequenceReport
+			Eventually(func() bool {
+				report = checkLogSequence(filepath.Join(logDir, logPath), lines, lineSize)
+				return report.OK()
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(BeTrue(),
+				"container log should hold all lines intact")
+			framework.Logf("Container log of %.0fMB: %v", size, report)
+		}, logOperationTimes)
+	})
+})
+
+// checkLogSequence checks the lines written by framework.LogSequenceCommand
+// in the container log at path.
+func checkLogSequence(path string, lines, lineSize int) framework.LogSequenceReport {
+	f, err := os.Open(path)
+	framework.ExpectNoError(err, "failed to open container log: %v", err)
+	defer f.Close()
+	report, err := framework.CheckLogSequence(f, lines, lineSize)
+	framework.ExpectNoError(err, "failed to read container log: %v", err)
+	return report
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmark
+
+import (
+	"time"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// phasePollInterval is the interval between the status polls of the pod
+// startup phases benchmark, short enough not to skew the phase latencies.
+const phasePollInterval = 10 * time.Millisecond
+
+var _ = framework.KubeDescribe("PodSandbox", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	var rc internalapi.RuntimeService
+	var ic internalapi.ImageManagerService
+
+	BeforeEach(func() {
+		rc = f.CRIClient.CRIRuntimeClient
+		ic = f.CRIClient.CRIImageClient
+		// The image is pulled first not to time the pull with the first
+		// container.
+		framework.PullImageIfNotPresent(ic, framework.DefaultContainerImage)
+	})
+
+	Context("benchmark about the phases of PodSandbox startup", func() {
+		Measure("benchmark about the latency of each phase of PodSandbox startup", func(b Benchmarker) {
+			var podID, containerID string
+			var err error
+
+			podSandboxName := "PodSandbox-for-phases-benchmark-" + framework.NewUUID()
+			uid := framework.DefaultUIDPrefix + framework.NewUUID()
+			namespace := framework.DefaultNamespacePrefix + framework.NewUUID()
+			config := &runtimeapi.PodSandboxConfig{
+				Metadata: framework.BuildPodSandboxMetadata(podSandboxName, uid, namespace, framework.DefaultAttempt),
+				Linux:    &runtimeapi.LinuxPodSandboxConfig{},
+			}
+
+			run := b.Time("run PodSandbox", func() {
+				podID, err = rc.RunPodSandbox(config)
+			})
+			framework.ExpectNoError(err, "failed to run PodSandbox: %v", err)
+			defer func() {
+				rc.StopPodSandbox(podID)
+				rc.RemovePodSandbox(podID)
+			}()
+
+			network := b.Time("PodSandbox network ready", func() {
+				By("wait for the PodSandbox to be ready with an IP")
+				Eventually(func() string {
+					status, err := rc.PodSandboxStatus(podID)
+					framework.ExpectNoError(err, "failed to get PodSandbox status: %v", err)
+					if status.State != runtimeapi.PodSandboxState_SANDBOX_READY {
+						return ""
+					}
+					return status.GetNetwork().GetIp()
+				}, framework.TestContext.StateTimeout, phasePollInterval).ShouldNot(BeEmpty(), "PodSandbox should be ready with an IP")
+			})
+
+			container := b.Time("first container ready", func() {
+				By("create and start the first container and wait for it to run")
+				containerID = framework.CreateDefaultContainer(rc, ic, podID, config, "Container-for-phases-benchmark-")
+				err = rc.StartContainer(containerID)
+				framework.ExpectNoError(err, "failed to start Container: %v", err)
+				Eventually(func() runtimeapi.ContainerState {
+					status, err := rc.ContainerStatus(containerID)
+					framework.ExpectNoError(err, "failed to get Container status: %v", err)
+					return status.State
+				}, framework.TestContext.StateTimeout, phasePollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+			})
+
+			total := run + network + container
+			b.RecordValue("PodSandbox startup latency (s)", total.Seconds())
+			framework.Logf("PodSandbox started in %v: run PodSandbox %v (%.0f%%), network ready %v (%.0f%%), first container ready %v (%.0f%%)",
+				total, run, phaseShare(run, total), network, phaseShare(network, total), container, phaseShare(container, total))
+		}, defaultOperationTimes)
+	})
+})
+
+// phaseShare returns the percentage of total spent in phase.
+func phaseShare(phase, total time.Duration) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * phase.Seconds() / total.Seconds()
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmark
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	// interferenceOperationTimes is the number of samples taken for the
+	// image pull interference benchmark.
+	interferenceOperationTimes int = 3
+
+	// interferenceLifecycles is the number of container lifecycles timed
+	// with and without image pulls in each sample.
+	interferenceLifecycles = 10
+)
+
+// interferencePullImages are the images pulled and removed in a loop while
+// the container lifecycles are timed. They must not include the image of
+// the containers. The images present before the benchmark are pulled but
+// kept.
+var interferencePullImages = []string{
+	"nginx",
+	"busybox:1-glibc",
+	"busybox:1-musl",
+}
+
+var _ = framework.KubeDescribe("Container", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	var rc internalapi.RuntimeService
+	var ic internalapi.ImageManagerService
+
+	BeforeEach(func() {
+		rc = f.CRIClient.CRIRuntimeClient
+		ic = f.CRIClient.CRIImageClient
+	})
+
+	Context("benchmark about container lifecycle under image pulls", func() {
+		var podID string
+		var podConfig *runtimeapi.PodSandboxConfig
+		// present are the interferencePullImages present before the
+		// benchmark, which are not removed.
+		var present map[string]bool
+
+		BeforeEach(func() {
+			framework.PullImageIfNotPresent(ic, framework.DefaultContainerImage)
+			present = make(map[string]bool)
+			for _, image := range interferencePullImages {
+				present[image] = framework.ImageStatus(ic, image) != nil
+			}
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+			for _, image := range interferencePullImages {
+				if !present[image] {
+					ic.RemoveImage(&runtimeapi.ImageSpec{Image: framework.ResolveImage(image)})
+				}
+			}
+		})
+
+		Measure("benchmark about the container lifecycle latency under sustained image pulls", func(b Benchmarker) {
+			By("time the container lifecycles without image pulls")
+			idleCreate, idleStart := timeLifecycles(b, rc, ic, podID, podConfig, "without image pulls")
+
+			By("time the container lifecycles during image pulls")
+			stop := make(chan struct{})
+			var pulls, pullErrors int
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				pulls, pullErrors = pullImagesUntil(ic, present, stop)
+			}()
+			pullCreate, pullStart := timeLifecycles(b, rc, ic, podID, podConfig, "during image pulls")
+			close(stop)
+			wg.Wait()
+
+			b.RecordValue("image pulls during the container lifecycles", float64(pulls))
+			Expect(pulls).NotTo(BeZero(), "at least an image should be pulled during the container lifecycles")
+			if pullErrors > 0 {
+				framework.Logf("%d of %d image pulls failed", pullErrors, pulls+pullErrors)
+			}
+			createSlowdown := slowdown(idleCreate, pullCreate)
+			startSlowdown := slowdown(idleStart, pullStart)
+			b.RecordValue("create Container slowdown during image pulls (%)", createSlowdown)
+			b.RecordValue("start Container slowdown during image pulls (%)", startSlowdown)
+			framework.Logf("Image pulls slowed down create Container from %v to %v (%+.0f%%) and start Container from %v to %v (%+.0f%%)",
+				idleCreate, pullCreate, createSlowdown, idleStart, pullStart, startSlowdown)
+		}, interferenceOperationTimes)
+	})
+})
+
+// timeLifecycles creates, starts, stops and removes interferenceLifecycles
+// containers in the pod, timing their creation and start as operations
+// suffixed with condition, and returns the mean create and start latencies.
+func timeLifecycles(b Benchmarker, rc internalapi.RuntimeService, ic internalapi.ImageManagerService, podID string, podConfig *runtimeapi.PodSandboxConfig, condition string) (time.Duration, time.Duration) {
+	var create, start time.Duration
+	for i := 0; i < interferenceLifecycles; i++ {
+		var containerID string
+		var err error
+		create += b.Time("create Container "+condition, func() {
+			containerID = framework.CreateDefaultContainer(rc, ic, podID, podConfig, "Container-for-pull-interference-benchmark-")
+		})
+		start += b.Time("start Container "+condition, func() {
+			err = rc.StartContainer(containerID)
+		})
+		framework.ExpectNoError(err, "failed to start Container: %v", err)
+		framework.ExpectNoError(rc.StopContainer(containerID, framework.DefaultStopContainerTimeout), "failed to stop Container")
+		framework.ExpectNoError(rc.RemoveContainer(containerID), "failed to remove Container")
+	}
+	return create / interferenceLifecycles, start / interferenceLifecycles
+}
+
+// pullImagesUntil pulls and removes interferencePullImages in a loop until
+// stop is closed, so that each pull downloads the image, and returns the
+// number of successful and of failed pulls. The present images are kept.
+func pullImagesUntil(ic internalapi.ImageManagerService, present map[string]bool, stop <-chan struct{}) (int, int) {
+	var pulls, errors int
+	for {
+		for _, image := range interferencePullImages {
+			select {
+			case <-stop:
+				return pulls, errors
+			default:
+			}
+			imageSpec := &runtimeapi.ImageSpec{Image: framework.ResolveImage(image)}
+			if _, err := ic.PullImage(imageSpec, nil); err != nil {
+				if errors == 0 {
+					framework.Logf("Failed to pull image %q: %v", imageSpec.Image, err)
+				}
+				errors++
+				continue
+			}
+			pulls++
+			if !present[image] {
+				ic.RemoveImage(imageSpec)
+			}
+		}
+	}
+}
+
+// slowdown returns the increase of the latency from idle to loaded, as a
+// percentage.
+func slowdown(idle, loaded time.Duration) float64 {
+	if idle == 0 {
+		return 0
+	}
+	return 100 * (loaded.Seconds() - idle.Seconds()) / idle.Seconds()
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package benchmark
+
+import (
+	"fmt"
+	"math/rand"
+	goruntime "runtime"
+	"strings"
+	"time"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// goroutinesSettleTimeout is the time given to the goroutines of the soak
+// operations to exit before checking for leaked goroutines.
+const goroutinesSettleTimeout = 10 * time.Second
+
+var _ = framework.KubeDescribe("Soak", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	var rc internalapi.RuntimeService
+	var ic internalapi.ImageManagerService
+
+	BeforeEach(func() {
+		if !framework.TestContext.Soak {
+			Skip("soak mode is not enabled, use -soak")
+		}
+		rc = f.CRIClient.CRIRuntimeClient
+		ic = f.CRIClient.CRIImageClient
+	})
+
+	It("should sustain a weighted mix of operations [Soak]", func() {
+		weights, err := framework.ParseSoakWeights(framework.TestContext.SoakWeights)
+		framework.ExpectNoError(err, "invalid soak weights")
+		if weights["stats"] > 0 && !framework.IsCapable(rc, framework.CapabilityStats) {
+			framework.Logf("Runtime doesn't support stats, not running the stats operation")
+			delete(weights, "stats")
+		}
+
+		containersBefore := countContainers(rc)
+		mountsBefore, mountsErr := framework.CountMounts()
+		goroutinesBefore := goruntime.NumGoroutine()
+
+		By("create the container used by the exec and stats operations")
+		podID, podConfig := framework.CreatePodSandboxForContainer(rc)
+		containerID := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "soak-container-")
+		framework.ExpectNoError(rc.StartContainer(containerID), "failed to start container")
+		image := framework.ResolveImage(framework.DefaultContainerImage)
+
+		operations := map[string]func() error{
+			"lifecycle": func() error { return soakLifecycle(rc, image) },
+			"exec": func() error {
+				_, _, err := rc.ExecSync(containerID, []string{"echo", "soak"}, framework.TestContext.ExecTimeout)
+				return err
+			},
+			"image": func() error {
+				if _, err := ic.ImageStatus(&runtimeapi.ImageSpec{Image: image}); err != nil {
+					return err
+				}
+				_, err := ic.ListImages(nil)
+				return err
+			},
+			"stats": func() error {
+				if _, err := rc.ContainerStats(containerID); err != nil {
+					return err
+				}
+				_, err := rc.ListContainerStats(nil)
+				return err
+			},
+		}
+
+		By(fmt.Sprintf("run the operations for %v", framework.TestContext.SoakDuration))
+		start := time.Now()
+		stats := framework.NewSoakStats(start, framework.TestContext.SoakReportInterval)
+		r := rand.New(rand.NewSource(start.UnixNano()))
+		nextReport := start.Add(framework.TestContext.SoakReportInterval)
+		for time.Since(start) < framework.TestContext.SoakDuration {
+			operation := framework.PickSoakOperation(weights, r)
+			begin := time.Now()
+			err := operations[operation]()
+			end := time.Now()
+			if err != nil {
+				framework.Logf("Soak operation %s failed: %v", operation, err)
+			}
+			stats.Record(operation, end, end.Sub(begin), err)
+			if end.After(nextReport) {
+				framework.Logf("Soak progress after %v:\n%s", end.Sub(start).Round(time.Second), strings.Join(stats.Summary(), "\n"))
+				nextReport = nextReport.Add(framework.TestContext.SoakReportInterval)
+			}
+		}
+		framework.Logf("Soak results after %v:\n%s", time.Since(start).Round(time.Second), strings.Join(stats.Summary(), "\n"))
+
+		By("remove the container used by the exec and stats operations")
+		framework.ExpectNoError(rc.StopPodSandbox(podID), "failed to stop PodSandbox")
+		framework.ExpectNoError(rc.RemovePodSandbox(podID), "failed to remove PodSandbox")
+
+		By("check the error rates, latency drifts and leaks")
+		failures := stats.Check(framework.TestContext.SoakMaxErrorRate, framework.TestContext.SoakMaxLatencyDrift)
+		if leaked := countContainers(rc) - containersBefore; leaked > framework.TestContext.SoakMaxLeakedContainers {
+			failures = append(failures, fmt.Sprintf("%d containers and pod sandboxes leaked, more than %d", leaked, framework.TestContext.SoakMaxLeakedContainers))
+		}
+		if mountsErr != nil {
+			framework.Logf("Not checking leaked mounts: %v", mountsErr)
+		} else {
+			mountsAfter, err := framework.CountMounts()
+			framework.ExpectNoError(err, "failed to count mounts")
+			if leaked := mountsAfter - mountsBefore; leaked > framework.TestContext.SoakMaxLeakedMounts {
+				failures = append(failures, fmt.Sprintf("%d mounts leaked, more than %d", leaked, framework.TestContext.SoakMaxLeakedMounts))
+			}
+		}
+		if leaked := leakedGoroutines(goroutinesBefore); leaked > framework.TestContext.SoakMaxLeakedGoroutines {
+			failures = append(failures, fmt.Sprintf("%d goroutines leaked, more than %d", leaked, framework.TestContext.SoakMaxLeakedGoroutines))
+		}
+		Expect(failures).To(BeEmpty(), "soak test failed")
+	})
+})
+
+// soakLifecycle runs a pod sandbox and a container, then stops and removes
+// them. The resources are removed even if a step fails.
+func soakLifecycle(rc internalapi.RuntimeService, image string) (err error) {
+	podConfig := &runtimeapi.PodSandboxConfig{
+		Metadata: framework.BuildPodSandboxMetadata("soak-pod-"+framework.NewUUID(), framework.DefaultUIDPrefix+framework.NewUUID(),
+			framework.DefaultNamespacePrefix+framework.NewUUID(), framework.DefaultAttempt),
+		Linux: &runtimeapi.LinuxPodSandboxConfig{},
+	}
+	podID, err := rc.RunPodSandbox(podConfig)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if stopErr := rc.StopPodSandbox(podID); stopErr != nil && err == nil {
+			err = stopErr
+		}
+		if removeErr := rc.RemovePodSandbox(podID); removeErr != nil && err == nil {
+			err = removeErr
+		}
+	}()
+
+	containerConfig := &runtimeapi.ContainerConfig{
+		Metadata: framework.BuildContainerMetadata("soak-lifecycle-"+framework.NewUUID(), framework.DefaultAttempt),
+		Image:    &runtimeapi.ImageSpec{Image: image},
+		Command:  []string{"top"},
+		Linux:    &runtimeapi.LinuxContainerConfig{},
+	}
+	containerID, err := rc.CreateContainer(podID, containerConfig, podConfig)
+	if err != nil {
+		return err
+	}
+	if err := rc.StartContainer(containerID); err != nil {
+		return err
+	}
+	if err := rc.StopContainer(containerID, framework.DefaultStopContainerTimeout); err != nil {
+		return err
+	}
+	return rc.RemoveContainer(containerID)
+}
+
+// countContainers returns the number of containers and pod sandboxes on the runtime.
+func countContainers(rc internalapi.RuntimeService) int {
+	containers, err := rc.ListContainers(nil)
+	framework.ExpectNoError(err, "failed to list containers")
+	pods, err := rc.ListPodSandbox(nil)
+	framework.ExpectNoError(err, "failed to list PodSandboxes")
+	return len(containers) + len(pods)
+}
+
+// leakedGoroutines returns the number of goroutines started since there
+// were before, giving them some time to exit.
+func leakedGoroutines(before int) int {
+	deadline := time.Now().Add(goroutinesSettleTimeout)
+	for {
+		leaked := goruntime.NumGoroutine() - before
+		if leaked <= framework.TestContext.SoakMaxLeakedGoroutines || time.Now().After(deadline) {
+			return leaked
+		}
+		time.Sleep(time.Second)
+	}
+}
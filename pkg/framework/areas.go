@@ -0,0 +1,78 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AreaBenchmark is the area of the benchmark specs.
+const AreaBenchmark = "benchmark"
+
+// areas maps the areas accepted by --focus-area and --skip-area to a
+// regular expression matching the full text of their specs.
+var areas = map[string]string{
+	"container":   `\[k8s\.io\] Container `,
+	"pod":         `\[k8s\.io\] (PodSandbox|Networking) `,
+	"image":       `\[k8s\.io\] Image Manager `,
+	"volume":      `\[k8s\.io\] Container (Mount Propagation|runtime should support adding volume)`,
+	"streaming":   `\[k8s\.io\] Streaming `,
+	"security":    `\[k8s\.io\] (Security Context|AppArmor|SELinux) `,
+	"extension":   `\[k8s\.io\] \[Extension\] `,
+	AreaBenchmark: `benchmark`,
+}
+
+// Areas returns the names of the known areas.
+func Areas() []string {
+	var names []string
+	for name := range areas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseAreas parses a comma separated list of areas.
+func ParseAreas(list string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := areas[name]; !ok {
+			return nil, fmt.Errorf("unknown area %q, should be one of %s", name, strings.Join(Areas(), ", "))
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// AreasRegexp returns a regular expression matching the specs of any of the
+// areas, or an empty string if no area is given.
+func AreasRegexp(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var regexps []string
+	for _, name := range names {
+		regexps = append(regexps, areas[name])
+	}
+	return "(" + strings.Join(regexps, "|") + ")"
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestAreasRegexp(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		areas       string
+		spec        string
+		expectMatch bool
+		expectError bool
+	}{
+		{"container area should match container specs", "container", "[k8s.io] Container runtime should support basic operations on container", true, false},
+		{"container area should not match pod specs", "container", "[k8s.io] PodSandbox runtime should support basic operations on PodSandbox", false, false},
+		{"volume area should match volume specs", "volume", "[k8s.io] Container runtime should support adding volume and device", true, false},
+		{"volume area should match mount propagation specs", "volume", "[k8s.io] Container Mount Propagation runtime should support mount propagation", true, false},
+		{"security area should match selinux specs", "security", "[k8s.io] SELinux runtime should support selinux", true, false},
+		{"areas should be combined", "image, Streaming", "[k8s.io] Streaming runtime should support streaming interfaces", true, false},
+		{"extension area should match extension suites", "extension", "[k8s.io] [Extension] pouch runtime should support rich containers", true, false},
+		{"container area should not match extension suites", "container", "[k8s.io] [Extension] Container runtime should support rich containers", false, false},
+		{"benchmark area should match benchmarks", "benchmark", "[k8s.io] PodSandbox benchmark about operations on PodSandbox", true, false},
+		{"unknown area should fail", "network", "", false, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			names, err := ParseAreas(tc.areas)
+			if (err != nil) != tc.expectError {
+				t.Fatalf("expected error: %v; actual error is %v", tc.expectError, err)
+			}
+			if err != nil {
+				return
+			}
+			expr := AreasRegexp(names)
+			if match := regexp.MustCompile(expr).MatchString(tc.spec); match != tc.expectMatch {
+				t.Errorf("expected %q to match %q: %v", expr, tc.spec, tc.expectMatch)
+			}
+		})
+	}
+}
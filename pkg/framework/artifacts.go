@@ -0,0 +1,264 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/kubernetes-sigs/cri-tools/pkg/output"
+	"golang.org/x/net/context"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	. "github.com/onsi/ginkgo"
+)
+
+const (
+	// artifactsTimeout is the timeout of the calls collecting the failure
+	// artifacts, short as the runtime may be the cause of the failure.
+	artifactsTimeout = 10 * time.Second
+	// specStartEnv is the environment variable giving the start time of the
+	// failed spec to the runtime log command.
+	specStartEnv = "CRITEST_SPEC_START"
+	// specStartFormat is the format of specStartEnv, understood by
+	// journalctl --since.
+	specStartFormat = "2006-01-02 15:04:05"
+	// maxSpecFileName is the maximum length of the file names derived from
+	// the spec names.
+	maxSpecFileName = 200
+)
+
+var (
+	artifactsMu sync.Mutex
+	// specStart is the start time of the current spec, set by the
+	// framework.
+	specStart time.Time
+	// collectedSpec is the spec whose artifacts were collected, as only the
+	// first failure of a spec is collected.
+	collectedSpec string
+)
+
+// unsafePathChars are the characters replaced in the file names derived
+// from the spec names.
+var unsafePathChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// markSpecStart records the start of the current spec.
+func markSpecStart() {
+	artifactsMu.Lock()
+	defer artifactsMu.Unlock()
+	specStart = time.Now()
+}
+
+// FailWithArtifacts collects the failure artifacts of the current spec in
+// TestContext.ArtifactsDir, if set, then fails it with ginkgo.Fail. It is
+// meant to be registered as the gomega fail handler. The failures of specs
+// checking a behavior tolerated by the runtime profile are recorded as
+// deviations, and the specs skipped.
+func FailWithArtifacts(message string, callerSkip ...int) {
+	skip := 1
+	if len(callerSkip) > 0 {
+		skip += callerSkip[0]
+	}
+	spec := CurrentGinkgoTestDescription().FullTestText
+	if d, ok := recordDeviation(spec, message); ok {
+		Skip(fmt.Sprintf("runtime profile %s tolerates %s: %s", d.Profile, d.Behavior, message), skip)
+	}
+	if TestContext.ArtifactsDir != "" {
+		collectFailureArtifacts(spec, message)
+	}
+	Fail(message, skip)
+}
+
+// collectFailureArtifacts writes the failure message, the CRI calls made
+// since the spec started, the verbose status of the pods and containers of
+// the tests and the runtime log in the artifacts directory of the spec.
+func collectFailureArtifacts(spec, message string) {
+	artifactsMu.Lock()
+	if collectedSpec == spec {
+		artifactsMu.Unlock()
+		return
+	}
+	collectedSpec = spec
+	start := specStart
+	artifactsMu.Unlock()
+
+	dir := filepath.Join(TestContext.ArtifactsDir, specFileName(spec))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		Logf("Failed to create the artifacts directory: %v", err)
+		return
+	}
+	Logf("Collecting the failure artifacts in %s", dir)
+	writeArtifact(dir, "failure.txt", []byte(message+"\n"), nil)
+	data, err := json.MarshalIndent(recentCalls.since(start), "", "  ")
+	writeArtifact(dir, "calls.json", data, err)
+	data, podIDs, err := dumpPodSandboxes()
+	writeArtifact(dir, "pods.json", data, err)
+	data, err = dumpContainers(podIDs)
+	writeArtifact(dir, "containers.json", data, err)
+	if TestContext.RuntimeLogCommand != "" {
+		data, err = runtimeLog(TestContext.RuntimeLogCommand, start)
+		writeArtifact(dir, "runtime.log", data, err)
+	}
+}
+
+// specFileName returns the name of the artifacts directory and of the trace
+// file of spec.
+func specFileName(spec string) string {
+	name := unsafePathChars.ReplaceAllString(spec, "_")
+	if len(name) > maxSpecFileName {
+		name = name[:maxSpecFileName]
+	}
+	return name
+}
+
+// writeArtifact writes the artifact data, or the error collecting it, which
+// is also logged.
+func writeArtifact(dir, name string, data []byte, err error) {
+	if err != nil {
+		Logf("Failed to collect the %s artifact: %v", name, err)
+		data = append(data, []byte(fmt.Sprintf("\nfailed to collect the artifact: %v\n", err))...)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+		Logf("Failed to write the %s artifact: %v", name, err)
+	}
+}
+
+// dumpPodSandboxes returns the verbose status of the pods created by the
+// tests as JSON, and their IDs.
+func dumpPodSandboxes() ([]byte, map[string]bool, error) {
+	client, err := artifactsRuntimeClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), artifactsTimeout)
+	defer cancel()
+	pods, err := client.ListPodSandbox(ctx, &runtimeapi.ListPodSandboxRequest{})
+	if err != nil {
+		return nil, nil, err
+	}
+	podIDs := make(map[string]bool)
+	var statuses []proto.Message
+	for _, pod := range pods.Items {
+		if !isTestPodSandbox(pod.Metadata) {
+			continue
+		}
+		podIDs[pod.Id] = true
+		status, err := client.PodSandboxStatus(ctx, &runtimeapi.PodSandboxStatusRequest{PodSandboxId: pod.Id, Verbose: true})
+		if err != nil {
+			return nil, podIDs, err
+		}
+		statuses = append(statuses, status)
+	}
+	data, err := marshalArtifacts(statuses)
+	return data, podIDs, err
+}
+
+// dumpContainers returns the verbose status of the containers of the pods
+// podIDs as JSON.
+func dumpContainers(podIDs map[string]bool) ([]byte, error) {
+	client, err := artifactsRuntimeClient()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), artifactsTimeout)
+	defer cancel()
+	containers, err := client.ListContainers(ctx, &runtimeapi.ListContainersRequest{})
+	if err != nil {
+		return nil, err
+	}
+	var statuses []proto.Message
+	for _, container := range containers.Containers {
+		if !podIDs[container.PodSandboxId] {
+			continue
+		}
+		status, err := client.ContainerStatus(ctx, &runtimeapi.ContainerStatusRequest{ContainerId: container.Id, Verbose: true})
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return marshalArtifacts(statuses)
+}
+
+// artifactsRuntimeClient returns a runtime client using the shared
+// connection, for the verbose statuses internalapi doesn't request.
+func artifactsRuntimeClient() (runtimeapi.RuntimeServiceClient, error) {
+	conn, err := getConnections().Dial(TestContext.RuntimeServiceAddr)
+	if err != nil {
+		return nil, err
+	}
+	return runtimeapi.NewRuntimeServiceClient(conn), nil
+}
+
+// marshalArtifacts returns the messages as a JSON array.
+func marshalArtifacts(messages []proto.Message) ([]byte, error) {
+	var items []json.RawMessage
+	for _, m := range messages {
+		data, err := output.ProtobufToJSON(m)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, json.RawMessage(data))
+	}
+	if items == nil {
+		items = []json.RawMessage{}
+	}
+	return json.MarshalIndent(items, "", "  ")
+}
+
+// runtimeLogTimeout bounds the runtime log command, which may follow the log
+// or hang.
+var runtimeLogTimeout = 30 * time.Second
+
+// runtimeLog runs the runtime log command in a shell, with the start of the
+// spec in specStartEnv. The command is killed after runtimeLogTimeout, and its
+// output so far is returned with the timeout error.
+func runtimeLog(command string, start time.Time) ([]byte, error) {
+	// The output goes to a file rather than a pipe, so that waiting for the
+	// shell doesn't wait for the processes it started which still write to
+	// it, e.g. a log follower.
+	out, err := ioutil.TempFile("", "critest-runtime-log")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), runtimeLogTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", specStartEnv, start.Format(specStartFormat)))
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("runtime log command timed out after %v", runtimeLogTimeout)
+	}
+	data, readErr := ioutil.ReadFile(out.Name())
+	if readErr != nil && err == nil {
+		err = readErr
+	}
+	return data, err
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCallLog(t *testing.T) {
+	l := newCallLog(3)
+	start := time.Now()
+	for i, method := range []string{"Version", "Status", "RunPodSandbox", "CreateContainer", "StartContainer"} {
+		l.add(CallRecord{Time: start.Add(time.Duration(i) * time.Second), Method: method})
+	}
+
+	var methods []string
+	for _, r := range l.since(start.Add(3 * time.Second)) {
+		methods = append(methods, r.Method)
+	}
+	if strings.Join(methods, ",") != "CreateContainer,StartContainer" {
+		t.Errorf("expected the calls since the 4th one, got %v", methods)
+	}
+	if records := l.since(time.Time{}); len(records) != 3 || records[0].Method != "RunPodSandbox" {
+		t.Errorf("expected the last 3 calls in order, got %+v", records)
+	}
+}
+
+func TestArtifactsDirName(t *testing.T) {
+	name := specFileName("[k8s.io] Container runtime should support basic operations on container [Conformance]")
+	if name != "_k8s.io_Container_runtime_should_support_basic_operations_on_container_Conformance_" {
+		t.Errorf("unexpected artifacts directory name %q", name)
+	}
+	if name := specFileName(strings.Repeat("a", 300)); len(name) != maxSpecFileName {
+		t.Errorf("expected the name to be truncated to %d characters, got %d", maxSpecFileName, len(name))
+	}
+}
+
+func TestRuntimeLog(t *testing.T) {
+	start := time.Date(2018, 10, 1, 12, 30, 0, 0, time.Local)
+	out, err := runtimeLog("echo since $"+specStartEnv, start)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != "since 2018-10-01 12:30:00\n" {
+		t.Errorf("unexpected runtime log %q", out)
+	}
+}
+
+func TestRuntimeLogTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { runtimeLogTimeout = timeout }(runtimeLogTimeout)
+	runtimeLogTimeout = 100 * time.Millisecond
+
+	begin := time.Now()
+	out, err := runtimeLog("echo partial; sleep 60", time.Now())
+	if err == nil {
+		t.Errorf("expected a timeout error")
+	}
+	if string(out) != "partial\n" {
+		t.Errorf("expected the partial runtime log; actual log is %q", out)
+	}
+	if elapsed := time.Since(begin); elapsed > 10*time.Second {
+		t.Errorf("expected the command to be killed; actual time is %v", elapsed)
+	}
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+)
+
+// BenchmarkResult are the statistics of a measurement of a benchmark spec.
+type BenchmarkResult struct {
+	// Spec is the full text of the benchmark spec.
+	Spec string `json:"spec"`
+	// Operation is the name of the measurement.
+	Operation string `json:"operation"`
+	// Unit is "s" for timed operations, and empty for recorded values.
+	Unit  string  `json:"unit"`
+	Count int     `json:"count"`
+	Mean  float64 `json:"mean"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P99   float64 `json:"p99"`
+}
+
+// isLatency returns whether the result is a latency, which regresses when it
+// increases.
+func (r BenchmarkResult) isLatency() bool {
+	return r.Unit == "s" || strings.Contains(strings.ToLower(r.Operation), "latency")
+}
+
+// percentile returns the nearest rank percentile p of the sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// newBenchmarkResult computes the statistics of a measurement.
+func newBenchmarkResult(spec string, m *types.SpecMeasurement) BenchmarkResult {
+	sorted := append([]float64(nil), m.Results...)
+	sort.Float64s(sorted)
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+	result := BenchmarkResult{
+		Spec:      spec,
+		Operation: m.Name,
+		Unit:      m.Units,
+		Count:     len(sorted),
+		P50:       percentile(sorted, 50),
+		P90:       percentile(sorted, 90),
+		P99:       percentile(sorted, 99),
+	}
+	if len(sorted) > 0 {
+		result.Mean = sum / float64(len(sorted))
+	}
+	return result
+}
+
+// BenchmarkReporter is a ginkgo reporter collecting the measurements of the
+// passed benchmark specs.
+type BenchmarkReporter struct {
+	results []BenchmarkResult
+}
+
+// NewBenchmarkReporter creates a BenchmarkReporter.
+func NewBenchmarkReporter() *BenchmarkReporter {
+	return &BenchmarkReporter{results: []BenchmarkResult{}}
+}
+
+// Results returns the collected results sorted by spec and operation.
+func (r *BenchmarkReporter) Results() []BenchmarkResult {
+	sort.Slice(r.results, func(i, j int) bool {
+		if r.results[i].Spec != r.results[j].Spec {
+			return r.results[i].Spec < r.results[j].Spec
+		}
+		return r.results[i].Operation < r.results[j].Operation
+	})
+	return r.results
+}
+
+// SpecDidComplete collects the measurements of the passed specs.
+func (r *BenchmarkReporter) SpecDidComplete(summary *types.SpecSummary) {
+	if summary.State != types.SpecStatePassed || !summary.IsMeasurement {
+		return
+	}
+	// The first component is the top level container.
+	spec := strings.Join(summary.ComponentTexts[1:], " ")
+	for _, m := range summary.Measurements {
+		r.results = append(r.results, newBenchmarkResult(spec, m))
+	}
+}
+
+// SpecSuiteWillBegin implements ginkgo.Reporter.
+func (r *BenchmarkReporter) SpecSuiteWillBegin(config.GinkgoConfigType, *types.SuiteSummary) {}
+
+// BeforeSuiteDidRun implements ginkgo.Reporter.
+func (r *BenchmarkReporter) BeforeSuiteDidRun(*types.SetupSummary) {}
+
+// SpecWillRun implements ginkgo.Reporter.
+func (r *BenchmarkReporter) SpecWillRun(*types.SpecSummary) {}
+
+// AfterSuiteDidRun implements ginkgo.Reporter.
+func (r *BenchmarkReporter) AfterSuiteDidRun(*types.SetupSummary) {}
+
+// SpecSuiteDidEnd implements ginkgo.Reporter.
+func (r *BenchmarkReporter) SpecSuiteDidEnd(*types.SuiteSummary) {}
+
+// WriteBenchmarkResults writes the results as JSON to path.
+func WriteBenchmarkResults(path string, results []BenchmarkResult) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// LoadBenchmarkResults loads the results written by WriteBenchmarkResults.
+func LoadBenchmarkResults(path string) ([]BenchmarkResult, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var results []BenchmarkResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse benchmark results %q: %v", path, err)
+	}
+	return results, nil
+}
+
+// ParseRegression parses a maximum regression given as a percentage, e.g.
+// "10%" or "10", into a ratio.
+func ParseRegression(s string) (float64, error) {
+	value, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid regression %q, should be a positive percentage such as 10%%", s)
+	}
+	return value / 100, nil
+}
+
+// BenchmarkRegression is a percentile of a latency which increased by more
+// than the maximum regression.
+type BenchmarkRegression struct {
+	Spec       string
+	Operation  string
+	Percentile string
+	Baseline   float64
+	Current    float64
+}
+
+// String describes the regression.
+func (r BenchmarkRegression) String() string {
+	return fmt.Sprintf("%s: %s %s regressed by %.1f%% (%g -> %g)", r.Spec, r.Operation, r.Percentile, (r.Current/r.Baseline-1)*100, r.Baseline, r.Current)
+}
+
+// CompareBenchmarkResults returns the percentiles of the latencies of current
+// which exceed the ones of baseline by more than the maxRegression ratio.
+// Operations missing from either results are ignored.
+func CompareBenchmarkResults(baseline, current []BenchmarkResult, maxRegression float64) []BenchmarkRegression {
+	type key struct{ spec, operation string }
+	base := make(map[key]BenchmarkResult)
+	for _, r := range baseline {
+		base[key{r.Spec, r.Operation}] = r
+	}
+
+	var regressions []BenchmarkRegression
+	for _, r := range current {
+		b, ok := base[key{r.Spec, r.Operation}]
+		if !ok || !r.isLatency() {
+			continue
+		}
+		for _, p := range []struct {
+			name              string
+			baseline, current float64
+		}{
+			{"p50", b.P50, r.P50},
+			{"p90", b.P90, r.P90},
+			{"p99", b.P99, r.P99},
+		} {
+			if p.baseline > 0 && p.current > p.baseline*(1+maxRegression) {
+				regressions = append(regressions, BenchmarkRegression{
+					Spec:       r.Spec,
+					Operation:  r.Operation,
+					Percentile: p.name,
+					Baseline:   p.baseline,
+					Current:    p.current,
+				})
+			}
+		}
+	}
+	return regressions
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/onsi/ginkgo/types"
+)
+
+func TestBenchmarkReporter(t *testing.T) {
+	reporter := NewBenchmarkReporter()
+	var results []float64
+	for i := 100; i > 0; i-- {
+		results = append(results, float64(i))
+	}
+	for _, summary := range []*types.SpecSummary{
+		{
+			ComponentTexts: []string{"[Top Level]", "[k8s.io] Pod", "benchmark"},
+			State:          types.SpecStatePassed,
+			IsMeasurement:  true,
+			Measurements: map[string]*types.SpecMeasurement{
+				"create": {Name: "create", Units: "s", Results: results},
+			},
+		},
+		{
+			ComponentTexts: []string{"[Top Level]", "[k8s.io] Pod", "failed benchmark"},
+			State:          types.SpecStateFailed,
+			IsMeasurement:  true,
+			Measurements: map[string]*types.SpecMeasurement{
+				"create": {Name: "create", Units: "s", Results: results},
+			},
+		},
+	} {
+		reporter.SpecDidComplete(summary)
+	}
+
+	expected := []BenchmarkResult{
+		{Spec: "[k8s.io] Pod benchmark", Operation: "create", Unit: "s", Count: 100, Mean: 50.5, P50: 50, P90: 90, P99: 99},
+	}
+	if results := reporter.Results(); !reflect.DeepEqual(results, expected) {
+		t.Errorf("expected results %+v, got %+v", expected, results)
+	}
+}
+
+func TestBenchmarkResultsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benchmark-results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "results.json")
+	results := []BenchmarkResult{{Spec: "spec", Operation: "create", Unit: "s", Count: 1, Mean: 1, P50: 1, P90: 1, P99: 1}}
+	if err := WriteBenchmarkResults(path, results); err != nil {
+		t.Fatalf("failed to write results: %v", err)
+	}
+	loaded, err := LoadBenchmarkResults(path)
+	if err != nil {
+		t.Fatalf("failed to load results: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, results) {
+		t.Errorf("expected results %+v, got %+v", results, loaded)
+	}
+}
+
+func TestCompareBenchmarkResults(t *testing.T) {
+	baseline := []BenchmarkResult{
+		{Spec: "spec", Operation: "create", Unit: "s", P50: 1, P90: 2, P99: 4},
+		{Spec: "spec", Operation: "ExecSync latency (ms/exec)", P50: 10, P90: 10, P99: 10},
+		{Spec: "spec", Operation: "throughput (MB/s)", P50: 100, P90: 100, P99: 100},
+		{Spec: "spec", Operation: "removed", Unit: "s", P50: 1, P90: 1, P99: 1},
+	}
+	current := []BenchmarkResult{
+		{Spec: "spec", Operation: "create", Unit: "s", P50: 1.05, P90: 2.5, P99: 4},
+		{Spec: "spec", Operation: "ExecSync latency (ms/exec)", P50: 12, P90: 10, P99: 10},
+		{Spec: "spec", Operation: "throughput (MB/s)", P50: 1000, P90: 1000, P99: 1000},
+		{Spec: "spec", Operation: "added", Unit: "s", P50: 1, P90: 1, P99: 1},
+	}
+	expected := []BenchmarkRegression{
+		{Spec: "spec", Operation: "create", Percentile: "p90", Baseline: 2, Current: 2.5},
+		{Spec: "spec", Operation: "ExecSync latency (ms/exec)", Percentile: "p50", Baseline: 10, Current: 12},
+	}
+	if regressions := CompareBenchmarkResults(baseline, current, 0.1); !reflect.DeepEqual(regressions, expected) {
+		t.Errorf("expected regressions %+v, got %+v", expected, regressions)
+	}
+}
+
+func TestParseRegression(t *testing.T) {
+	for s, expected := range map[string]float64{"10%": 0.1, "25": 0.25, " 0% ": 0} {
+		if regression, err := ParseRegression(s); err != nil || regression != expected {
+			t.Errorf("expected %q to be parsed as %v, got %v: %v", s, expected, regression, err)
+		}
+	}
+	for _, s := range []string{"", "ten", "-5%"} {
+		if _, err := ParseRegression(s); err == nil {
+			t.Errorf("expected %q to be invalid", s)
+		}
+	}
+}
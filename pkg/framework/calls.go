@@ -0,0 +1,100 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"path"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// recentCallsSize is the number of CRI calls kept in recentCalls.
+const recentCallsSize = 500
+
+// CallRecord is a CRI call.
+type CallRecord struct {
+	Time     time.Time     `json:"time"`
+	Method   string        `json:"method"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// callLog keeps the last CRI calls in a ring buffer.
+type callLog struct {
+	mu      sync.Mutex
+	records []CallRecord
+	// next is the index of the next record in the full buffer.
+	next int
+}
+
+// newCallLog creates a callLog keeping size calls.
+func newCallLog(size int) *callLog {
+	return &callLog{records: make([]CallRecord, 0, size)}
+}
+
+// add records a call, replacing the oldest one if the log is full.
+func (l *callLog) add(record CallRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.records) < cap(l.records) {
+		l.records = append(l.records, record)
+		return
+	}
+	l.records[l.next] = record
+	l.next = (l.next + 1) % len(l.records)
+}
+
+// since returns the calls started after start, in order.
+func (l *callLog) since(start time.Time) []CallRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	records := []CallRecord{}
+	for i := range l.records {
+		r := l.records[(l.next+i)%len(l.records)]
+		if !r.Time.Before(start) {
+			records = append(records, r)
+		}
+	}
+	return records
+}
+
+// recentCalls are the last CRI calls of the test process, collected in the
+// failure artifacts.
+var recentCalls = newCallLog(recentCallsSize)
+
+// recordCall is a gRPC interceptor recording the calls in recentCalls, and
+// in the trace of the current spec.
+func recordCall(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	duration := time.Since(start)
+	traceCall(method, req, reply, start, duration, err)
+	record := CallRecord{
+		Time: start,
+		// Only keep the method name of /runtime.v1alpha2.RuntimeService/Version.
+		Method:   path.Base(method),
+		Duration: duration,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	recentCalls.add(record)
+	return err
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	. "github.com/onsi/ginkgo"
+)
+
+// Capability is an optional runtime feature which specs can depend on.
+type Capability string
+
+const (
+	// CapabilityStreaming is the support of the Exec, Attach and PortForward RPCs.
+	CapabilityStreaming Capability = "streaming"
+	// CapabilityStats is the support of the ContainerStats and ListContainerStats RPCs.
+	CapabilityStats Capability = "stats"
+)
+
+// preflightContainerID is a container ID which never exists, used to probe
+// whether an RPC is implemented without side effects.
+const preflightContainerID = "critest-preflight-nonexistent"
+
+// capabilityProbes probe whether the runtime supports a capability by trial
+// API calls. An RPC is considered supported unless it returns Unimplemented.
+var capabilityProbes = map[Capability]func(c internalapi.RuntimeService) error{
+	CapabilityStreaming: func(c internalapi.RuntimeService) error {
+		_, err := c.Exec(&runtimeapi.ExecRequest{
+			ContainerId: preflightContainerID,
+			Cmd:         []string{"true"},
+			Stdout:      true,
+		})
+		return err
+	},
+	CapabilityStats: func(c internalapi.RuntimeService) error {
+		_, err := c.ListContainerStats(&runtimeapi.ContainerStatsFilter{})
+		return err
+	},
+}
+
+var (
+	capabilitiesLock sync.Mutex
+	// capabilities caches the probed capabilities.
+	capabilities = make(map[Capability]bool)
+	// skippedSpecs counts the specs skipped for each unsupported capability.
+	skippedSpecs = make(map[Capability]int)
+)
+
+// SkipUnlessCapable skips the current spec if the runtime doesn't support
+// capability. Each capability is only probed once.
+func SkipUnlessCapable(c internalapi.RuntimeService, capability Capability) {
+	if isCapable(c, capability) {
+		return
+	}
+	capabilitiesLock.Lock()
+	skippedSpecs[capability]++
+	capabilitiesLock.Unlock()
+	Skip(fmt.Sprintf("runtime doesn't support %s", capability))
+}
+
+// IsCapable returns whether the runtime supports capability, for the specs
+// using it optionally. Each capability is only probed once.
+func IsCapable(c internalapi.RuntimeService, capability Capability) bool {
+	return isCapable(c, capability)
+}
+
+func isCapable(c internalapi.RuntimeService, capability Capability) bool {
+	capabilitiesLock.Lock()
+	defer capabilitiesLock.Unlock()
+	if supported, ok := capabilities[capability]; ok {
+		return supported
+	}
+	probe, ok := capabilityProbes[capability]
+	if !ok {
+		Failf("unknown capability %q", capability)
+	}
+	err := probe(c)
+	supported := !isUnimplemented(err)
+	Logf("Runtime capability %s supported: %v", capability, supported)
+	capabilities[capability] = supported
+	return supported
+}
+
+// isUnimplemented returns whether err is a gRPC Unimplemented error.
+func isUnimplemented(err error) bool {
+	if err == nil {
+		return false
+	}
+	s, ok := status.FromError(err)
+	return ok && s.Code() == codes.Unimplemented
+}
+
+// LogSkippedCapabilities logs a summary of the specs skipped because of
+// unsupported capabilities.
+func LogSkippedCapabilities() {
+	capabilitiesLock.Lock()
+	defer capabilitiesLock.Unlock()
+	var unsupported []string
+	for capability := range skippedSpecs {
+		unsupported = append(unsupported, string(capability))
+	}
+	sort.Strings(unsupported)
+	for _, capability := range unsupported {
+		Logf("Skipped %d spec(s) because the runtime doesn't support %s", skippedSpecs[Capability(capability)], capability)
+	}
+}
+
+// requiredCapabilities maps the describe texts of specs to the capabilities
+// they require, as reported by critest -list.
+var requiredCapabilities = make(map[string][]Capability)
+
+// RequireCapabilities records that the specs under the KubeDescribe text
+// require capabilities. text may go on with the texts of the nested
+// containers and specs, separated by spaces, to only select some of them. It
+// doesn't skip them: the specs still have to call SkipUnlessCapable.
+func RequireCapabilities(text string, capabilities ...Capability) bool {
+	key := "[k8s.io] " + text
+	requiredCapabilities[key] = append(requiredCapabilities[key], capabilities...)
+	return true
+}
+
+// specCapabilities returns the capabilities required by the spec with the
+// given full text.
+func specCapabilities(name string) []Capability {
+	result := []Capability{}
+	for text, capabilities := range requiredCapabilities {
+		if name == text || strings.HasPrefix(name, text+" ") {
+			result = append(result, capabilities...)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
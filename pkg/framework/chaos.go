@@ -0,0 +1,190 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+
+	. "github.com/onsi/gomega"
+)
+
+// Faults injected by the chaos specs.
+const (
+	// ChaosRestartRuntime restarts the runtime with -chaos-restart-command.
+	ChaosRestartRuntime = "restart-runtime"
+	// ChaosKillShims sends SIGKILL to the shim processes of the containers.
+	ChaosKillShims = "kill-shims"
+	// ChaosDropConnection closes the connections to the runtime.
+	ChaosDropConnection = "drop-connection"
+)
+
+// ChaosFaults are the faults which can be injected.
+var ChaosFaults = []string{ChaosRestartRuntime, ChaosKillShims, ChaosDropConnection}
+
+// ChaosPoints are the steps of the container lifecycle after which the
+// faults can be injected.
+var ChaosPoints = []string{"pod-ready", "container-created", "container-running", "container-exited"}
+
+// ParseChaosList parses a comma separated list of the known faults or points.
+func ParseChaosList(list string, known []string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		found := false
+		for _, k := range known {
+			if name == k {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown %q, should be one of %s", name, strings.Join(known, ", "))
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// ChaosEnabled returns whether faults should be injected.
+func ChaosEnabled() bool {
+	return strings.TrimSpace(TestContext.ChaosFaults) != ""
+}
+
+// InjectChaos injects the faults of -chaos-faults if point is one of
+// -chaos-points, and returns whether it did. The shims of the pods and
+// containers with ids are the ones killed. It waits for the runtime to be
+// ready again.
+func InjectChaos(c internalapi.RuntimeService, point string, ids ...string) bool {
+	points, err := ParseChaosList(TestContext.ChaosPoints, ChaosPoints)
+	ExpectNoError(err, "invalid chaos points")
+	if !containsString(points, point) {
+		return false
+	}
+	faults, err := ParseChaosList(TestContext.ChaosFaults, ChaosFaults)
+	ExpectNoError(err, "invalid chaos faults")
+	if len(faults) == 0 {
+		return false
+	}
+
+	for _, fault := range faults {
+		Logf("Injecting %s after %s", fault, point)
+		switch fault {
+		case ChaosRestartRuntime:
+			ExpectNoError(restartRuntime(), "failed to restart the runtime")
+		case ChaosKillShims:
+			pattern, err := regexp.Compile(TestContext.ChaosShimPattern)
+			ExpectNoError(err, "invalid shim pattern")
+			pids, err := findShims("/proc", pattern, ids)
+			ExpectNoError(err, "failed to find the shims")
+			if len(pids) == 0 {
+				Logf("WARNING: no shim of %v matches -chaos-shim-pattern %q, no shim killed after %s", ids, TestContext.ChaosShimPattern, point)
+			}
+			for _, pid := range pids {
+				Logf("Killing shim process %d", pid)
+				ExpectNoError(killProcess(pid), "failed to kill shim process %d", pid)
+			}
+		case ChaosDropConnection:
+			getConnections().Drop()
+		}
+	}
+
+	Eventually(func() error {
+		status, err := c.Status()
+		if err != nil {
+			return err
+		}
+		return checkRuntimeConditions(status)
+	}, TestContext.StateTimeout, TestContext.PollInterval).Should(Succeed(), "runtime should be ready after the faults")
+	return true
+}
+
+// restartRuntime runs -chaos-restart-command.
+func restartRuntime() error {
+	if TestContext.ChaosRestartCommand == "" {
+		return fmt.Errorf("-chaos-restart-command is not set")
+	}
+	output, err := exec.Command("sh", "-c", TestContext.ChaosRestartCommand).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%q failed: %v, output: %s", TestContext.ChaosRestartCommand, err, output)
+	}
+	// Leave some time to the runtime to stop before checking it's ready.
+	time.Sleep(time.Second)
+	return nil
+}
+
+// findShims returns the PIDs of the processes in procDir whose command line
+// matches pattern and contains one of ids.
+func findShims(procDir string, pattern *regexp.Regexp, ids []string) ([]int, error) {
+	entries, err := ioutil.ReadDir(procDir)
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(procDir, entry.Name(), "cmdline"))
+		if err != nil {
+			// The process exited.
+			continue
+		}
+		cmdline := strings.Replace(string(data), "\x00", " ", -1)
+		if !pattern.MatchString(cmdline) {
+			continue
+		}
+		for _, id := range ids {
+			if strings.Contains(cmdline, id) {
+				pids = append(pids, pid)
+				break
+			}
+		}
+	}
+	return pids, nil
+}
+
+// killProcess sends SIGKILL to the process pid.
+func killProcess(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Kill()
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
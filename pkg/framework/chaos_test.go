@@ -0,0 +1,83 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestParseChaosList(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		list      string
+		expected  []string
+		expectErr bool
+	}{
+		{desc: "empty", list: ""},
+		{desc: "faults", list: "restart-runtime, drop-connection", expected: []string{"restart-runtime", "drop-connection"}},
+		{desc: "unknown fault", list: "kill-runtime", expectErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			faults, err := ParseChaosList(tc.list, ChaosFaults)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error: %v; actual error is %v", tc.expectErr, err)
+			}
+			if !reflect.DeepEqual(faults, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, faults)
+			}
+		})
+	}
+}
+
+func TestFindShims(t *testing.T) {
+	procDir, err := ioutil.TempDir("", "chaos-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(procDir)
+
+	processes := map[string]string{
+		"10":   "containerd-shim\x00-namespace\x00k8s.io\x00-workdir\x00/var/lib/containerd/k8s.io/abc123\x00",
+		"11":   "containerd-shim\x00-namespace\x00k8s.io\x00-workdir\x00/var/lib/containerd/k8s.io/def456\x00",
+		"12":   "conmon\x00-c\x00def456\x00",
+		"13":   "sleep\x00abc123\x00",
+		"self": "containerd-shim\x00abc123\x00",
+	}
+	for name, cmdline := range processes {
+		dir := filepath.Join(procDir, name)
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatalf("failed to create %q: %v", dir, err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "cmdline"), []byte(cmdline), 0644); err != nil {
+			t.Fatalf("failed to write cmdline of %q: %v", name, err)
+		}
+	}
+
+	pids, err := findShims(procDir, regexp.MustCompile(`containerd-shim|conmon`), []string{"abc123"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(pids, []int{10}) {
+		t.Errorf("expected the shim of abc123, got %v", pids)
+	}
+}
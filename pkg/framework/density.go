@@ -0,0 +1,160 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// DensityOperations are the operations timed for each pod by the density
+// test, in order.
+var DensityOperations = []string{"run PodSandbox", "create container", "start container"}
+
+// DensityStep is the number of pods averaged in each point of the density
+// latency curves.
+const DensityStep = 10
+
+// DensityPoint is the mean latency of the operations of the pods created
+// while the number of pods on the node grew to Pods.
+type DensityPoint struct {
+	Pods int `json:"pods"`
+	// Latencies are the mean latencies in seconds, by operation.
+	Latencies map[string]float64 `json:"latencies"`
+}
+
+// DensityResults are the results of the density test.
+type DensityResults struct {
+	MaxPods int `json:"maxPods"`
+	// Pods is the number of pods created and started.
+	Pods int `json:"pods"`
+	// FailedOperation and Failure are the operation and the error which
+	// stopped the test before MaxPods pods, empty if it reached MaxPods.
+	FailedOperation string `json:"failedOperation,omitempty"`
+	Failure         string `json:"failure,omitempty"`
+	// Curve is the latency curve of the operations, a point per
+	// DensityStep pods.
+	Curve []DensityPoint `json:"curve"`
+}
+
+// DensityStats records the latencies of the operations of the pods created
+// by the density test.
+type DensityStats struct {
+	results DensityResults
+	// step are the total latencies of the pods of the current point, by
+	// operation.
+	step      map[string]time.Duration
+	stepCount int
+}
+
+// NewDensityStats creates a DensityStats for a density test creating up to
+// maxPods pods.
+func NewDensityStats(maxPods int) *DensityStats {
+	return &DensityStats{
+		results: DensityResults{MaxPods: maxPods, Curve: []DensityPoint{}},
+		step:    make(map[string]time.Duration),
+	}
+}
+
+// Record records a pod created and started, with the latencies of its
+// operations.
+func (s *DensityStats) Record(latencies map[string]time.Duration) {
+	s.results.Pods++
+	s.stepCount++
+	for operation, latency := range latencies {
+		s.step[operation] += latency
+	}
+	if s.stepCount == DensityStep {
+		s.addPoint()
+	}
+}
+
+// Fail records the failure of operation which stopped the test.
+func (s *DensityStats) Fail(operation string, err error) {
+	s.results.FailedOperation = operation
+	s.results.Failure = err.Error()
+}
+
+// addPoint adds the point of the pods recorded since the last one.
+func (s *DensityStats) addPoint() {
+	point := DensityPoint{Pods: s.results.Pods, Latencies: make(map[string]float64)}
+	for operation, total := range s.step {
+		point.Latencies[operation] = total.Seconds() / float64(s.stepCount)
+	}
+	s.results.Curve = append(s.results.Curve, point)
+	s.step = make(map[string]time.Duration)
+	s.stepCount = 0
+}
+
+// Results returns the results of the test, the last point of the curve
+// averaging the pods recorded since the previous one.
+func (s *DensityStats) Results() DensityResults {
+	if s.stepCount > 0 {
+		s.addPoint()
+	}
+	return s.results
+}
+
+// Degradation returns the ratio of the mean latency of operation in the
+// last point of the curve to the one in the first point. It is 1 with less
+// than two points.
+func (r DensityResults) Degradation(operation string) float64 {
+	if len(r.Curve) < 2 {
+		return 1
+	}
+	first := r.Curve[0].Latencies[operation]
+	if first == 0 {
+		return 1
+	}
+	return r.Curve[len(r.Curve)-1].Latencies[operation] / first
+}
+
+// Summary returns a line per point of the curve, followed by the failure
+// point and the latency degradation of each operation.
+func (r DensityResults) Summary() []string {
+	var lines []string
+	for _, point := range r.Curve {
+		var latencies []string
+		for _, operation := range DensityOperations {
+			latencies = append(latencies, fmt.Sprintf("%s %.3fs", operation, point.Latencies[operation]))
+		}
+		lines = append(lines, fmt.Sprintf("%d pods: %s", point.Pods, strings.Join(latencies, ", ")))
+	}
+	if r.Failure != "" {
+		lines = append(lines, fmt.Sprintf("failed at pod %d on %s: %s", r.Pods+1, r.FailedOperation, r.Failure))
+	} else {
+		lines = append(lines, fmt.Sprintf("reached the maximum of %d pods", r.MaxPods))
+	}
+	var degradations []string
+	for _, operation := range DensityOperations {
+		degradations = append(degradations, fmt.Sprintf("%s %.2f", operation, r.Degradation(operation)))
+	}
+	lines = append(lines, "latency degradation: "+strings.Join(degradations, ", "))
+	return lines
+}
+
+// WriteDensityResults writes results as JSON to path.
+func WriteDensityResults(path string, results DensityResults) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func densityLatencies(run, create, start time.Duration) map[string]time.Duration {
+	return map[string]time.Duration{
+		DensityOperations[0]: run,
+		DensityOperations[1]: create,
+		DensityOperations[2]: start,
+	}
+}
+
+func TestDensityStats(t *testing.T) {
+	stats := NewDensityStats(100)
+	for i := 0; i < DensityStep; i++ {
+		stats.Record(densityLatencies(100*time.Millisecond, 10*time.Millisecond, 20*time.Millisecond))
+	}
+	for i := 0; i < DensityStep/2; i++ {
+		stats.Record(densityLatencies(300*time.Millisecond, 10*time.Millisecond, 40*time.Millisecond))
+	}
+	stats.Fail(DensityOperations[0], errors.New("out of IPs"))
+
+	results := stats.Results()
+	if results.Pods != DensityStep+DensityStep/2 {
+		t.Errorf("expected %d pods; actual pods are %d", DensityStep+DensityStep/2, results.Pods)
+	}
+	expectedCurve := []DensityPoint{
+		{Pods: DensityStep, Latencies: map[string]float64{DensityOperations[0]: 0.1, DensityOperations[1]: 0.01, DensityOperations[2]: 0.02}},
+		{Pods: DensityStep + DensityStep/2, Latencies: map[string]float64{DensityOperations[0]: 0.3, DensityOperations[1]: 0.01, DensityOperations[2]: 0.04}},
+	}
+	if !reflect.DeepEqual(results.Curve, expectedCurve) {
+		t.Errorf("expected curve %v; actual curve is %v", expectedCurve, results.Curve)
+	}
+	if d := results.Degradation(DensityOperations[0]); d < 2.99 || d > 3.01 {
+		t.Errorf("expected a degradation of 3; actual degradation is %.2f", d)
+	}
+
+	summary := strings.Join(results.Summary(), "\n")
+	for _, expected := range []string{
+		"10 pods: run PodSandbox 0.100s, create container 0.010s, start container 0.020s",
+		"failed at pod 16 on run PodSandbox: out of IPs",
+		"latency degradation: run PodSandbox 3.00, create container 1.00, start container 2.00",
+	} {
+		if !strings.Contains(summary, expected) {
+			t.Errorf("expected the summary to contain %q; actual summary is %q", expected, summary)
+		}
+	}
+}
+
+func TestDensityStatsReachingMaxPods(t *testing.T) {
+	stats := NewDensityStats(3)
+	for i := 0; i < 3; i++ {
+		stats.Record(densityLatencies(time.Second, time.Second, time.Second))
+	}
+	results := stats.Results()
+	if len(results.Curve) != 1 || results.Curve[0].Pods != 3 {
+		t.Errorf("expected a single point of 3 pods; actual curve is %v", results.Curve)
+	}
+	if d := results.Degradation(DensityOperations[0]); d != 1 {
+		t.Errorf("expected no degradation with a single point; actual degradation is %.2f", d)
+	}
+	if summary := strings.Join(results.Summary(), "\n"); !strings.Contains(summary, "reached the maximum of 3 pods") {
+		t.Errorf("expected the summary to report the maximum; actual summary is %q", summary)
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeruntime
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// DefaultImageSize is the size of the images pulled with PullImage.
+const DefaultImageSize uint64 = 1024 * 1024
+
+// AddImage adds an image of the given size without a PullImage call, e.g. to
+// seed the images expected on the node. It returns the image ID.
+func (r *Runtime) AddImage(ref string, size uint64) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.addImage(ref, size)
+}
+
+// addImage adds the image ref, or the ref to the image with the same ID. It
+// must be called with the lock held.
+func (r *Runtime) addImage(ref string, size uint64) string {
+	ref = normalizeImage(ref)
+	id := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(ref)))
+	image, ok := r.images[id]
+	if !ok {
+		image = &runtimeapi.Image{Id: id, Size_: size}
+		r.images[id] = image
+	}
+	if strings.Contains(ref, "@") {
+		image.RepoDigests = appendUnique(image.RepoDigests, ref)
+	} else {
+		image.RepoTags = appendUnique(image.RepoTags, ref)
+	}
+	return id
+}
+
+// findImage returns the image matching ref by tag, digest or ID, or nil. It
+// must be called with the lock held.
+func (r *Runtime) findImage(ref string) *runtimeapi.Image {
+	if image, ok := r.images[ref]; ok {
+		return image
+	}
+	ref = normalizeImage(ref)
+	for _, image := range r.images {
+		for _, r := range append(image.RepoTags, image.RepoDigests...) {
+			if r == ref {
+				return image
+			}
+		}
+	}
+	return nil
+}
+
+// ListImages returns the images matching filter.
+func (r *Runtime) ListImages(filter *runtimeapi.ImageFilter) ([]*runtimeapi.Image, error) {
+	if err := r.call("ListImages"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if ref := filter.GetImage().GetImage(); ref != "" {
+		if image := r.findImage(ref); image != nil {
+			return []*runtimeapi.Image{copyImage(image)}, nil
+		}
+		return nil, nil
+	}
+	var images []*runtimeapi.Image
+	for _, image := range r.images {
+		images = append(images, copyImage(image))
+	}
+	return images, nil
+}
+
+// ImageStatus returns the status of the image, or nil if it doesn't exist.
+func (r *Runtime) ImageStatus(image *runtimeapi.ImageSpec) (*runtimeapi.Image, error) {
+	if err := r.call("ImageStatus"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if found := r.findImage(image.GetImage()); found != nil {
+		return copyImage(found), nil
+	}
+	return nil, nil
+}
+
+// PullImage adds the image with DefaultImageSize, ignoring auth, and
+// returns its ID.
+func (r *Runtime) PullImage(image *runtimeapi.ImageSpec, auth *runtimeapi.AuthConfig) (string, error) {
+	if err := r.call("PullImage"); err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.addImage(image.GetImage(), DefaultImageSize), nil
+}
+
+// RemoveImage removes the image with all its references. Removing a
+// nonexistent image succeeds.
+func (r *Runtime) RemoveImage(image *runtimeapi.ImageSpec) error {
+	if err := r.call("RemoveImage"); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if found := r.findImage(image.GetImage()); found != nil {
+		delete(r.images, found.Id)
+	}
+	return nil
+}
+
+// ImageFsInfo returns a single filesystem used by the images.
+func (r *Runtime) ImageFsInfo() ([]*runtimeapi.FilesystemUsage, error) {
+	if err := r.call("ImageFsInfo"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var used uint64
+	for _, image := range r.images {
+		used += image.Size_
+	}
+	return []*runtimeapi.FilesystemUsage{{
+		Timestamp:  time.Now().UnixNano(),
+		FsId:       &runtimeapi.FilesystemIdentifier{Mountpoint: "/var/lib/" + RuntimeName},
+		UsedBytes:  &runtimeapi.UInt64Value{Value: used},
+		InodesUsed: &runtimeapi.UInt64Value{Value: uint64(len(r.images))},
+	}}, nil
+}
+
+// normalizeImage adds the latest tag to references without tag or digest.
+func normalizeImage(ref string) string {
+	if strings.Contains(ref, "@") {
+		return ref
+	}
+	if i := strings.LastIndex(ref, ":"); i == -1 || strings.Contains(ref[i:], "/") {
+		return ref + ":latest"
+	}
+	return ref
+}
+
+func copyImage(image *runtimeapi.Image) *runtimeapi.Image {
+	c := *image
+	c.RepoTags = append([]string(nil), image.RepoTags...)
+	c.RepoDigests = append([]string(nil), image.RepoDigests...)
+	return &c
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, item := range list {
+		if item == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,708 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fakeruntime provides an in-memory CRI runtime implementing the
+// RuntimeService and ImageManagerService interfaces, so that the validation
+// helpers, crictl and the benchmarks can be tested without a container
+// runtime. Latencies and errors can be injected per method.
+package fakeruntime
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+	utilexec "k8s.io/utils/exec"
+)
+
+const (
+	// RuntimeName is the runtime name reported by Version.
+	RuntimeName = "fakeruntime"
+	// RuntimeVersion is the runtime version reported by Version.
+	RuntimeVersion = "0.1.0"
+	// RuntimeAPIVersion is the CRI version reported by Version.
+	RuntimeAPIVersion = "v1alpha2"
+
+	// streamingURL is the base URL of the streaming endpoints returned by
+	// Exec, Attach and PortForward, which are not served.
+	streamingURL = "http://127.0.0.1:10250/"
+)
+
+// ExecHandler runs the command of an ExecSync call in the container.
+type ExecHandler func(containerID string, cmd []string) (stdout, stderr []byte, exitCode int32)
+
+// Runtime is an in-memory CRI runtime. It is safe for concurrent use.
+type Runtime struct {
+	mu sync.Mutex
+
+	pods          map[string]*runtimeapi.PodSandboxStatus
+	containers    map[string]*container
+	images        map[string]*runtimeapi.Image
+	runtimeConfig *runtimeapi.RuntimeConfig
+	execHandler   ExecHandler
+
+	latencies map[string]time.Duration
+	faults    map[string]*fault
+	calls     map[string]int
+}
+
+// container is a container of the runtime.
+type container struct {
+	podID     string
+	status    *runtimeapi.ContainerStatus
+	resources *runtimeapi.LinuxContainerResources
+}
+
+// fault is an error injected in the calls of a method.
+type fault struct {
+	err error
+	// times is the number of calls left to fail, or a negative number if
+	// all the calls fail.
+	times int
+}
+
+var (
+	_ internalapi.RuntimeService      = &Runtime{}
+	_ internalapi.ImageManagerService = &Runtime{}
+)
+
+// New creates an empty Runtime. Its ExecSync calls succeed without output
+// unless SetExecHandler is called.
+func New() *Runtime {
+	return &Runtime{
+		pods:       make(map[string]*runtimeapi.PodSandboxStatus),
+		containers: make(map[string]*container),
+		images:     make(map[string]*runtimeapi.Image),
+		latencies:  make(map[string]time.Duration),
+		faults:     make(map[string]*fault),
+		calls:      make(map[string]int),
+	}
+}
+
+// SetLatency makes the calls of method, e.g. "CreateContainer", take at
+// least latency.
+func (r *Runtime) SetLatency(method string, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latencies[method] = latency
+}
+
+// InjectError makes the next times calls of method fail with err, or all of
+// them if times is not positive, without side effects.
+func (r *Runtime) InjectError(method string, err error, times int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if times <= 0 {
+		times = -1
+	}
+	r.faults[method] = &fault{err: err, times: times}
+}
+
+// ClearErrors removes the injected errors.
+func (r *Runtime) ClearErrors() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.faults = make(map[string]*fault)
+}
+
+// Calls returns the number of calls of method so far, including the failed
+// ones.
+func (r *Runtime) Calls(method string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls[method]
+}
+
+// SetExecHandler sets the handler running the commands of the ExecSync calls.
+func (r *Runtime) SetExecHandler(handler ExecHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.execHandler = handler
+}
+
+// call records a call of method, waits for its latency, and returns its
+// injected error if any. It must be called without holding the lock.
+func (r *Runtime) call(method string) error {
+	r.mu.Lock()
+	r.calls[method]++
+	latency := r.latencies[method]
+	var err error
+	if f, ok := r.faults[method]; ok {
+		err = f.err
+		if f.times > 0 {
+			f.times--
+			if f.times == 0 {
+				delete(r.faults, method)
+			}
+		}
+	}
+	r.mu.Unlock()
+
+	time.Sleep(latency)
+	return err
+}
+
+// Version returns the runtime name, runtime version and runtime API version.
+func (r *Runtime) Version(apiVersion string) (*runtimeapi.VersionResponse, error) {
+	if err := r.call("Version"); err != nil {
+		return nil, err
+	}
+	return &runtimeapi.VersionResponse{
+		Version:           RuntimeVersion,
+		RuntimeName:       RuntimeName,
+		RuntimeVersion:    RuntimeVersion,
+		RuntimeApiVersion: RuntimeAPIVersion,
+	}, nil
+}
+
+// UpdateRuntimeConfig records the runtime configuration.
+func (r *Runtime) UpdateRuntimeConfig(runtimeConfig *runtimeapi.RuntimeConfig) error {
+	if err := r.call("UpdateRuntimeConfig"); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runtimeConfig = runtimeConfig
+	return nil
+}
+
+// RuntimeConfig returns the last runtime configuration updated.
+func (r *Runtime) RuntimeConfig() *runtimeapi.RuntimeConfig {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.runtimeConfig
+}
+
+// Status returns a ready runtime and network.
+func (r *Runtime) Status() (*runtimeapi.RuntimeStatus, error) {
+	if err := r.call("Status"); err != nil {
+		return nil, err
+	}
+	return &runtimeapi.RuntimeStatus{
+		Conditions: []*runtimeapi.RuntimeCondition{
+			{Type: runtimeapi.RuntimeReady, Status: true},
+			{Type: runtimeapi.NetworkReady, Status: true},
+		},
+	}, nil
+}
+
+// RunPodSandbox creates a ready PodSandbox.
+func (r *Runtime) RunPodSandbox(config *runtimeapi.PodSandboxConfig) (string, error) {
+	if err := r.call("RunPodSandbox"); err != nil {
+		return "", err
+	}
+	if config.GetMetadata() == nil {
+		return "", status.Error(codes.InvalidArgument, "PodSandbox metadata is required")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, pod := range r.pods {
+		if sameSandboxMetadata(pod.Metadata, config.Metadata) {
+			return "", status.Errorf(codes.AlreadyExists, "PodSandbox %q already exists", pod.Id)
+		}
+	}
+	id := newID()
+	r.pods[id] = &runtimeapi.PodSandboxStatus{
+		Id:          id,
+		Metadata:    config.Metadata,
+		State:       runtimeapi.PodSandboxState_SANDBOX_READY,
+		CreatedAt:   time.Now().UnixNano(),
+		Network:     &runtimeapi.PodSandboxNetworkStatus{Ip: "10.88.0.1"},
+		Labels:      copyMap(config.Labels),
+		Annotations: copyMap(config.Annotations),
+	}
+	return id, nil
+}
+
+// StopPodSandbox stops the PodSandbox and its containers.
+func (r *Runtime) StopPodSandbox(podSandboxID string) error {
+	if err := r.call("StopPodSandbox"); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pod, ok := r.pods[podSandboxID]
+	if !ok {
+		return podNotFound(podSandboxID)
+	}
+	for _, c := range r.containers {
+		if c.podID == podSandboxID {
+			c.stop()
+		}
+	}
+	pod.State = runtimeapi.PodSandboxState_SANDBOX_NOTREADY
+	return nil
+}
+
+// RemovePodSandbox removes the PodSandbox and its containers. Removing a
+// nonexistent PodSandbox succeeds.
+func (r *Runtime) RemovePodSandbox(podSandboxID string) error {
+	if err := r.call("RemovePodSandbox"); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, c := range r.containers {
+		if c.podID == podSandboxID {
+			delete(r.containers, id)
+		}
+	}
+	delete(r.pods, podSandboxID)
+	return nil
+}
+
+// PodSandboxStatus returns the status of the PodSandbox.
+func (r *Runtime) PodSandboxStatus(podSandboxID string) (*runtimeapi.PodSandboxStatus, error) {
+	if err := r.call("PodSandboxStatus"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pod, ok := r.pods[podSandboxID]
+	if !ok {
+		return nil, podNotFound(podSandboxID)
+	}
+	s := *pod
+	return &s, nil
+}
+
+// ListPodSandbox returns the PodSandboxes matching filter.
+func (r *Runtime) ListPodSandbox(filter *runtimeapi.PodSandboxFilter) ([]*runtimeapi.PodSandbox, error) {
+	if err := r.call("ListPodSandbox"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var pods []*runtimeapi.PodSandbox
+	for id, pod := range r.pods {
+		if filter != nil {
+			if filter.Id != "" && filter.Id != id {
+				continue
+			}
+			if filter.State != nil && filter.State.State != pod.State {
+				continue
+			}
+			if !matchLabels(pod.Labels, filter.LabelSelector) {
+				continue
+			}
+		}
+		pods = append(pods, &runtimeapi.PodSandbox{
+			Id:          id,
+			Metadata:    pod.Metadata,
+			State:       pod.State,
+			CreatedAt:   pod.CreatedAt,
+			Labels:      pod.Labels,
+			Annotations: pod.Annotations,
+		})
+	}
+	return pods, nil
+}
+
+// PortForward returns a streaming URL, which is not served.
+func (r *Runtime) PortForward(req *runtimeapi.PortForwardRequest) (*runtimeapi.PortForwardResponse, error) {
+	if err := r.call("PortForward"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pod, ok := r.pods[req.PodSandboxId]
+	if !ok {
+		return nil, podNotFound(req.PodSandboxId)
+	}
+	if pod.State != runtimeapi.PodSandboxState_SANDBOX_READY {
+		return nil, status.Errorf(codes.FailedPrecondition, "PodSandbox %q is not ready", req.PodSandboxId)
+	}
+	return &runtimeapi.PortForwardResponse{Url: streamingURL + "portforward/" + req.PodSandboxId}, nil
+}
+
+// CreateContainer creates a container in a ready PodSandbox. The image must
+// have been pulled.
+func (r *Runtime) CreateContainer(podSandboxID string, config *runtimeapi.ContainerConfig, sandboxConfig *runtimeapi.PodSandboxConfig) (string, error) {
+	if err := r.call("CreateContainer"); err != nil {
+		return "", err
+	}
+	if config.GetMetadata() == nil || config.GetImage() == nil {
+		return "", status.Error(codes.InvalidArgument, "container metadata and image are required")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pod, ok := r.pods[podSandboxID]
+	if !ok {
+		return "", podNotFound(podSandboxID)
+	}
+	if pod.State != runtimeapi.PodSandboxState_SANDBOX_READY {
+		return "", status.Errorf(codes.FailedPrecondition, "PodSandbox %q is not ready", podSandboxID)
+	}
+	image := r.findImage(config.Image.Image)
+	if image == nil {
+		return "", status.Errorf(codes.NotFound, "image %q not found", config.Image.Image)
+	}
+	for _, c := range r.containers {
+		if c.podID == podSandboxID && c.status.Metadata.Name == config.Metadata.Name && c.status.Metadata.Attempt == config.Metadata.Attempt {
+			return "", status.Errorf(codes.AlreadyExists, "container %q already exists", c.status.Id)
+		}
+	}
+	id := newID()
+	var logPath string
+	if config.LogPath != "" {
+		logPath = sandboxConfig.GetLogDirectory() + "/" + config.LogPath
+	}
+	r.containers[id] = &container{
+		podID: podSandboxID,
+		status: &runtimeapi.ContainerStatus{
+			Id:          id,
+			Metadata:    config.Metadata,
+			State:       runtimeapi.ContainerState_CONTAINER_CREATED,
+			CreatedAt:   time.Now().UnixNano(),
+			Image:       config.Image,
+			ImageRef:    image.Id,
+			Labels:      copyMap(config.Labels),
+			Annotations: copyMap(config.Annotations),
+			Mounts:      config.Mounts,
+			LogPath:     logPath,
+		},
+		resources: config.GetLinux().GetResources(),
+	}
+	return id, nil
+}
+
+// StartContainer starts a created container.
+func (r *Runtime) StartContainer(containerID string) error {
+	if err := r.call("StartContainer"); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.containers[containerID]
+	if !ok {
+		return containerNotFound(containerID)
+	}
+	if c.status.State != runtimeapi.ContainerState_CONTAINER_CREATED {
+		return status.Errorf(codes.FailedPrecondition, "container %q is %s, not created", containerID, c.status.State)
+	}
+	c.status.State = runtimeapi.ContainerState_CONTAINER_RUNNING
+	c.status.StartedAt = time.Now().UnixNano()
+	return nil
+}
+
+// StopContainer stops a running container. Stopping a container which isn't
+// running succeeds.
+func (r *Runtime) StopContainer(containerID string, timeout int64) error {
+	if err := r.call("StopContainer"); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.containers[containerID]
+	if !ok {
+		return containerNotFound(containerID)
+	}
+	c.stop()
+	return nil
+}
+
+// stop makes the container exit if it is running.
+func (c *container) stop() {
+	if c.status.State != runtimeapi.ContainerState_CONTAINER_RUNNING {
+		return
+	}
+	c.status.State = runtimeapi.ContainerState_CONTAINER_EXITED
+	c.status.FinishedAt = time.Now().UnixNano()
+	c.status.ExitCode = 0
+	c.status.Reason = "Completed"
+}
+
+// RemoveContainer removes the container, even if it is running. Removing a
+// nonexistent container succeeds.
+func (r *Runtime) RemoveContainer(containerID string) error {
+	if err := r.call("RemoveContainer"); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.containers, containerID)
+	return nil
+}
+
+// ListContainers returns the containers matching filter.
+func (r *Runtime) ListContainers(filter *runtimeapi.ContainerFilter) ([]*runtimeapi.Container, error) {
+	if err := r.call("ListContainers"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var containers []*runtimeapi.Container
+	for id, c := range r.containers {
+		if filter != nil {
+			if filter.Id != "" && filter.Id != id {
+				continue
+			}
+			if filter.PodSandboxId != "" && filter.PodSandboxId != c.podID {
+				continue
+			}
+			if filter.State != nil && filter.State.State != c.status.State {
+				continue
+			}
+			if !matchLabels(c.status.Labels, filter.LabelSelector) {
+				continue
+			}
+		}
+		containers = append(containers, &runtimeapi.Container{
+			Id:           id,
+			PodSandboxId: c.podID,
+			Metadata:     c.status.Metadata,
+			Image:        c.status.Image,
+			ImageRef:     c.status.ImageRef,
+			State:        c.status.State,
+			CreatedAt:    c.status.CreatedAt,
+			Labels:       c.status.Labels,
+			Annotations:  c.status.Annotations,
+		})
+	}
+	return containers, nil
+}
+
+// ContainerStatus returns the status of the container.
+func (r *Runtime) ContainerStatus(containerID string) (*runtimeapi.ContainerStatus, error) {
+	if err := r.call("ContainerStatus"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.containers[containerID]
+	if !ok {
+		return nil, containerNotFound(containerID)
+	}
+	s := *c.status
+	return &s, nil
+}
+
+// UpdateContainerResources records the resources of a created or running
+// container.
+func (r *Runtime) UpdateContainerResources(containerID string, resources *runtimeapi.LinuxContainerResources) error {
+	if err := r.call("UpdateContainerResources"); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.containers[containerID]
+	if !ok {
+		return containerNotFound(containerID)
+	}
+	if c.status.State == runtimeapi.ContainerState_CONTAINER_EXITED {
+		return status.Errorf(codes.FailedPrecondition, "container %q is exited", containerID)
+	}
+	c.resources = resources
+	return nil
+}
+
+// ContainerResources returns the last resources set for the container.
+func (r *Runtime) ContainerResources(containerID string) *runtimeapi.LinuxContainerResources {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.containers[containerID]; ok {
+		return c.resources
+	}
+	return nil
+}
+
+// ExecSync runs cmd in a running container with the exec handler. A non-zero
+// exit code is returned as a CodeExitError, like the remote runtime does.
+func (r *Runtime) ExecSync(containerID string, cmd []string, timeout time.Duration) ([]byte, []byte, error) {
+	if err := r.call("ExecSync"); err != nil {
+		return nil, nil, err
+	}
+	r.mu.Lock()
+	if err := r.checkRunning(containerID); err != nil {
+		r.mu.Unlock()
+		return nil, nil, err
+	}
+	handler := r.execHandler
+	r.mu.Unlock()
+
+	if handler == nil {
+		return nil, nil, nil
+	}
+	stdout, stderr, exitCode := handler(containerID, cmd)
+	if exitCode != 0 {
+		return stdout, stderr, utilexec.CodeExitError{
+			Err:  fmt.Errorf("command '%s' exited with %d: %s", strings.Join(cmd, " "), exitCode, stderr),
+			Code: int(exitCode),
+		}
+	}
+	return stdout, stderr, nil
+}
+
+// Exec returns a streaming URL for a running container, which is not served.
+func (r *Runtime) Exec(req *runtimeapi.ExecRequest) (*runtimeapi.ExecResponse, error) {
+	if err := r.call("Exec"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.checkRunning(req.ContainerId); err != nil {
+		return nil, err
+	}
+	return &runtimeapi.ExecResponse{Url: streamingURL + "exec/" + req.ContainerId}, nil
+}
+
+// Attach returns a streaming URL for a running container, which is not served.
+func (r *Runtime) Attach(req *runtimeapi.AttachRequest) (*runtimeapi.AttachResponse, error) {
+	if err := r.call("Attach"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.checkRunning(req.ContainerId); err != nil {
+		return nil, err
+	}
+	return &runtimeapi.AttachResponse{Url: streamingURL + "attach/" + req.ContainerId}, nil
+}
+
+// ReopenContainerLog succeeds for running containers.
+func (r *Runtime) ReopenContainerLog(containerID string) error {
+	if err := r.call("ReopenContainerLog"); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.checkRunning(containerID)
+}
+
+// checkRunning returns an error if the container isn't running. It must be
+// called with the lock held.
+func (r *Runtime) checkRunning(containerID string) error {
+	c, ok := r.containers[containerID]
+	if !ok {
+		return containerNotFound(containerID)
+	}
+	if c.status.State != runtimeapi.ContainerState_CONTAINER_RUNNING {
+		return status.Errorf(codes.FailedPrecondition, "container %q is not running", containerID)
+	}
+	return nil
+}
+
+// ContainerStats returns empty stats of the container.
+func (r *Runtime) ContainerStats(containerID string) (*runtimeapi.ContainerStats, error) {
+	if err := r.call("ContainerStats"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.containers[containerID]
+	if !ok {
+		return nil, containerNotFound(containerID)
+	}
+	return c.stats(), nil
+}
+
+// ListContainerStats returns empty stats of the running containers matching
+// filter.
+func (r *Runtime) ListContainerStats(filter *runtimeapi.ContainerStatsFilter) ([]*runtimeapi.ContainerStats, error) {
+	if err := r.call("ListContainerStats"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var stats []*runtimeapi.ContainerStats
+	for id, c := range r.containers {
+		if c.status.State != runtimeapi.ContainerState_CONTAINER_RUNNING {
+			continue
+		}
+		if filter != nil {
+			if filter.Id != "" && filter.Id != id {
+				continue
+			}
+			if filter.PodSandboxId != "" && filter.PodSandboxId != c.podID {
+				continue
+			}
+			if !matchLabels(c.status.Labels, filter.LabelSelector) {
+				continue
+			}
+		}
+		stats = append(stats, c.stats())
+	}
+	return stats, nil
+}
+
+// stats returns the stats of the container, which uses no resources.
+func (c *container) stats() *runtimeapi.ContainerStats {
+	now := time.Now().UnixNano()
+	return &runtimeapi.ContainerStats{
+		Attributes: &runtimeapi.ContainerAttributes{
+			Id:          c.status.Id,
+			Metadata:    c.status.Metadata,
+			Labels:      c.status.Labels,
+			Annotations: c.status.Annotations,
+		},
+		Cpu:    &runtimeapi.CpuUsage{Timestamp: now, UsageCoreNanoSeconds: &runtimeapi.UInt64Value{}},
+		Memory: &runtimeapi.MemoryUsage{Timestamp: now, WorkingSetBytes: &runtimeapi.UInt64Value{}},
+		WritableLayer: &runtimeapi.FilesystemUsage{
+			Timestamp:  now,
+			UsedBytes:  &runtimeapi.UInt64Value{},
+			InodesUsed: &runtimeapi.UInt64Value{},
+		},
+	}
+}
+
+// newID returns a random 64 hexadecimal characters ID.
+func newID() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// sameSandboxMetadata returns whether the metadata identify the same
+// PodSandbox.
+func sameSandboxMetadata(a, b *runtimeapi.PodSandboxMetadata) bool {
+	return a.Name == b.Name && a.Namespace == b.Namespace && a.Uid == b.Uid && a.Attempt == b.Attempt
+}
+
+// matchLabels returns whether labels contain all the labels of selector.
+func matchLabels(labels, selector map[string]string) bool {
+	for k, v := range selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func copyMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func podNotFound(podSandboxID string) error {
+	return status.Errorf(codes.NotFound, "PodSandbox %q not found", podSandboxID)
+}
+
+func containerNotFound(containerID string) error {
+	return status.Errorf(codes.NotFound, "container %q not found", containerID)
+}
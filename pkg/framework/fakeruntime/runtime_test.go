@@ -0,0 +1,192 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fakeruntime
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+	utilexec "k8s.io/utils/exec"
+)
+
+const testImage = "busybox:1.28"
+
+func runTestPod(t *testing.T, r *Runtime) (string, *runtimeapi.PodSandboxConfig) {
+	config := &runtimeapi.PodSandboxConfig{
+		Metadata: &runtimeapi.PodSandboxMetadata{Name: "pod", Uid: "uid", Namespace: "ns"},
+		Labels:   map[string]string{"app": "test"},
+	}
+	podID, err := r.RunPodSandbox(config)
+	if err != nil {
+		t.Fatalf("failed to run PodSandbox: %v", err)
+	}
+	return podID, config
+}
+
+func createTestContainer(t *testing.T, r *Runtime, podID string, podConfig *runtimeapi.PodSandboxConfig, name string) string {
+	containerID, err := r.CreateContainer(podID, &runtimeapi.ContainerConfig{
+		Metadata: &runtimeapi.ContainerMetadata{Name: name},
+		Image:    &runtimeapi.ImageSpec{Image: testImage},
+	}, podConfig)
+	if err != nil {
+		t.Fatalf("failed to create container: %v", err)
+	}
+	return containerID
+}
+
+func expectCode(t *testing.T, err error, code codes.Code) {
+	if s, ok := status.FromError(err); !ok || s.Code() != code {
+		t.Errorf("expected %s error; actual error is %v", code, err)
+	}
+}
+
+func TestContainerLifecycle(t *testing.T) {
+	r := New()
+	podID, podConfig := runTestPod(t, r)
+
+	_, err := r.CreateContainer(podID, &runtimeapi.ContainerConfig{
+		Metadata: &runtimeapi.ContainerMetadata{Name: "missing-image"},
+		Image:    &runtimeapi.ImageSpec{Image: testImage},
+	}, podConfig)
+	expectCode(t, err, codes.NotFound)
+
+	if _, err := r.PullImage(&runtimeapi.ImageSpec{Image: testImage}, nil); err != nil {
+		t.Fatalf("failed to pull image: %v", err)
+	}
+	containerID := createTestContainer(t, r, podID, podConfig, "container")
+	_, _, err = r.ExecSync(containerID, []string{"true"}, time.Second)
+	expectCode(t, err, codes.FailedPrecondition)
+
+	if err := r.StartContainer(containerID); err != nil {
+		t.Fatalf("failed to start container: %v", err)
+	}
+	expectCode(t, r.StartContainer(containerID), codes.FailedPrecondition)
+	r.SetExecHandler(func(containerID string, cmd []string) ([]byte, []byte, int32) {
+		return nil, []byte("failed"), 3
+	})
+	_, _, err = r.ExecSync(containerID, []string{"false"}, time.Second)
+	if exitErr, ok := err.(utilexec.CodeExitError); !ok || exitErr.Code != 3 {
+		t.Errorf("expected exit code 3; actual error is %v", err)
+	}
+
+	if err := r.StopPodSandbox(podID); err != nil {
+		t.Fatalf("failed to stop PodSandbox: %v", err)
+	}
+	containerStatus, err := r.ContainerStatus(containerID)
+	if err != nil || containerStatus.State != runtimeapi.ContainerState_CONTAINER_EXITED {
+		t.Errorf("expected the container to exit with its PodSandbox; actual containerStatus is %v: %v", containerStatus, err)
+	}
+	_, err = r.CreateContainer(podID, &runtimeapi.ContainerConfig{
+		Metadata: &runtimeapi.ContainerMetadata{Name: "stopped-pod"},
+		Image:    &runtimeapi.ImageSpec{Image: testImage},
+	}, podConfig)
+	expectCode(t, err, codes.FailedPrecondition)
+
+	if err := r.RemovePodSandbox(podID); err != nil {
+		t.Fatalf("failed to remove PodSandbox: %v", err)
+	}
+	_, err = r.ContainerStatus(containerID)
+	expectCode(t, err, codes.NotFound)
+	if err := r.RemoveContainer(containerID); err != nil {
+		t.Errorf("expected removing a removed container to succeed; actual error is %v", err)
+	}
+}
+
+func TestListFilters(t *testing.T) {
+	r := New()
+	r.AddImage(testImage, DefaultImageSize)
+	podID, podConfig := runTestPod(t, r)
+	running := createTestContainer(t, r, podID, podConfig, "running")
+	if err := r.StartContainer(running); err != nil {
+		t.Fatalf("failed to start container: %v", err)
+	}
+	created := createTestContainer(t, r, podID, podConfig, "created")
+
+	containers, err := r.ListContainers(&runtimeapi.ContainerFilter{
+		State: &runtimeapi.ContainerStateValue{State: runtimeapi.ContainerState_CONTAINER_CREATED},
+	})
+	if err != nil || len(containers) != 1 || containers[0].Id != created {
+		t.Errorf("expected only the created container; actual containers are %v: %v", containers, err)
+	}
+	stats, err := r.ListContainerStats(&runtimeapi.ContainerStatsFilter{PodSandboxId: podID})
+	if err != nil || len(stats) != 1 || stats[0].Attributes.Id != running {
+		t.Errorf("expected only the stats of the running container; actual stats are %v: %v", stats, err)
+	}
+	pods, err := r.ListPodSandbox(&runtimeapi.PodSandboxFilter{LabelSelector: map[string]string{"app": "other"}})
+	if err != nil || len(pods) != 0 {
+		t.Errorf("expected no PodSandbox; actual PodSandboxes are %v: %v", pods, err)
+	}
+}
+
+func TestImages(t *testing.T) {
+	r := New()
+	id, err := r.PullImage(&runtimeapi.ImageSpec{Image: "busybox"}, nil)
+	if err != nil {
+		t.Fatalf("failed to pull image: %v", err)
+	}
+	for _, ref := range []string{"busybox", "busybox:latest", id} {
+		image, err := r.ImageStatus(&runtimeapi.ImageSpec{Image: ref})
+		if err != nil || image == nil || image.Id != id {
+			t.Errorf("expected image %q to be found by %q; actual image is %v: %v", id, ref, image, err)
+		}
+	}
+	fs, err := r.ImageFsInfo()
+	if err != nil || len(fs) != 1 || fs[0].UsedBytes.Value != DefaultImageSize {
+		t.Errorf("expected %d used bytes; actual usage is %v: %v", DefaultImageSize, fs, err)
+	}
+	if err := r.RemoveImage(&runtimeapi.ImageSpec{Image: "busybox"}); err != nil {
+		t.Fatalf("failed to remove image: %v", err)
+	}
+	if image, err := r.ImageStatus(&runtimeapi.ImageSpec{Image: id}); err != nil || image != nil {
+		t.Errorf("expected image %q to be removed; actual image is %v: %v", id, image, err)
+	}
+}
+
+func TestInjection(t *testing.T) {
+	r := New()
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	r.InjectError("Version", unavailable, 2)
+	for i, expected := range []error{unavailable, unavailable, nil} {
+		if _, err := r.Version("v1alpha2"); err != expected {
+			t.Errorf("expected call %d to return %v; actual error is %v", i, expected, err)
+		}
+	}
+	if calls := r.Calls("Version"); calls != 3 {
+		t.Errorf("expected 3 calls; actual calls are %d", calls)
+	}
+
+	r.InjectError("Status", unavailable, 0)
+	for i := 0; i < 3; i++ {
+		if _, err := r.Status(); err != unavailable {
+			t.Errorf("expected all calls to fail; actual error is %v", err)
+		}
+	}
+	r.ClearErrors()
+	if _, err := r.Status(); err != nil {
+		t.Errorf("expected the errors to be cleared; actual error is %v", err)
+	}
+
+	r.SetLatency("Status", 50*time.Millisecond)
+	start := time.Now()
+	r.Status()
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected the call to take at least 50ms; actual time is %v", elapsed)
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+)
+
+// flakyTag is appended to the text of the specs which passed on retry by
+// FlakeReporter.
+const flakyTag = " [Flaky]"
+
+// FlakeReporter wraps a ginkgo reporter to report each spec once when ginkgo
+// retries the failed specs: the failed attempts of the specs which pass on
+// retry aren't reported, and the specs are tagged [Flaky].
+type FlakeReporter struct {
+	reporter ginkgo.Reporter
+	attempts int
+	// failed is the number of failed attempts of the spec being run.
+	failed int
+}
+
+// NewFlakeReporter wraps reporter, with ginkgo making up to attempts
+// attempts to run each spec.
+func NewFlakeReporter(reporter ginkgo.Reporter, attempts int) *FlakeReporter {
+	return &FlakeReporter{reporter: reporter, attempts: attempts}
+}
+
+// SpecSuiteWillBegin implements ginkgo.Reporter.
+func (r *FlakeReporter) SpecSuiteWillBegin(config config.GinkgoConfigType, summary *types.SuiteSummary) {
+	r.reporter.SpecSuiteWillBegin(config, summary)
+}
+
+// BeforeSuiteDidRun implements ginkgo.Reporter.
+func (r *FlakeReporter) BeforeSuiteDidRun(summary *types.SetupSummary) {
+	r.reporter.BeforeSuiteDidRun(summary)
+}
+
+// SpecWillRun reports the first attempt of the spec.
+func (r *FlakeReporter) SpecWillRun(summary *types.SpecSummary) {
+	if r.failed == 0 {
+		r.reporter.SpecWillRun(summary)
+	}
+}
+
+// SpecDidComplete reports the last attempt of the spec, tagged [Flaky] if it
+// passed after failed attempts.
+func (r *FlakeReporter) SpecDidComplete(summary *types.SpecSummary) {
+	if summary.HasFailureState() {
+		r.failed++
+		if r.failed < r.attempts {
+			// ginkgo retries the spec.
+			return
+		}
+	} else if r.failed > 0 && summary.Passed() {
+		flaky := *summary
+		flaky.ComponentTexts = append([]string(nil), summary.ComponentTexts...)
+		flaky.ComponentTexts[len(flaky.ComponentTexts)-1] += flakyTag
+		summary = &flaky
+	}
+	r.failed = 0
+	r.reporter.SpecDidComplete(summary)
+}
+
+// AfterSuiteDidRun implements ginkgo.Reporter.
+func (r *FlakeReporter) AfterSuiteDidRun(summary *types.SetupSummary) {
+	r.reporter.AfterSuiteDidRun(summary)
+}
+
+// SpecSuiteDidEnd implements ginkgo.Reporter.
+func (r *FlakeReporter) SpecSuiteDidEnd(summary *types.SuiteSummary) {
+	r.reporter.SpecSuiteDidEnd(summary)
+}
+
+// SuiteFlakes is the flake rate of the specs under a KubeDescribe text.
+type SuiteFlakes struct {
+	Suite string `json:"suite"`
+	// Specs is the number of specs which ran, and Flaky the number of them
+	// which passed on retry.
+	Specs int     `json:"specs"`
+	Flaky int     `json:"flaky"`
+	Rate  float64 `json:"rate"`
+}
+
+// flakeCounter counts the specs which ran and the flaky ones by KubeDescribe
+// text.
+type flakeCounter map[string]*SuiteFlakes
+
+// add counts the spec of summary, with its result.
+func (c flakeCounter) add(summary *types.SpecSummary, result SpecResult) {
+	if summary.Skipped() || summary.State == types.SpecStatePending || len(summary.ComponentTexts) < 2 {
+		return
+	}
+	suite := strings.TrimSuffix(summary.ComponentTexts[1], flakyTag)
+	f, ok := c[suite]
+	if !ok {
+		f = &SuiteFlakes{Suite: suite}
+		c[suite] = f
+	}
+	f.Specs++
+	if result.State == "flaky" {
+		f.Flaky++
+	}
+}
+
+// flaky returns the flake rates of the suites with flaky specs, sorted.
+func (c flakeCounter) flaky() []SuiteFlakes {
+	var flakes []SuiteFlakes
+	for _, f := range c {
+		if f.Flaky > 0 {
+			f.Rate = float64(f.Flaky) / float64(f.Specs)
+			flakes = append(flakes, *f)
+		}
+	}
+	sort.Slice(flakes, func(i, j int) bool { return flakes[i].Suite < flakes[j].Suite })
+	return flakes
+}
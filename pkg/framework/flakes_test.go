@@ -0,0 +1,76 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+)
+
+// fakeReporter records the specs reported to it.
+type fakeReporter struct {
+	ListReporter
+	completed []SpecResult
+}
+
+func (r *fakeReporter) SpecDidComplete(summary *types.SpecSummary) {
+	r.completed = append(r.completed, newSpecResult(summary))
+}
+
+func TestFlakeReporter(t *testing.T) {
+	inner := &fakeReporter{}
+	reporter := NewFlakeReporter(inner, 3)
+	reporter.SpecSuiteWillBegin(config.GinkgoConfigType{}, &types.SuiteSummary{})
+	for _, attempt := range []struct {
+		spec  string
+		state types.SpecState
+	}{
+		// Passes on retry.
+		{"flaky", types.SpecStateFailed},
+		{"flaky", types.SpecStatePassed},
+		// Fails every attempt.
+		{"broken", types.SpecStateFailed},
+		{"broken", types.SpecStateFailed},
+		{"broken", types.SpecStatePanicked},
+		{"stable", types.SpecStatePassed},
+	} {
+		summary := &types.SpecSummary{ComponentTexts: []string{"[Top Level]", "[k8s.io] Pod", attempt.spec}, State: attempt.state}
+		reporter.SpecWillRun(summary)
+		reporter.SpecDidComplete(summary)
+	}
+
+	var states []string
+	for _, r := range inner.completed {
+		states = append(states, r.Name+": "+r.State)
+	}
+	expected := []string{"[k8s.io] Pod flaky: flaky", "[k8s.io] Pod broken: panicked", "[k8s.io] Pod stable: passed"}
+	if !reflect.DeepEqual(states, expected) {
+		t.Errorf("expected %v, got %v", expected, states)
+	}
+
+	counter := make(flakeCounter)
+	for i, r := range inner.completed {
+		counter.add(&types.SpecSummary{ComponentTexts: []string{"[Top Level]", "[k8s.io] Pod", expected[i]}, State: types.SpecStatePassed}, r)
+	}
+	flakes := counter.flaky()
+	if len(flakes) != 1 || flakes[0].Suite != "[k8s.io] Pod" || flakes[0].Flaky != 1 || flakes[0].Specs != 3 {
+		t.Errorf("unexpected flakes %+v", flakes)
+	}
+}
@@ -55,6 +55,10 @@ func NewCRIFramework(client *InternalAPIClient) *Framework {
 
 // BeforeEach gets a client
 func (f *Framework) BeforeEach() {
+	markSpecStart()
+	startSpecTrace(CurrentGinkgoTestDescription().FullTestText)
+	Expect(LoadTestImages()).To(Succeed())
+
 	if f.CRIClient == nil {
 		c, err := LoadCRIClient()
 		Expect(err).NotTo(HaveOccurred())
@@ -64,6 +68,7 @@ func (f *Framework) BeforeEach() {
 
 // AfterEach clean resources
 func (f *Framework) AfterEach() {
+	stopSpecTrace()
 	f.CRIClient = nil
 }
 
@@ -0,0 +1,116 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package helpers provides the container helpers of the critest validation
+// specs, for runtimes writing their own extension suites on top of critest.
+//
+// The helpers run inside ginkgo specs: they report their steps with By and
+// fail the current spec on errors. Their signatures are stable: they are
+// only changed in a backwards compatible way, and the helpers are deprecated
+// for at least one release before being removed.
+package helpers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// DefaultLog is the line logged by the containers of CreateLogContainer.
+const DefaultLog = "hello World"
+
+// HostPathFlagFile is the name of the file created by CreateHostPath.
+const HostPathFlagFile = "testVolume.file"
+
+// GetContainerStatus gets the status of the container containerID, and fails
+// if it gets an error.
+func GetContainerStatus(c internalapi.RuntimeService, containerID string) *runtimeapi.ContainerStatus {
+	By("Get container status for containerID: " + containerID)
+	status, err := c.ContainerStatus(containerID)
+	framework.ExpectNoError(err, "failed to get container %q status: %v", containerID, err)
+	return status
+}
+
+// WaitContainerExited waits for the container containerID to exit, and
+// returns its status.
+func WaitContainerExited(c internalapi.RuntimeService, containerID string) *runtimeapi.ContainerStatus {
+	var containerStatus *runtimeapi.ContainerStatus
+	Eventually(func() runtimeapi.ContainerState {
+		containerStatus = GetContainerStatus(c, containerID)
+		return containerStatus.State
+	}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_EXITED))
+	return containerStatus
+}
+
+// StopContainer stops the container containerID with timeout seconds of
+// grace period. It fails if the runtime doesn't return within the grace
+// period, rather than letting a hung StopContainer hang the spec.
+func StopContainer(c internalapi.RuntimeService, containerID string, timeout int64) {
+	By("Stop container for containerID: " + containerID)
+	stopped := make(chan bool, 1)
+
+	go func() {
+		defer GinkgoRecover()
+		err := c.StopContainer(containerID, timeout)
+		framework.ExpectNoError(err, "failed to stop container: %v", err)
+		stopped <- true
+	}()
+
+	select {
+	case <-time.After(time.Duration(timeout) * time.Second):
+		framework.Failf("stop container %q timeout.\n", containerID)
+	case <-stopped:
+		framework.Logf("Stopped container %q\n", containerID)
+	}
+}
+
+// CreateHostPath creates a temporary directory on the host for the pod
+// podID, to be mounted in containers, with an empty HostPathFlagFile file in
+// it. It returns the directory and the name of the file.
+func CreateHostPath(podID string) (hostPath string, flagFile string) {
+	hostPath, err := ioutil.TempDir("", "test"+podID)
+	framework.ExpectNoError(err, "failed to create TempDir %q: %v", hostPath, err)
+
+	flagFile = HostPathFlagFile
+	_, err = os.Create(filepath.Join(hostPath, flagFile))
+	framework.ExpectNoError(err, "failed to create volume file %q: %v", flagFile, err)
+
+	return hostPath, flagFile
+}
+
+// CreateLogContainer creates a container named with prefix in the pod podID,
+// which logs DefaultLog. It returns the log path of the container, relative
+// to the log directory of the pod, and the container ID.
+func CreateLogContainer(rc internalapi.RuntimeService, ic internalapi.ImageManagerService, prefix string, podID string, podConfig *runtimeapi.PodSandboxConfig) (logPath string, containerID string) {
+	By("create a container with log and name")
+	containerName := prefix + framework.NewUUID()
+	containerConfig := &runtimeapi.ContainerConfig{
+		Metadata: framework.BuildContainerMetadata(containerName, framework.DefaultAttempt),
+		Image:    &runtimeapi.ImageSpec{Image: framework.DefaultContainerImage},
+		Command:  []string{"echo", DefaultLog},
+		LogPath:  fmt.Sprintf("%s.log", containerName),
+	}
+	return containerConfig.LogPath, framework.CreateContainer(rc, ic, containerConfig, podID, podConfig)
+}
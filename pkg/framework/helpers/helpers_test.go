@@ -0,0 +1,82 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework/fakeruntime"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/onsi/gomega"
+)
+
+func TestContainerHelpers(t *testing.T) {
+	gomega.RegisterTestingT(t)
+	framework.TestContext.StateTimeout = time.Second
+	framework.TestContext.PollInterval = 10 * time.Millisecond
+
+	r := fakeruntime.New()
+	if _, err := r.PullImage(&runtimeapi.ImageSpec{Image: framework.DefaultContainerImage}, nil); err != nil {
+		t.Fatalf("failed to pull the image: %v", err)
+	}
+	podConfig := &runtimeapi.PodSandboxConfig{
+		Metadata: framework.BuildPodSandboxMetadata("pod", "uid", "namespace", framework.DefaultAttempt),
+	}
+	podID, err := r.RunPodSandbox(podConfig)
+	if err != nil {
+		t.Fatalf("failed to run the pod: %v", err)
+	}
+
+	logPath, containerID := CreateLogContainer(r, r, "log-container-", podID, podConfig)
+	if !strings.HasPrefix(logPath, "log-container-") || !strings.HasSuffix(logPath, ".log") {
+		t.Errorf("expected a log path named after the container; actual log path is %q", logPath)
+	}
+	status := GetContainerStatus(r, containerID)
+	if status.State != runtimeapi.ContainerState_CONTAINER_CREATED {
+		t.Errorf("expected a created container; actual state is %s", status.State)
+	}
+	if status.GetMetadata().GetName()+".log" != logPath {
+		t.Errorf("expected the log path of container %q; actual log path is %q", status.GetMetadata().GetName(), logPath)
+	}
+
+	if err := r.StartContainer(containerID); err != nil {
+		t.Fatalf("failed to start the container: %v", err)
+	}
+	StopContainer(r, containerID, 10)
+	status = WaitContainerExited(r, containerID)
+	if status.ExitCode != 0 {
+		t.Errorf("expected the container to exit successfully; actual exit code is %d", status.ExitCode)
+	}
+}
+
+func TestCreateHostPath(t *testing.T) {
+	gomega.RegisterTestingT(t)
+	hostPath, flagFile := CreateHostPath("pod")
+	defer os.RemoveAll(hostPath)
+	if flagFile != HostPathFlagFile {
+		t.Errorf("expected flag file %q; actual flag file is %q", HostPathFlagFile, flagFile)
+	}
+	if _, err := os.Stat(filepath.Join(hostPath, flagFile)); err != nil {
+		t.Errorf("expected the flag file to be created: %v", err)
+	}
+}
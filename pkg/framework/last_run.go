@@ -0,0 +1,153 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+)
+
+// lastRunPattern matches the results files of the test nodes in the last run
+// directory.
+const lastRunPattern = "results_*.json"
+
+// LastRunReporter is a ginkgo reporter recording the results of the specs
+// which ran in the last run directory, to rerun the failed ones. Each test
+// node writes its own file.
+type LastRunReporter struct {
+	path    string
+	results SuiteResults
+}
+
+// NewLastRunReporter creates a LastRunReporter writing the results of the
+// test node to dir.
+func NewLastRunReporter(dir string, node int) *LastRunReporter {
+	return &LastRunReporter{
+		path:    filepath.Join(dir, fmt.Sprintf("results_%02d.json", node)),
+		results: SuiteResults{Specs: []SpecResult{}},
+	}
+}
+
+// SpecSuiteWillBegin records the start of the suite.
+func (r *LastRunReporter) SpecSuiteWillBegin(_ config.GinkgoConfigType, summary *types.SuiteSummary) {
+	r.results.Suite = summary.SuiteDescription
+	r.results.Start = time.Now()
+}
+
+// BeforeSuiteDidRun implements ginkgo.Reporter.
+func (r *LastRunReporter) BeforeSuiteDidRun(*types.SetupSummary) {}
+
+// SpecWillRun implements ginkgo.Reporter.
+func (r *LastRunReporter) SpecWillRun(*types.SpecSummary) {}
+
+// SpecDidComplete records the result of the spec, if it ran.
+func (r *LastRunReporter) SpecDidComplete(summary *types.SpecSummary) {
+	if summary.Skipped() || summary.State == types.SpecStatePending {
+		return
+	}
+	r.results.Specs = append(r.results.Specs, newSpecResult(summary))
+}
+
+// AfterSuiteDidRun implements ginkgo.Reporter.
+func (r *LastRunReporter) AfterSuiteDidRun(*types.SetupSummary) {}
+
+// SpecSuiteDidEnd writes the results.
+func (r *LastRunReporter) SpecSuiteDidEnd(summary *types.SuiteSummary) {
+	r.results.Duration = summary.RunTime.Seconds()
+	if err := r.write(); err != nil {
+		Logf("Failed to record the results of the run: %v", err)
+	}
+}
+
+func (r *LastRunReporter) write() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(r.results, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+// ClearLastRun removes the results of the last run from dir, before a new
+// run records its own.
+func ClearLastRun(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, lastRunPattern))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LastRunFailures returns the names of the specs which failed in the last
+// run recorded in dir, sorted.
+func LastRunFailures(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, lastRunPattern))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no results of a previous run in %s", dir)
+	}
+	failed := make(map[string]bool)
+	for _, path := range paths {
+		results, err := LoadSuiteResults(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range results.Specs {
+			switch s.State {
+			case "failed", "panicked", "timedout":
+				failed[s.Name] = true
+			}
+		}
+	}
+	var names []string
+	for name := range failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// RerunFocus returns the ginkgo focus regular expression matching exactly
+// the specs named names.
+func RerunFocus(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = regexp.QuoteMeta(name)
+	}
+	// The focus matches the suite description and the top level container
+	// followed by the spec name.
+	return `(^| )(` + strings.Join(quoted, "|") + `)$`
+}
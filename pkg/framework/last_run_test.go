@@ -0,0 +1,84 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+)
+
+func TestLastRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "last-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := LastRunFailures(dir); err == nil {
+		t.Errorf("expected an error without a previous run")
+	}
+	for node, states := range map[int][]types.SpecState{
+		1: {types.SpecStatePassed, types.SpecStateFailed},
+		2: {types.SpecStateSkipped, types.SpecStateTimedOut},
+	} {
+		reporter := NewLastRunReporter(dir, node)
+		reporter.SpecSuiteWillBegin(config.GinkgoConfigType{}, &types.SuiteSummary{SuiteDescription: "CRI validation"})
+		for i, state := range states {
+			reporter.SpecDidComplete(&types.SpecSummary{
+				ComponentTexts: []string{"[Top Level]", "[k8s.io] Pod", fmt.Sprintf("%s spec (node %d)", []string{"first", "second"}[i], node)},
+				State:          state,
+			})
+		}
+		reporter.SpecSuiteDidEnd(&types.SuiteSummary{RunTime: time.Second})
+	}
+
+	failed, err := LastRunFailures(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"[k8s.io] Pod second spec (node 1)", "[k8s.io] Pod second spec (node 2)"}
+	if !reflect.DeepEqual(failed, expected) {
+		t.Errorf("expected failed specs %v, got %v", expected, failed)
+	}
+
+	focus := regexp.MustCompile(RerunFocus(failed))
+	for text, matches := range map[string]bool{
+		"CRI validation [Top Level] [k8s.io] Pod second spec (node 1)":       true,
+		"CRI validation [Top Level] [k8s.io] Pod second spec (node 2)":       true,
+		"CRI validation [Top Level] [k8s.io] Pod first spec (node 1)":        false,
+		"CRI validation [Top Level] [k8s.io] Pod second spec (node 1) again": false,
+	} {
+		if focus.MatchString(text) != matches {
+			t.Errorf("%q: expected the focus to match: %v", text, matches)
+		}
+	}
+
+	if err := ClearLastRun(dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := LastRunFailures(dir); err == nil {
+		t.Errorf("expected an error once the last run is cleared")
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+)
+
+// SpecInfo describes a spec for critest -list.
+type SpecInfo struct {
+	// Name is the full text of the spec.
+	Name string `json:"name"`
+	// Conformance is whether the spec is tagged [Conformance].
+	Conformance bool `json:"conformance"`
+	// Capabilities are the runtime capabilities required by the spec.
+	Capabilities []Capability `json:"capabilities"`
+}
+
+// ListReporter is a ginkgo reporter collecting the specs which would run,
+// meant to be used with a dry run.
+type ListReporter struct {
+	specs []SpecInfo
+}
+
+// NewListReporter creates a ListReporter.
+func NewListReporter() *ListReporter {
+	return &ListReporter{specs: []SpecInfo{}}
+}
+
+// Specs returns the collected specs sorted by name.
+func (r *ListReporter) Specs() []SpecInfo {
+	sort.Slice(r.specs, func(i, j int) bool { return r.specs[i].Name < r.specs[j].Name })
+	return r.specs
+}
+
+// SpecDidComplete collects the specs which are not skipped.
+func (r *ListReporter) SpecDidComplete(summary *types.SpecSummary) {
+	if summary.State != types.SpecStatePassed {
+		return
+	}
+	// The first component is the top level container.
+	name := strings.Join(summary.ComponentTexts[1:], " ")
+	r.specs = append(r.specs, SpecInfo{
+		Name:         name,
+		Conformance:  strings.Contains(name, "[Conformance]"),
+		Capabilities: specCapabilities(name),
+	})
+}
+
+// SpecSuiteWillBegin implements ginkgo.Reporter.
+func (r *ListReporter) SpecSuiteWillBegin(config.GinkgoConfigType, *types.SuiteSummary) {}
+
+// BeforeSuiteDidRun implements ginkgo.Reporter.
+func (r *ListReporter) BeforeSuiteDidRun(*types.SetupSummary) {}
+
+// SpecWillRun implements ginkgo.Reporter.
+func (r *ListReporter) SpecWillRun(*types.SpecSummary) {}
+
+// AfterSuiteDidRun implements ginkgo.Reporter.
+func (r *ListReporter) AfterSuiteDidRun(*types.SetupSummary) {}
+
+// SpecSuiteDidEnd implements ginkgo.Reporter.
+func (r *ListReporter) SpecSuiteDidEnd(*types.SuiteSummary) {}
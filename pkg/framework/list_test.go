@@ -0,0 +1,46 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/onsi/ginkgo/types"
+)
+
+func TestListReporter(t *testing.T) {
+	RequireCapabilities("Listed", CapabilityStats)
+	defer delete(requiredCapabilities, "[k8s.io] Listed")
+
+	reporter := NewListReporter()
+	for _, summary := range []*types.SpecSummary{
+		{ComponentTexts: []string{"[Top Level]", "[k8s.io] Listed", "spec b"}, State: types.SpecStatePassed},
+		{ComponentTexts: []string{"[Top Level]", "[k8s.io] Other", "spec a [Conformance]"}, State: types.SpecStatePassed},
+		{ComponentTexts: []string{"[Top Level]", "[k8s.io] Listed", "skipped spec"}, State: types.SpecStateSkipped},
+	} {
+		reporter.SpecDidComplete(summary)
+	}
+
+	expected := []SpecInfo{
+		{Name: "[k8s.io] Listed spec b", Conformance: false, Capabilities: []Capability{CapabilityStats}},
+		{Name: "[k8s.io] Other spec a [Conformance]", Conformance: true, Capabilities: []Capability{}},
+	}
+	if specs := reporter.Specs(); !reflect.DeepEqual(specs, expected) {
+		t.Errorf("expected specs %+v, got %+v", expected, specs)
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// logSequenceDigits is the width of the sequence number of the lines
+	// emitted by LogSequenceCommand.
+	logSequenceDigits = 8
+	// minLogSequenceLineSize is the size of a line holding only the
+	// sequence number, its separator and the newline.
+	minLogSequenceLineSize = logSequenceDigits + 2
+	// logSequencePadding is the character filling the lines.
+	logSequencePadding = 'x'
+)
+
+// LogSequenceCommand returns the command of a container writing lines
+// sequence-numbered lines of lineSize bytes, newline included, to stdout as
+// fast as possible.
+func LogSequenceCommand(lines, lineSize int) ([]string, error) {
+	if lines < 1 {
+		return nil, fmt.Errorf("invalid number of lines %d", lines)
+	}
+	if lineSize < minLogSequenceLineSize {
+		return nil, fmt.Errorf("invalid line size %d, should be at least %d", lineSize, minLogSequenceLineSize)
+	}
+	padding := strings.Repeat(string(logSequencePadding), lineSize-minLogSequenceLineSize)
+	program := fmt.Sprintf(`BEGIN { for (i = 0; i < %d; i++) printf "%%0%dd %s\n", i }`, lines, logSequenceDigits, padding)
+	return []string{"awk", program}, nil
+}
+
+// LogSequenceReport is the result of the check of a container log holding
+// the lines written by LogSequenceCommand.
+type LogSequenceReport struct {
+	// Received is the number of lines found in the log.
+	Received int
+	// Missing is the number of lines not found in the log, e.g. because it
+	// was truncated.
+	Missing int
+	// Duplicated is the number of lines found more than once.
+	Duplicated int
+	// OutOfOrder is the number of lines found before a line they follow.
+	OutOfOrder int
+	// Corrupted is the number of lines whose content is not the expected
+	// one, e.g. because writes were interleaved.
+	Corrupted int
+}
+
+// OK returns whether all the lines were found once, in order and intact.
+func (r LogSequenceReport) OK() bool {
+	return r.Missing == 0 && r.Duplicated == 0 && r.OutOfOrder == 0 && r.Corrupted == 0
+}
+
+// String summarizes the report.
+func (r LogSequenceReport) String() string {
+	return fmt.Sprintf("received %d lines, %d missing, %d duplicated, %d out of order, %d corrupted",
+		r.Received, r.Missing, r.Duplicated, r.OutOfOrder, r.Corrupted)
+}
+
+// CheckLogSequence reads a container log, in CRI or Docker JSON format, and
+// checks that its stdout holds the lines lines of lineSize bytes written by
+// LogSequenceCommand. Partial log entries are joined before being checked.
+func CheckLogSequence(r io.Reader, lines, lineSize int) (LogSequenceReport, error) {
+	var report LogSequenceReport
+	seen := make([]bool, lines)
+	next := 0
+	partial := ""
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		stream, content, complete, err := parseLogEntry(scanner.Text())
+		if err != nil {
+			return report, err
+		}
+		if stream != "stdout" {
+			continue
+		}
+		partial += content
+		if !complete {
+			continue
+		}
+		line := partial
+		partial = ""
+
+		report.Received++
+		seq, ok := parseLogSequenceLine(line, lineSize)
+		if !ok || seq >= lines {
+			report.Corrupted++
+			continue
+		}
+		if seen[seq] {
+			report.Duplicated++
+			continue
+		}
+		seen[seq] = true
+		if seq < next {
+			report.OutOfOrder++
+		}
+		next = seq + 1
+	}
+	if err := scanner.Err(); err != nil {
+		return report, err
+	}
+	if partial != "" {
+		// The last entry was never completed.
+		report.Received++
+		report.Corrupted++
+	}
+	for _, found := range seen {
+		if !found {
+			report.Missing++
+		}
+	}
+	return report, nil
+}
+
+// parseLogSequenceLine returns the sequence number of line, without its
+// newline, and whether it has the expected content.
+func parseLogSequenceLine(line string, lineSize int) (int, bool) {
+	if len(line) != lineSize-1 || line[logSequenceDigits] != ' ' {
+		return 0, false
+	}
+	seq, err := strconv.Atoi(line[:logSequenceDigits])
+	if err != nil || seq < 0 {
+		return 0, false
+	}
+	for i := logSequenceDigits + 1; i < len(line); i++ {
+		if line[i] != logSequencePadding {
+			return 0, false
+		}
+	}
+	return seq, true
+}
+
+// parseLogEntry parses a log entry in CRI or Docker JSON format, and returns
+// its stream, its content without the newline, and whether the entry ends a
+// line.
+func parseLogEntry(entry string) (string, string, bool, error) {
+	if strings.HasPrefix(entry, "{") {
+		var l struct {
+			Log    string `json:"log"`
+			Stream string `json:"stream"`
+		}
+		if err := json.Unmarshal([]byte(entry), &l); err != nil {
+			return "", "", false, fmt.Errorf("invalid Docker JSON log entry %q: %v", entry, err)
+		}
+		if strings.HasSuffix(l.Log, "\n") {
+			return l.Stream, strings.TrimSuffix(l.Log, "\n"), true, nil
+		}
+		return l.Stream, l.Log, false, nil
+	}
+	// The CRI log format is "<timestamp> <stream> <tag> <content>", the tag
+	// being P for partial entries and F for the last entry of a line.
+	fields := strings.SplitN(entry, " ", 4)
+	if len(fields) < 3 {
+		return "", "", false, fmt.Errorf("invalid CRI log entry %q", entry)
+	}
+	content := ""
+	if len(fields) == 4 {
+		content = fields[3]
+	}
+	return fields[1], content, fields[2] != "P", nil
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLogSequenceCommand(t *testing.T) {
+	command, err := LogSequenceCommand(3, 16)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `BEGIN { for (i = 0; i < 3; i++) printf "%08d xxxxxx\n", i }`
+	if len(command) != 2 || command[0] != "awk" || command[1] != expected {
+		t.Errorf("expected awk %q, got %q", expected, command)
+	}
+	if _, err := LogSequenceCommand(0, 16); err == nil {
+		t.Error("expected an error for no lines")
+	}
+	if _, err := LogSequenceCommand(3, 9); err == nil {
+		t.Error("expected an error for too short lines")
+	}
+}
+
+func TestCheckLogSequence(t *testing.T) {
+	// Lines of 16 bytes: 8 digits, a space, 6 padding characters and the
+	// newline.
+	cri := func(tag, content string) string {
+		return "2018-10-06T00:17:09.669794202Z stdout " + tag + " " + content
+	}
+	testCases := []struct {
+		desc     string
+		log      []string
+		expected LogSequenceReport
+	}{
+		{
+			desc: "complete CRI log",
+			log: []string{
+				cri("F", "00000000 xxxxxx"),
+				"2018-10-06T00:17:09.669794202Z stderr F ignored",
+				cri("F", "00000001 xxxxxx"),
+				cri("F", "00000002 xxxxxx"),
+			},
+			expected: LogSequenceReport{Received: 3},
+		},
+		{
+			desc: "partial CRI entries",
+			log: []string{
+				cri("P", "00000000 xx"),
+				cri("F", "xxxx"),
+				cri("F", "00000001 xxxxxx"),
+				cri("F", "00000002 xxxxxx"),
+			},
+			expected: LogSequenceReport{Received: 3},
+		},
+		{
+			desc: "Docker JSON log",
+			log: []string{
+				`{"log":"00000000 xxxxxx\n","stream":"stdout","time":"2016-10-20T18:39:20.57606443Z"}`,
+				`{"log":"00000001 xxx","stream":"stdout","time":"2016-10-20T18:39:20.57606443Z"}`,
+				`{"log":"xxx\n","stream":"stdout","time":"2016-10-20T18:39:20.57606443Z"}`,
+				`{"log":"00000002 xxxxxx\n","stream":"stdout","time":"2016-10-20T18:39:20.57606443Z"}`,
+			},
+			expected: LogSequenceReport{Received: 3},
+		},
+		{
+			desc: "truncated log",
+			log: []string{
+				cri("F", "00000000 xxxxxx"),
+				cri("P", "00000001 xx"),
+			},
+			expected: LogSequenceReport{Received: 2, Missing: 2, Corrupted: 1},
+		},
+		{
+			desc: "duplicated and out of order lines",
+			log: []string{
+				cri("F", "00000001 xxxxxx"),
+				cri("F", "00000000 xxxxxx"),
+				cri("F", "00000001 xxxxxx"),
+				cri("F", "00000002 xxxxxx"),
+			},
+			expected: LogSequenceReport{Received: 4, Duplicated: 1, OutOfOrder: 1},
+		},
+		{
+			desc: "interleaved lines",
+			log: []string{
+				cri("F", "00000000 xxx00000001 xxxxxx"),
+				cri("F", "xxx"),
+				cri("F", "00000002 xxxxxx"),
+				cri("F", "00000009 xxxxxx"),
+			},
+			expected: LogSequenceReport{Received: 4, Missing: 2, Corrupted: 3},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			report, err := CheckLogSequence(strings.NewReader(strings.Join(tc.log, "\n")+"\n"), 3, 16)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if report != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, report)
+			}
+			if report.OK() != (tc.expected == LogSequenceReport{Received: 3}) {
+				t.Errorf("unexpected OK() %v for %v", report.OK(), report)
+			}
+		})
+	}
+
+	if _, err := CheckLogSequence(strings.NewReader("invalid\n"), 3, 16); err == nil {
+		t.Error("expected an error for an invalid log entry")
+	}
+}
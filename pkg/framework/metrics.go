@@ -0,0 +1,300 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/metrics"
+	"github.com/onsi/ginkgo/config"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+	utilexec "k8s.io/utils/exec"
+)
+
+// criMetrics records the CRI calls of the clients loaded by LoadCRIClient
+// once StartMetricsServer is called.
+var criMetrics *metrics.Registry
+
+// StartMetricsServer exports the latency and errors of the CRI calls as
+// Prometheus metrics on TestContext.MetricsAddress. Each parallel test node
+// listens on the port following the one of the previous node.
+func StartMetricsServer() error {
+	addr, err := nodeMetricsAddress(TestContext.MetricsAddress, config.GinkgoConfig.ParallelNode)
+	if err != nil {
+		return err
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %q: %v", addr, err)
+	}
+
+	criMetrics = metrics.NewRegistry(metrics.DefaultBuckets)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", criMetrics)
+	go http.Serve(l, mux)
+	Logf("Exporting CRI metrics on http://%s/metrics", l.Addr())
+	return nil
+}
+
+// nodeMetricsAddress returns the address of the metrics server of the
+// parallel test node, numbered from 1, offsetting the port of addr.
+func nodeMetricsAddress(addr string, node int) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid metrics address %q: %v", addr, err)
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return "", fmt.Errorf("invalid port in metrics address %q: %v", addr, err)
+	}
+	if node > 1 {
+		p += node - 1
+	}
+	return net.JoinHostPort(host, strconv.Itoa(p)), nil
+}
+
+// observer records the CRI calls in a metrics registry.
+type observer struct {
+	registry *metrics.Registry
+}
+
+// observe records the call of method started at start, which failed with err.
+func (o *observer) observe(method string, start time.Time, err error) {
+	o.registry.Observe(method, time.Since(start), err)
+}
+
+// metricsRuntimeService is a RuntimeService recording the metrics of its calls.
+type metricsRuntimeService struct {
+	observer
+	service internalapi.RuntimeService
+}
+
+// newMetricsRuntimeService wraps service to record its calls in registry.
+func newMetricsRuntimeService(service internalapi.RuntimeService, registry *metrics.Registry) internalapi.RuntimeService {
+	return &metricsRuntimeService{observer: observer{registry: registry}, service: service}
+}
+
+func (r *metricsRuntimeService) Version(apiVersion string) (*runtimeapi.VersionResponse, error) {
+	start := time.Now()
+	resp, err := r.service.Version(apiVersion)
+	r.observe("Version", start, err)
+	return resp, err
+}
+
+func (r *metricsRuntimeService) CreateContainer(podSandboxID string, config *runtimeapi.ContainerConfig, sandboxConfig *runtimeapi.PodSandboxConfig) (string, error) {
+	start := time.Now()
+	id, err := r.service.CreateContainer(podSandboxID, config, sandboxConfig)
+	r.observe("CreateContainer", start, err)
+	return id, err
+}
+
+func (r *metricsRuntimeService) StartContainer(containerID string) error {
+	start := time.Now()
+	err := r.service.StartContainer(containerID)
+	r.observe("StartContainer", start, err)
+	return err
+}
+
+func (r *metricsRuntimeService) StopContainer(containerID string, timeout int64) error {
+	start := time.Now()
+	err := r.service.StopContainer(containerID, timeout)
+	r.observe("StopContainer", start, err)
+	return err
+}
+
+func (r *metricsRuntimeService) RemoveContainer(containerID string) error {
+	start := time.Now()
+	err := r.service.RemoveContainer(containerID)
+	r.observe("RemoveContainer", start, err)
+	return err
+}
+
+func (r *metricsRuntimeService) ListContainers(filter *runtimeapi.ContainerFilter) ([]*runtimeapi.Container, error) {
+	start := time.Now()
+	containers, err := r.service.ListContainers(filter)
+	r.observe("ListContainers", start, err)
+	return containers, err
+}
+
+func (r *metricsRuntimeService) ContainerStatus(containerID string) (*runtimeapi.ContainerStatus, error) {
+	start := time.Now()
+	status, err := r.service.ContainerStatus(containerID)
+	r.observe("ContainerStatus", start, err)
+	return status, err
+}
+
+func (r *metricsRuntimeService) UpdateContainerResources(containerID string, resources *runtimeapi.LinuxContainerResources) error {
+	start := time.Now()
+	err := r.service.UpdateContainerResources(containerID, resources)
+	r.observe("UpdateContainerResources", start, err)
+	return err
+}
+
+func (r *metricsRuntimeService) ExecSync(containerID string, cmd []string, timeout time.Duration) ([]byte, []byte, error) {
+	start := time.Now()
+	stdout, stderr, err := r.service.ExecSync(containerID, cmd, timeout)
+	if _, ok := err.(utilexec.CodeExitError); ok {
+		// The call succeeded, the command failed.
+		r.observe("ExecSync", start, nil)
+	} else {
+		r.observe("ExecSync", start, err)
+	}
+	return stdout, stderr, err
+}
+
+func (r *metricsRuntimeService) Exec(req *runtimeapi.ExecRequest) (*runtimeapi.ExecResponse, error) {
+	start := time.Now()
+	resp, err := r.service.Exec(req)
+	r.observe("Exec", start, err)
+	return resp, err
+}
+
+func (r *metricsRuntimeService) Attach(req *runtimeapi.AttachRequest) (*runtimeapi.AttachResponse, error) {
+	start := time.Now()
+	resp, err := r.service.Attach(req)
+	r.observe("Attach", start, err)
+	return resp, err
+}
+
+func (r *metricsRuntimeService) ReopenContainerLog(containerID string) error {
+	start := time.Now()
+	err := r.service.ReopenContainerLog(containerID)
+	r.observe("ReopenContainerLog", start, err)
+	return err
+}
+
+func (r *metricsRuntimeService) RunPodSandbox(config *runtimeapi.PodSandboxConfig) (string, error) {
+	start := time.Now()
+	id, err := r.service.RunPodSandbox(config)
+	r.observe("RunPodSandbox", start, err)
+	return id, err
+}
+
+func (r *metricsRuntimeService) StopPodSandbox(podSandboxID string) error {
+	start := time.Now()
+	err := r.service.StopPodSandbox(podSandboxID)
+	r.observe("StopPodSandbox", start, err)
+	return err
+}
+
+func (r *metricsRuntimeService) RemovePodSandbox(podSandboxID string) error {
+	start := time.Now()
+	err := r.service.RemovePodSandbox(podSandboxID)
+	r.observe("RemovePodSandbox", start, err)
+	return err
+}
+
+func (r *metricsRuntimeService) PodSandboxStatus(podSandboxID string) (*runtimeapi.PodSandboxStatus, error) {
+	start := time.Now()
+	status, err := r.service.PodSandboxStatus(podSandboxID)
+	r.observe("PodSandboxStatus", start, err)
+	return status, err
+}
+
+func (r *metricsRuntimeService) ListPodSandbox(filter *runtimeapi.PodSandboxFilter) ([]*runtimeapi.PodSandbox, error) {
+	start := time.Now()
+	pods, err := r.service.ListPodSandbox(filter)
+	r.observe("ListPodSandbox", start, err)
+	return pods, err
+}
+
+func (r *metricsRuntimeService) PortForward(req *runtimeapi.PortForwardRequest) (*runtimeapi.PortForwardResponse, error) {
+	start := time.Now()
+	resp, err := r.service.PortForward(req)
+	r.observe("PortForward", start, err)
+	return resp, err
+}
+
+func (r *metricsRuntimeService) ContainerStats(containerID string) (*runtimeapi.ContainerStats, error) {
+	start := time.Now()
+	stats, err := r.service.ContainerStats(containerID)
+	r.observe("ContainerStats", start, err)
+	return stats, err
+}
+
+func (r *metricsRuntimeService) ListContainerStats(filter *runtimeapi.ContainerStatsFilter) ([]*runtimeapi.ContainerStats, error) {
+	start := time.Now()
+	stats, err := r.service.ListContainerStats(filter)
+	r.observe("ListContainerStats", start, err)
+	return stats, err
+}
+
+func (r *metricsRuntimeService) UpdateRuntimeConfig(runtimeConfig *runtimeapi.RuntimeConfig) error {
+	start := time.Now()
+	err := r.service.UpdateRuntimeConfig(runtimeConfig)
+	r.observe("UpdateRuntimeConfig", start, err)
+	return err
+}
+
+func (r *metricsRuntimeService) Status() (*runtimeapi.RuntimeStatus, error) {
+	start := time.Now()
+	status, err := r.service.Status()
+	r.observe("Status", start, err)
+	return status, err
+}
+
+// metricsImageService is an ImageManagerService recording the metrics of
+// its calls.
+type metricsImageService struct {
+	observer
+	service internalapi.ImageManagerService
+}
+
+// newMetricsImageService wraps service to record its calls in registry.
+func newMetricsImageService(service internalapi.ImageManagerService, registry *metrics.Registry) internalapi.ImageManagerService {
+	return &metricsImageService{observer: observer{registry: registry}, service: service}
+}
+
+func (r *metricsImageService) ListImages(filter *runtimeapi.ImageFilter) ([]*runtimeapi.Image, error) {
+	start := time.Now()
+	images, err := r.service.ListImages(filter)
+	r.observe("ListImages", start, err)
+	return images, err
+}
+
+func (r *metricsImageService) ImageStatus(image *runtimeapi.ImageSpec) (*runtimeapi.Image, error) {
+	start := time.Now()
+	status, err := r.service.ImageStatus(image)
+	r.observe("ImageStatus", start, err)
+	return status, err
+}
+
+func (r *metricsImageService) PullImage(image *runtimeapi.ImageSpec, auth *runtimeapi.AuthConfig) (string, error) {
+	start := time.Now()
+	ref, err := r.service.PullImage(image, auth)
+	r.observe("PullImage", start, err)
+	return ref, err
+}
+
+func (r *metricsImageService) RemoveImage(image *runtimeapi.ImageSpec) error {
+	start := time.Now()
+	err := r.service.RemoveImage(image)
+	r.observe("RemoveImage", start, err)
+	return err
+}
+
+func (r *metricsImageService) ImageFsInfo() ([]*runtimeapi.FilesystemUsage, error) {
+	start := time.Now()
+	usage, err := r.service.ImageFsInfo()
+	r.observe("ImageFsInfo", start, err)
+	return usage, err
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import "testing"
+
+func TestNodeMetricsAddress(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		addr      string
+		node      int
+		expected  string
+		expectErr bool
+	}{
+		{desc: "single node", addr: ":9090", node: 1, expected: ":9090"},
+		{desc: "unset node", addr: "localhost:9090", node: 0, expected: "localhost:9090"},
+		{desc: "third node", addr: "127.0.0.1:9090", node: 3, expected: "127.0.0.1:9092"},
+		{desc: "missing port", addr: "localhost", node: 1, expectErr: true},
+		{desc: "invalid port", addr: ":http", node: 1, expectErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			addr, err := nodeMetricsAddress(tc.addr, tc.node)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error: %v; actual error is %v", tc.expectErr, err)
+			}
+			if addr != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, addr)
+			}
+		})
+	}
+}
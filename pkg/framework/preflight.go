@@ -0,0 +1,54 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"fmt"
+	"strings"
+
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// GetRuntimeCondition returns the runtime condition of conditionType, or nil
+// if it is not reported.
+func GetRuntimeCondition(status *runtimeapi.RuntimeStatus, conditionType string) *runtimeapi.RuntimeCondition {
+	for _, condition := range status.GetConditions() {
+		if condition.Type == conditionType {
+			return condition
+		}
+	}
+	return nil
+}
+
+// checkRuntimeConditions returns an error describing the required runtime
+// conditions which are missing or false.
+func checkRuntimeConditions(status *runtimeapi.RuntimeStatus) error {
+	var notReady []string
+	for _, conditionType := range []string{runtimeapi.RuntimeReady, runtimeapi.NetworkReady} {
+		condition := GetRuntimeCondition(status, conditionType)
+		switch {
+		case condition == nil:
+			notReady = append(notReady, fmt.Sprintf("%s is not reported", conditionType))
+		case !condition.Status:
+			notReady = append(notReady, fmt.Sprintf("%s=false (reason: %q, message: %q)", conditionType, condition.Reason, condition.Message))
+		}
+	}
+	if len(notReady) > 0 {
+		return fmt.Errorf("runtime is not ready: %s", strings.Join(notReady, "; "))
+	}
+	return nil
+}
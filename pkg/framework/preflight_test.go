@@ -0,0 +1,63 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"testing"
+
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+func TestCheckRuntimeConditions(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		conditions  []*runtimeapi.RuntimeCondition
+		expectError bool
+	}{
+		{
+			"ready runtime should pass",
+			[]*runtimeapi.RuntimeCondition{
+				{Type: runtimeapi.RuntimeReady, Status: true},
+				{Type: runtimeapi.NetworkReady, Status: true},
+			},
+			false,
+		},
+		{
+			"network not ready should fail",
+			[]*runtimeapi.RuntimeCondition{
+				{Type: runtimeapi.RuntimeReady, Status: true},
+				{Type: runtimeapi.NetworkReady, Status: false, Reason: "NetworkPluginNotReady"},
+			},
+			true,
+		},
+		{
+			"missing condition should fail",
+			[]*runtimeapi.RuntimeCondition{
+				{Type: runtimeapi.RuntimeReady, Status: true},
+			},
+			true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := checkRuntimeConditions(&runtimeapi.RuntimeStatus{Conditions: tc.conditions})
+			if (err != nil) != tc.expectError {
+				t.Errorf("expected error: %v; actual error is %v", tc.expectError, err)
+			}
+		})
+	}
+}
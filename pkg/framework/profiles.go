@@ -0,0 +1,212 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+
+	. "github.com/onsi/ginkgo"
+)
+
+// Behavior is a runtime behavior checked by specs, which some runtimes
+// don't support by design.
+type Behavior string
+
+const (
+	// BehaviorHostNetwork is running pods in the network namespace of the
+	// host.
+	BehaviorHostNetwork Behavior = "host-network"
+	// BehaviorHostNamespaces is running pods in the PID or IPC namespace of
+	// the host.
+	BehaviorHostNamespaces Behavior = "host-namespaces"
+	// BehaviorDevices is passing host devices through to containers.
+	BehaviorDevices Behavior = "devices"
+)
+
+// RuntimeProfile describes the behaviors a runtime doesn't support. The
+// failures of the specs checking them are recorded as deviations instead of
+// failing the specs.
+type RuntimeProfile struct {
+	Name string
+	// RuntimeNames are the runtime names reported by Version which select
+	// the profile when -runtime-profile isn't set. Runtimes without one,
+	// such as the VM based runtimes running under containerd or CRI-O, have
+	// to be selected with -runtime-profile.
+	RuntimeNames []string
+	// Tolerated are the behaviors the runtime doesn't support.
+	Tolerated []Behavior
+}
+
+// tolerates returns whether the runtime of the profile doesn't support
+// behavior.
+func (p *RuntimeProfile) tolerates(behavior Behavior) bool {
+	for _, b := range p.Tolerated {
+		if b == behavior {
+			return true
+		}
+	}
+	return false
+}
+
+// runtimeProfiles are the known runtime profiles.
+var runtimeProfiles = []*RuntimeProfile{
+	{Name: "containerd", RuntimeNames: []string{"containerd"}},
+	{Name: "cri-o", RuntimeNames: []string{"cri-o"}},
+	{Name: "pouch", RuntimeNames: []string{"pouch"}},
+	{Name: "kata", Tolerated: []Behavior{BehaviorHostNetwork, BehaviorHostNamespaces, BehaviorDevices}},
+	{Name: "gvisor", Tolerated: []Behavior{BehaviorHostNamespaces, BehaviorDevices}},
+}
+
+// RuntimeProfiles returns the names of the known runtime profiles.
+func RuntimeProfiles() []string {
+	var names []string
+	for _, p := range runtimeProfiles {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// LookupRuntimeProfile returns the runtime profile named name.
+func LookupRuntimeProfile(name string) (*RuntimeProfile, error) {
+	for _, p := range runtimeProfiles {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown runtime profile %q, expected one of %s", name, strings.Join(RuntimeProfiles(), ", "))
+}
+
+// detectRuntimeProfile returns the profile of the runtime named
+// runtimeName, nil if none matches.
+func detectRuntimeProfile(runtimeName string) *RuntimeProfile {
+	for _, p := range runtimeProfiles {
+		for _, name := range p.RuntimeNames {
+			if strings.EqualFold(name, runtimeName) {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+// Deviation is the failure of a spec checking a behavior the runtime profile
+// tolerates.
+type Deviation struct {
+	Spec     string   `json:"spec"`
+	Profile  string   `json:"profile"`
+	Behavior Behavior `json:"behavior"`
+	Message  string   `json:"message"`
+}
+
+var (
+	profileLock sync.Mutex
+	// profileResolved is whether activeProfile was resolved, nil meaning
+	// that no profile matches the runtime.
+	profileResolved bool
+	activeProfile   *RuntimeProfile
+	// toleratedSpecs are the behaviors tolerated by the specs, by full text.
+	toleratedSpecs = make(map[string]Behavior)
+	// deviations are the deviations recorded, by spec full text.
+	deviations = make(map[string]Deviation)
+)
+
+// getRuntimeProfile returns the profile set by -runtime-profile, or else
+// the one matching the name of the runtime. Either is only resolved once.
+func getRuntimeProfile(c internalapi.RuntimeService) *RuntimeProfile {
+	profileLock.Lock()
+	defer profileLock.Unlock()
+	if profileResolved {
+		return activeProfile
+	}
+	profileResolved = true
+	if name := TestContext.RuntimeProfile; name != "" {
+		p, err := LookupRuntimeProfile(name)
+		if err != nil {
+			Failf("%v", err)
+		}
+		activeProfile = p
+	} else if version, err := c.Version(reportAPIVersion); err != nil {
+		Logf("Failed to get the runtime version to detect its profile: %v", err)
+	} else {
+		activeProfile = detectRuntimeProfile(version.RuntimeName)
+	}
+	if activeProfile != nil {
+		Logf("Runtime profile: %s", activeProfile.Name)
+	}
+	return activeProfile
+}
+
+// Tolerate declares that the current spec checks behavior. If the runtime
+// profile tolerates it, a failure of the spec is recorded as a deviation,
+// and the spec is skipped instead of failed.
+func Tolerate(c internalapi.RuntimeService, behavior Behavior) {
+	p := getRuntimeProfile(c)
+	if p == nil || !p.tolerates(behavior) {
+		return
+	}
+	profileLock.Lock()
+	defer profileLock.Unlock()
+	toleratedSpecs[CurrentGinkgoTestDescription().FullTestText] = behavior
+}
+
+// recordDeviation records the failure of spec as a deviation if its runtime
+// profile tolerates the behavior it checks, and returns whether it did.
+func recordDeviation(spec, message string) (Deviation, bool) {
+	profileLock.Lock()
+	defer profileLock.Unlock()
+	behavior, ok := toleratedSpecs[spec]
+	if !ok {
+		return Deviation{}, false
+	}
+	d := Deviation{
+		Spec:     spec,
+		Profile:  activeProfile.Name,
+		Behavior: behavior,
+		Message:  message,
+	}
+	deviations[spec] = d
+	return d, true
+}
+
+// specDeviation returns the deviation recorded for spec, if any.
+func specDeviation(spec string) (Deviation, bool) {
+	profileLock.Lock()
+	defer profileLock.Unlock()
+	d, ok := deviations[spec]
+	return d, ok
+}
+
+// LogDeviations logs a summary of the deviations from the expected runtime
+// behaviors tolerated by the runtime profile.
+func LogDeviations() {
+	profileLock.Lock()
+	defer profileLock.Unlock()
+	var specs []string
+	for spec := range deviations {
+		specs = append(specs, spec)
+	}
+	sort.Strings(specs)
+	for _, spec := range specs {
+		d := deviations[spec]
+		Logf("Tolerated %s deviation of the %s profile in %q", d.Behavior, d.Profile, spec)
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"testing"
+)
+
+func TestDetectRuntimeProfile(t *testing.T) {
+	for runtimeName, expected := range map[string]string{
+		"containerd": "containerd",
+		"cri-o":      "cri-o",
+		"pouch":      "pouch",
+		"docker":     "",
+	} {
+		p := detectRuntimeProfile(runtimeName)
+		name := ""
+		if p != nil {
+			name = p.Name
+		}
+		if name != expected {
+			t.Errorf("%s: expected profile %q, got %q", runtimeName, expected, name)
+		}
+	}
+
+	// The VM based runtimes have to be selected explicitly.
+	p, err := LookupRuntimeProfile("kata")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !p.tolerates(BehaviorDevices) || p.tolerates("checkpoint") {
+		t.Errorf("unexpected tolerated behaviors %v", p.Tolerated)
+	}
+	if _, err := LookupRuntimeProfile("unknown"); err == nil {
+		t.Errorf("expected an unknown profile to be rejected")
+	}
+}
+
+func TestRecordDeviation(t *testing.T) {
+	defer func() {
+		activeProfile = nil
+		toleratedSpecs = make(map[string]Behavior)
+		deviations = make(map[string]Deviation)
+	}()
+	activeProfile, _ = LookupRuntimeProfile("gvisor")
+	toleratedSpecs["[k8s.io] Container Devices device"] = BehaviorDevices
+
+	if _, ok := recordDeviation("[k8s.io] Pod spec", "failed"); ok {
+		t.Errorf("expected a spec without tolerated behavior to fail")
+	}
+	d, ok := recordDeviation("[k8s.io] Container Devices device", "no such device")
+	if !ok || d.Profile != "gvisor" || d.Behavior != BehaviorDevices || d.Message != "no such device" {
+		t.Errorf("unexpected deviation %+v", d)
+	}
+	if _, ok := specDeviation("[k8s.io] Container Devices device"); !ok {
+		t.Errorf("expected the deviation to be recorded")
+	}
+}
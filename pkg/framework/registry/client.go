@@ -0,0 +1,203 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	godigest "github.com/opencontainers/go-digest"
+)
+
+// maxManifestSize is the maximum size of the manifests read by Client.
+const maxManifestSize = 4 * 1024 * 1024
+
+// manifestMediaTypes are the manifest media types accepted by Client: the
+// Docker and OCI manifests and manifest lists.
+var manifestMediaTypes = []string{
+	manifestMediaType,
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+}
+
+// challengeParamRegexp matches the parameters of a WWW-Authenticate header.
+var challengeParamRegexp = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// Client is a minimal Docker Registry v2 client, resolving the manifest
+// digests of images independently of the runtime, and fetching the content
+// of the source images of the test registries.
+type Client struct {
+	// HTTPClient sends the requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+	// Username and Password are the credentials sent to the registry or to
+	// its token endpoint. The requests are anonymous if empty.
+	Username string
+	Password string
+}
+
+// ManifestDigest returns the digest of the manifest of repository:reference
+// in the registry at registryURL, e.g. http://localhost:5000. The digest is
+// computed from the manifest, and checked against the digest reported by the
+// registry.
+func (c *Client) ManifestDigest(registryURL, repository, reference string) (string, error) {
+	manifest, _, err := c.Manifest(registryURL, repository, reference)
+	if err != nil {
+		return "", err
+	}
+	return godigest.FromBytes(manifest).String(), nil
+}
+
+// Manifest returns the manifest of repository:reference in the registry at
+// registryURL and its media type. The manifest is checked against the digest
+// reported by the registry.
+func (c *Client) Manifest(registryURL, repository, reference string) ([]byte, string, error) {
+	manifestURL := fmt.Sprintf("%s/v2/%s/manifests/%s", strings.TrimSuffix(registryURL, "/"), repository, reference)
+	resp, err := c.fetch(manifestURL, repository, strings.Join(manifestMediaTypes, ", "))
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to get manifest %s: %s", manifestURL, resp.Status)
+	}
+
+	manifest, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read manifest %s: %v", manifestURL, err)
+	}
+	digest := godigest.FromBytes(manifest).String()
+	if reported := resp.Header.Get("Docker-Content-Digest"); reported != "" && reported != digest {
+		return nil, "", fmt.Errorf("registry reports digest %s for manifest %s with digest %s", reported, manifestURL, digest)
+	}
+	return manifest, resp.Header.Get("Content-Type"), nil
+}
+
+// Blob returns the blob digest of repository in the registry at registryURL,
+// checked against its digest.
+func (c *Client) Blob(registryURL, repository string, digest godigest.Digest) ([]byte, error) {
+	blobURL := fmt.Sprintf("%s/v2/%s/blobs/%s", strings.TrimSuffix(registryURL, "/"), repository, digest)
+	resp, err := c.fetch(blobURL, repository, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get blob %s: %s", blobURL, resp.Status)
+	}
+
+	blob, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %v", blobURL, err)
+	}
+	if actual := godigest.FromBytes(blob); actual != digest {
+		return nil, fmt.Errorf("blob %s has digest %s", blobURL, actual)
+	}
+	return blob, nil
+}
+
+// fetch sends a GET request of a resource of repository, accepting the given
+// media types if not empty, and retries it with a pull token if the registry
+// answers with a Bearer challenge.
+func (c *Client) fetch(resourceURL, repository, accept string) (*http.Response, error) {
+	resp, err := c.get(resourceURL, accept, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && strings.HasPrefix(resp.Header.Get("WWW-Authenticate"), "Bearer ") {
+		resp.Body.Close()
+		token, err := c.token(resp.Header.Get("WWW-Authenticate"), repository)
+		if err != nil {
+			return nil, err
+		}
+		return c.get(resourceURL, accept, token)
+	}
+	return resp, nil
+}
+
+// get sends a GET request, with the bearer token if not empty or else with
+// the credentials if any.
+func (c *Client) get(resourceURL, accept, token string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, resourceURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	return c.httpClient().Do(req)
+}
+
+// token gets a pull token of repository from the token endpoint of the
+// Bearer challenge.
+func (c *Client) token(challenge, repository string) (string, error) {
+	params := make(map[string]string)
+	for _, m := range challengeParamRegexp.FindAllStringSubmatch(challenge, -1) {
+		params[m[1]] = m[2]
+	}
+	if params["realm"] == "" {
+		return "", fmt.Errorf("invalid token challenge %q", challenge)
+	}
+	query := url.Values{"scope": {fmt.Sprintf("repository:%s:pull", repository)}}
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	req, err := http.NewRequest(http.MethodGet, params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get token from %s: %s", params["realm"], resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("invalid token response from %s: %v", params["realm"], err)
+	}
+	if token.Token != "" {
+		return token.Token, nil
+	}
+	return token.AccessToken, nil
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"testing"
+)
+
+func TestClientManifestDigest(t *testing.T) {
+	for _, opts := range []Options{
+		{Images: []string{"busybox:1.28"}, Source: testSource},
+		{Auth: AuthToken, Username: "critest", Password: "secret", Images: []string{"busybox:1.28"}, Source: testSource},
+	} {
+		r, err := Start(opts)
+		if err != nil {
+			t.Fatalf("failed to start registry: %v", err)
+		}
+		defer r.Close()
+
+		expected, err := r.Digest(r.Ref("busybox:1.28"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		client := &Client{Username: opts.Username, Password: opts.Password}
+		for _, reference := range []string{"1.28", expected} {
+			digest, err := client.ManifestDigest(r.URL(), "busybox", reference)
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", reference, err)
+			}
+			if digest != expected {
+				t.Errorf("%s: expected digest %s, got %s", reference, expected, digest)
+			}
+		}
+		if _, err := client.ManifestDigest(r.URL(), "busybox", "unknown"); err == nil {
+			t.Errorf("expected an error for an unknown tag")
+		}
+	}
+
+	r, err := Start(Options{Auth: AuthToken, Username: "critest", Password: "secret", Images: []string{"busybox:1.28"}, Source: testSource})
+	if err != nil {
+		t.Fatalf("failed to start registry: %v", err)
+	}
+	defer r.Close()
+	if _, err := (&Client{}).ManifestDigest(r.URL(), "busybox", "1.28"); err == nil {
+		t.Errorf("expected an error without credentials")
+	}
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strings"
+	"sync"
+
+	godigest "github.com/opencontainers/go-digest"
+)
+
+const (
+	manifestMediaType    = "application/vnd.docker.distribution.manifest.v2+json"
+	imageConfigMediaType = "application/vnd.docker.container.image.v1+json"
+	layerMediaType       = "application/vnd.docker.image.rootfs.diff.tar.gzip"
+)
+
+// sourceImage is the content of a source image.
+type sourceImage struct {
+	config []byte
+	layers [][]byte
+}
+
+var (
+	sourcesLock sync.Mutex
+	// sources caches the source images fetched, by reference, so that they
+	// are fetched once per process.
+	sources = make(map[string]*sourceImage)
+)
+
+// getSource returns the source image of reference image, fetching it from
+// its registry if it isn't cached yet.
+func getSource(image string) (*sourceImage, error) {
+	sourcesLock.Lock()
+	defer sourcesLock.Unlock()
+	if source, ok := sources[image]; ok {
+		return source, nil
+	}
+	source, err := fetchSource(image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch source image %q: %v", image, err)
+	}
+	sources[image] = source
+	return source, nil
+}
+
+// fetchSource fetches the config and layers of image from its registry. The
+// linux image of the current architecture is picked from manifest lists.
+func fetchSource(image string) (*sourceImage, error) {
+	registryURL, repository, reference := sourceLocation(image)
+	client := &Client{}
+	manifest, mediaType, err := client.Manifest(registryURL, repository, reference)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(mediaType, "list") || strings.Contains(mediaType, "index") {
+		var list struct {
+			Manifests []struct {
+				Digest   string `json:"digest"`
+				Platform struct {
+					Architecture string `json:"architecture"`
+					OS           string `json:"os"`
+				} `json:"platform"`
+			} `json:"manifests"`
+		}
+		if err := json.Unmarshal(manifest, &list); err != nil {
+			return nil, err
+		}
+		reference = ""
+		for _, m := range list.Manifests {
+			if m.Platform.OS == "linux" && m.Platform.Architecture == runtime.GOARCH {
+				reference = m.Digest
+				break
+			}
+		}
+		if reference == "" {
+			return nil, fmt.Errorf("no linux/%s image in manifest list", runtime.GOARCH)
+		}
+		if manifest, _, err = client.Manifest(registryURL, repository, reference); err != nil {
+			return nil, err
+		}
+	}
+
+	var m struct {
+		Config struct {
+			Digest godigest.Digest `json:"digest"`
+		} `json:"config"`
+		Layers []struct {
+			Digest godigest.Digest `json:"digest"`
+		} `json:"layers"`
+	}
+	if err := json.Unmarshal(manifest, &m); err != nil {
+		return nil, err
+	}
+	source := &sourceImage{}
+	if source.config, err = client.Blob(registryURL, repository, m.Config.Digest); err != nil {
+		return nil, err
+	}
+	for _, layer := range m.Layers {
+		blob, err := client.Blob(registryURL, repository, layer.Digest)
+		if err != nil {
+			return nil, err
+		}
+		source.layers = append(source.layers, blob)
+	}
+	return source, nil
+}
+
+// sourceLocation returns the URL of the registry of the image reference
+// image, its repository and its tag or digest. Images without domain are
+// Docker Hub images.
+func sourceLocation(image string) (string, string, string) {
+	name := trimDomain(image)
+	domain := strings.TrimSuffix(strings.TrimSuffix(image, name), "/")
+	if domain == "" || domain == "docker.io" {
+		domain = "registry-1.docker.io"
+		if !strings.Contains(name, "/") {
+			name = "library/" + name
+		}
+	}
+	scheme := "https"
+	if strings.HasPrefix(domain, "localhost") || strings.HasPrefix(domain, "127.0.0.1") {
+		scheme = "http"
+	}
+
+	var reference string
+	if i := strings.Index(name, "@"); i != -1 {
+		name, reference = name[:i], name[i+1:]
+	} else {
+		name, reference = splitTag(name)
+	}
+	return scheme + "://" + domain, name, reference
+}
+
+// generateImage generates a schema2 image with the config and layers of
+// source, and a layer containing content, padded with padding bytes of random
+// data. The last layer makes the images of a registry differ. It returns the
+// manifest and the blobs referenced by it.
+func generateImage(source *sourceImage, content string, padding int) ([]byte, [][]byte, error) {
+	layer, diffID, err := generateLayer(content, padding)
+	if err != nil {
+		return nil, nil, err
+	}
+	config, err := appendDiffID(source.config, diffID)
+	if err != nil {
+		return nil, nil, err
+	}
+	layers := append(append([][]byte{}, source.layers...), layer)
+
+	var descriptors []map[string]interface{}
+	for _, layer := range layers {
+		descriptors = append(descriptors, map[string]interface{}{
+			"mediaType": layerMediaType,
+			"size":      len(layer),
+			"digest":    godigest.FromBytes(layer),
+		})
+	}
+	manifest, err := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     manifestMediaType,
+		"config": map[string]interface{}{
+			"mediaType": imageConfigMediaType,
+			"size":      len(config),
+			"digest":    godigest.FromBytes(config),
+		},
+		"layers": descriptors,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return manifest, append(layers, config), nil
+}
+
+// appendDiffID returns the image config config with the layer diffID added
+// on top of its layers, and to its history.
+func appendDiffID(config []byte, diffID godigest.Digest) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(config, &fields); err != nil {
+		return nil, fmt.Errorf("invalid image config: %v", err)
+	}
+	var rootfs struct {
+		Type    string            `json:"type"`
+		DiffIDs []godigest.Digest `json:"diff_ids"`
+	}
+	if err := json.Unmarshal(fields["rootfs"], &rootfs); err != nil {
+		return nil, fmt.Errorf("invalid image config rootfs: %v", err)
+	}
+	rootfs.DiffIDs = append(rootfs.DiffIDs, diffID)
+	var history []json.RawMessage
+	if raw, ok := fields["history"]; ok {
+		if err := json.Unmarshal(raw, &history); err != nil {
+			return nil, fmt.Errorf("invalid image config history: %v", err)
+		}
+	}
+	history = append(history, json.RawMessage(`{"created_by":"critest"}`))
+
+	var err error
+	if fields["rootfs"], err = json.Marshal(rootfs); err != nil {
+		return nil, err
+	}
+	if fields["history"], err = json.Marshal(history); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// layerFile is a file of a generated layer.
+type layerFile struct {
+	name string
+	data []byte
+}
+
+// generateLayer creates a gzipped layer tarball containing a file with the
+// given content, and a file of padding bytes of random data if padding isn't
+// 0. Random data doesn't compress, so the layer is at least padding bytes
+// large. It returns the layer and its uncompressed digest.
+func generateLayer(content string, padding int) ([]byte, godigest.Digest, error) {
+	files := []layerFile{{"critest", []byte(content)}}
+	if padding > 0 {
+		data := make([]byte, padding)
+		rand.Read(data)
+		files = append(files, layerFile{"critest-padding", data})
+	}
+
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+	for _, file := range files {
+		if err := tw.WriteHeader(&tar.Header{
+			Name: file.name,
+			Mode: 0644,
+			Size: int64(len(file.data)),
+		}); err != nil {
+			return nil, "", err
+		}
+		if _, err := tw.Write(file.data); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, "", err
+	}
+
+	var gzBuf bytes.Buffer
+	gw := gzip.NewWriter(&gzBuf)
+	if _, err := gw.Write(tarBuf.Bytes()); err != nil {
+		return nil, "", err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, "", err
+	}
+	return gzBuf.Bytes(), godigest.FromBytes(tarBuf.Bytes()), nil
+}
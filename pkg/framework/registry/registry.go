@@ -0,0 +1,453 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package registry provides a minimal read-only Docker Registry v2 which is
+// served from the test process, so that the auth, TLS and mirror tests
+// control the registry the runtime pulls from. Its images have the content of
+// a source image, fetched once per process from its registry, e.g. Docker
+// Hub, or from a mirror.
+package registry
+
+import (
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+	"github.com/pborman/uuid"
+)
+
+// AuthMode is the way clients authenticate against the registry.
+type AuthMode string
+
+const (
+	// AuthNone allows anonymous access.
+	AuthNone AuthMode = ""
+	// AuthHtpasswd requires basic auth checked against an htpasswd entry.
+	AuthHtpasswd AuthMode = "htpasswd"
+	// AuthToken requires a bearer token issued by the registry token endpoint,
+	// which accepts basic auth credentials or an identity token.
+	AuthToken AuthMode = "token"
+
+	// service is the service name the registry uses in its token challenge.
+	service = "critest-registry"
+
+	// rateTicksPerSecond is the number of chunks of blobs written per second
+	// when their rate is limited.
+	rateTicksPerSecond = 10
+)
+
+var (
+	manifestPathRegexp = regexp.MustCompile(`^/v2/(.+)/manifests/([^/]+)$`)
+	blobPathRegexp     = regexp.MustCompile(`^/v2/(.+)/blobs/([^/]+)$`)
+)
+
+// Options are the options to start a Registry with.
+type Options struct {
+	// TLS serves the registry over HTTPS with a generated self-signed certificate.
+	TLS bool
+	// Auth is the authentication mode of the registry.
+	Auth AuthMode
+	// Username and Password are the credentials accepted by the registry.
+	Username string
+	Password string
+	// IdentityToken is accepted as refresh token by the token endpoint.
+	IdentityToken string
+	// Images are seeded into the registry when it starts, see Ref.
+	Images []string
+	// Source is the reference of the image whose config and layers are
+	// served for Images, so that containers can run them. It is fetched from
+	// its registry once per process.
+	Source string
+	// LayerSize pads the layer generated for each image with random data to
+	// about this size in bytes, so that pulling them takes time.
+	LayerSize int
+	// BlobRate limits the rate at which blobs are served, in bytes per
+	// second, shared between the concurrent requests like the bandwidth of
+	// a real registry. 0 means unlimited.
+	BlobRate int
+}
+
+// Registry is a running test registry.
+type Registry struct {
+	server   *httptest.Server
+	opts     Options
+	htpasswd map[string]string
+	caCert   []byte
+
+	mu        sync.Mutex
+	tokens    map[string]bool
+	manifests map[string][]byte
+	blobs     map[godigest.Digest][]byte
+	// blobRequests counts the GET requests of the blobs.
+	blobRequests int
+
+	// chunks allows writing a chunk of a blob at each tick when the blob
+	// rate is limited, until stop is closed.
+	chunks chan struct{}
+	stop   chan struct{}
+}
+
+// Start starts a registry on localhost with the given options.
+func Start(opts Options) (*Registry, error) {
+	r := &Registry{
+		opts:      opts,
+		tokens:    make(map[string]bool),
+		manifests: make(map[string][]byte),
+		blobs:     make(map[godigest.Digest][]byte),
+	}
+	if opts.Auth == AuthHtpasswd {
+		htpasswd, err := parseHtpasswd(HtpasswdEntry(opts.Username, opts.Password))
+		if err != nil {
+			return nil, err
+		}
+		r.htpasswd = htpasswd
+	}
+
+	if opts.BlobRate > 0 {
+		r.chunks = make(chan struct{})
+		r.stop = make(chan struct{})
+		go r.meter()
+	}
+
+	r.server = httptest.NewUnstartedServer(http.HandlerFunc(r.serveHTTP))
+	if opts.TLS {
+		cert, caCert, err := generateCertificate()
+		if err != nil {
+			return nil, err
+		}
+		r.caCert = caCert
+		r.server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+		r.server.StartTLS()
+	} else {
+		r.server.Start()
+	}
+
+	for _, image := range opts.Images {
+		if _, err := r.AddImage(image); err != nil {
+			r.Close()
+			return nil, fmt.Errorf("failed to seed image %q: %v", image, err)
+		}
+	}
+	return r, nil
+}
+
+// Close shuts down the registry.
+func (r *Registry) Close() {
+	r.server.Close()
+	if r.stop != nil {
+		close(r.stop)
+	}
+}
+
+// meter hands out rateTicksPerSecond chunks per second to the requests
+// writing blobs. The chunks of the ticks without waiting request are lost.
+func (r *Registry) meter() {
+	ticker := time.NewTicker(time.Second / rateTicksPerSecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			select {
+			case r.chunks <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// Host returns the registry host which should be used in image references.
+func (r *Registry) Host() string {
+	u, _ := url.Parse(r.server.URL)
+	return "localhost:" + u.Port()
+}
+
+// URL returns the base URL of the registry.
+func (r *Registry) URL() string {
+	return strings.Replace(r.server.URL, "127.0.0.1", "localhost", 1)
+}
+
+// CACert returns the PEM encoded CA certificate of the registry, or nil if
+// the registry doesn't serve TLS.
+func (r *Registry) CACert() []byte {
+	return r.caCert
+}
+
+// Ref returns the reference of image in the registry, e.g.
+// "gcr.io/cri-tools/test-image-1:latest" becomes
+// "localhost:<port>/cri-tools/test-image-1:latest".
+func (r *Registry) Ref(image string) string {
+	return r.Host() + "/" + trimDomain(image)
+}
+
+// AddImage generates an image for image, with the content of the source
+// image and a layer of its own, and returns its reference in the registry.
+// Digested references can't be seeded because the digest of the generated
+// image differs.
+func (r *Registry) AddImage(image string) (string, error) {
+	if strings.Contains(image, "@") {
+		return "", fmt.Errorf("image %q with digest can't be added", image)
+	}
+	if r.opts.Source == "" {
+		return "", fmt.Errorf("no source image to add image %q", image)
+	}
+	source, err := getSource(r.opts.Source)
+	if err != nil {
+		return "", err
+	}
+	repository, tag := splitTag(trimDomain(image))
+	manifest, blobs, err := generateImage(source, repository+":"+tag, r.opts.LayerSize)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, blob := range blobs {
+		r.blobs[godigest.FromBytes(blob)] = blob
+	}
+	r.blobs[godigest.FromBytes(manifest)] = manifest
+	r.manifests[repository+":"+tag] = manifest
+	r.manifests[repository+"@"+godigest.FromBytes(manifest).String()] = manifest
+	return r.Host() + "/" + repository + ":" + tag, nil
+}
+
+// Digest returns the manifest digest of the image reference returned by AddImage.
+func (r *Registry) Digest(ref string) (string, error) {
+	repository, tag := splitTag(strings.TrimPrefix(ref, r.Host()+"/"))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	manifest, ok := r.manifests[repository+":"+tag]
+	if !ok {
+		return "", fmt.Errorf("image %q not found", ref)
+	}
+	return godigest.FromBytes(manifest).String(), nil
+}
+
+// BlobRequests returns the number of GET requests of blobs served so far.
+func (r *Registry) BlobRequests() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.blobRequests
+}
+
+func (r *Registry) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/token" && r.opts.Auth == AuthToken {
+		r.serveToken(w, req)
+		return
+	}
+	if !r.authorized(req) {
+		switch r.opts.Auth {
+		case AuthHtpasswd:
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", service))
+		case AuthToken:
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf("Bearer realm=%q,service=%q", r.URL()+"/token", service))
+		}
+		http.Error(w, `{"errors":[{"code":"UNAUTHORIZED","message":"authentication required"}]}`, http.StatusUnauthorized)
+		return
+	}
+	if req.URL.Path == "/v2/" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if m := manifestPathRegexp.FindStringSubmatch(req.URL.Path); m != nil {
+		sep := ":"
+		if strings.Contains(m[2], ":") {
+			sep = "@"
+		}
+		r.mu.Lock()
+		manifest, ok := r.manifests[m[1]+sep+m[2]]
+		r.mu.Unlock()
+		if !ok {
+			http.Error(w, `{"errors":[{"code":"MANIFEST_UNKNOWN"}]}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", manifestMediaType)
+		w.Header().Set("Docker-Content-Digest", godigest.FromBytes(manifest).String())
+		writeData(w, req, manifest, nil, 0)
+		return
+	}
+	if m := blobPathRegexp.FindStringSubmatch(req.URL.Path); m != nil {
+		r.mu.Lock()
+		blob, ok := r.blobs[godigest.Digest(m[2])]
+		if ok && req.Method == http.MethodGet {
+			r.blobRequests++
+		}
+		r.mu.Unlock()
+		if !ok {
+			http.Error(w, `{"errors":[{"code":"BLOB_UNKNOWN"}]}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Docker-Content-Digest", m[2])
+		writeData(w, req, blob, r.chunks, r.opts.BlobRate/rateTicksPerSecond)
+		return
+	}
+	http.NotFound(w, req)
+}
+
+// writeData writes data at once, or in chunks of chunkSize bytes each
+// allowed by chunks if it isn't nil.
+func writeData(w http.ResponseWriter, req *http.Request, data []byte, chunks <-chan struct{}, chunkSize int) {
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+	w.WriteHeader(http.StatusOK)
+	if req.Method == http.MethodHead {
+		return
+	}
+	if chunks == nil {
+		w.Write(data)
+		return
+	}
+	if chunkSize == 0 {
+		chunkSize = 1
+	}
+	for len(data) > 0 {
+		select {
+		case <-chunks:
+		case <-req.Context().Done():
+			// The client went away, e.g. a cancelled pull.
+			return
+		}
+		n := chunkSize
+		if n > len(data) {
+			n = len(data)
+		}
+		if _, err := w.Write(data[:n]); err != nil {
+			return
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		data = data[n:]
+	}
+}
+
+// authorized checks whether the request is allowed by the auth mode.
+func (r *Registry) authorized(req *http.Request) bool {
+	switch r.opts.Auth {
+	case AuthHtpasswd:
+		username, password, ok := req.BasicAuth()
+		return ok && checkHtpasswd(r.htpasswd, username, password)
+	case AuthToken:
+		auth := req.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") {
+			return false
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.tokens[strings.TrimPrefix(auth, "Bearer ")]
+	}
+	return true
+}
+
+// serveToken issues tokens for valid basic auth credentials (GET) or a valid
+// identity token used as OAuth2 refresh token (POST).
+func (r *Registry) serveToken(w http.ResponseWriter, req *http.Request) {
+	valid := false
+	switch req.Method {
+	case http.MethodGet:
+		username, password, ok := req.BasicAuth()
+		valid = ok && username == r.opts.Username && password == r.opts.Password
+	case http.MethodPost:
+		if err := req.ParseForm(); err == nil {
+			valid = req.PostForm.Get("grant_type") == "refresh_token" &&
+				r.opts.IdentityToken != "" && req.PostForm.Get("refresh_token") == r.opts.IdentityToken
+		}
+	}
+	if !valid {
+		http.Error(w, `{"errors":[{"code":"UNAUTHORIZED","message":"invalid credentials"}]}`, http.StatusUnauthorized)
+		return
+	}
+
+	token := uuid.NewUUID().String()
+	r.mu.Lock()
+	r.tokens[token] = true
+	r.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"token":         token,
+		"access_token":  token,
+		"refresh_token": r.opts.IdentityToken,
+		"expires_in":    300,
+	})
+}
+
+// HtpasswdEntry returns an htpasswd line for username and password using the
+// {SHA} scheme.
+func HtpasswdEntry(username, password string) string {
+	sum := sha1.Sum([]byte(password))
+	return username + ":{SHA}" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// parseHtpasswd parses htpasswd content into a map of username to hash.
+func parseHtpasswd(content string) (map[string]string, error) {
+	entries := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.Index(line, ":")
+		if i == -1 {
+			return nil, fmt.Errorf("invalid htpasswd line %q", line)
+		}
+		if !strings.HasPrefix(line[i+1:], "{SHA}") {
+			return nil, fmt.Errorf("unsupported htpasswd hash for user %q", line[:i])
+		}
+		entries[line[:i]] = line[i+1:]
+	}
+	return entries, nil
+}
+
+// checkHtpasswd checks username and password against htpasswd entries.
+func checkHtpasswd(entries map[string]string, username, password string) bool {
+	hash, ok := entries[username]
+	return ok && HtpasswdEntry(username, password) == username+":"+hash
+}
+
+// trimDomain removes the registry domain from an image reference.
+func trimDomain(image string) string {
+	i := strings.Index(image, "/")
+	if i == -1 {
+		return image
+	}
+	domain := image[:i]
+	if strings.ContainsAny(domain, ".:") || domain == "localhost" {
+		return image[i+1:]
+	}
+	return image
+}
+
+// splitTag splits an image reference without domain into repository and tag.
+func splitTag(image string) (string, string) {
+	i := strings.LastIndex(image, ":")
+	if i == -1 {
+		return image, "latest"
+	}
+	return image[:i], image[i+1:]
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testSource is the source image of the test registries. It is cached
+// beforehand, so that the tests don't fetch it.
+const testSource = "critest/source:latest"
+
+func init() {
+	layer, diffID, err := generateLayer("source", 0)
+	if err != nil {
+		panic(err)
+	}
+	config, err := json.Marshal(map[string]interface{}{
+		"architecture": runtime.GOARCH,
+		"os":           "linux",
+		"rootfs": map[string]interface{}{
+			"type":     "layers",
+			"diff_ids": []string{diffID.String()},
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+	sources[testSource] = &sourceImage{config: config, layers: [][]byte{layer}}
+}
+
+func TestRegistry(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		opts     Options
+		username string
+		password string
+		expected int
+	}{
+		{
+			"anonymous registry should serve seeded image",
+			Options{Images: []string{"busybox:1.28"}, Source: testSource},
+			"",
+			"",
+			http.StatusOK,
+		},
+		{
+			"htpasswd registry should reject anonymous access",
+			Options{Auth: AuthHtpasswd, Username: "critest", Password: "secret", Images: []string{"busybox:1.28"}, Source: testSource},
+			"",
+			"",
+			http.StatusUnauthorized,
+		},
+		{
+			"htpasswd registry should reject wrong password",
+			Options{Auth: AuthHtpasswd, Username: "critest", Password: "secret", Images: []string{"busybox:1.28"}, Source: testSource},
+			"critest",
+			"wrong",
+			http.StatusUnauthorized,
+		},
+		{
+			"TLS htpasswd registry should serve seeded image",
+			Options{TLS: true, Auth: AuthHtpasswd, Username: "critest", Password: "secret", Images: []string{"busybox:1.28"}, Source: testSource},
+			"critest",
+			"secret",
+			http.StatusOK,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			r, err := Start(tc.opts)
+			if err != nil {
+				t.Fatalf("failed to start registry: %v", err)
+			}
+			defer r.Close()
+
+			client := http.DefaultClient
+			if tc.opts.TLS {
+				pool := x509.NewCertPool()
+				if !pool.AppendCertsFromPEM(r.CACert()) {
+					t.Fatalf("failed to parse CA certificate")
+				}
+				client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+			}
+			req, err := http.NewRequest(http.MethodGet, r.URL()+"/v2/busybox/manifests/1.28", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.username != "" {
+				req.SetBasicAuth(tc.username, tc.password)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("failed to get manifest: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tc.expected {
+				t.Errorf("expected status %d; actual status is %d", tc.expected, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestRegistryLayerSize(t *testing.T) {
+	const layerSize = 64 * 1024
+	r, err := Start(Options{LayerSize: layerSize, BlobRate: 4 * layerSize, Images: []string{"busybox:1.28"}, Source: testSource})
+	if err != nil {
+		t.Fatalf("failed to start registry: %v", err)
+	}
+	defer r.Close()
+
+	digest, size := getLayer(t, r)
+	if size < layerSize {
+		t.Fatalf("expected a layer of at least %d bytes; actual size is %d", layerSize, size)
+	}
+
+	start := time.Now()
+	getBlob(t, r, digest, size)
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("expected the layer to be served at a limited rate; actual time is %v", elapsed)
+	}
+	if requests := r.BlobRequests(); requests != 1 {
+		t.Errorf("expected 1 blob request; actual requests are %d", requests)
+	}
+}
+
+func TestRegistryBlobRateShared(t *testing.T) {
+	const layerSize = 64 * 1024
+	r, err := Start(Options{LayerSize: layerSize, BlobRate: 4 * layerSize, Images: []string{"busybox:1.28"}, Source: testSource})
+	if err != nil {
+		t.Fatalf("failed to start registry: %v", err)
+	}
+	defer r.Close()
+
+	digest, size := getLayer(t, r)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			getBlob(t, r, digest, size)
+		}()
+	}
+	wg.Wait()
+	// A single download takes about 250ms at the limited rate.
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("expected the concurrent downloads to share the rate; actual time is %v", elapsed)
+	}
+}
+
+func TestFetchSource(t *testing.T) {
+	r, err := Start(Options{Images: []string{"busybox:1.28"}, Source: testSource})
+	if err != nil {
+		t.Fatalf("failed to start registry: %v", err)
+	}
+	defer r.Close()
+
+	source, err := fetchSource(r.Ref("busybox:1.28"))
+	if err != nil {
+		t.Fatalf("failed to fetch source: %v", err)
+	}
+	if len(source.layers) != 2 || !bytes.Equal(source.layers[0], sources[testSource].layers[0]) {
+		t.Fatalf("expected the source layer and the image layer; actual layers are %d", len(source.layers))
+	}
+	var config struct {
+		RootFS struct {
+			DiffIDs []string `json:"diff_ids"`
+		} `json:"rootfs"`
+	}
+	if err := json.Unmarshal(source.config, &config); err != nil {
+		t.Fatalf("failed to decode config: %v", err)
+	}
+	if len(config.RootFS.DiffIDs) != 2 {
+		t.Errorf("expected 2 diff IDs; actual diff IDs are %v", config.RootFS.DiffIDs)
+	}
+}
+
+func TestSourceLocation(t *testing.T) {
+	testCases := []struct {
+		image      string
+		url        string
+		repository string
+		reference  string
+	}{
+		{"busybox:1.28", "https://registry-1.docker.io", "library/busybox", "1.28"},
+		{"docker.io/library/busybox", "https://registry-1.docker.io", "library/busybox", "latest"},
+		{"gcr.io/cri-tools/test-image-tag:test", "https://gcr.io", "cri-tools/test-image-tag", "test"},
+		{"localhost:5000/busybox@sha256:abc", "http://localhost:5000", "busybox", "sha256:abc"},
+	}
+	for _, tc := range testCases {
+		url, repository, reference := sourceLocation(tc.image)
+		if url != tc.url || repository != tc.repository || reference != tc.reference {
+			t.Errorf("expected %s, %s and %s for %q; actual location is %s, %s and %s",
+				tc.url, tc.repository, tc.reference, tc.image, url, repository, reference)
+		}
+	}
+}
+
+// getLayer returns the digest and size of the layer generated for the seeded
+// image, on top of the source layers.
+func getLayer(t *testing.T, r *Registry) (string, int) {
+	var manifest struct {
+		Layers []struct {
+			Size   int    `json:"size"`
+			Digest string `json:"digest"`
+		} `json:"layers"`
+	}
+	resp, err := http.Get(r.URL() + "/v2/busybox/manifests/1.28")
+	if err != nil {
+		t.Fatalf("failed to get manifest: %v", err)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&manifest)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("failed to decode manifest: %v", err)
+	}
+	if len(manifest.Layers) != len(sources[testSource].layers)+1 {
+		t.Fatalf("expected the source layers and a generated layer; actual layers are %+v", manifest.Layers)
+	}
+	last := manifest.Layers[len(manifest.Layers)-1]
+	return last.Digest, last.Size
+}
+
+// getBlob downloads the blob with the given digest and checks its size.
+func getBlob(t *testing.T, r *Registry, digest string, size int) {
+	resp, err := http.Get(r.URL() + "/v2/busybox/blobs/" + digest)
+	if err != nil {
+		t.Errorf("failed to get blob: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+	n, err := io.Copy(ioutil.Discard, resp.Body)
+	if err != nil || int(n) != size {
+		t.Errorf("failed to read blob: read %d bytes: %v", n, err)
+	}
+}
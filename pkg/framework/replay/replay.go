@@ -0,0 +1,215 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package replay serves the CRI calls recorded by critest --trace-dir, to
+// test CRI clients such as crictl without a container runtime.
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+)
+
+// services are the replayed CRI services, by gRPC service name.
+var services = map[string]reflect.Type{
+	"runtime.v1alpha2.RuntimeService": reflect.TypeOf((*runtimeapi.RuntimeServiceServer)(nil)).Elem(),
+	"runtime.v1alpha2.ImageService":   reflect.TypeOf((*runtimeapi.ImageServiceServer)(nil)).Elem(),
+}
+
+func init() {
+	// The CRI messages are generated with gogo/protobuf, which registers the
+	// enums in its own registry, while jsonpb looks the enum names of the
+	// recorded messages up in the golang/protobuf one.
+	proto.RegisterEnum("runtime.v1alpha2.Protocol", runtimeapi.Protocol_name, runtimeapi.Protocol_value)
+	proto.RegisterEnum("runtime.v1alpha2.MountPropagation", runtimeapi.MountPropagation_name, runtimeapi.MountPropagation_value)
+	proto.RegisterEnum("runtime.v1alpha2.NamespaceMode", runtimeapi.NamespaceMode_name, runtimeapi.NamespaceMode_value)
+	proto.RegisterEnum("runtime.v1alpha2.PodSandboxState", runtimeapi.PodSandboxState_name, runtimeapi.PodSandboxState_value)
+	proto.RegisterEnum("runtime.v1alpha2.ContainerState", runtimeapi.ContainerState_name, runtimeapi.ContainerState_value)
+}
+
+// Server is a gRPC server answering the CRI calls with the responses
+// recorded in traces.
+//
+// A call is answered by the first recorded call of the same method with the
+// same request which wasn't replayed yet, or else by the first recorded call
+// of the same method which wasn't replayed yet. Calls which can't be matched
+// fail with Unimplemented.
+type Server struct {
+	server *grpc.Server
+
+	mu       sync.Mutex
+	entries  []framework.TraceEntry
+	replayed []bool
+}
+
+// NewServer creates a Server replaying entries.
+func NewServer(entries []framework.TraceEntry) *Server {
+	s := &Server{
+		server:   grpc.NewServer(),
+		entries:  entries,
+		replayed: make([]bool, len(entries)),
+	}
+	for name, service := range services {
+		s.server.RegisterService(serviceDesc(name, service), s)
+	}
+	return s
+}
+
+// Load creates a Server replaying the trace files at paths, in order.
+func Load(paths ...string) (*Server, error) {
+	var entries []framework.TraceEntry
+	for _, path := range paths {
+		trace, err := framework.ReadTrace(path)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, trace...)
+	}
+	return NewServer(entries), nil
+}
+
+// Serve serves the CRI calls on l until Stop is called.
+func (s *Server) Serve(l net.Listener) error {
+	return s.server.Serve(l)
+}
+
+// Stop stops the server.
+func (s *Server) Stop() {
+	s.server.Stop()
+}
+
+// Remaining returns the recorded calls which weren't replayed.
+func (s *Server) Remaining() []framework.TraceEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var remaining []framework.TraceEntry
+	for i, e := range s.entries {
+		if !s.replayed[i] {
+			remaining = append(remaining, e)
+		}
+	}
+	return remaining
+}
+
+// serviceDesc describes the unary methods of service, all handled by
+// replaying the recorded calls. The handler is the Server, which doesn't
+// implement the service interface.
+func serviceDesc(name string, service reflect.Type) *grpc.ServiceDesc {
+	desc := &grpc.ServiceDesc{
+		ServiceName: name,
+		HandlerType: (*interface{})(nil),
+	}
+	for i := 0; i < service.NumMethod(); i++ {
+		m := service.Method(i)
+		method := fmt.Sprintf("/%s/%s", name, m.Name)
+		// The methods are func(context.Context, *Request) (*Response, error).
+		requestType, responseType := m.Type.In(1).Elem(), m.Type.Out(0).Elem()
+		desc.Methods = append(desc.Methods, grpc.MethodDesc{
+			MethodName: m.Name,
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				req := reflect.New(requestType).Interface().(proto.Message)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				return srv.(*Server).replay(method, req, responseType)
+			},
+		})
+	}
+	return desc
+}
+
+// replay returns the recorded response of the call of method with req.
+func (s *Server) replay(method string, req proto.Message, responseType reflect.Type) (interface{}, error) {
+	var marshaler jsonpb.Marshaler
+	request, err := marshaler.MarshalToString(req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	match := -1
+	for i, e := range s.entries {
+		if s.replayed[i] || e.Method != method {
+			continue
+		}
+		if sameJSON(e.Request, []byte(request)) {
+			match = i
+			break
+		}
+		if match == -1 {
+			match = i
+		}
+	}
+	if match == -1 {
+		return nil, status.Errorf(codes.Unimplemented, "no recorded call of %s left to replay", method)
+	}
+	s.replayed[match] = true
+
+	e := s.entries[match]
+	if code := parseCode(e.Code); code != codes.OK {
+		return nil, status.Error(code, errorDesc(e.Error))
+	}
+	resp := reflect.New(responseType).Interface().(proto.Message)
+	if len(e.Response) > 0 {
+		if err := jsonpb.Unmarshal(bytes.NewReader(e.Response), resp); err != nil {
+			return nil, status.Errorf(codes.Internal, "invalid recorded response of %s: %v", method, err)
+		}
+	}
+	return resp, nil
+}
+
+// sameJSON returns whether a and b are the same JSON, ignoring whitespaces.
+func sameJSON(a, b []byte) bool {
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return false
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
+
+// parseCode returns the gRPC code named name, Unknown if none is.
+func parseCode(name string) codes.Code {
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		if c.String() == name {
+			return c
+		}
+	}
+	return codes.Unknown
+}
+
+// errorDesc returns the description of a recorded gRPC error message.
+func errorDesc(message string) string {
+	if i := strings.Index(message, " desc = "); strings.HasPrefix(message, "rpc error: ") && i != -1 {
+		return message[i+len(" desc = "):]
+	}
+	return message
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replay
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+)
+
+// startServer serves entries on a unix socket and returns a connection to
+// it.
+func startServer(t *testing.T, entries []framework.TraceEntry) (*Server, *grpc.ClientConn, func()) {
+	dir, err := ioutil.TempDir("", "replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "replay.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("failed to listen on %q: %v", path, err)
+	}
+	s := NewServer(entries)
+	go s.Serve(l)
+	conn, err := grpc.Dial(path, grpc.WithInsecure(), grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+		return net.DialTimeout("unix", addr, timeout)
+	}))
+	if err != nil {
+		t.Fatalf("failed to dial %q: %v", path, err)
+	}
+	return s, conn, func() {
+		conn.Close()
+		s.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestServerReplay(t *testing.T) {
+	s, conn, cleanup := startServer(t, []framework.TraceEntry{
+		{
+			Method:   "/runtime.v1alpha2.RuntimeService/ContainerStatus",
+			Request:  json.RawMessage(`{"containerId":"first"}`),
+			Response: json.RawMessage(`{"status":{"id":"first"}}`),
+			Code:     "OK",
+		},
+		{
+			Method:   "/runtime.v1alpha2.RuntimeService/ContainerStatus",
+			Request:  json.RawMessage(`{"containerId":"second"}`),
+			Response: json.RawMessage(`{"status":{"id":"second"}}`),
+			Code:     "OK",
+		},
+		{
+			Method:  "/runtime.v1alpha2.RuntimeService/ContainerStatus",
+			Request: json.RawMessage(`{"containerId":"missing"}`),
+			Code:    "NotFound",
+			Error:   "rpc error: code = NotFound desc = container \"missing\" not found",
+		},
+		{
+			Method:   "/runtime.v1alpha2.ImageService/ImageFsInfo",
+			Request:  json.RawMessage(`{}`),
+			Response: json.RawMessage(`{"imageFilesystems":[{"usedBytes":{"value":"42"}}]}`),
+			Code:     "OK",
+		},
+	})
+	defer cleanup()
+	client := runtimeapi.NewRuntimeServiceClient(conn)
+	ctx := context.Background()
+
+	// The request matching a recorded call is answered first.
+	resp, err := client.ContainerStatus(ctx, &runtimeapi.ContainerStatusRequest{ContainerId: "second"})
+	if err != nil || resp.Status.Id != "second" {
+		t.Errorf("expected the status of the second container, got %v: %v", resp, err)
+	}
+	// Other requests are answered in order.
+	resp, err = client.ContainerStatus(ctx, &runtimeapi.ContainerStatusRequest{ContainerId: "other"})
+	if err != nil || resp.Status.Id != "first" {
+		t.Errorf("expected the status of the first container, got %v: %v", resp, err)
+	}
+	_, err = client.ContainerStatus(ctx, &runtimeapi.ContainerStatusRequest{ContainerId: "missing"})
+	if s, ok := status.FromError(err); !ok || s.Code() != codes.NotFound || s.Message() != `container "missing" not found` {
+		t.Errorf("expected the recorded NotFound error, got %v", err)
+	}
+	_, err = client.ContainerStatus(ctx, &runtimeapi.ContainerStatusRequest{ContainerId: "first"})
+	if s, ok := status.FromError(err); !ok || s.Code() != codes.Unimplemented {
+		t.Errorf("expected an Unimplemented error once the calls are replayed, got %v", err)
+	}
+
+	if remaining := s.Remaining(); len(remaining) != 1 || remaining[0].Method != "/runtime.v1alpha2.ImageService/ImageFsInfo" {
+		t.Errorf("expected the ImageFsInfo call to remain, got %+v", remaining)
+	}
+	fs, err := runtimeapi.NewImageServiceClient(conn).ImageFsInfo(ctx, &runtimeapi.ImageFsInfoRequest{})
+	if err != nil || len(fs.ImageFilesystems) != 1 || fs.ImageFilesystems[0].UsedBytes.Value != 42 {
+		t.Errorf("expected the recorded image filesystem, got %v: %v", fs, err)
+	}
+}
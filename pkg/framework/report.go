@@ -0,0 +1,353 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+)
+
+// reportAPIVersion is the CRI API version requested to get the runtime
+// version of the report.
+const reportAPIVersion = "v1alpha2"
+
+// RuntimeInfo is the version of the runtime under test.
+type RuntimeInfo struct {
+	Endpoint   string `json:"endpoint"`
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	APIVersion string `json:"apiVersion"`
+}
+
+// SpecResult is the result of a spec.
+type SpecResult struct {
+	// Name is the full text of the spec.
+	Name string `json:"name"`
+	// State is one of passed, failed, skipped, pending, panicked, timedout,
+	// deviated and flaky, for the specs which passed on retry.
+	State    string  `json:"state"`
+	Duration float64 `json:"duration"`
+	// Failure is the failure message of failed specs.
+	Failure string `json:"failure,omitempty"`
+	// Deviation is the failure of deviated specs, which check a behavior
+	// the runtime profile tolerates.
+	Deviation *Deviation `json:"deviation,omitempty"`
+}
+
+// SuiteResults are the results of a critest run, written as JSON and
+// rendered as HTML in the report directory.
+type SuiteResults struct {
+	Suite      string            `json:"suite"`
+	Runtime    *RuntimeInfo      `json:"runtime,omitempty"`
+	Start      time.Time         `json:"start"`
+	Duration   float64           `json:"duration"`
+	Specs      []SpecResult      `json:"specs"`
+	Benchmarks []BenchmarkResult `json:"benchmarks"`
+	// Flakes are the flake rates of the suites with flaky specs.
+	Flakes []SuiteFlakes `json:"flakes,omitempty"`
+}
+
+// specStates are the names of the spec states in the results.
+var specStates = map[types.SpecState]string{
+	types.SpecStatePassed:   "passed",
+	types.SpecStateFailed:   "failed",
+	types.SpecStateSkipped:  "skipped",
+	types.SpecStatePending:  "pending",
+	types.SpecStatePanicked: "panicked",
+	types.SpecStateTimedOut: "timedout",
+}
+
+// SuiteReporter is a ginkgo reporter writing the SuiteResults of the run as
+// results_PREFIX.json and report_PREFIX.html in a directory once the suite
+// ends.
+type SuiteReporter struct {
+	dir    string
+	prefix string
+
+	// runtimeInfo returns the version of the runtime under test.
+	runtimeInfo func() (*RuntimeInfo, error)
+
+	results    SuiteResults
+	benchmarks *BenchmarkReporter
+	flakes     flakeCounter
+}
+
+// NewSuiteReporter creates a SuiteReporter writing to dir, with the file
+// name prefix of the JUnit reports.
+func NewSuiteReporter(dir, prefix string) *SuiteReporter {
+	return &SuiteReporter{
+		dir:         dir,
+		prefix:      prefix,
+		runtimeInfo: getRuntimeInfo,
+		results:     SuiteResults{Specs: []SpecResult{}},
+		benchmarks:  NewBenchmarkReporter(),
+		flakes:      make(flakeCounter),
+	}
+}
+
+// getRuntimeInfo returns the version of the runtime under test.
+func getRuntimeInfo() (*RuntimeInfo, error) {
+	client, err := LoadCRIClient()
+	if err != nil {
+		return nil, err
+	}
+	version, err := client.CRIRuntimeClient.Version(reportAPIVersion)
+	if err != nil {
+		return nil, err
+	}
+	return &RuntimeInfo{
+		Endpoint:   TestContext.RuntimeServiceAddr,
+		Name:       version.RuntimeName,
+		Version:    version.RuntimeVersion,
+		APIVersion: version.RuntimeApiVersion,
+	}, nil
+}
+
+// SpecSuiteWillBegin records the start of the suite.
+func (r *SuiteReporter) SpecSuiteWillBegin(_ config.GinkgoConfigType, summary *types.SuiteSummary) {
+	r.results.Suite = summary.SuiteDescription
+	r.results.Start = time.Now()
+}
+
+// BeforeSuiteDidRun implements ginkgo.Reporter.
+func (r *SuiteReporter) BeforeSuiteDidRun(*types.SetupSummary) {}
+
+// SpecWillRun implements ginkgo.Reporter.
+func (r *SuiteReporter) SpecWillRun(*types.SpecSummary) {}
+
+// SpecDidComplete records the result of the spec, and its measurements.
+func (r *SuiteReporter) SpecDidComplete(summary *types.SpecSummary) {
+	result := newSpecResult(summary)
+	r.results.Specs = append(r.results.Specs, result)
+	r.flakes.add(summary, result)
+	r.benchmarks.SpecDidComplete(summary)
+}
+
+// newSpecResult returns the result of a completed spec.
+func newSpecResult(summary *types.SpecSummary) SpecResult {
+	result := SpecResult{
+		// The first component is the top level container.
+		Name:     strings.Join(summary.ComponentTexts[1:], " "),
+		State:    specStates[summary.State],
+		Duration: summary.RunTime.Seconds(),
+	}
+	if summary.HasFailureState() {
+		result.Failure = fmt.Sprintf("%s\n%s", summary.Failure.Message, summary.Failure.Location.String())
+	}
+	if strings.HasSuffix(result.Name, flakyTag) {
+		result.Name = strings.TrimSuffix(result.Name, flakyTag)
+		result.State = "flaky"
+	}
+	if d, ok := specDeviation(result.Name); ok {
+		result.State = "deviated"
+		result.Deviation = &d
+	}
+	return result
+}
+
+// AfterSuiteDidRun implements ginkgo.Reporter.
+func (r *SuiteReporter) AfterSuiteDidRun(*types.SetupSummary) {}
+
+// SpecSuiteDidEnd writes the results and the HTML report.
+func (r *SuiteReporter) SpecSuiteDidEnd(summary *types.SuiteSummary) {
+	r.results.Duration = summary.RunTime.Seconds()
+	r.results.Benchmarks = r.benchmarks.Results()
+	r.results.Flakes = r.flakes.flaky()
+	if info, err := r.runtimeInfo(); err != nil {
+		Logf("Failed to get the runtime version for the report: %v", err)
+	} else {
+		r.results.Runtime = info
+	}
+	if err := r.write(); err != nil {
+		Logf("Failed to write the report: %v", err)
+	}
+}
+
+// write writes the JSON results and the HTML report to the directory.
+func (r *SuiteReporter) write() error {
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(r.results, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(r.dir, fmt.Sprintf("results_%s.json", r.prefix)), append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(r.dir, fmt.Sprintf("report_%s.html", r.prefix)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return RenderHTMLReport(f, &r.results)
+}
+
+// LoadSuiteResults loads the JSON results written by a SuiteReporter.
+func LoadSuiteResults(path string) (*SuiteResults, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var results SuiteResults
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse suite results %q: %v", path, err)
+	}
+	return &results, nil
+}
+
+const (
+	// reportChartWidth is the width in pixels of the longest bar of the
+	// benchmark charts.
+	reportChartWidth = 400
+	// reportBarHeight is the height in pixels of the bars, with their
+	// spacing.
+	reportBarHeight = 22
+)
+
+// reportBar is a bar of a benchmark chart.
+type reportBar struct {
+	Label string
+	Value float64
+	// Y is the vertical position of the bar, and Width its length.
+	Y     int
+	Width int
+}
+
+// reportChart is the chart of the percentiles of a benchmark latency.
+type reportChart struct {
+	Spec      string
+	Operation string
+	Unit      string
+	Bars      []reportBar
+}
+
+// reportView is the data rendered by reportTemplate.
+type reportView struct {
+	*SuiteResults
+	Counts map[string]int
+	Charts []reportChart
+}
+
+// newReportView computes the spec counts by state and the latency charts of
+// the results.
+func newReportView(results *SuiteResults) reportView {
+	view := reportView{SuiteResults: results, Counts: make(map[string]int)}
+	for _, s := range results.Specs {
+		view.Counts[s.State]++
+	}
+	for _, b := range results.Benchmarks {
+		if !b.isLatency() || b.P99 <= 0 {
+			continue
+		}
+		chart := reportChart{Spec: b.Spec, Operation: b.Operation, Unit: b.Unit}
+		for i, p := range []struct {
+			label string
+			value float64
+		}{{"p50", b.P50}, {"p90", b.P90}, {"p99", b.P99}} {
+			chart.Bars = append(chart.Bars, reportBar{
+				Label: p.label,
+				Value: p.value,
+				Y:     i * reportBarHeight,
+				// The percentiles are increasing, p99 is the longest bar.
+				Width: int(p.value / b.P99 * reportChartWidth),
+			})
+		}
+		view.Charts = append(view.Charts, chart)
+	}
+	return view
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent": func(rate float64) float64 { return rate * 100 },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Suite}} report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+.passed { color: #2e7d32; }
+.failed, .panicked, .timedout { color: #c62828; }
+.skipped, .pending { color: #757575; }
+.deviated, .flaky { color: #ef6c00; }
+pre { margin: 0; white-space: pre-wrap; }
+svg text { font-size: 12px; }
+</style>
+</head>
+<body>
+<h1>{{.Suite}}</h1>
+<table>
+{{- with .Runtime}}
+<tr><th>Runtime</th><td>{{.Name}} {{.Version}} (CRI {{.APIVersion}})</td></tr>
+<tr><th>Endpoint</th><td>{{.Endpoint}}</td></tr>
+{{- end}}
+<tr><th>Start</th><td>{{.Start.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+<tr><th>Duration</th><td>{{printf "%.1f" .Duration}}s</td></tr>
+<tr><th>Specs</th><td>{{range $state, $count := .Counts}}<span class="{{$state}}">{{$count}} {{$state}}</span> {{end}}</td></tr>
+</table>
+{{- if .Charts}}
+<h2>Benchmark latencies</h2>
+{{- range $chart := .Charts}}
+<h3>{{$chart.Spec}}: {{$chart.Operation}}</h3>
+<svg width="540" height="66">
+{{- range $chart.Bars}}
+<g transform="translate(0,{{.Y}})">
+<text x="0" y="13">{{.Label}}</text>
+<rect x="40" y="0" width="{{.Width}}" height="16" fill="#1976d2"></rect>
+<text x="{{.Width}}" dx="46" y="13">{{printf "%.4g" .Value}}{{$chart.Unit}}</text>
+</g>
+{{- end}}
+</svg>
+{{- end}}
+{{- end}}
+{{- if .Flakes}}
+<h2>Flaky specs</h2>
+<table>
+<tr><th>Suite</th><th>Flaky</th><th>Rate</th></tr>
+{{- range .Flakes}}
+<tr><td>{{.Suite}}</td><td>{{.Flaky}}/{{.Specs}}</td><td>{{printf "%.1f" (percent .Rate)}}%</td></tr>
+{{- end}}
+</table>
+{{- end}}
+<h2>Specs</h2>
+<table>
+<tr><th>Spec</th><th>State</th><th>Duration</th></tr>
+{{- range .Specs}}
+<tr><td>{{.Name}}{{if .Failure}}<pre>{{.Failure}}</pre>{{end}}{{with .Deviation}}<pre>{{.Profile}} profile tolerates {{.Behavior}}: {{.Message}}</pre>{{end}}</td><td class="{{.State}}">{{.State}}</td><td>{{printf "%.3f" .Duration}}s</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// RenderHTMLReport renders the results as a standalone HTML page.
+func RenderHTMLReport(w io.Writer, results *SuiteResults) error {
+	return reportTemplate.Execute(w, newReportView(results))
+}
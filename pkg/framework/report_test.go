@@ -0,0 +1,89 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo/config"
+	"github.com/onsi/ginkgo/types"
+)
+
+func TestSuiteReporter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "suite-reporter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	reporter := NewSuiteReporter(dir, "test")
+	reporter.runtimeInfo = func() (*RuntimeInfo, error) {
+		return &RuntimeInfo{Endpoint: "unix:///run/fake.sock", Name: "fake", Version: "1.0", APIVersion: "v1alpha2"}, nil
+	}
+	reporter.SpecSuiteWillBegin(config.GinkgoConfigType{}, &types.SuiteSummary{SuiteDescription: "CRI validation"})
+	for _, summary := range []*types.SpecSummary{
+		{
+			ComponentTexts: []string{"[Top Level]", "[k8s.io] Pod", "benchmark"},
+			State:          types.SpecStatePassed,
+			RunTime:        time.Second,
+			IsMeasurement:  true,
+			Measurements: map[string]*types.SpecMeasurement{
+				"create": {Name: "create", Units: "s", Results: []float64{0.1, 0.2, 0.4}},
+			},
+		},
+		{
+			ComponentTexts: []string{"[Top Level]", "[k8s.io] Pod", "<failed> spec"},
+			State:          types.SpecStateFailed,
+			Failure:        types.SpecFailure{Message: "expected <true>"},
+		},
+	} {
+		reporter.SpecDidComplete(summary)
+	}
+	reporter.SpecSuiteDidEnd(&types.SuiteSummary{RunTime: 2 * time.Second})
+
+	results, err := LoadSuiteResults(filepath.Join(dir, "results_test.json"))
+	if err != nil {
+		t.Fatalf("failed to load results: %v", err)
+	}
+	if results.Suite != "CRI validation" || results.Runtime.Name != "fake" || len(results.Specs) != 2 || len(results.Benchmarks) != 1 {
+		t.Errorf("unexpected results %+v", results)
+	}
+	if state := results.Specs[1].State; state != "failed" {
+		t.Errorf("expected the second spec to be failed, got %q", state)
+	}
+
+	html, err := ioutil.ReadFile(filepath.Join(dir, "report_test.html"))
+	if err != nil {
+		t.Fatalf("failed to read the HTML report: %v", err)
+	}
+	for _, expected := range []string{
+		"fake 1.0 (CRI v1alpha2)",
+		"1 failed",
+		"&lt;failed&gt; spec",
+		"expected &lt;true&gt;",
+		`<rect x="40" y="0" width="400"`,
+	} {
+		if !strings.Contains(string(html), expected) {
+			t.Errorf("expected the HTML report to contain %q:\n%s", expected, html)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// retrier retries CRI calls failing with transient gRPC errors, with an
+// exponential backoff.
+type retrier struct {
+	retries int
+	backoff time.Duration
+}
+
+// do calls fn until it succeeds, fails with a non transient error or the
+// retries are exhausted. Calls which may have taken effect although they
+// timed out are not retried on DeadlineExceeded, since retrying them could
+// fail because of the first attempt.
+func (r *retrier) do(name string, idempotent bool, fn func() error) error {
+	backoff := r.backoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= r.retries || !isTransient(err, idempotent) {
+			return err
+		}
+		Logf("Retrying %s in %v after transient error: %v", name, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// isTransient returns whether err is a gRPC error worth retrying.
+func isTransient(err error, idempotent bool) bool {
+	s, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	switch s.Code() {
+	case codes.Unavailable:
+		return true
+	case codes.DeadlineExceeded:
+		return idempotent
+	}
+	return false
+}
+
+// retryRuntimeService is a RuntimeService retrying transient errors.
+type retryRuntimeService struct {
+	retrier
+	service internalapi.RuntimeService
+}
+
+// newRetryRuntimeService wraps service to retry calls failing with
+// transient errors up to retries times, backoff being the first delay.
+func newRetryRuntimeService(service internalapi.RuntimeService, retries int, backoff time.Duration) internalapi.RuntimeService {
+	return &retryRuntimeService{retrier: retrier{retries: retries, backoff: backoff}, service: service}
+}
+
+func (r *retryRuntimeService) Version(apiVersion string) (resp *runtimeapi.VersionResponse, err error) {
+	err = r.do("Version", true, func() error {
+		resp, err = r.service.Version(apiVersion)
+		return err
+	})
+	return resp, err
+}
+
+func (r *retryRuntimeService) CreateContainer(podSandboxID string, config *runtimeapi.ContainerConfig, sandboxConfig *runtimeapi.PodSandboxConfig) (id string, err error) {
+	err = r.do("CreateContainer", false, func() error {
+		id, err = r.service.CreateContainer(podSandboxID, config, sandboxConfig)
+		return err
+	})
+	return id, err
+}
+
+func (r *retryRuntimeService) StartContainer(containerID string) error {
+	return r.do("StartContainer", false, func() error {
+		return r.service.StartContainer(containerID)
+	})
+}
+
+func (r *retryRuntimeService) StopContainer(containerID string, timeout int64) error {
+	return r.do("StopContainer", true, func() error {
+		return r.service.StopContainer(containerID, timeout)
+	})
+}
+
+func (r *retryRuntimeService) RemoveContainer(containerID string) error {
+	return r.do("RemoveContainer", true, func() error {
+		return r.service.RemoveContainer(containerID)
+	})
+}
+
+func (r *retryRuntimeService) ListContainers(filter *runtimeapi.ContainerFilter) (containers []*runtimeapi.Container, err error) {
+	err = r.do("ListContainers", true, func() error {
+		containers, err = r.service.ListContainers(filter)
+		return err
+	})
+	return containers, err
+}
+
+func (r *retryRuntimeService) ContainerStatus(containerID string) (status *runtimeapi.ContainerStatus, err error) {
+	err = r.do("ContainerStatus", true, func() error {
+		status, err = r.service.ContainerStatus(containerID)
+		return err
+	})
+	return status, err
+}
+
+func (r *retryRuntimeService) UpdateContainerResources(containerID string, resources *runtimeapi.LinuxContainerResources) error {
+	return r.do("UpdateContainerResources", true, func() error {
+		return r.service.UpdateContainerResources(containerID, resources)
+	})
+}
+
+func (r *retryRuntimeService) ExecSync(containerID string, cmd []string, timeout time.Duration) (stdout []byte, stderr []byte, err error) {
+	err = r.do("ExecSync", false, func() error {
+		stdout, stderr, err = r.service.ExecSync(containerID, cmd, timeout)
+		return err
+	})
+	return stdout, stderr, err
+}
+
+func (r *retryRuntimeService) Exec(req *runtimeapi.ExecRequest) (resp *runtimeapi.ExecResponse, err error) {
+	err = r.do("Exec", true, func() error {
+		resp, err = r.service.Exec(req)
+		return err
+	})
+	return resp, err
+}
+
+func (r *retryRuntimeService) Attach(req *runtimeapi.AttachRequest) (resp *runtimeapi.AttachResponse, err error) {
+	err = r.do("Attach", true, func() error {
+		resp, err = r.service.Attach(req)
+		return err
+	})
+	return resp, err
+}
+
+func (r *retryRuntimeService) ReopenContainerLog(containerID string) error {
+	return r.do("ReopenContainerLog", true, func() error {
+		return r.service.ReopenContainerLog(containerID)
+	})
+}
+
+func (r *retryRuntimeService) RunPodSandbox(config *runtimeapi.PodSandboxConfig) (id string, err error) {
+	err = r.do("RunPodSandbox", false, func() error {
+		id, err = r.service.RunPodSandbox(config)
+		return err
+	})
+	return id, err
+}
+
+func (r *retryRuntimeService) StopPodSandbox(podSandboxID string) error {
+	return r.do("StopPodSandbox", true, func() error {
+		return r.service.StopPodSandbox(podSandboxID)
+	})
+}
+
+func (r *retryRuntimeService) RemovePodSandbox(podSandboxID string) error {
+	return r.do("RemovePodSandbox", true, func() error {
+		return r.service.RemovePodSandbox(podSandboxID)
+	})
+}
+
+func (r *retryRuntimeService) PodSandboxStatus(podSandboxID string) (status *runtimeapi.PodSandboxStatus, err error) {
+	err = r.do("PodSandboxStatus", true, func() error {
+		status, err = r.service.PodSandboxStatus(podSandboxID)
+		return err
+	})
+	return status, err
+}
+
+func (r *retryRuntimeService) ListPodSandbox(filter *runtimeapi.PodSandboxFilter) (pods []*runtimeapi.PodSandbox, err error) {
+	err = r.do("ListPodSandbox", true, func() error {
+		pods, err = r.service.ListPodSandbox(filter)
+		return err
+	})
+	return pods, err
+}
+
+func (r *retryRuntimeService) PortForward(req *runtimeapi.PortForwardRequest) (resp *runtimeapi.PortForwardResponse, err error) {
+	err = r.do("PortForward", true, func() error {
+		resp, err = r.service.PortForward(req)
+		return err
+	})
+	return resp, err
+}
+
+func (r *retryRuntimeService) ContainerStats(containerID string) (stats *runtimeapi.ContainerStats, err error) {
+	err = r.do("ContainerStats", true, func() error {
+		stats, err = r.service.ContainerStats(containerID)
+		return err
+	})
+	return stats, err
+}
+
+func (r *retryRuntimeService) ListContainerStats(filter *runtimeapi.ContainerStatsFilter) (stats []*runtimeapi.ContainerStats, err error) {
+	err = r.do("ListContainerStats", true, func() error {
+		stats, err = r.service.ListContainerStats(filter)
+		return err
+	})
+	return stats, err
+}
+
+func (r *retryRuntimeService) UpdateRuntimeConfig(runtimeConfig *runtimeapi.RuntimeConfig) error {
+	return r.do("UpdateRuntimeConfig", true, func() error {
+		return r.service.UpdateRuntimeConfig(runtimeConfig)
+	})
+}
+
+func (r *retryRuntimeService) Status() (status *runtimeapi.RuntimeStatus, err error) {
+	err = r.do("Status", true, func() error {
+		status, err = r.service.Status()
+		return err
+	})
+	return status, err
+}
+
+// retryImageService is an ImageManagerService retrying transient errors.
+type retryImageService struct {
+	retrier
+	service internalapi.ImageManagerService
+}
+
+// newRetryImageService wraps service to retry calls failing with transient
+// errors up to retries times, backoff being the first delay.
+func newRetryImageService(service internalapi.ImageManagerService, retries int, backoff time.Duration) internalapi.ImageManagerService {
+	return &retryImageService{retrier: retrier{retries: retries, backoff: backoff}, service: service}
+}
+
+func (r *retryImageService) ListImages(filter *runtimeapi.ImageFilter) (images []*runtimeapi.Image, err error) {
+	err = r.do("ListImages", true, func() error {
+		images, err = r.service.ListImages(filter)
+		return err
+	})
+	return images, err
+}
+
+func (r *retryImageService) ImageStatus(image *runtimeapi.ImageSpec) (status *runtimeapi.Image, err error) {
+	err = r.do("ImageStatus", true, func() error {
+		status, err = r.service.ImageStatus(image)
+		return err
+	})
+	return status, err
+}
+
+func (r *retryImageService) PullImage(image *runtimeapi.ImageSpec, auth *runtimeapi.AuthConfig) (ref string, err error) {
+	err = r.do("PullImage", true, func() error {
+		ref, err = r.service.PullImage(image, auth)
+		return err
+	})
+	return ref, err
+}
+
+func (r *retryImageService) RemoveImage(image *runtimeapi.ImageSpec) error {
+	return r.do("RemoveImage", true, func() error {
+		return r.service.RemoveImage(image)
+	})
+}
+
+func (r *retryImageService) ImageFsInfo() (usage []*runtimeapi.FilesystemUsage, err error) {
+	err = r.do("ImageFsInfo", true, func() error {
+		usage, err = r.service.ImageFsInfo()
+		return err
+	})
+	return usage, err
+}
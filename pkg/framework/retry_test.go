@@ -0,0 +1,78 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework/fakeruntime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetrier(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	deadline := status.Error(codes.DeadlineExceeded, "deadline exceeded")
+	testCases := []struct {
+		desc          string
+		idempotent    bool
+		errs          []error
+		expectedCalls int
+		expectError   bool
+	}{
+		{"success should not be retried", true, nil, 1, false},
+		{"unavailable should be retried", false, []error{unavailable, unavailable}, 3, false},
+		{"deadline exceeded should be retried if idempotent", true, []error{deadline}, 2, false},
+		{"deadline exceeded should not be retried if not idempotent", false, []error{deadline}, 1, true},
+		{"other errors should not be retried", true, []error{status.Error(codes.NotFound, "not found")}, 1, true},
+		{"non grpc errors should not be retried", true, []error{errors.New("failed")}, 1, true},
+		{"retries should be limited", true, []error{unavailable, unavailable, unavailable, unavailable}, 3, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := retrier{retries: 2}
+			calls := 0
+			err := r.do("test", tc.idempotent, func() error {
+				calls++
+				if calls <= len(tc.errs) {
+					return tc.errs[calls-1]
+				}
+				return nil
+			})
+			if (err != nil) != tc.expectError {
+				t.Errorf("expected error: %v; actual error is %v", tc.expectError, err)
+			}
+			if calls != tc.expectedCalls {
+				t.Errorf("expected %d calls, got %d", tc.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestRetryRuntimeService(t *testing.T) {
+	r := fakeruntime.New()
+	r.InjectError("Version", status.Error(codes.Unavailable, "unavailable"), 2)
+	service := newRetryRuntimeService(r, 2, time.Millisecond)
+	if _, err := service.Version("v1alpha2"); err != nil {
+		t.Errorf("expected the transient errors to be retried; actual error is %v", err)
+	}
+	if calls := r.Calls("Version"); calls != 3 {
+		t.Errorf("expected 3 calls; actual calls are %d", calls)
+	}
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SoakOperations are the kinds of operations run in soak mode.
+var SoakOperations = []string{"lifecycle", "exec", "image", "stats"}
+
+// ParseSoakWeights parses a comma separated list of operation=weight pairs.
+// Operations which are not listed are not run.
+func ParseSoakWeights(list string) (map[string]int, error) {
+	weights := make(map[string]int)
+	for _, pair := range strings.Split(list, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid weight %q, should be operation=weight", pair)
+		}
+		operation := strings.TrimSpace(parts[0])
+		if !isSoakOperation(operation) {
+			return nil, fmt.Errorf("unknown operation %q, should be one of %s", operation, strings.Join(SoakOperations, ", "))
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight %q for operation %q", parts[1], operation)
+		}
+		weights[operation] = weight
+	}
+	total := 0
+	for _, weight := range weights {
+		total += weight
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("no operation has a positive weight in %q", list)
+	}
+	return weights, nil
+}
+
+func isSoakOperation(operation string) bool {
+	for _, o := range SoakOperations {
+		if o == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// PickSoakOperation returns an operation picked randomly in proportion to
+// weights, which must have a positive total.
+func PickSoakOperation(weights map[string]int, r *rand.Rand) string {
+	var operations []string
+	total := 0
+	for operation, weight := range weights {
+		operations = append(operations, operation)
+		total += weight
+	}
+	// Iterate in a stable order for the picks to only depend on r.
+	sort.Strings(operations)
+	n := r.Intn(total)
+	for _, operation := range operations {
+		if n < weights[operation] {
+			return operation
+		}
+		n -= weights[operation]
+	}
+	return ""
+}
+
+// SoakStats tracks the error rate and the latency of the soak operations
+// over windows of a fixed interval.
+type SoakStats struct {
+	start    time.Time
+	interval time.Duration
+
+	mu         sync.Mutex
+	operations map[string]*soakOperationStats
+}
+
+type soakOperationStats struct {
+	calls  int
+	errors int
+	// windows are the latencies of the calls in each interval.
+	windows []latencyWindow
+}
+
+type latencyWindow struct {
+	calls int
+	total time.Duration
+}
+
+func (w latencyWindow) mean() time.Duration {
+	if w.calls == 0 {
+		return 0
+	}
+	return w.total / time.Duration(w.calls)
+}
+
+// NewSoakStats creates a SoakStats for a soak started at start, measuring
+// the latency drift over windows of interval.
+func NewSoakStats(start time.Time, interval time.Duration) *SoakStats {
+	return &SoakStats{
+		start:      start,
+		interval:   interval,
+		operations: make(map[string]*soakOperationStats),
+	}
+}
+
+// Record records a call of operation ended at end, which took latency and
+// failed if err is not nil.
+func (s *SoakStats) Record(operation string, end time.Time, latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.operations[operation]
+	if !ok {
+		o = &soakOperationStats{}
+		s.operations[operation] = o
+	}
+	o.calls++
+	if err != nil {
+		o.errors++
+		return
+	}
+	window := int(end.Sub(s.start) / s.interval)
+	if window < 0 {
+		window = 0
+	}
+	for len(o.windows) <= window {
+		o.windows = append(o.windows, latencyWindow{})
+	}
+	o.windows[window].calls++
+	o.windows[window].total += latency
+}
+
+// errorRate returns the ratio of failed calls of o.
+func (o *soakOperationStats) errorRate() float64 {
+	if o.calls == 0 {
+		return 0
+	}
+	return float64(o.errors) / float64(o.calls)
+}
+
+// latencyDrift returns the ratio of the mean latency of the successful
+// calls in the last window to the one in the first window, ignoring the
+// windows without successful calls. It is 1 with less than two windows.
+func (o *soakOperationStats) latencyDrift() float64 {
+	var first, last latencyWindow
+	for _, w := range o.windows {
+		if w.calls == 0 {
+			continue
+		}
+		if first.calls == 0 {
+			first = w
+		}
+		last = w
+	}
+	if first.calls == 0 || first.mean() == 0 {
+		return 1
+	}
+	return float64(last.mean()) / float64(first.mean())
+}
+
+// Check returns a description of each operation exceeding maxErrorRate or
+// maxLatencyDrift.
+func (s *SoakStats) Check(maxErrorRate, maxLatencyDrift float64) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var failures []string
+	for _, operation := range s.sortedOperations() {
+		o := s.operations[operation]
+		if rate := o.errorRate(); rate > maxErrorRate {
+			failures = append(failures, fmt.Sprintf("%s error rate %.4f exceeds %.4f (%d/%d calls failed)", operation, rate, maxErrorRate, o.errors, o.calls))
+		}
+		if drift := o.latencyDrift(); drift > maxLatencyDrift {
+			failures = append(failures, fmt.Sprintf("%s latency drift %.2f exceeds %.2f", operation, drift, maxLatencyDrift))
+		}
+	}
+	return failures
+}
+
+// Summary returns a line per operation with its calls, error rate, mean
+// latency in the last window and latency drift.
+func (s *SoakStats) Summary() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var lines []string
+	for _, operation := range s.sortedOperations() {
+		o := s.operations[operation]
+		var last time.Duration
+		if len(o.windows) > 0 {
+			last = o.windows[len(o.windows)-1].mean()
+		}
+		lines = append(lines, fmt.Sprintf("%s: %d calls, error rate %.4f, mean latency %v, latency drift %.2f",
+			operation, o.calls, o.errorRate(), last, o.latencyDrift()))
+	}
+	return lines
+}
+
+func (s *SoakStats) sortedOperations() []string {
+	var operations []string
+	for operation := range s.operations {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+	return operations
+}
+
+// CountMounts returns the number of mounts visible by the test process, to
+// detect the mounts leaked by the runtime.
+func CountMounts() (int, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	count := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		count++
+	}
+	return count, scanner.Err()
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSoakWeights(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		list      string
+		expected  map[string]int
+		expectErr bool
+	}{
+		{desc: "all operations", list: "lifecycle=4,exec=3,image=1,stats=2", expected: map[string]int{"lifecycle": 4, "exec": 3, "image": 1, "stats": 2}},
+		{desc: "spaces", list: " exec = 1 , ", expected: map[string]int{"exec": 1}},
+		{desc: "unknown operation", list: "exec=1,pull=2", expectErr: true},
+		{desc: "missing weight", list: "exec", expectErr: true},
+		{desc: "negative weight", list: "exec=-1", expectErr: true},
+		{desc: "no positive weight", list: "exec=0", expectErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			weights, err := ParseSoakWeights(tc.list)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error: %v; actual error is %v", tc.expectErr, err)
+			}
+			if !tc.expectErr && !reflect.DeepEqual(weights, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, weights)
+			}
+		})
+	}
+}
+
+func TestPickSoakOperation(t *testing.T) {
+	weights := map[string]int{"exec": 3, "image": 1, "stats": 0}
+	r := rand.New(rand.NewSource(1))
+	picks := make(map[string]int)
+	for i := 0; i < 4000; i++ {
+		picks[PickSoakOperation(weights, r)]++
+	}
+	if picks["stats"] != 0 {
+		t.Errorf("expected operations with a zero weight not to be picked, got %d picks", picks["stats"])
+	}
+	if picks["exec"] < 2*picks["image"] {
+		t.Errorf("expected exec to be picked about 3 times more than image, got %v", picks)
+	}
+}
+
+func TestSoakStatsCheck(t *testing.T) {
+	start := time.Now()
+	failure := errors.New("failed")
+	testCases := []struct {
+		desc     string
+		record   func(s *SoakStats)
+		failures []string
+	}{
+		{
+			desc: "stable latency without errors",
+			record: func(s *SoakStats) {
+				s.Record("exec", start.Add(time.Second), 10*time.Millisecond, nil)
+				s.Record("exec", start.Add(time.Minute+time.Second), 12*time.Millisecond, nil)
+			},
+		},
+		{
+			desc: "error rate exceeded",
+			record: func(s *SoakStats) {
+				s.Record("exec", start.Add(time.Second), 10*time.Millisecond, nil)
+				s.Record("exec", start.Add(2*time.Second), 10*time.Millisecond, failure)
+			},
+			failures: []string{"exec error rate"},
+		},
+		{
+			desc: "latency drift exceeded",
+			record: func(s *SoakStats) {
+				s.Record("lifecycle", start.Add(time.Second), time.Second, nil)
+				s.Record("lifecycle", start.Add(3*time.Minute), 3*time.Second, nil)
+			},
+			failures: []string{"lifecycle latency drift"},
+		},
+		{
+			desc: "failed calls don't count in the latency",
+			record: func(s *SoakStats) {
+				s.Record("image", start.Add(time.Second), time.Second, nil)
+				for i := 0; i < 99; i++ {
+					s.Record("image", start.Add(time.Second), time.Second, nil)
+				}
+				s.Record("image", start.Add(3*time.Minute), time.Minute, failure)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			s := NewSoakStats(start, time.Minute)
+			tc.record(s)
+			failures := s.Check(0.02, 2)
+			if len(failures) != len(tc.failures) {
+				t.Fatalf("expected failures %v, got %v", tc.failures, failures)
+			}
+			for i, failure := range failures {
+				if !strings.HasPrefix(failure, tc.failures[i]) {
+					t.Errorf("expected failure %q to start with %q", failure, tc.failures[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"plugin"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+
+	. "github.com/onsi/ginkgo"
+)
+
+// ExtensionSkipExitCode is the exit code of the extension suite binaries
+// skipping a spec.
+const ExtensionSkipExitCode = 3
+
+// extensionPrefix prefixes the names of the extension suites in the spec
+// texts.
+const extensionPrefix = "[Extension] "
+
+var (
+	suitesLock sync.Mutex
+	// registeredSuites are the names of the extension suites registered.
+	registeredSuites = make(map[string]bool)
+)
+
+// RegisterSuite registers the extension suite named name, whose specs are
+// defined by body like in KubeDescribe. The specs run with the validation
+// specs, and are focused with the extension area. It is called in the init
+// functions of the Go plugins loaded by LoadExtraSuites, or of the packages
+// built into critest.
+func RegisterSuite(name string, body func()) bool {
+	suitesLock.Lock()
+	defer suitesLock.Unlock()
+	if registeredSuites[name] {
+		panic(fmt.Sprintf("extension suite %q registered twice", name))
+	}
+	registeredSuites[name] = true
+	return KubeDescribe(extensionPrefix+name, body)
+}
+
+// RegisteredSuites returns the names of the extension suites registered,
+// sorted.
+func RegisteredSuites() []string {
+	suitesLock.Lock()
+	defer suitesLock.Unlock()
+	var names []string
+	for name := range registeredSuites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadExtraSuites loads the extension suites of dir, before the specs run:
+//
+//   - The Go plugins, .so files, register their suites with RegisterSuite when
+//     loaded. They have to be built against the same cri-tools sources as
+//     critest.
+//   - The other executables are suite binaries named after their suite. They
+//     are run with the CRITEST_RUNTIME_ENDPOINT and CRITEST_IMAGE_ENDPOINT
+//     environment variables set. `binary list` prints the names of their specs,
+//     one per line, and `binary run <spec>` runs a spec. The spec passes if the
+//     binary exits with 0, is skipped if it exits with ExtensionSkipExitCode,
+//     and fails otherwise. Its output is logged.
+func LoadExtraSuites(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f.Name())
+		switch {
+		case !f.Mode().IsRegular():
+		case filepath.Ext(path) == ".so":
+			if err := loadPluginSuites(path); err != nil {
+				return err
+			}
+		case f.Mode()&0111 != 0:
+			if err := loadBinarySuite(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// loadPluginSuites loads the Go plugin at path, which registers its suites.
+func loadPluginSuites(path string) error {
+	registered := len(RegisteredSuites())
+	if _, err := plugin.Open(path); err != nil {
+		return fmt.Errorf("failed to load suite plugin %s: %v", path, err)
+	}
+	if len(RegisteredSuites()) == registered {
+		return fmt.Errorf("suite plugin %s registers no suite", path)
+	}
+	return nil
+}
+
+// loadBinarySuite registers the suite of the binary at path, with a spec
+// running each of the specs it lists.
+func loadBinarySuite(path string) error {
+	specs, err := listBinarySpecs(path)
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	RegisterSuite(name, func() {
+		for _, spec := range specs {
+			spec := spec
+			It(spec, func() {
+				skipped, output, err := runBinarySpec(path, spec)
+				if output != "" {
+					Logf("Output of %s:\n%s", spec, output)
+				}
+				if skipped {
+					Skip(strings.TrimSpace(output))
+				}
+				ExpectNoError(err, "spec %q of suite binary %s failed", spec, path)
+			})
+		}
+	})
+	return nil
+}
+
+// listBinarySpecs returns the specs listed by the suite binary at path.
+func listBinarySpecs(path string) ([]string, error) {
+	var stderr bytes.Buffer
+	cmd := binarySuiteCommand(path, "list")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the specs of suite binary %s: %v: %s", path, err, stderr.String())
+	}
+	var specs []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if spec := strings.TrimSpace(line); spec != "" {
+			specs = append(specs, spec)
+		}
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("suite binary %s lists no spec", path)
+	}
+	return specs, nil
+}
+
+// runBinarySpec runs spec with the suite binary at path, and returns whether
+// it was skipped, and its output.
+func runBinarySpec(path, spec string) (bool, string, error) {
+	out, err := binarySuiteCommand(path, "run", spec).CombinedOutput()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.ExitStatus() == ExtensionSkipExitCode {
+			return true, string(out), nil
+		}
+	}
+	return false, string(out), err
+}
+
+// binarySuiteCommand returns the command running the suite binary at path
+// with args, given the CRI endpoints.
+func binarySuiteCommand(path string, args ...string) *exec.Cmd {
+	cmd := exec.Command(path, args...)
+	cmd.Env = append(os.Environ(),
+		"CRITEST_RUNTIME_ENDPOINT="+TestContext.RuntimeServiceAddr,
+		"CRITEST_IMAGE_ENDPOINT="+imageServiceAddr(),
+	)
+	return cmd
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// suiteBinary is a suite binary with a passing, a skipped and a failing
+// spec, printing the runtime endpoint it is given.
+const suiteBinary = `#!/bin/sh
+case "$1 $2" in
+"list "*) printf 'should pass\n\nshould be skipped\nshould fail\n' ;;
+"run should pass") echo "endpoint $CRITEST_RUNTIME_ENDPOINT" ;;
+"run should be skipped") echo "not supported"; exit 3 ;;
+*) echo "failed" >&2; exit 1 ;;
+esac
+`
+
+func writeSuiteFile(t *testing.T, dir, name, content string, mode os.FileMode) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+func TestBinarySuite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "suites")
+	if err != nil {
+		t.Fatalf("failed to create the suites directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := writeSuiteFile(t, dir, "vendor-suite.sh", suiteBinary, 0755)
+	TestContext.RuntimeServiceAddr = "unix:///run/test.sock"
+
+	specs, err := listBinarySpecs(path)
+	if err != nil {
+		t.Fatalf("failed to list the specs: %v", err)
+	}
+	expected := []string{"should pass", "should be skipped", "should fail"}
+	if !reflect.DeepEqual(specs, expected) {
+		t.Errorf("expected specs %q; actual specs are %q", expected, specs)
+	}
+
+	testCases := []struct {
+		spec          string
+		expectSkipped bool
+		expectError   bool
+		expectOutput  string
+	}{
+		{"should pass", false, false, "endpoint unix:///run/test.sock\n"},
+		{"should be skipped", true, false, "not supported\n"},
+		{"should fail", false, true, "failed\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			skipped, output, err := runBinarySpec(path, tc.spec)
+			if skipped != tc.expectSkipped {
+				t.Errorf("expected skipped: %v; actual skipped is %v", tc.expectSkipped, skipped)
+			}
+			if (err != nil) != tc.expectError {
+				t.Errorf("expected error: %v; actual error is %v", tc.expectError, err)
+			}
+			if output != tc.expectOutput {
+				t.Errorf("expected output %q; actual output is %q", tc.expectOutput, output)
+			}
+		})
+	}
+}
+
+func TestLoadExtraSuites(t *testing.T) {
+	dir, err := ioutil.TempDir("", "suites")
+	if err != nil {
+		t.Fatalf("failed to create the suites directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	writeSuiteFile(t, dir, "loaded-suite", suiteBinary, 0755)
+	writeSuiteFile(t, dir, "README", "not a suite", 0644)
+
+	if err := LoadExtraSuites(dir); err != nil {
+		t.Fatalf("failed to load the suites: %v", err)
+	}
+	found := false
+	for _, name := range RegisteredSuites() {
+		found = found || name == "loaded-suite"
+	}
+	if !found {
+		t.Errorf("expected suite loaded-suite to be registered; actual suites are %q", RegisteredSuites())
+	}
+
+	writeSuiteFile(t, dir, "empty-suite", "#!/bin/sh\n", 0755)
+	if err := LoadExtraSuites(dir); err == nil || !strings.Contains(err.Error(), "lists no spec") {
+		t.Errorf("expected a suite binary listing no spec to fail; actual error is %v", err)
+	}
+}
+
+func TestLoadInvalidPluginSuite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "suites")
+	if err != nil {
+		t.Fatalf("failed to create the suites directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	writeSuiteFile(t, dir, "invalid.so", "not a plugin", 0644)
+
+	if err := LoadExtraSuites(dir); err == nil || !strings.Contains(err.Error(), "failed to load suite plugin") {
+		t.Errorf("expected an invalid plugin to fail; actual error is %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// testImageRepositoryPrefix is the repository prefix of the images only used
+// by the tests, once the registry domain is removed.
+const testImageRepositoryPrefix = "cri-tools/test-image-"
+
+// isTestPodSandbox returns whether the pod sandbox was created by the tests.
+func isTestPodSandbox(metadata *runtimeapi.PodSandboxMetadata) bool {
+	return metadata != nil && (strings.HasPrefix(metadata.Namespace, DefaultNamespacePrefix) ||
+		strings.HasPrefix(metadata.Uid, DefaultUIDPrefix))
+}
+
+// isTestImage returns whether the image was pulled by the tests only: the
+// cri-tools test images, from their registry, a mirror or the embedded test
+// registry. Common images such as busybox are kept since users may rely on
+// them.
+func isTestImage(image *runtimeapi.Image) bool {
+	for _, tag := range image.RepoTags {
+		if strings.HasPrefix(trimRegistry(tag), testImageRepositoryPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// SweepLeakedResources force-removes the pod sandboxes, containers and
+// images left by the tests on the runtime. It returns a description of each
+// removed resource.
+func SweepLeakedResources(c *InternalAPIClient) ([]string, error) {
+	var leaked, errs []string
+
+	pods, err := c.CRIRuntimeClient.ListPodSandbox(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pod sandboxes: %v", err)
+	}
+	testPods := make(map[string]bool)
+	for _, pod := range pods {
+		if isTestPodSandbox(pod.Metadata) {
+			testPods[pod.Id] = true
+		}
+	}
+
+	containers, err := c.CRIRuntimeClient.ListContainers(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %v", err)
+	}
+	for _, container := range containers {
+		if !testPods[container.PodSandboxId] {
+			continue
+		}
+		leaked = append(leaked, fmt.Sprintf("container %s (%s)", container.GetMetadata().GetName(), container.Id))
+		if err := c.CRIRuntimeClient.RemoveContainer(container.Id); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to remove container %q: %v", container.Id, err))
+		}
+	}
+
+	for _, pod := range pods {
+		if !testPods[pod.Id] {
+			continue
+		}
+		leaked = append(leaked, fmt.Sprintf("pod sandbox %s (%s)", pod.Metadata.Name, pod.Id))
+		if err := c.CRIRuntimeClient.StopPodSandbox(pod.Id); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to stop pod sandbox %q: %v", pod.Id, err))
+		}
+		if err := c.CRIRuntimeClient.RemovePodSandbox(pod.Id); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to remove pod sandbox %q: %v", pod.Id, err))
+		}
+	}
+
+	images, err := c.CRIImageClient.ListImages(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list images: %v", err)
+	}
+	for _, image := range images {
+		if !isTestImage(image) {
+			continue
+		}
+		leaked = append(leaked, fmt.Sprintf("image %s (%s)", strings.Join(image.RepoTags, ", "), image.Id))
+		if err := c.CRIImageClient.RemoveImage(&runtimeapi.ImageSpec{Image: image.Id}); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to remove image %q: %v", image.Id, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return leaked, errors.New(strings.Join(errs, "; "))
+	}
+	return leaked, nil
+}
+
+// CheckLeakedResources removes the resources left by the tests, reporting
+// them as warnings, or as a failure with --fail-on-leak.
+func CheckLeakedResources() {
+	c, err := LoadCRIClient()
+	ExpectNoError(err, "failed to load CRI client")
+
+	leaked, err := SweepLeakedResources(c)
+	for _, resource := range leaked {
+		log("WARNING", "Removed leaked %s", resource)
+	}
+	ExpectNoError(err, "failed to remove leaked resources")
+	if len(leaked) > 0 && TestContext.FailOnLeak {
+		Failf("%d resource(s) were leaked by the tests", len(leaked))
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"testing"
+
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+func TestIsTestImage(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		tags     []string
+		expected bool
+	}{
+		{"test image should be swept", []string{"gcr.io/cri-tools/test-image-tag:test"}, true},
+		{"mirrored test image should be swept", []string{"mirror.local:5000/cri-tools/test-image-latest:latest"}, true},
+		{"test registry image should be swept", []string{"localhost:5000/cri-tools/test-image-on-create:latest"}, true},
+		{"local image should be kept", []string{"localhost:5000/busybox:latest"}, false},
+		{"common image should be kept", []string{"docker.io/library/busybox:1.28"}, false},
+		{"untagged image should be kept", nil, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			if isTestImage(&runtimeapi.Image{RepoTags: tc.tags}) != tc.expected {
+				t.Errorf("expected %v for %v", tc.expected, tc.tags)
+			}
+		})
+	}
+}
+
+func TestIsTestPodSandbox(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		metadata *runtimeapi.PodSandboxMetadata
+		expected bool
+	}{
+		{"test pod should be swept", BuildPodSandboxMetadata("pod", DefaultUIDPrefix+"1", DefaultNamespacePrefix+"1", DefaultAttempt), true},
+		{"other pod should be kept", BuildPodSandboxMetadata("pod", "uid", "default", DefaultAttempt), false},
+		{"pod without metadata should be kept", nil, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			if isTestPodSandbox(tc.metadata) != tc.expected {
+				t.Errorf("expected %v for %+v", tc.expected, tc.metadata)
+			}
+		})
+	}
+}
@@ -18,7 +18,10 @@ package framework
 
 import (
 	"flag"
+	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/onsi/ginkgo/config"
@@ -35,9 +38,106 @@ type TestContextType struct {
 	ImageServiceTimeout   time.Duration
 	RuntimeServiceAddr    string
 	RuntimeServiceTimeout time.Duration
+	APIRetries            int
+	APIRetryBackoff       time.Duration
+	KeepaliveTime         time.Duration
+
+	// Timeouts of the validation tests.
+	PollInterval time.Duration
+	StateTimeout time.Duration
+	ExecTimeout  time.Duration
+	// MaxClockSkew is the tolerated difference between the clocks of
+	// critest and of the runtime, when checking the reported timestamps.
+	MaxClockSkew time.Duration
+
+	// ArtifactsDir is the directory where the artifacts of the failed specs
+	// are collected, disabled if empty.
+	ArtifactsDir string
+	// RuntimeLogCommand is the shell command printing the runtime log
+	// collected in the artifacts.
+	RuntimeLogCommand string
+
+	// TraceDir is the directory where the CRI calls of each spec are traced,
+	// disabled if empty.
+	TraceDir string
+
+	// FlakeAttempts is the number of attempts to run each spec, the specs
+	// passing on retry being reported as flaky.
+	FlakeAttempts int
+
+	// LastRunDir is the directory where the results of the last run are
+	// recorded, to rerun the failed specs.
+	LastRunDir string
+
+	// RuntimeProfile is the profile of the runtime behaviors, detected from
+	// the runtime name if empty.
+	RuntimeProfile string
+
+	// ExtraSuitesDir is the directory of the extension suites loaded with
+	// LoadExtraSuites, disabled if empty.
+	ExtraSuitesDir string
+
+	// FailOnLeak fails the suite if resources are left by the tests.
+	FailOnLeak bool
+
+	// ExternalConnectivity enables the tests reaching addresses outside of
+	// the node.
+	ExternalConnectivity bool
+
+	// Chaos settings, injecting faults in the chaos tests.
+	ChaosFaults         string
+	ChaosPoints         string
+	ChaosRestartCommand string
+	ChaosShimPattern    string
+
+	// Streaming protocol used by the exec and attach tests.
+	StreamingProtocol string
+
+	// Test images settings.
+	TestImagesFile string
+	// PullImageOnCreate pulls the images of the containers created by the
+	// tests if they don't exist.
+	PullImageOnCreate bool
 
 	// Benchmark setting.
 	Number int
+
+	// BenchmarkResults is the path of the JSON benchmark results written by
+	// the run, disabled if empty.
+	BenchmarkResults string
+	// BenchmarkBaseline is the path of the benchmark results the run is
+	// compared to, disabled if empty.
+	BenchmarkBaseline string
+	// BenchmarkMaxRegression is the maximum regression of the latencies
+	// compared to the baseline, as a percentage.
+	BenchmarkMaxRegression string
+
+	// MetricsAddress is the address exporting the CRI call metrics in
+	// benchmark mode, disabled if empty.
+	MetricsAddress string
+
+	// ExecSync benchmark settings.
+	ExecSyncNumber      int
+	ExecSyncConcurrency int
+
+	// Container log benchmark settings.
+	LogSize     int
+	LogLineSize int
+
+	// Density mode settings.
+	Density bool
+	MaxPods int
+
+	// Soak mode settings.
+	Soak                    bool
+	SoakDuration            time.Duration
+	SoakWeights             string
+	SoakReportInterval      time.Duration
+	SoakMaxErrorRate        float64
+	SoakMaxLatencyDrift     float64
+	SoakMaxLeakedContainers int
+	SoakMaxLeakedMounts     int
+	SoakMaxLeakedGoroutines int
 }
 
 // TestContext is a test context.
@@ -55,7 +155,7 @@ func RegisterFlags() {
 	config.GinkgoConfig.RandomizeAllSpecs = true
 
 	flag.StringVar(&TestContext.ReportPrefix, "report-prefix", "", "Optional prefix for JUnit XML reports. Default is empty, which doesn't prepend anything to the default name.")
-	flag.StringVar(&TestContext.ReportDir, "report-dir", "", "Path to the directory where the JUnit XML reports should be saved. Default is empty, which doesn't generate these reports.")
+	flag.StringVar(&TestContext.ReportDir, "report-dir", "", "Path to the directory where the JUnit XML reports, the JSON results and the HTML report should be saved. Default is empty, which doesn't generate these reports.")
 	flag.StringVar(&TestContext.ImageServiceAddr, "image-endpoint", "", "Image service socket for client to connect.")
 	flag.DurationVar(&TestContext.ImageServiceTimeout, "image-service-timeout", 300*time.Second, "Timeout when trying to connect to image service.")
 
@@ -65,5 +165,47 @@ func RegisterFlags() {
 	}
 	flag.StringVar(&TestContext.RuntimeServiceAddr, "runtime-endpoint", svcaddr, "Runtime service socket for client to connect..")
 	flag.DurationVar(&TestContext.RuntimeServiceTimeout, "runtime-service-timeout", 300*time.Second, "Timeout when trying to connect to a runtime service.")
+	flag.IntVar(&TestContext.APIRetries, "api-retries", 0, "Number of times CRI calls failing with transient errors (Unavailable, DeadlineExceeded) are retried.")
+	flag.DurationVar(&TestContext.APIRetryBackoff, "api-retry-backoff", time.Second, "Delay before the first retry of a CRI call, doubled after each retry.")
+	flag.DurationVar(&TestContext.KeepaliveTime, "keepalive-time", 5*time.Minute, "Interval of the keepalive pings sent to the runtime during long calls, 0 to disable them. Should not be lower than the minimum interval allowed by the runtime (5m for gRPC servers by default).")
+	flag.DurationVar(&TestContext.PollInterval, "poll-interval", 4*time.Second, "Interval between checks of a container state.")
+	flag.DurationVar(&TestContext.StateTimeout, "state-timeout", time.Minute, "Timeout waiting for a container to reach a state, or for its logs.")
+	flag.DurationVar(&TestContext.ExecTimeout, "exec-timeout", 5*time.Second, "Timeout of the ExecSync calls.")
+	flag.DurationVar(&TestContext.MaxClockSkew, "max-clock-skew", 5*time.Second, "Tolerated difference between the clocks of critest and of the runtime when checking the timestamps reported by the runtime, e.g. when the runtime runs on another host.")
+	flag.StringVar(&TestContext.ArtifactsDir, "artifacts-dir", "", "Path to the directory where the failure message, the recent CRI calls, the pod and container statuses and the runtime log are collected for each failed spec. Disabled by default.")
+	flag.StringVar(&TestContext.RuntimeLogCommand, "runtime-log-command", "", "Shell command printing the runtime log collected with -artifacts-dir, e.g. 'journalctl -u pouch --since \"$CRITEST_SPEC_START\"'. $CRITEST_SPEC_START is the start time of the failed spec.")
+	flag.StringVar(&TestContext.TraceDir, "trace-dir", "", "Path to the directory where the CRI requests and responses of each spec are recorded, with their duration and error, in a JSON lines file named after the spec. Disabled by default.")
+	flag.IntVar(&TestContext.FlakeAttempts, "flake-attempts", 1, "Number of attempts to run each spec. The specs which pass on retry are reported as flaky instead of failed, with the flake rate of their suite.")
+	flag.StringVar(&TestContext.LastRunDir, "last-run-dir", filepath.Join(os.TempDir(), "critest-last-run"), "Path to the directory where the results of the specs are recorded, to rerun the failed ones with -rerun-failed.")
+	flag.StringVar(&TestContext.RuntimeProfile, "runtime-profile", "", "Profile of the runtime, among "+strings.Join(RuntimeProfiles(), ", ")+". The failures of the specs checking a behavior the runtime doesn't support by design are recorded as deviations instead. Detected from the runtime name if empty.")
+	flag.StringVar(&TestContext.ExtraSuitesDir, "extra-suites-dir", "", "Path to the directory of the extension suites to run with the validation tests: Go plugins (.so files) registering suites with framework.RegisterSuite, and suite binaries. Disabled by default.")
+	flag.BoolVar(&TestContext.FailOnLeak, "fail-on-leak", false, "Fail if pod sandboxes, containers or test images are left once the tests are done. They are removed and reported as warnings otherwise.")
+	flag.BoolVar(&TestContext.ExternalConnectivity, "external-connectivity", false, "Run the tests checking that containers can reach an address outside of the node.")
+	flag.StringVar(&TestContext.ChaosFaults, "chaos-faults", "", "Comma separated faults injected by the chaos tests, among "+strings.Join(ChaosFaults, ", ")+". The chaos tests are skipped if empty.")
+	flag.StringVar(&TestContext.ChaosPoints, "chaos-points", strings.Join(ChaosPoints, ","), "Comma separated steps of the container lifecycle after which the chaos tests inject the faults, among "+strings.Join(ChaosPoints, ", ")+".")
+	flag.StringVar(&TestContext.ChaosRestartCommand, "chaos-restart-command", "", "Shell command restarting the runtime, e.g. \"systemctl restart containerd\", for the restart-runtime fault.")
+	flag.StringVar(&TestContext.ChaosShimPattern, "chaos-shim-pattern", "containerd-shim|conmon", "Regular expression matching the command lines of the shim processes killed by the kill-shims fault, along with the container ID.")
+	flag.StringVar(&TestContext.StreamingProtocol, "streaming-protocol", "auto", "Protocol used by the exec and attach tests: auto, spdy or websocket. auto falls back to websocket if the runtime does not serve spdy.")
+	flag.StringVar(&TestContext.TestImagesFile, "test-images", "", "Optional path to a YAML file overriding the registry and references of the images used by tests.")
+	flag.BoolVar(&TestContext.PullImageOnCreate, "pull-image-on-create", true, "Pull the image of the containers created by the tests if it doesn't exist. If false, the images have to be present on the node beforehand.")
 	flag.IntVar(&TestContext.Number, "number", 5, "Number of PodSandbox/container in listing benchmark test.")
+	flag.StringVar(&TestContext.BenchmarkResults, "benchmark-results", "", "Path of the JSON file where the percentiles of the benchmark measurements are written. Disabled by default.")
+	flag.StringVar(&TestContext.BenchmarkBaseline, "baseline", "", "Path of benchmark results written by -benchmark-results in a previous run. The benchmarks fail if a latency percentile regressed by more than -max-regression.")
+	flag.StringVar(&TestContext.BenchmarkMaxRegression, "max-regression", "10%", "Maximum increase of the p50, p90 and p99 latencies of each benchmark operation compared to -baseline.")
+	flag.StringVar(&TestContext.MetricsAddress, "metrics-address", "", "Address, e.g. :9090, of the HTTP endpoint exporting the latency and errors of the CRI calls as Prometheus metrics in benchmark mode. Parallel test nodes listen on the following ports. Disabled by default.")
+	flag.IntVar(&TestContext.ExecSyncNumber, "exec-number", 1000, "Number of ExecSync calls issued in the ExecSync benchmark test.")
+	flag.IntVar(&TestContext.ExecSyncConcurrency, "exec-concurrency", 10, "Number of concurrent workers issuing ExecSync calls in the ExecSync benchmark test.")
+	flag.IntVar(&TestContext.LogSize, "log-size", 64, "Size in MB of the log written by the container in the container log benchmark test.")
+	flag.IntVar(&TestContext.LogLineSize, "log-line-size", 128, "Size in bytes of the log lines written by the container in the container log benchmark test.")
+	flag.BoolVar(&TestContext.Density, "density", false, "Run the density test, creating pod sandboxes with a container until a failure or -max-pods, instead of the validation tests.")
+	flag.IntVar(&TestContext.MaxPods, "max-pods", 110, "Maximum number of pod sandboxes created by the density test.")
+	flag.BoolVar(&TestContext.Soak, "soak", false, "Run the soak test, looping a weighted mix of operations for -duration, instead of the validation tests.")
+	flag.DurationVar(&TestContext.SoakDuration, "duration", time.Hour, "Duration of the soak test.")
+	flag.StringVar(&TestContext.SoakWeights, "soak-weights", "lifecycle=4,exec=3,image=1,stats=2", "Comma separated operation=weight pairs of the soak test operations, among "+strings.Join(SoakOperations, ", ")+".")
+	flag.DurationVar(&TestContext.SoakReportInterval, "soak-report-interval", 5*time.Minute, "Interval of the soak test progress reports, and of the windows comparing the latency of the operations.")
+	flag.Float64Var(&TestContext.SoakMaxErrorRate, "soak-max-error-rate", 0.01, "Maximum ratio of failed calls of each soak test operation.")
+	flag.Float64Var(&TestContext.SoakMaxLatencyDrift, "soak-max-latency-drift", 2, "Maximum ratio of the mean latency of each soak test operation in the last report interval to the one in the first interval.")
+	flag.IntVar(&TestContext.SoakMaxLeakedContainers, "soak-max-leaked-containers", 0, "Maximum number of containers and pod sandboxes left on the runtime by the soak test.")
+	flag.IntVar(&TestContext.SoakMaxLeakedMounts, "soak-max-leaked-mounts", 0, "Maximum increase of the number of mounts on the node during the soak test.")
+	flag.IntVar(&TestContext.SoakMaxLeakedGoroutines, "soak-max-leaked-goroutines", 10, "Maximum increase of the number of goroutines of critest during the soak test.")
 }
@@ -0,0 +1,120 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"fmt"
+	"io/ioutil"
+	"runtime"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// TestImagesConfig is the representation of the file passed by --test-images.
+// It allows air-gapped users to point every spec at mirrored images.
+//
+// Example:
+//
+//	registry: registry.local:5000
+//	images:
+//	  busybox:1.28: registry.local:5000/library/busybox:1.28
+type TestImagesConfig struct {
+	// Registry replaces the registry of every test image which is not
+	// overridden in Images, e.g. "gcr.io/cri-tools/test-image-1:latest"
+	// becomes "<registry>/cri-tools/test-image-1:latest".
+	Registry string `yaml:"registry"`
+	// Images maps the image references used by the specs to their overrides.
+	Images map[string]string `yaml:"images"`
+}
+
+// archImages maps the test images which are not published as multi-arch
+// manifest lists to their references for each non-amd64 architecture.
+var archImages = map[string]map[string]string{
+	"arm64": {
+		"gcr.io/google_containers/nonewprivs:1.2": "gcr.io/kubernetes-e2e-test-images/nonewprivs-arm64:1.0",
+	},
+	"ppc64le": {
+		"gcr.io/google_containers/nonewprivs:1.2": "gcr.io/kubernetes-e2e-test-images/nonewprivs-ppc64le:1.0",
+	},
+	"s390x": {
+		"gcr.io/google_containers/nonewprivs:1.2": "gcr.io/kubernetes-e2e-test-images/nonewprivs-s390x:1.0",
+	},
+}
+
+var (
+	testImagesOnce sync.Once
+	testImagesErr  error
+	testImages     TestImagesConfig
+)
+
+// LoadTestImages loads the test image overrides from TestContext.TestImagesFile.
+// It is safe to call it multiple times, the file is only read once.
+func LoadTestImages() error {
+	testImagesOnce.Do(func() {
+		if TestContext.TestImagesFile == "" {
+			return
+		}
+		data, err := ioutil.ReadFile(TestContext.TestImagesFile)
+		if err != nil {
+			testImagesErr = fmt.Errorf("failed to read test images file %q: %v", TestContext.TestImagesFile, err)
+			return
+		}
+		if err := yaml.Unmarshal(data, &testImages); err != nil {
+			testImagesErr = fmt.Errorf("failed to parse test images file %q: %v", TestContext.TestImagesFile, err)
+		}
+	})
+	return testImagesErr
+}
+
+// ResolveImage returns the image reference which should be used for image,
+// taking the node architecture and the overrides from the test images file
+// into account.
+func ResolveImage(image string) string {
+	return testImages.resolve(image, runtime.GOARCH)
+}
+
+func (c *TestImagesConfig) resolve(image, arch string) string {
+	if override, ok := c.Images[image]; ok {
+		return override
+	}
+	if archImage, ok := archImages[arch][image]; ok {
+		image = archImage
+	}
+	if c.Registry == "" || strings.HasPrefix(image, c.Registry+"/") {
+		return image
+	}
+	// Images on localhost are served by test fixtures and never mirrored.
+	if strings.HasPrefix(image, "localhost/") || strings.HasPrefix(image, "localhost:") {
+		return image
+	}
+	return c.Registry + "/" + trimRegistry(image)
+}
+
+// trimRegistry removes the registry domain from an image reference.
+func trimRegistry(image string) string {
+	i := strings.Index(image, "/")
+	if i == -1 {
+		return image
+	}
+	domain := image[:i]
+	if strings.ContainsAny(domain, ".:") || domain == "localhost" {
+		return image[i+1:]
+	}
+	return image
+}
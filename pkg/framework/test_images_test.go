@@ -0,0 +1,102 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"testing"
+)
+
+func TestResolveImage(t *testing.T) {
+	config := TestImagesConfig{
+		Registry: "registry.local:5000",
+		Images: map[string]string{
+			"nginx": "mirror.local/nginx:stable",
+		},
+	}
+	testCases := []struct {
+		desc     string
+		config   TestImagesConfig
+		arch     string
+		image    string
+		expected string
+	}{
+		{
+			"image should not change without overrides",
+			TestImagesConfig{},
+			"amd64",
+			"busybox:1.28",
+			"busybox:1.28",
+		},
+		{
+			"single-arch image should be replaced on other architectures",
+			TestImagesConfig{},
+			"arm64",
+			"gcr.io/google_containers/nonewprivs:1.2",
+			"gcr.io/kubernetes-e2e-test-images/nonewprivs-arm64:1.0",
+		},
+		{
+			"registry should apply to architecture specific image",
+			config,
+			"s390x",
+			"gcr.io/google_containers/nonewprivs:1.2",
+			"registry.local:5000/kubernetes-e2e-test-images/nonewprivs-s390x:1.0",
+		},
+		{
+			"explicit override should be used",
+			config,
+			"amd64",
+			"nginx",
+			"mirror.local/nginx:stable",
+		},
+		{
+			"docker hub image should be moved to registry",
+			config,
+			"amd64",
+			"busybox:1.28",
+			"registry.local:5000/busybox:1.28",
+		},
+		{
+			"registry domain should be replaced",
+			config,
+			"amd64",
+			"gcr.io/cri-tools/test-image-1:latest",
+			"registry.local:5000/cri-tools/test-image-1:latest",
+		},
+		{
+			"localhost image should not change",
+			config,
+			"amd64",
+			"localhost:5000/cri-tools/test-image-auth:latest",
+			"localhost:5000/cri-tools/test-image-auth:latest",
+		},
+		{
+			"resolved image should not change",
+			config,
+			"amd64",
+			"registry.local:5000/busybox:1.28",
+			"registry.local:5000/busybox:1.28",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := tc.config.resolve(tc.image, tc.arch)
+			if r != tc.expected {
+				t.Errorf("expected %q; actual result is %q", tc.expected, r)
+			}
+		})
+	}
+}
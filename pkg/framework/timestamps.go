@@ -0,0 +1,62 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"fmt"
+	"time"
+)
+
+// Timestamp is a nanosecond timestamp reported by the runtime, such as the
+// CreatedAt of a container.
+type Timestamp struct {
+	Name  string
+	Nanos int64
+}
+
+// String returns the name and the time of t.
+func (t Timestamp) String() string {
+	return fmt.Sprintf("%s %s", t.Name, time.Unix(0, t.Nanos).UTC().Format(time.RFC3339Nano))
+}
+
+// CheckTimestamp checks that t is set, and between notBefore and notAfter,
+// read from the clock of critest, give or take skew for the clock of the
+// runtime.
+func CheckTimestamp(t Timestamp, notBefore, notAfter time.Time, skew time.Duration) error {
+	if t.Nanos <= 0 {
+		return fmt.Errorf("%s is not set", t.Name)
+	}
+	tm := time.Unix(0, t.Nanos)
+	if tm.Before(notBefore.Add(-skew)) || tm.After(notAfter.Add(skew)) {
+		return fmt.Errorf("%v is not between %s and %s, with a clock skew of %v", t,
+			notBefore.UTC().Format(time.RFC3339Nano), notAfter.UTC().Format(time.RFC3339Nano), skew)
+	}
+	return nil
+}
+
+// CheckTimestampOrder checks that the timestamps are set, and in order.
+func CheckTimestampOrder(timestamps ...Timestamp) error {
+	for i, t := range timestamps {
+		if t.Nanos <= 0 {
+			return fmt.Errorf("%s is not set", t.Name)
+		}
+		if i > 0 && t.Nanos < timestamps[i-1].Nanos {
+			return fmt.Errorf("%v is before %v", t, timestamps[i-1])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckTimestamp(t *testing.T) {
+	start := time.Unix(1000, 0)
+	end := start.Add(time.Second)
+	testCases := []struct {
+		desc        string
+		ts          time.Time
+		expectError bool
+	}{
+		{"timestamp in the window", start.Add(500 * time.Millisecond), false},
+		{"timestamp before the window within the skew", start.Add(-time.Second), false},
+		{"timestamp after the window within the skew", end.Add(time.Second), false},
+		{"timestamp before the window beyond the skew", start.Add(-3 * time.Second), true},
+		{"timestamp after the window beyond the skew", end.Add(3 * time.Second), true},
+		{"timestamp in seconds instead of nanoseconds", time.Unix(0, start.Unix()), true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := CheckTimestamp(Timestamp{Name: "CreatedAt", Nanos: tc.ts.UnixNano()}, start, end, 2*time.Second)
+			if (err != nil) != tc.expectError {
+				t.Errorf("expected error: %v; actual error is %v", tc.expectError, err)
+			}
+		})
+	}
+	if err := CheckTimestamp(Timestamp{Name: "CreatedAt"}, start, end, time.Second); err == nil {
+		t.Errorf("expected an unset timestamp to fail")
+	}
+}
+
+func TestCheckTimestampOrder(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		timestamps  []Timestamp
+		expectError bool
+	}{
+		{"ordered timestamps", []Timestamp{{"CreatedAt", 1}, {"StartedAt", 2}, {"FinishedAt", 3}}, false},
+		{"equal timestamps", []Timestamp{{"CreatedAt", 1}, {"StartedAt", 1}}, false},
+		{"unordered timestamps", []Timestamp{{"CreatedAt", 2}, {"StartedAt", 1}}, true},
+		{"unset timestamp", []Timestamp{{"CreatedAt", 1}, {"StartedAt", 0}}, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := CheckTimestampOrder(tc.timestamps...)
+			if (err != nil) != tc.expectError {
+				t.Errorf("expected error: %v; actual error is %v", tc.expectError, err)
+			}
+		})
+	}
+}
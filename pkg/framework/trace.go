@@ -0,0 +1,180 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// traceFileExt is the extension of the trace files, with a JSON
+	// TraceEntry per line.
+	traceFileExt = ".jsonl"
+	// maxTraceLine is the maximum size of a trace entry read by ReadTrace,
+	// the maximum size of the CRI messages.
+	maxTraceLine = 16 * 1024 * 1024
+)
+
+// TraceEntry is a CRI call recorded in a trace file.
+type TraceEntry struct {
+	Time time.Time `json:"time"`
+	// Method is the full gRPC method, e.g.
+	// /runtime.v1alpha2.RuntimeService/Version.
+	Method   string        `json:"method"`
+	Duration time.Duration `json:"duration"`
+	// Request and Response are the JSON messages. Response is omitted when
+	// the call failed.
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response,omitempty"`
+	// Code is the gRPC code of the call, and Error its error message.
+	Code  string `json:"code"`
+	Error string `json:"error,omitempty"`
+}
+
+// tracer writes the CRI calls of a spec to its trace file.
+type tracer struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+var (
+	traceMu sync.Mutex
+	// specTracer is the tracer of the current spec, nil if the calls are
+	// not traced.
+	specTracer *tracer
+)
+
+// startSpecTrace starts tracing the CRI calls of spec in its trace file in
+// TestContext.TraceDir, if set.
+func startSpecTrace(spec string) {
+	if TestContext.TraceDir == "" {
+		return
+	}
+	stopSpecTrace()
+	if err := os.MkdirAll(TestContext.TraceDir, 0755); err != nil {
+		Logf("Failed to create the trace directory: %v", err)
+		return
+	}
+	f, err := os.Create(filepath.Join(TestContext.TraceDir, specFileName(spec)+traceFileExt))
+	if err != nil {
+		Logf("Failed to create the trace file: %v", err)
+		return
+	}
+	traceMu.Lock()
+	defer traceMu.Unlock()
+	specTracer = &tracer{file: f, enc: json.NewEncoder(f)}
+}
+
+// stopSpecTrace closes the trace file of the current spec.
+func stopSpecTrace() {
+	traceMu.Lock()
+	t := specTracer
+	specTracer = nil
+	traceMu.Unlock()
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.file.Close(); err != nil {
+		Logf("Failed to close the trace file: %v", err)
+	}
+}
+
+// traceCall writes a call to the trace file of the current spec, if traced.
+func traceCall(method string, req, reply interface{}, start time.Time, duration time.Duration, err error) {
+	traceMu.Lock()
+	t := specTracer
+	traceMu.Unlock()
+	if t == nil {
+		return
+	}
+
+	entry := TraceEntry{
+		Time:     start,
+		Method:   method,
+		Duration: duration,
+		Request:  marshalTraceMessage(req),
+		Code:     codes.Unknown.String(),
+	}
+	if s, ok := status.FromError(err); ok {
+		entry.Code = s.Code().String()
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.Response = marshalTraceMessage(reply)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.enc.Encode(&entry); err != nil {
+		Logf("Failed to write the trace of %s: %v", method, err)
+	}
+}
+
+// marshalTraceMessage marshals a request or response, or returns a JSON
+// string describing why it can't be marshaled.
+func marshalTraceMessage(message interface{}) json.RawMessage {
+	m, ok := message.(proto.Message)
+	if !ok {
+		data, _ := json.Marshal(fmt.Sprintf("%T is not a protobuf message", message))
+		return data
+	}
+	var marshaler jsonpb.Marshaler
+	data, err := marshaler.MarshalToString(m)
+	if err != nil {
+		data, _ := json.Marshal(fmt.Sprintf("failed to marshal %T: %v", message, err))
+		return data
+	}
+	return json.RawMessage(data)
+}
+
+// ReadTrace reads the calls of a trace file written with
+// TestContext.TraceDir.
+func ReadTrace(path string) ([]TraceEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []TraceEntry
+	scanner := bufio.NewScanner(f)
+	// Responses such as ListContainers can be large.
+	scanner.Buffer(make([]byte, 64*1024), maxTraceLine)
+	for line := 1; scanner.Scan(); line++ {
+		var entry TraceEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid trace entry at %s:%d: %v", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+func TestSpecTrace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	TestContext.TraceDir = dir
+	defer func() { TestContext.TraceDir = "" }()
+
+	start := time.Now()
+	traceCall("/runtime.v1alpha2.RuntimeService/Version", &runtimeapi.VersionRequest{}, &runtimeapi.VersionResponse{}, start, time.Second, nil)
+	startSpecTrace("[k8s.io] Traced spec")
+	traceCall("/runtime.v1alpha2.RuntimeService/Version", &runtimeapi.VersionRequest{Version: "v1alpha2"}, &runtimeapi.VersionResponse{RuntimeName: "fake"}, start, time.Second, nil)
+	traceCall("/runtime.v1alpha2.RuntimeService/ContainerStatus", &runtimeapi.ContainerStatusRequest{ContainerId: "missing"}, &runtimeapi.ContainerStatusResponse{}, start, time.Millisecond, status.Error(codes.NotFound, "not found"))
+	stopSpecTrace()
+	traceCall("/runtime.v1alpha2.RuntimeService/Version", &runtimeapi.VersionRequest{}, &runtimeapi.VersionResponse{}, start, time.Second, nil)
+
+	entries, err := ReadTrace(filepath.Join(dir, "_k8s.io_Traced_spec.jsonl"))
+	if err != nil {
+		t.Fatalf("failed to read the trace: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected the 2 calls of the spec, got %+v", entries)
+	}
+	var response runtimeapi.VersionResponse
+	if err := jsonpb.UnmarshalString(string(entries[0].Response), &response); err != nil || response.RuntimeName != "fake" {
+		t.Errorf("expected the Version response to be recorded, got %s: %v", entries[0].Response, err)
+	}
+	if e := entries[1]; e.Code != "NotFound" || e.Response != nil || e.Duration != time.Millisecond {
+		t.Errorf("expected the failed ContainerStatus call to be recorded, got %+v", e)
+	}
+}
@@ -22,10 +22,11 @@ import (
 	"sync"
 	"time"
 
+	"github.com/kubernetes-sigs/cri-tools/pkg/remote"
 	"github.com/pborman/uuid"
+	"golang.org/x/net/context"
 	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
 	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
-	"k8s.io/kubernetes/pkg/kubelet/remote"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -56,27 +57,71 @@ const (
 	DefaultStopContainerTimeout int64 = 60
 )
 
-// LoadCRIClient creates a InternalAPIClient.
+// keepaliveTimeout is the time waited for a keepalive acknowledgement before
+// closing the connection to the runtime.
+const keepaliveTimeout = 20 * time.Second
+
+var (
+	connectionsOnce sync.Once
+	// connections are the connections to the CRI endpoints shared by all
+	// the clients of the test process.
+	connections *remote.Manager
+)
+
+// getConnections returns the manager of the connections to the CRI endpoints.
+func getConnections() *remote.Manager {
+	connectionsOnce.Do(func() {
+		connections = remote.NewManager(remote.Options{
+			KeepaliveTime:    TestContext.KeepaliveTime,
+			KeepaliveTimeout: keepaliveTimeout,
+			WaitForReady:     true,
+			UnaryInterceptor: recordCall,
+		})
+	})
+	return connections
+}
+
+// LoadCRIClient creates a InternalAPIClient. The clients share a single
+// connection per endpoint, reestablished after transient failures.
 func LoadCRIClient() (*InternalAPIClient, error) {
-	rService, err := remote.NewRemoteRuntimeService(TestContext.RuntimeServiceAddr, TestContext.RuntimeServiceTimeout)
+	rService, err := getConnections().RuntimeService(TestContext.RuntimeServiceAddr, TestContext.RuntimeServiceTimeout)
 	if err != nil {
 		return nil, err
 	}
 
-	var imageServiceAddr = TestContext.ImageServiceAddr
-	if imageServiceAddr == "" {
-		// Fallback to runtime service endpoint
-		imageServiceAddr = TestContext.RuntimeServiceAddr
-	}
-	iService, err := remote.NewRemoteImageService(imageServiceAddr, TestContext.ImageServiceTimeout)
+	iService, err := getConnections().ImageService(imageServiceAddr(), TestContext.ImageServiceTimeout)
 	if err != nil {
 		return nil, err
 	}
 
-	return &InternalAPIClient{
+	if criMetrics != nil {
+		rService = newMetricsRuntimeService(rService, criMetrics)
+		iService = newMetricsImageService(iService, criMetrics)
+	}
+
+	client := &InternalAPIClient{
 		CRIRuntimeClient: rService,
 		CRIImageClient:   iService,
-	}, nil
+	}
+	if TestContext.APIRetries > 0 {
+		client.CRIRuntimeClient = newRetryRuntimeService(rService, TestContext.APIRetries, TestContext.APIRetryBackoff)
+		client.CRIImageClient = newRetryImageService(iService, TestContext.APIRetries, TestContext.APIRetryBackoff)
+	}
+	return client, nil
+}
+
+// imageServiceAddr returns the image service endpoint.
+func imageServiceAddr() string {
+	if TestContext.ImageServiceAddr == "" {
+		// Fallback to runtime service endpoint
+		return TestContext.RuntimeServiceAddr
+	}
+	return TestContext.ImageServiceAddr
+}
+
+// CloseCRIConnections closes the connections to the CRI endpoints.
+func CloseCRIConnections() error {
+	return getConnections().Close()
 }
 
 func nowStamp() string {
@@ -96,7 +141,7 @@ func Logf(format string, args ...interface{}) {
 func Failf(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
 	log("INFO", msg)
-	Fail(nowStamp()+": "+msg, 1)
+	FailWithArtifacts(nowStamp()+": "+msg, 1)
 }
 
 // ExpectNoError reports error if err is not nil.
@@ -188,10 +233,30 @@ func CreateDefaultContainer(rc internalapi.RuntimeService, ic internalapi.ImageM
 	return CreateContainer(rc, ic, containerConfig, podID, podConfig)
 }
 
-// CreateContainerWithError creates a container but leave error check to caller
+// CreateContainerWithError creates a container but leave error check to
+// caller. The image is pulled first if it does not exist, unless
+// TestContext.PullImageOnCreate is false.
 func CreateContainerWithError(rc internalapi.RuntimeService, ic internalapi.ImageManagerService, config *runtimeapi.ContainerConfig, podID string, podConfig *runtimeapi.PodSandboxConfig) (string, error) {
-	// Pull the image if it does not exist.
-	imageName := config.Image.Image
+	return CreateContainerWithPullPolicy(rc, ic, config, podID, podConfig, TestContext.PullImageOnCreate)
+}
+
+// CreateContainerWithPullPolicy creates a container but leave error check to
+// caller. The image is pulled first if it does not exist and pull is true,
+// otherwise the runtime is expected to fail if it does not exist.
+func CreateContainerWithPullPolicy(rc internalapi.RuntimeService, ic internalapi.ImageManagerService, config *runtimeapi.ContainerConfig, podID string, podConfig *runtimeapi.PodSandboxConfig, pull bool) (string, error) {
+	config.Image.Image = ResolveImage(config.Image.Image)
+	if pull {
+		PullImageIfNotPresent(ic, config.Image.Image)
+	}
+
+	By("Create container.")
+	containerID, err := rc.CreateContainer(podID, config, podConfig)
+	return containerID, err
+}
+
+// PullImageIfNotPresent pulls the image named imageName if it does not exist.
+func PullImageIfNotPresent(ic internalapi.ImageManagerService, imageName string) {
+	imageName = ResolveImage(imageName)
 	if !strings.Contains(imageName, ":") {
 		imageName = imageName + ":latest"
 		Logf("Use latest as default image tag.")
@@ -201,10 +266,6 @@ func CreateContainerWithError(rc internalapi.RuntimeService, ic internalapi.Imag
 	if status == nil {
 		PullPublicImage(ic, imageName)
 	}
-
-	By("Create container.")
-	containerID, err := rc.CreateContainer(podID, config, podConfig)
-	return containerID, err
 }
 
 // CreateContainer creates a container with the prefix of containerName.
@@ -217,6 +278,7 @@ func CreateContainer(rc internalapi.RuntimeService, ic internalapi.ImageManagerS
 
 // ImageStatus gets the status of the image named imageName.
 func ImageStatus(c internalapi.ImageManagerService, imageName string) *runtimeapi.Image {
+	imageName = ResolveImage(imageName)
 	By("Get image status for image: " + imageName)
 	imageSpec := &runtimeapi.ImageSpec{
 		Image: imageName,
@@ -226,6 +288,43 @@ func ImageStatus(c internalapi.ImageManagerService, imageName string) *runtimeap
 	return status
 }
 
+// ImageStatusVerbose gets the status of the image named imageName along with
+// its verbose info, which the ImageManagerService interface doesn't expose.
+func ImageStatusVerbose(imageName string) *runtimeapi.ImageStatusResponse {
+	imageName = ResolveImage(imageName)
+	By("Get verbose image status for image: " + imageName)
+	ctx, cancel := context.WithTimeout(context.Background(), TestContext.ImageServiceTimeout)
+	defer cancel()
+	resp, err := imageServiceClient().ImageStatus(ctx, &runtimeapi.ImageStatusRequest{
+		Image:   &runtimeapi.ImageSpec{Image: imageName},
+		Verbose: true,
+	})
+	ExpectNoError(err, "failed to get verbose image status: %v", err)
+	return resp
+}
+
+// PullImageWithContext pulls the image named imageName until ctx is done,
+// which allows cancelling the pull unlike the ImageManagerService interface.
+func PullImageWithContext(ctx context.Context, imageName string) (string, error) {
+	imageName = ResolveImage(imageName)
+	By("Pull image with context : " + imageName)
+	resp, err := imageServiceClient().PullImage(ctx, &runtimeapi.PullImageRequest{
+		Image: &runtimeapi.ImageSpec{Image: imageName},
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.ImageRef, nil
+}
+
+// imageServiceClient returns a gRPC client of the image service, for the
+// calls which can't go through the ImageManagerService interface.
+func imageServiceClient() runtimeapi.ImageServiceClient {
+	conn, err := getConnections().Dial(imageServiceAddr())
+	ExpectNoError(err, "failed to connect to the image service: %v", err)
+	return runtimeapi.NewImageServiceClient(conn)
+}
+
 // ListImage list the image filtered by the image filter.
 func ListImage(c internalapi.ImageManagerService, filter *runtimeapi.ImageFilter) []*runtimeapi.Image {
 	images, err := c.ListImages(filter)
@@ -235,6 +334,7 @@ func ListImage(c internalapi.ImageManagerService, filter *runtimeapi.ImageFilter
 
 // PullPublicImage pulls the public image named imageName.
 func PullPublicImage(c internalapi.ImageManagerService, imageName string) string {
+	imageName = ResolveImage(imageName)
 	if !strings.Contains(imageName, ":") {
 		imageName = imageName + ":latest"
 		Logf("Use latest as default image tag.")
@@ -0,0 +1,164 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics records the latency and errors of CRI calls, and exports
+// them in the Prometheus text format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// latencyMetric is the name of the histogram of the call latencies.
+	latencyMetric = "cri_client_request_duration_seconds"
+	// errorsMetric is the name of the counter of the failed calls.
+	errorsMetric = "cri_client_request_errors_total"
+
+	// contentType is the content type of the Prometheus text format.
+	contentType = "text/plain; version=0.0.4"
+)
+
+// DefaultBuckets are the default upper bounds, in seconds, of the latency
+// histogram buckets.
+var DefaultBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+// Registry records the latency and errors of the CRI calls by method.
+type Registry struct {
+	buckets []float64
+
+	mu      sync.Mutex
+	methods map[string]*methodMetrics
+}
+
+// methodMetrics are the metrics of the calls of a method.
+type methodMetrics struct {
+	// counts are the number of calls in each bucket, not cumulative.
+	counts []uint64
+	count  uint64
+	sum    float64
+	// errors are the number of failed calls by gRPC code.
+	errors map[codes.Code]uint64
+}
+
+// NewRegistry creates a Registry with latency histograms of the given
+// bucket upper bounds, in seconds and in increasing order.
+func NewRegistry(buckets []float64) *Registry {
+	return &Registry{
+		buckets: buckets,
+		methods: make(map[string]*methodMetrics),
+	}
+}
+
+// Observe records a call of method which took latency and failed with err,
+// if not nil. Errors which are not gRPC errors are counted as Unknown.
+func (r *Registry) Observe(method string, latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.methods[method]
+	if !ok {
+		m = &methodMetrics{
+			counts: make([]uint64, len(r.buckets)),
+			errors: make(map[codes.Code]uint64),
+		}
+		r.methods[method] = m
+	}
+
+	seconds := latency.Seconds()
+	for i, bound := range r.buckets {
+		if seconds <= bound {
+			m.counts[i]++
+			break
+		}
+	}
+	m.count++
+	m.sum += seconds
+	if err != nil {
+		m.errors[errorCode(err)]++
+	}
+}
+
+// errorCode returns the gRPC code of err, Unknown if it isn't a gRPC error.
+func errorCode(err error) codes.Code {
+	if s, ok := status.FromError(err); ok {
+		return s.Code()
+	}
+	return codes.Unknown
+}
+
+// Write writes the metrics in the Prometheus text format to w.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var methods []string
+	for method := range r.methods {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "# HELP %s Latency of the CRI calls in seconds.\n", latencyMetric)
+	fmt.Fprintf(b, "# TYPE %s histogram\n", latencyMetric)
+	for _, method := range methods {
+		m := r.methods[method]
+		var cumulative uint64
+		for i, bound := range r.buckets {
+			cumulative += m.counts[i]
+			fmt.Fprintf(b, "%s_bucket{method=%q,le=%q} %d\n", latencyMetric, method, formatFloat(bound), cumulative)
+		}
+		fmt.Fprintf(b, "%s_bucket{method=%q,le=\"+Inf\"} %d\n", latencyMetric, method, m.count)
+		fmt.Fprintf(b, "%s_sum{method=%q} %s\n", latencyMetric, method, formatFloat(m.sum))
+		fmt.Fprintf(b, "%s_count{method=%q} %d\n", latencyMetric, method, m.count)
+	}
+
+	fmt.Fprintf(b, "# HELP %s Number of CRI calls which failed, by gRPC code.\n", errorsMetric)
+	fmt.Fprintf(b, "# TYPE %s counter\n", errorsMetric)
+	for _, method := range methods {
+		m := r.methods[method]
+		var errorCodes []codes.Code
+		for code := range m.errors {
+			errorCodes = append(errorCodes, code)
+		}
+		sort.Slice(errorCodes, func(i, j int) bool { return errorCodes[i] < errorCodes[j] })
+		for _, code := range errorCodes {
+			fmt.Fprintf(b, "%s{method=%q,code=%q} %d\n", errorsMetric, method, code.String(), m.errors[code])
+		}
+	}
+	return b.Flush()
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", contentType)
+	r.Write(w)
+}
+
+// formatFloat formats f as in the Prometheus text format.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	r := NewRegistry([]float64{0.01, 0.1})
+	r.Observe("Version", 5*time.Millisecond, nil)
+	r.Observe("Version", 50*time.Millisecond, status.Error(codes.Unavailable, "unavailable"))
+	r.Observe("Version", time.Second, errors.New("failed"))
+	r.Observe("ListContainers", 20*time.Millisecond, status.Error(codes.Unavailable, "unavailable"))
+
+	var b bytes.Buffer
+	if err := r.Write(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `# HELP cri_client_request_duration_seconds Latency of the CRI calls in seconds.
+# TYPE cri_client_request_duration_seconds histogram
+cri_client_request_duration_seconds_bucket{method="ListContainers",le="0.01"} 0
+cri_client_request_duration_seconds_bucket{method="ListContainers",le="0.1"} 1
+cri_client_request_duration_seconds_bucket{method="ListContainers",le="+Inf"} 1
+cri_client_request_duration_seconds_sum{method="ListContainers"} 0.02
+cri_client_request_duration_seconds_count{method="ListContainers"} 1
+cri_client_request_duration_seconds_bucket{method="Version",le="0.01"} 1
+cri_client_request_duration_seconds_bucket{method="Version",le="0.1"} 2
+cri_client_request_duration_seconds_bucket{method="Version",le="+Inf"} 3
+cri_client_request_duration_seconds_sum{method="Version"} 1.055
+cri_client_request_duration_seconds_count{method="Version"} 3
+# HELP cri_client_request_errors_total Number of CRI calls which failed, by gRPC code.
+# TYPE cri_client_request_errors_total counter
+cri_client_request_errors_total{method="ListContainers",code="Unavailable"} 1
+cri_client_request_errors_total{method="Version",code="Unknown"} 1
+cri_client_request_errors_total{method="Version",code="Unavailable"} 1
+`
+	if b.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
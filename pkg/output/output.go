@@ -0,0 +1,110 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package output renders the results of the crictl list commands in the
+// json, yaml, table and template output formats.
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+)
+
+// Options are the output options shared by the list commands.
+type Options struct {
+	// Format is one of json, yaml, table, go-template=TEMPLATE and
+	// jsonpath=TEMPLATE. Empty means table.
+	Format string
+	// Columns selects and orders the table columns by name. All columns
+	// are shown if it is empty.
+	Columns []string
+	// NoTrunc disables truncating IDs in the table.
+	NoTrunc bool
+	// Quiet only prints the IDColumn of the table, without header.
+	Quiet bool
+}
+
+// ParseColumns parses a comma separated list of column names.
+func ParseColumns(columns string) []string {
+	var result []string
+	for _, c := range strings.Split(columns, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			result = append(result, strings.ToLower(c))
+		}
+	}
+	return result
+}
+
+// Write writes obj in the format selected by opts. The table is only used
+// for the table format.
+func Write(w io.Writer, obj proto.Message, table *Table, opts Options) error {
+	switch {
+	case opts.Format == "json":
+		data, err := ProtobufToJSON(obj)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, data)
+		return err
+	case opts.Format == "yaml":
+		data, err := ProtobufToJSON(obj)
+		if err != nil {
+			return err
+		}
+		yamlData, err := yaml.JSONToYAML([]byte(data))
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(yamlData))
+		return err
+	case IsTemplate(opts.Format):
+		data, err := ProtobufToJSON(obj)
+		if err != nil {
+			return err
+		}
+		return WriteTemplate(w, data, opts.Format)
+	case opts.Format == "table" || opts.Format == "":
+		return table.Write(w, opts)
+	}
+	return fmt.Errorf("unsupported output format %q", opts.Format)
+}
+
+// ProtobufToJSON marshals obj to indented JSON.
+func ProtobufToJSON(obj proto.Message) (string, error) {
+	marshaler := jsonpb.Marshaler{EmitDefaults: true, Indent: "  "}
+	return marshaler.MarshalToString(obj)
+}
+
+// TruncateID trims prefix from id and truncates it to the length used in
+// tables, unless noTrunc is set.
+func TruncateID(id, prefix string, noTrunc bool) string {
+	if noTrunc {
+		return id
+	}
+	id = strings.TrimPrefix(id, prefix)
+	if len(id) > truncatedIDLen {
+		id = id[:truncatedIDLen]
+	}
+	return id
+}
+
+// truncatedIDLen is the length of truncated IDs.
+const truncatedIDLen = 13
@@ -0,0 +1,119 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// IDColumn is the name of the column printed in quiet mode.
+const IDColumn = "id"
+
+// Column is a table column.
+type Column struct {
+	// Name is used to select the column with Options.Columns.
+	Name string
+	// Header is printed in the table header.
+	Header string
+}
+
+// Table is a table of rows with named columns.
+type Table struct {
+	columns []Column
+	rows    [][]string
+}
+
+// NewTable creates a table with columns.
+func NewTable(columns ...Column) *Table {
+	return &Table{columns: columns}
+}
+
+// AddRow adds a row with a cell for each column.
+func (t *Table) AddRow(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+// Write writes the table with the columns selected by opts.
+func (t *Table) Write(w io.Writer, opts Options) error {
+	if opts.Quiet {
+		indexes, err := t.selectColumns([]string{IDColumn})
+		if err != nil {
+			return err
+		}
+		for _, row := range t.rows {
+			if _, err := fmt.Fprintln(w, row[indexes[0]]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	indexes, err := t.selectColumns(opts.Columns)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 20, 1, 3, ' ', 0)
+	var cells []string
+	for _, i := range indexes {
+		cells = append(cells, t.columns[i].Header)
+	}
+	fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	for _, row := range t.rows {
+		cells = cells[:0]
+		for _, i := range indexes {
+			cells = append(cells, row[i])
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// selectColumns returns the indexes of the selected columns.
+func (t *Table) selectColumns(names []string) ([]int, error) {
+	var indexes []int
+	if len(names) == 0 {
+		for i := range t.columns {
+			indexes = append(indexes, i)
+		}
+		return indexes, nil
+	}
+	for _, name := range names {
+		found := false
+		for i, c := range t.columns {
+			if c.Name == name {
+				indexes = append(indexes, i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %q, should be one of %s", name, strings.Join(t.columnNames(), ", "))
+		}
+	}
+	return indexes, nil
+}
+
+func (t *Table) columnNames() []string {
+	var names []string
+	for _, c := range t.columns {
+		names = append(names, c.Name)
+	}
+	return names
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTableWrite(t *testing.T) {
+	newTable := func() *Table {
+		table := NewTable(
+			Column{Name: "name", Header: "NAME"},
+			Column{Name: IDColumn, Header: "ID"},
+			Column{Name: "state", Header: "STATE"},
+		)
+		table.AddRow("foo", "1234", "Running")
+		table.AddRow("bar", "5678", "Exited")
+		return table
+	}
+	testCases := []struct {
+		desc        string
+		opts        Options
+		expected    []string
+		expectError bool
+	}{
+		{
+			desc:     "all columns should be shown by default",
+			opts:     Options{},
+			expected: []string{"NAME ID STATE", "foo 1234 Running", "bar 5678 Exited"},
+		},
+		{
+			desc:     "selected columns should be shown in order",
+			opts:     Options{Columns: []string{"state", "name"}},
+			expected: []string{"STATE NAME", "Running foo", "Exited bar"},
+		},
+		{
+			desc:     "quiet should only show the id column",
+			opts:     Options{Quiet: true, Columns: []string{"name"}},
+			expected: []string{"1234", "5678"},
+		},
+		{
+			desc:        "unknown column should fail",
+			opts:        Options{Columns: []string{"size"}},
+			expectError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := newTable().Write(&buf, tc.opts)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error; actual result is %q", buf.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var lines []string
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				lines = append(lines, strings.Join(strings.Fields(line), " "))
+			}
+			if strings.Join(lines, "\n") != strings.Join(tc.expected, "\n") {
+				t.Errorf("expected %q; actual result is %q", tc.expected, lines)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+const (
+	goTemplateOutputPrefix = "go-template="
+	jsonPathOutputPrefix   = "jsonpath="
+)
+
+// IsTemplate returns whether format is a go-template or jsonpath output format.
+func IsTemplate(format string) bool {
+	return strings.HasPrefix(format, goTemplateOutputPrefix) || strings.HasPrefix(format, jsonPathOutputPrefix)
+}
+
+// WriteTemplate renders the JSON encoded data with a "go-template=" or
+// "jsonpath=" output format and writes the result.
+func WriteTemplate(w io.Writer, data string, format string) error {
+	result, err := renderTemplate(data, format)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, result)
+	return err
+}
+
+func renderTemplate(data string, output string) (string, error) {
+	var obj interface{}
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&obj); err != nil {
+		return "", fmt.Errorf("failed to decode output: %v", err)
+	}
+
+	switch {
+	case strings.HasPrefix(output, goTemplateOutputPrefix):
+		tmpl, err := template.New("output").Parse(strings.TrimPrefix(output, goTemplateOutputPrefix))
+		if err != nil {
+			return "", fmt.Errorf("failed to parse go-template: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, obj); err != nil {
+			return "", fmt.Errorf("failed to execute go-template: %v", err)
+		}
+		return buf.String(), nil
+	case strings.HasPrefix(output, jsonPathOutputPrefix):
+		return executeJSONPath(strings.TrimPrefix(output, jsonPathOutputPrefix), obj)
+	}
+	return "", fmt.Errorf("unsupported output format %q", output)
+}
+
+// executeJSONPath evaluates a jsonpath template on obj. The supported
+// syntax is a subset of the kubectl one: text with {expression} blocks,
+// where an expression is a path of .field, [index] and [*] selectors
+// starting at the root (optionally written as $).
+func executeJSONPath(tmpl string, obj interface{}) (string, error) {
+	var buf bytes.Buffer
+	for len(tmpl) > 0 {
+		start := strings.Index(tmpl, "{")
+		if start == -1 {
+			buf.WriteString(tmpl)
+			break
+		}
+		end := strings.Index(tmpl[start:], "}")
+		if end == -1 {
+			return "", fmt.Errorf("unclosed expression in jsonpath %q", tmpl)
+		}
+		buf.WriteString(tmpl[:start])
+
+		values, err := evalJSONPath(tmpl[start+1:start+end], obj)
+		if err != nil {
+			return "", err
+		}
+		var results []string
+		for _, v := range values {
+			s, err := jsonPathValueToString(v)
+			if err != nil {
+				return "", err
+			}
+			results = append(results, s)
+		}
+		buf.WriteString(strings.Join(results, " "))
+		tmpl = tmpl[start+end+1:]
+	}
+	return buf.String(), nil
+}
+
+// evalJSONPath evaluates a single jsonpath expression and returns the matched values.
+func evalJSONPath(expr string, obj interface{}) ([]interface{}, error) {
+	path := strings.TrimPrefix(strings.TrimSpace(expr), "$")
+	values := []interface{}{obj}
+	for len(path) > 0 {
+		var next []interface{}
+		switch path[0] {
+		case '.':
+			path = path[1:]
+			i := strings.IndexAny(path, ".[")
+			if i == -1 {
+				i = len(path)
+			}
+			field := path[:i]
+			path = path[i:]
+			if field == "" {
+				continue
+			}
+			for _, v := range values {
+				m, ok := v.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if fv, ok := m[field]; ok {
+					next = append(next, fv)
+				}
+			}
+		case '[':
+			i := strings.Index(path, "]")
+			if i == -1 {
+				return nil, fmt.Errorf("unclosed index in jsonpath expression %q", expr)
+			}
+			index := path[1:i]
+			path = path[i+1:]
+			for _, v := range values {
+				list, ok := v.([]interface{})
+				if !ok {
+					continue
+				}
+				if index == "*" {
+					next = append(next, list...)
+					continue
+				}
+				n, err := strconv.Atoi(index)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index %q in jsonpath expression %q", index, expr)
+				}
+				if n < 0 {
+					n += len(list)
+				}
+				if n >= 0 && n < len(list) {
+					next = append(next, list[n])
+				}
+			}
+		default:
+			return nil, fmt.Errorf("invalid jsonpath expression %q", expr)
+		}
+		values = next
+	}
+	return values, nil
+}
+
+func jsonPathValueToString(v interface{}) (string, error) {
+	switch value := v.(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case nil:
+		return "", nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
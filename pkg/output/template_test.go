@@ -0,0 +1,94 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	data := `{"status":{"id":"pod1","network":{"ip":"10.0.0.2"},"attempt":1},"items":[{"id":"a"},{"id":"b"}]}`
+	testCases := []struct {
+		desc        string
+		output      string
+		expected    string
+		expectError bool
+	}{
+		{
+			desc:     "go-template should render field",
+			output:   "go-template={{.status.network.ip}}",
+			expected: "10.0.0.2",
+		},
+		{
+			desc:     "go-template should range over list",
+			output:   "go-template={{range .items}}{{.id}};{{end}}",
+			expected: "a;b;",
+		},
+		{
+			desc:     "jsonpath should render field",
+			output:   "jsonpath={.status.network.ip}",
+			expected: "10.0.0.2",
+		},
+		{
+			desc:     "jsonpath should render number and text",
+			output:   "jsonpath=attempt: {$.status.attempt}",
+			expected: "attempt: 1",
+		},
+		{
+			desc:     "jsonpath should render wildcard",
+			output:   "jsonpath={.items[*].id}",
+			expected: "a b",
+		},
+		{
+			desc:     "jsonpath should render index",
+			output:   "jsonpath={.items[-1].id}",
+			expected: "b",
+		},
+		{
+			desc:     "jsonpath should render object as json",
+			output:   "jsonpath={.status.network}",
+			expected: `{"ip":"10.0.0.2"}`,
+		},
+		{
+			desc:        "jsonpath with unclosed expression should fail",
+			output:      "jsonpath={.status",
+			expectError: true,
+		},
+		{
+			desc:        "invalid go-template should fail",
+			output:      "go-template={{.status",
+			expectError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			r, err := renderTemplate(data, tc.output)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected error; actual result is %q", r)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if r != tc.expected {
+				t.Errorf("expected %q; actual result is %q", tc.expected, r)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	"k8s.io/kubernetes/pkg/kubelet/util"
+)
+
+const (
+	// maxMsgSize is the maximum size of the messages received, 8MB, the gRPC
+	// default being 4MB.
+	maxMsgSize = 1024 * 1024 * 8
+
+	// maxBackoffDelay bounds the delay between two reconnection attempts
+	// after a transient failure, the gRPC default being 2 minutes.
+	maxBackoffDelay = 5 * time.Second
+)
+
+// Options configures the connections of a Manager.
+type Options struct {
+	// KeepaliveTime is the interval of the keepalive pings sent on active
+	// connections. 0 disables the keepalive pings. It should not be lower
+	// than the minimum interval enforced by the runtime, 5 minutes by
+	// default for gRPC servers, which closes the connection otherwise.
+	KeepaliveTime time.Duration
+	// KeepaliveTimeout is the time waited for the keepalive acknowledgement
+	// before closing the connection.
+	KeepaliveTimeout time.Duration
+	// WaitForReady makes the calls with a timeout wait for the connection
+	// to be reestablished after a transient failure, instead of failing
+	// immediately.
+	WaitForReady bool
+	// UnaryInterceptor intercepts all the calls on the connections, e.g. to
+	// record them. None if nil.
+	UnaryInterceptor grpc.UnaryClientInterceptor
+}
+
+// Manager shares the gRPC connections to the CRI endpoints between the
+// runtime and image services. The connections are reestablished by gRPC
+// after transient failures.
+type Manager struct {
+	options Options
+
+	mu    sync.Mutex
+	conns map[string]*grpc.ClientConn
+	// negotiators negotiate the CRI API version of the connections, by
+	// endpoint.
+	negotiators map[string]*versionNegotiator
+
+	netConnsLock sync.Mutex
+	// netConns are the open network connections dialed for the gRPC
+	// connections.
+	netConns map[net.Conn]bool
+}
+
+// trackedConn is a network connection of a Manager, which forgets it once
+// closed.
+type trackedConn struct {
+	net.Conn
+	m *Manager
+}
+
+// Close closes the connection and removes it from the connections of the
+// Manager.
+func (c *trackedConn) Close() error {
+	c.m.netConnsLock.Lock()
+	delete(c.m.netConns, c)
+	c.m.netConnsLock.Unlock()
+	return c.Conn.Close()
+}
+
+// NewManager creates a Manager dialing connections configured by options.
+func NewManager(options Options) *Manager {
+	return &Manager{
+		options:     options,
+		conns:       make(map[string]*grpc.ClientConn),
+		negotiators: make(map[string]*versionNegotiator),
+		netConns:    make(map[net.Conn]bool),
+	}
+}
+
+// Dial returns the connection to endpoint, dialing it on first use.
+func (m *Manager) Dial(endpoint string) (*grpc.ClientConn, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if conn, ok := m.conns[endpoint]; ok {
+		return conn, nil
+	}
+	addr, dialer, err := util.GetAddressAndDialer(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	dialOptions := []grpc.DialOption{
+		grpc.WithInsecure(),
+		grpc.WithDialer(m.trackDialer(dialer)),
+		grpc.WithBackoffMaxDelay(maxBackoffDelay),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxMsgSize)),
+	}
+	// The interceptor of the options sees the v1alpha2 calls, whatever the
+	// version negotiated.
+	negotiator := newVersionNegotiator()
+	interceptor := negotiator.intercept
+	if m.options.UnaryInterceptor != nil {
+		interceptor = chainUnaryInterceptors(m.options.UnaryInterceptor, negotiator.intercept)
+	}
+	dialOptions = append(dialOptions, grpc.WithUnaryInterceptor(interceptor))
+	if m.options.KeepaliveTime > 0 {
+		dialOptions = append(dialOptions, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    m.options.KeepaliveTime,
+			Timeout: m.options.KeepaliveTimeout,
+		}))
+	}
+	conn, err := grpc.Dial(addr, dialOptions...)
+	if err != nil {
+		return nil, err
+	}
+	m.conns[endpoint] = conn
+	m.negotiators[endpoint] = negotiator
+	return conn, nil
+}
+
+// APIVersion returns the CRI API version of the runtime service served at
+// endpoint, APIVersionV1 or APIVersionV1alpha2, once negotiated by a call.
+// It is empty until then.
+func (m *Manager) APIVersion(endpoint string) string {
+	m.mu.Lock()
+	negotiator, ok := m.negotiators[endpoint]
+	m.mu.Unlock()
+	if !ok {
+		return ""
+	}
+	return negotiator.version("RuntimeService")
+}
+
+// trackDialer returns a dialer recording the network connections dialed by
+// dialer, to be closed by Drop.
+func (m *Manager) trackDialer(dialer func(string, time.Duration) (net.Conn, error)) func(string, time.Duration) (net.Conn, error) {
+	return func(addr string, timeout time.Duration) (net.Conn, error) {
+		conn, err := dialer(addr, timeout)
+		if err != nil {
+			return nil, err
+		}
+		tracked := &trackedConn{Conn: conn, m: m}
+		m.netConnsLock.Lock()
+		m.netConns[tracked] = true
+		m.netConnsLock.Unlock()
+		return tracked, nil
+	}
+}
+
+// Drop closes the network connections under the gRPC connections, which
+// gRPC then reestablishes, to simulate a network failure.
+func (m *Manager) Drop() {
+	m.netConnsLock.Lock()
+	conns := make([]net.Conn, 0, len(m.netConns))
+	for conn := range m.netConns {
+		conns = append(conns, conn)
+	}
+	m.netConnsLock.Unlock()
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+}
+
+// RuntimeService returns a RuntimeService using the connection to endpoint.
+// timeout is the timeout of its calls.
+func (m *Manager) RuntimeService(endpoint string, timeout time.Duration) (internalapi.RuntimeService, error) {
+	conn, err := m.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return newRuntimeService(conn, timeout, m.callOptions()), nil
+}
+
+// ImageService returns an ImageManagerService using the connection to
+// endpoint. timeout is the timeout of its calls.
+func (m *Manager) ImageService(endpoint string, timeout time.Duration) (internalapi.ImageManagerService, error) {
+	conn, err := m.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return newImageService(conn, timeout, m.callOptions()), nil
+}
+
+// callOptions returns the options of the calls with a timeout.
+func (m *Manager) callOptions() []grpc.CallOption {
+	if m.options.WaitForReady {
+		return []grpc.CallOption{grpc.FailFast(false)}
+	}
+	return nil
+}
+
+// Close closes all the connections of the manager.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var firstErr error
+	for endpoint, conn := range m.conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(m.conns, endpoint)
+		delete(m.negotiators, endpoint)
+	}
+	return firstErr
+}
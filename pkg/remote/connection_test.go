@@ -0,0 +1,253 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// fakeRuntimeServer implements the Version call of the runtime service.
+type fakeRuntimeServer struct {
+	runtimeapi.RuntimeServiceServer
+}
+
+func (f *fakeRuntimeServer) Version(ctx context.Context, req *runtimeapi.VersionRequest) (*runtimeapi.VersionResponse, error) {
+	return &runtimeapi.VersionResponse{
+		Version:           "0.1.0",
+		RuntimeName:       "fake",
+		RuntimeVersion:    "0.1.0",
+		RuntimeApiVersion: "v1alpha2",
+	}, nil
+}
+
+// startFakeRuntime serves a fakeRuntimeServer on the unix socket path.
+func startFakeRuntime(t *testing.T, path string) *grpc.Server {
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("failed to listen on %q: %v", path, err)
+	}
+	server := grpc.NewServer()
+	runtimeapi.RegisterRuntimeServiceServer(server, &fakeRuntimeServer{})
+	go server.Serve(l)
+	return server
+}
+
+func TestManagerSharesConnections(t *testing.T) {
+	m := NewManager(Options{})
+	defer m.Close()
+
+	runtimeConn, err := m.Dial("unix:///run/runtime.sock")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	imageConn, err := m.Dial("unix:///run/runtime.sock")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if runtimeConn != imageConn {
+		t.Errorf("expected the connection to the same endpoint to be shared")
+	}
+	otherConn, err := m.Dial("unix:///run/other.sock")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if otherConn == runtimeConn {
+		t.Errorf("expected different endpoints to use different connections")
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(m.conns) != 0 {
+		t.Errorf("expected no connection after Close, got %d", len(m.conns))
+	}
+}
+
+func TestManagerReconnects(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remote-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "runtime.sock")
+
+	server := startFakeRuntime(t, path)
+	m := NewManager(Options{KeepaliveTime: 10 * time.Second, KeepaliveTimeout: time.Second, WaitForReady: true})
+	defer m.Close()
+	service, err := m.RuntimeService("unix://"+path, 10*time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.Version("v1alpha2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Restart the runtime, the next call should wait for the reconnection.
+	server.Stop()
+	os.Remove(path)
+	server = startFakeRuntime(t, path)
+	defer server.Stop()
+	if _, err := service.Version("v1alpha2"); err != nil {
+		t.Fatalf("expected the call to succeed after the reconnection, got %v", err)
+	}
+	m.netConnsLock.Lock()
+	defer m.netConnsLock.Unlock()
+	if len(m.netConns) != 1 {
+		t.Errorf("expected the closed connection to be forgotten, got %d network connections", len(m.netConns))
+	}
+}
+
+func TestManagerDrop(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remote-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "runtime.sock")
+
+	server := startFakeRuntime(t, path)
+	defer server.Stop()
+	m := NewManager(Options{WaitForReady: true})
+	defer m.Close()
+	service, err := m.RuntimeService("unix://"+path, 10*time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.Version("v1alpha2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m.Drop()
+	if _, err := service.Version("v1alpha2"); err != nil {
+		t.Fatalf("expected the call to succeed after dropping the connection, got %v", err)
+	}
+	if len(m.netConns) != 1 {
+		t.Errorf("expected the connection to be reestablished, got %d network connections", len(m.netConns))
+	}
+}
+
+func TestManagerUnaryInterceptor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remote-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "runtime.sock")
+
+	server := startFakeRuntime(t, path)
+	defer server.Stop()
+	var methods []string
+	m := NewManager(Options{UnaryInterceptor: func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		methods = append(methods, method)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}})
+	defer m.Close()
+	service, err := m.RuntimeService("unix://"+path, 10*time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.Version("v1alpha2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(methods) != 1 || methods[0] != "/runtime.v1alpha2.RuntimeService/Version" {
+		t.Errorf("expected the Version call to be intercepted, got %v", methods)
+	}
+}
+
+// v1RuntimeServiceDesc serves the Version call of a RuntimeServiceServer as
+// the v1 runtime service.
+var v1RuntimeServiceDesc = grpc.ServiceDesc{
+	ServiceName: "runtime.v1.RuntimeService",
+	HandlerType: (*runtimeapi.RuntimeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Version",
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := new(runtimeapi.VersionRequest)
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			return srv.(runtimeapi.RuntimeServiceServer).Version(ctx, in)
+		},
+	}},
+}
+
+func TestManagerNegotiatesAPIVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remote-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	v1alpha2Path := filepath.Join(dir, "v1alpha2.sock")
+	v1alpha2Server := startFakeRuntime(t, v1alpha2Path)
+	defer v1alpha2Server.Stop()
+
+	v1Path := filepath.Join(dir, "v1.sock")
+	l, err := net.Listen("unix", v1Path)
+	if err != nil {
+		t.Fatalf("failed to listen on %q: %v", v1Path, err)
+	}
+	v1Server := grpc.NewServer()
+	v1Server.RegisterService(&v1RuntimeServiceDesc, &fakeRuntimeServer{})
+	go v1Server.Serve(l)
+	defer v1Server.Stop()
+
+	var methods []string
+	m := NewManager(Options{UnaryInterceptor: func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		methods = append(methods, method)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}})
+	defer m.Close()
+	for _, tc := range []struct {
+		endpoint string
+		version  string
+	}{
+		{"unix://" + v1alpha2Path, APIVersionV1alpha2},
+		{"unix://" + v1Path, APIVersionV1},
+	} {
+		service, err := m.RuntimeService(tc.endpoint, 10*time.Second)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if version := m.APIVersion(tc.endpoint); version != "" {
+			t.Errorf("%s: expected no version before the first call, got %q", tc.endpoint, version)
+		}
+		for i := 0; i < 2; i++ {
+			if _, err := service.Version("v1alpha2"); err != nil {
+				t.Fatalf("%s: unexpected error: %v", tc.endpoint, err)
+			}
+		}
+		if version := m.APIVersion(tc.endpoint); version != tc.version {
+			t.Errorf("%s: expected version %q, got %q", tc.endpoint, tc.version, version)
+		}
+	}
+	// The interceptor of the options sees the v1alpha2 calls.
+	for _, method := range methods {
+		if method != "/runtime.v1alpha2.RuntimeService/Version" {
+			t.Errorf("expected the v1alpha2 Version call to be intercepted, got %s", method)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"strings"
+	"sync"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// APIVersionV1 is the CRI API version served by the runtimes
+	// implementing the stable CRI API.
+	APIVersionV1 = "v1"
+	// APIVersionV1alpha2 is the CRI API version of the generated clients.
+	APIVersionV1alpha2 = "v1alpha2"
+)
+
+// versionNegotiator is a gRPC interceptor sending the v1alpha2 calls of the
+// generated clients to the CRI API version served by the runtime. v1 has the
+// same messages as v1alpha2, only the gRPC service names differ.
+//
+// The first call of each service is sent to the v1 service, then to the
+// v1alpha2 one if the runtime doesn't implement v1. The version is kept once
+// the runtime answers a call.
+type versionNegotiator struct {
+	mu sync.Mutex
+	// versions are the API versions served by the runtime, by service name,
+	// e.g. RuntimeService.
+	versions map[string]string
+}
+
+func newVersionNegotiator() *versionNegotiator {
+	return &versionNegotiator{versions: make(map[string]string)}
+}
+
+// intercept sends the call of method, e.g.
+// /runtime.v1alpha2.RuntimeService/Version, to the negotiated version of its
+// service.
+func (n *versionNegotiator) intercept(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	prefix := "/runtime." + APIVersionV1alpha2 + "."
+	if !strings.HasPrefix(method, prefix) {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	call := strings.TrimPrefix(method, prefix)
+	service := strings.SplitN(call, "/", 2)[0]
+	if version := n.version(service); version != "" {
+		return invoker(ctx, "/runtime."+version+"."+call, req, reply, cc, opts...)
+	}
+
+	err := invoker(ctx, "/runtime."+APIVersionV1+"."+call, req, reply, cc, opts...)
+	if errorCode(err) != codes.Unimplemented {
+		if answered(err) {
+			n.setVersion(service, APIVersionV1)
+		}
+		return err
+	}
+	err = invoker(ctx, method, req, reply, cc, opts...)
+	if answered(err) {
+		n.setVersion(service, APIVersionV1alpha2)
+	}
+	return err
+}
+
+// version returns the API version negotiated for service, empty if none was.
+func (n *versionNegotiator) version(service string) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.versions[service]
+}
+
+func (n *versionNegotiator) setVersion(service, version string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.versions[service] = version
+}
+
+// errorCode returns the gRPC code of err, OK if nil and Unknown if it isn't
+// a gRPC error.
+func errorCode(err error) codes.Code {
+	s, ok := status.FromError(err)
+	if !ok {
+		return codes.Unknown
+	}
+	return s.Code()
+}
+
+// answered returns whether err, returned by a call, comes from a runtime
+// implementing the called service, rather than from the connection or the
+// context of the call.
+func answered(err error) bool {
+	if _, ok := status.FromError(err); !ok {
+		return false
+	}
+	switch errorCode(err) {
+	case codes.Unimplemented, codes.Unavailable, codes.DeadlineExceeded, codes.Canceled:
+		return false
+	}
+	return true
+}
+
+// chainUnaryInterceptors returns an interceptor calling outer, with inner
+// intercepting the calls of outer.
+func chainUnaryInterceptors(outer, inner grpc.UnaryClientInterceptor) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return outer(ctx, method, req, reply, cc, func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return inner(ctx, method, req, reply, cc, invoker, opts...)
+		}, opts...)
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package streaming provides the client for the exec and attach streaming
+// endpoints served by CRI runtimes, over SPDY or WebSocket.
+package streaming
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// Protocol is the protocol used to connect to a streaming endpoint.
+type Protocol string
+
+const (
+	// ProtocolAuto uses SPDY and falls back to WebSocket if the server
+	// refuses the SPDY upgrade.
+	ProtocolAuto Protocol = "auto"
+	// ProtocolSPDY only uses SPDY.
+	ProtocolSPDY Protocol = "spdy"
+	// ProtocolWebSocket only uses WebSocket.
+	ProtocolWebSocket Protocol = "websocket"
+)
+
+// ParseProtocol parses a protocol name. An empty name means ProtocolAuto.
+func ParseProtocol(name string) (Protocol, error) {
+	switch p := Protocol(strings.ToLower(name)); p {
+	case "":
+		return ProtocolAuto, nil
+	case ProtocolAuto, ProtocolSPDY, ProtocolWebSocket:
+		return p, nil
+	}
+	return "", fmt.Errorf("unsupported streaming protocol %q, should be one of %s, %s or %s",
+		name, ProtocolAuto, ProtocolSPDY, ProtocolWebSocket)
+}
+
+// NewExecutor returns an executor for the exec or attach streaming url.
+// Server certificates are not verified for https urls.
+func NewExecutor(url *url.URL, protocol Protocol) (remotecommand.Executor, error) {
+	var spdy remotecommand.Executor
+	if protocol != ProtocolWebSocket {
+		var err error
+		spdy, err = remotecommand.NewSPDYExecutor(&restclient.Config{TLSClientConfig: restclient.TLSClientConfig{Insecure: true}}, "POST", url)
+		if err != nil {
+			return nil, err
+		}
+	}
+	switch protocol {
+	case ProtocolSPDY:
+		return spdy, nil
+	case ProtocolWebSocket:
+		return &webSocketExecutor{url: url}, nil
+	}
+	return &fallbackExecutor{spdy: spdy, webSocket: &webSocketExecutor{url: url}}, nil
+}
+
+// fallbackExecutor streams over SPDY, and over WebSocket if the server
+// refuses the SPDY upgrade.
+type fallbackExecutor struct {
+	spdy      remotecommand.Executor
+	webSocket remotecommand.Executor
+}
+
+func (e *fallbackExecutor) Stream(options remotecommand.StreamOptions) error {
+	err := e.spdy.Stream(options)
+	if err != nil && strings.Contains(err.Error(), "unable to upgrade connection") {
+		// The upgrade failed before any stream was created, so it is safe
+		// to use the IO again.
+		return e.webSocket.Stream(options)
+	}
+	return err
+}
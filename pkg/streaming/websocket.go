@@ -0,0 +1,391 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package streaming
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apiremotecommand "k8s.io/apimachinery/pkg/util/remotecommand"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/exec"
+)
+
+const (
+	// channelProtocolV4 and channelProtocolV1 are the Kubernetes streaming
+	// subprotocols over WebSocket. Each binary message starts with the
+	// channel it belongs to. In v4, the error channel carries a
+	// json-marshaled metav1.Status.
+	channelProtocolV4 = "v4.channel.k8s.io"
+	channelProtocolV1 = "channel.k8s.io"
+
+	stdinChannel  byte = 0
+	stdoutChannel byte = 1
+	stderrChannel byte = 2
+	errorChannel  byte = 3
+	resizeChannel byte = 4
+
+	// webSocketGUID is used to compute Sec-WebSocket-Accept, see RFC 6455.
+	webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	opContinuation byte = 0x0
+	opText         byte = 0x1
+	opBinary       byte = 0x2
+	opClose        byte = 0x8
+	opPing         byte = 0x9
+	opPong         byte = 0xa
+
+	// maxMessageSize is the maximum size of the messages read, and of the
+	// payload of their frames. The streaming servers send messages of a few
+	// KiB, so larger sizes are rejected instead of allocating them.
+	maxMessageSize = 16 * 1024 * 1024
+)
+
+// webSocketExecutor streams over WebSocket with the Kubernetes channel protocol.
+type webSocketExecutor struct {
+	url *url.URL
+}
+
+func (e *webSocketExecutor) Stream(options remotecommand.StreamOptions) error {
+	conn, protocol, err := dialWebSocket(e.url, []string{channelProtocolV4, channelProtocolV1})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if options.Stdin != nil {
+		go func() {
+			buf := make([]byte, 32*1024)
+			for {
+				n, err := options.Stdin.Read(buf)
+				if n > 0 {
+					if conn.WriteMessage(opBinary, append([]byte{stdinChannel}, buf[:n]...)) != nil {
+						return
+					}
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+	if options.Tty && options.TerminalSizeQueue != nil {
+		go func() {
+			for size := options.TerminalSizeQueue.Next(); size != nil; size = options.TerminalSizeQueue.Next() {
+				data, err := json.Marshal(size)
+				if err != nil {
+					return
+				}
+				if conn.WriteMessage(opBinary, append([]byte{resizeChannel}, data...)) != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	var errorData []byte
+	for {
+		_, message, err := conn.ReadMessage()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if len(message) == 0 {
+			continue
+		}
+		data := message[1:]
+		switch message[0] {
+		case stdoutChannel:
+			if options.Stdout != nil {
+				options.Stdout.Write(data)
+			}
+		case stderrChannel:
+			if options.Stderr != nil {
+				options.Stderr.Write(data)
+			}
+		case errorChannel:
+			errorData = append(errorData, data...)
+		}
+	}
+	return decodeError(protocol, errorData)
+}
+
+// decodeError decodes the content of the error channel.
+func decodeError(protocol string, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if protocol != channelProtocolV4 {
+		return errors.New(string(data))
+	}
+
+	status := metav1.Status{}
+	if err := json.Unmarshal(data, &status); err != nil {
+		return fmt.Errorf("error stream protocol error: %v in %q", err, string(data))
+	}
+	if status.Status == metav1.StatusSuccess {
+		return nil
+	}
+	if status.Reason == apiremotecommand.NonZeroExitCodeReason && status.Details != nil {
+		for _, cause := range status.Details.Causes {
+			if cause.Type != apiremotecommand.ExitCodeCauseType {
+				continue
+			}
+			rc, err := strconv.ParseUint(cause.Message, 10, 8)
+			if err != nil {
+				return fmt.Errorf("error stream protocol error: invalid exit code value %q", cause.Message)
+			}
+			return exec.CodeExitError{
+				Err:  fmt.Errorf("command terminated with exit code %d", rc),
+				Code: int(rc),
+			}
+		}
+	}
+	return errors.New(status.Message)
+}
+
+// webSocketConn is a minimal RFC 6455 client connection.
+type webSocketConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	// mask is set on the client side, where frames must be masked.
+	mask      bool
+	writeLock sync.Mutex
+}
+
+// dialWebSocket opens a WebSocket connection to u, negotiating one of the
+// subprotocols. It returns the connection and the selected subprotocol.
+func dialWebSocket(u *url.URL, protocols []string) (*webSocketConn, string, error) {
+	host := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "https" {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+	var conn net.Conn
+	var err error
+	if u.Scheme == "https" {
+		conn, err = tls.Dial("tcp", host, &tls.Config{InsecureSkipVerify: true})
+	} else {
+		conn, err = net.Dial("tcp", host)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	keyBytes := make([]byte, 16)
+	if _, err := rand.Read(keyBytes); err != nil {
+		conn.Close()
+		return nil, "", err
+	}
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Host:       u.Host,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header: http.Header{
+			"Upgrade":                []string{"websocket"},
+			"Connection":             []string{"Upgrade"},
+			"Sec-Websocket-Key":      []string{key},
+			"Sec-Websocket-Version":  []string{"13"},
+			"Sec-Websocket-Protocol": []string{strings.Join(protocols, ", ")},
+		},
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, "", err
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, "", err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		conn.Close()
+		return nil, "", fmt.Errorf("unable to upgrade connection to websocket: %s %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if resp.Header.Get("Sec-Websocket-Accept") != acceptKey(key) {
+		conn.Close()
+		return nil, "", errors.New("unable to upgrade connection to websocket: invalid Sec-WebSocket-Accept")
+	}
+	return &webSocketConn{conn: conn, reader: reader, mask: true}, resp.Header.Get("Sec-Websocket-Protocol"), nil
+}
+
+// acceptKey computes the Sec-WebSocket-Accept value for key.
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + webSocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Close closes the connection.
+func (c *webSocketConn) Close() error {
+	c.WriteMessage(opClose, nil)
+	return c.conn.Close()
+}
+
+// WriteMessage writes data as a single frame.
+func (c *webSocketConn) WriteMessage(opcode byte, data []byte) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	header := []byte{0x80 | opcode, 0}
+	switch {
+	case len(data) < 126:
+		header[1] = byte(len(data))
+	case len(data) <= 0xffff:
+		header[1] = 126
+		header = append(header, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(len(data)))
+	default:
+		header[1] = 127
+		header = append(header, make([]byte, 8)...)
+		binary.BigEndian.PutUint64(header[2:], uint64(len(data)))
+	}
+	payload := data
+	if c.mask {
+		header[1] |= 0x80
+		maskKey := make([]byte, 4)
+		if _, err := rand.Read(maskKey); err != nil {
+			return err
+		}
+		header = append(header, maskKey...)
+		payload = make([]byte, len(data))
+		for i := range data {
+			payload[i] = data[i] ^ maskKey[i%4]
+		}
+	}
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReadMessage reads the next text or binary message, answering pings. It
+// returns io.EOF once the peer closes the connection.
+func (c *webSocketConn) ReadMessage() (byte, []byte, error) {
+	var opcode byte
+	var message []byte
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch op {
+		case opClose:
+			return 0, nil, io.EOF
+		case opPing:
+			if err := c.WriteMessage(opPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opText, opBinary:
+			if opcode != 0 {
+				return 0, nil, errors.New("websocket data frame in the middle of a fragmented message")
+			}
+			opcode = op
+			message = payload
+		case opContinuation:
+			if opcode == 0 {
+				return 0, nil, errors.New("websocket continuation frame without a message in progress")
+			}
+			if len(message)+len(payload) > maxMessageSize {
+				return 0, nil, fmt.Errorf("websocket message exceeds %d bytes", maxMessageSize)
+			}
+			message = append(message, payload...)
+		default:
+			return 0, nil, fmt.Errorf("unsupported websocket opcode %d", op)
+		}
+		if fin {
+			return opcode, message, nil
+		}
+	}
+}
+
+func (c *webSocketConn) readFrame() (bool, byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return false, 0, nil, err
+	}
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(c.reader, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err := io.ReadFull(c.reader, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext)
+	}
+	if length > maxMessageSize {
+		return false, 0, nil, fmt.Errorf("websocket frame payload of %d bytes exceeds %d bytes", length, maxMessageSize)
+	}
+	var maskKey []byte
+	if masked {
+		maskKey = make([]byte, 4)
+		if _, err := io.ReadFull(c.reader, maskKey); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		if masked {
+			payload[i] ^= maskKey[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
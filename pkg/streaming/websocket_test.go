@@ -0,0 +1,202 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package streaming
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/exec"
+)
+
+// serveExec echoes one stdin message to stdout and closes the stream with
+// the given error channel content.
+func serveExec(t *testing.T, protocol string, errorData string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" {
+			http.Error(w, "websocket required", http.StatusBadRequest)
+			return
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+		rw.WriteString("Sec-WebSocket-Accept: " + acceptKey(r.Header.Get("Sec-Websocket-Key")) + "\r\n")
+		rw.WriteString("Sec-WebSocket-Protocol: " + protocol + "\r\n\r\n")
+		rw.Flush()
+
+		ws := &webSocketConn{conn: conn, reader: bufio.NewReader(rw)}
+		_, message, err := ws.ReadMessage()
+		if err != nil || len(message) == 0 || message[0] != stdinChannel {
+			t.Errorf("unexpected stdin message %q: %v", message, err)
+			return
+		}
+		ws.WriteMessage(opPing, nil)
+		ws.WriteMessage(opBinary, append([]byte{stdoutChannel}, message[1:]...))
+		if errorData != "" {
+			ws.WriteMessage(opBinary, append([]byte{errorChannel}, errorData...))
+		}
+		ws.WriteMessage(opClose, nil)
+	}
+}
+
+func TestWebSocketExecutor(t *testing.T) {
+	for desc, test := range map[string]struct {
+		protocol  string
+		errorData string
+		exitCode  int
+		expectErr bool
+	}{
+		"v4 success": {
+			protocol:  channelProtocolV4,
+			errorData: `{"status":"Success"}`,
+		},
+		"v4 non-zero exit code": {
+			protocol:  channelProtocolV4,
+			errorData: `{"status":"Failure","reason":"NonZeroExitCode","details":{"causes":[{"reason":"ExitCode","message":"3"}]}}`,
+			exitCode:  3,
+			expectErr: true,
+		},
+		"v4 failure": {
+			protocol:  channelProtocolV4,
+			errorData: `{"status":"Failure","message":"container not found"}`,
+			expectErr: true,
+		},
+		"v1 no error": {
+			protocol: channelProtocolV1,
+		},
+		"v1 error": {
+			protocol:  channelProtocolV1,
+			errorData: "container not found",
+			expectErr: true,
+		},
+	} {
+		t.Run(desc, func(t *testing.T) {
+			server := httptest.NewServer(serveExec(t, test.protocol, test.errorData))
+			defer server.Close()
+			u, err := url.Parse(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			executor, err := NewExecutor(u, ProtocolWebSocket)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stdout := &bytes.Buffer{}
+			err = executor.Stream(remotecommand.StreamOptions{
+				Stdin:  strings.NewReader("hello"),
+				Stdout: stdout,
+			})
+			if test.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", test.expectErr, err)
+			}
+			if exitErr, ok := err.(exec.ExitError); ok && exitErr.ExitStatus() != test.exitCode {
+				t.Errorf("expected exit code %d, got %d", test.exitCode, exitErr.ExitStatus())
+			}
+			if stdout.String() != "hello" {
+				t.Errorf("expected stdout %q, got %q", "hello", stdout.String())
+			}
+		})
+	}
+}
+
+func TestFallbackExecutor(t *testing.T) {
+	server := httptest.NewServer(serveExec(t, channelProtocolV4, ""))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	executor, err := NewExecutor(u, ProtocolAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := &bytes.Buffer{}
+	if err := executor.Stream(remotecommand.StreamOptions{
+		Stdin:  strings.NewReader("hello"),
+		Stdout: stdout,
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout.String() != "hello" {
+		t.Errorf("expected stdout %q, got %q", "hello", stdout.String())
+	}
+}
+
+func TestReadFrameTooLarge(t *testing.T) {
+	frame := []byte{0x80 | opBinary, 127, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	ws := &webSocketConn{reader: bufio.NewReader(bytes.NewReader(frame))}
+	if _, _, err := ws.ReadMessage(); err == nil {
+		t.Errorf("expected an error for a frame payload of %d bytes", uint64(1<<63-1))
+	}
+}
+
+func TestReadMessageInvalidFragments(t *testing.T) {
+	// A frame header without mask for the given fin bit, opcode and 16-bit
+	// payload length.
+	header := func(fin bool, op byte, length int) []byte {
+		b := []byte{op, 126, byte(length >> 8), byte(length)}
+		if fin {
+			b[0] |= 0x80
+		}
+		return b
+	}
+	var tooLarge []byte
+	tooLarge = append(tooLarge, header(false, opBinary, 0)...)
+	for size := 0; size <= maxMessageSize; size += 0xffff {
+		fin := size+0xffff > maxMessageSize
+		tooLarge = append(tooLarge, header(fin, opContinuation, 0xffff)...)
+		tooLarge = append(tooLarge, make([]byte, 0xffff)...)
+	}
+	for desc, frames := range map[string][]byte{
+		"continuation without message": header(true, opContinuation, 0),
+		"data frame in a message":      append(header(false, opBinary, 0), header(true, opBinary, 0)...),
+		"message too large":            tooLarge,
+	} {
+		ws := &webSocketConn{reader: bufio.NewReader(bytes.NewReader(frames))}
+		if _, _, err := ws.ReadMessage(); err == nil || err == io.EOF {
+			t.Errorf("%s: expected an invalid message error; actual error is %v", desc, err)
+		}
+	}
+}
+
+func TestParseProtocol(t *testing.T) {
+	for name, expected := range map[string]Protocol{
+		"":          ProtocolAuto,
+		"auto":      ProtocolAuto,
+		"SPDY":      ProtocolSPDY,
+		"websocket": ProtocolWebSocket,
+		"http2":     "",
+	} {
+		protocol, err := ParseProtocol(name)
+		if (expected == "") != (err != nil) || protocol != expected {
+			t.Errorf("ParseProtocol(%q) = %q, %v", name, protocol, err)
+		}
+	}
+}
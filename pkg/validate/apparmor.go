@@ -22,9 +22,9 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
-	"time"
 
 	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework/helpers"
 	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
 	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 
@@ -126,8 +126,8 @@ func createContainerWithAppArmor(rc internalapi.RuntimeService, ic internalapi.I
 
 	// wait container started and check the status.
 	Eventually(func() runtimeapi.ContainerState {
-		return getContainerStatus(rc, containerID).State
-	}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_EXITED))
+		return helpers.GetContainerStatus(rc, containerID).State
+	}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_EXITED))
 
 	return containerID
 }
@@ -0,0 +1,112 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework/helpers"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = framework.KubeDescribe("Chaos", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	var rc internalapi.RuntimeService
+	var ic internalapi.ImageManagerService
+
+	BeforeEach(func() {
+		if !framework.ChaosEnabled() {
+			Skip("no fault to inject, use -chaos-faults")
+		}
+		rc = f.CRIClient.CRIRuntimeClient
+		ic = f.CRIClient.CRIImageClient
+	})
+
+	Context("runtime should recover from faults", func() {
+		var podID string
+		var podConfig *runtimeapi.PodSandboxConfig
+
+		BeforeEach(func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+		})
+
+		It("runtime should keep the state of pods and containers consistent across faults [Chaos]", func() {
+			if framework.InjectChaos(rc, "pod-ready", podID) {
+				checkPodSandboxConsistent(rc, podID, runtimeapi.PodSandboxState_SANDBOX_READY)
+			}
+
+			By("create container")
+			containerID := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-for-chaos-test-")
+			if framework.InjectChaos(rc, "container-created", containerID) {
+				checkContainerConsistent(rc, containerID, runtimeapi.ContainerState_CONTAINER_CREATED)
+				checkPodSandboxConsistent(rc, podID, runtimeapi.PodSandboxState_SANDBOX_READY)
+			}
+
+			By("start container")
+			testStartContainer(rc, containerID)
+			if framework.InjectChaos(rc, "container-running", containerID) {
+				// A container whose shim was killed may have exited.
+				checkContainerConsistent(rc, containerID, runtimeapi.ContainerState_CONTAINER_RUNNING, runtimeapi.ContainerState_CONTAINER_EXITED)
+				checkPodSandboxConsistent(rc, podID, runtimeapi.PodSandboxState_SANDBOX_READY)
+			}
+
+			By("stop container")
+			testStopContainer(rc, containerID)
+			if framework.InjectChaos(rc, "container-exited", containerID) {
+				checkContainerConsistent(rc, containerID, runtimeapi.ContainerState_CONTAINER_EXITED)
+				checkPodSandboxConsistent(rc, podID, runtimeapi.PodSandboxState_SANDBOX_READY)
+			}
+
+			By("remove container")
+			removeContainer(rc, containerID)
+			Expect(containerFound(listContainerForID(rc, containerID), containerID)).To(BeFalse(), "container should be removed")
+		})
+	})
+})
+
+// checkContainerConsistent checks that the container is listed once, in the
+// same state as in its status, which is one of states.
+func checkContainerConsistent(c internalapi.RuntimeService, containerID string, states ...runtimeapi.ContainerState) {
+	By("check the container is listed with a consistent state")
+	containers := listContainerForID(c, containerID)
+	Expect(containers).To(HaveLen(1), "container %q should be listed once", containerID)
+	status := helpers.GetContainerStatus(c, containerID)
+	Expect(containers[0].State).To(Equal(status.State), "listed state of container %q should be the one of its status", containerID)
+	Expect(states).To(ContainElement(status.State), "unexpected state of container %q", containerID)
+}
+
+// checkPodSandboxConsistent checks that the pod is listed once, in the same
+// state as in its status, which is state.
+func checkPodSandboxConsistent(c internalapi.RuntimeService, podID string, state runtimeapi.PodSandboxState) {
+	By("check the PodSandbox is listed with a consistent state")
+	pods := listPodSanboxForID(c, podID)
+	Expect(pods).To(HaveLen(1), "PodSandbox %q should be listed once", podID)
+	status := getPodSandboxStatus(c, podID)
+	Expect(pods[0].State).To(Equal(status.State), "listed state of PodSandbox %q should be the one of its status", podID)
+	Expect(status.State).To(Equal(state), "unexpected state of PodSandbox %q", podID)
+}
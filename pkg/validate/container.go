@@ -17,10 +17,11 @@ limitations under the License.
 package validate
 
 import (
-	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -29,6 +30,10 @@ import (
 
 	"github.com/docker/docker/pkg/jsonlog"
 	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework/helpers"
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework/registry"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
 	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 
@@ -41,12 +46,36 @@ type streamType string
 
 const (
 	defaultStopContainerTimeout int64      = 60
-	defaultExecSyncTimeout      int64      = 5
-	defaultLog                  string     = "hello World"
+	defaultLog                  string     = helpers.DefaultLog
 	stdoutType                  streamType = "stdout"
 	stderrType                  streamType = "stderr"
+	// execTimeout is the timeout of the execSync timeout test, and
+	// execTimeoutSlack bounds the time taken to return once it is over.
+	execTimeout      = 2 * time.Second
+	execTimeoutSlack = 10 * time.Second
+	// execOutputSize is the size of the output of the large execSync
+	// output test, made of lines of execOutputPattern.
+	execOutputSize    = 4 * 1024 * 1024
+	execOutputPattern = "0123456789abcdefghijklmnopqrstuvwxyz"
+	// filterLabelKey selects the resources of a filtering test.
+	filterLabelKey string = "critest.filter"
+	// annotationFilterKey is an annotation key which must not be matched
+	// by label selectors.
+	annotationFilterKey string = "critest.annotation-filter"
+	// manyMetadataCount is the number of labels and annotations set by the
+	// propagation tests.
+	manyMetadataCount = 100
+	// largeAnnotationSize is the size of the large annotation values.
+	largeAnnotationSize = 4096
+	// testImageOnCreate is the image served by the test registry to the
+	// image pull policy tests.
+	testImageOnCreate = "gcr.io/cri-tools/test-image-on-create:latest"
 )
 
+// execTimeoutCommand is run by the execSync timeout test. Its unusual sleep
+// duration identifies its process in the container.
+var execTimeoutCommand = []string{"sleep", "3617"}
+
 // logMessage is the internal log type.
 type logMessage struct {
 	timestamp time.Time
@@ -130,6 +159,345 @@ var _ = framework.KubeDescribe("Container", func() {
 			expectedLogMessage := "hello\n"
 			verifyExecSyncOutput(rc, containerID, cmd, expectedLogMessage)
 		})
+
+		It("runtime should enforce the timeout of execSync [Conformance]", func() {
+			By("create container")
+			containerID := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-for-execSync-timeout-test-")
+
+			By("start container")
+			startContainer(rc, containerID)
+
+			By("execSync a command running longer than the timeout")
+			start := time.Now()
+			_, _, err := rc.ExecSync(containerID, execTimeoutCommand, execTimeout)
+			elapsed := time.Since(start)
+			Expect(err).To(HaveOccurred(), "execSync should time out")
+			s, ok := status.FromError(err)
+			Expect(ok && s.Code() == codes.DeadlineExceeded).To(BeTrue(), "expected DeadlineExceeded, got %v", err)
+			Expect(elapsed).To(BeNumerically("<", execTimeout+execTimeoutSlack), "execSync should return once the timeout is over")
+
+			By("check the timed out process is killed")
+			Eventually(func() string {
+				return execSyncContainer(rc, containerID, []string{"ps"})
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).ShouldNot(
+				ContainSubstring(strings.Join(execTimeoutCommand, " ")), "the timed out process should not be left")
+		})
+
+		It("runtime should return large execSync output consistently [Conformance]", func() {
+			By("create container")
+			containerID := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-for-execSync-large-output-test-")
+
+			By("start container")
+			startContainer(rc, containerID)
+
+			By("execSync a command with a large output")
+			cmd := []string{"sh", "-c", fmt.Sprintf("yes %s | head -c %d", execOutputPattern, execOutputSize)}
+			first := execSyncContainer(rc, containerID, cmd)
+			second := execSyncContainer(rc, containerID, cmd)
+			Expect(len(second)).To(Equal(len(first)), "the size of the output of execSync should be consistent")
+
+			expected := strings.Repeat(execOutputPattern+"\n", execOutputSize/(len(execOutputPattern)+1)+1)[:execOutputSize]
+			if len(first) < execOutputSize {
+				framework.Logf("Runtime caps the output of execSync at %d bytes", len(first))
+			}
+			Expect(first == expected[:len(first)]).To(BeTrue(), "the output of execSync should not be corrupted")
+			Expect(len(first)).To(BeNumerically(">", 0), "the output of execSync should not be empty")
+		})
+	})
+
+	Context("runtime should support container metadata", func() {
+		var podID string
+		var podConfig *runtimeapi.PodSandboxConfig
+
+		BeforeEach(func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+		})
+
+		It("runtime should support recreating container with incremented attempt [Conformance]", func() {
+			containerName := "container-for-attempt-test-" + framework.NewUUID()
+
+			By("create container with attempt 0")
+			containerID := createContainerWithMetadata(rc, ic, podID, podConfig, framework.BuildContainerMetadata(containerName, 0))
+			verifyContainerMetadata(rc, containerID, containerName, 0)
+
+			By("remove container")
+			removeContainer(rc, containerID)
+
+			By("recreate container with attempt 1")
+			containerID = createContainerWithMetadata(rc, ic, podID, podConfig, framework.BuildContainerMetadata(containerName, 1))
+			verifyContainerMetadata(rc, containerID, containerName, 1)
+		})
+
+		It("runtime should reject creating containers with identical metadata in a PodSandbox", func() {
+			containerConfig := &runtimeapi.ContainerConfig{
+				Metadata: framework.BuildContainerMetadata("container-for-duplicate-test-"+framework.NewUUID(), framework.DefaultAttempt),
+				Image:    &runtimeapi.ImageSpec{Image: framework.DefaultContainerImage},
+				Command:  []string{"top"},
+				Linux:    &runtimeapi.LinuxContainerConfig{},
+			}
+
+			By("create container")
+			framework.CreateContainer(rc, ic, containerConfig, podID, podConfig)
+
+			By("create container with the same metadata")
+			_, err := framework.CreateContainerWithError(rc, ic, containerConfig, podID, podConfig)
+			Expect(err).To(HaveOccurred(), "creating a container with the same metadata should fail")
+		})
+	})
+
+	Context("runtime should handle the image of created containers", func() {
+		var podID string
+		var podConfig *runtimeapi.PodSandboxConfig
+		var reg *registry.Registry
+		var image string
+
+		BeforeEach(func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+			var err error
+			reg, err = startTestRegistry(registry.Options{Images: []string{testImageOnCreate}})
+			framework.ExpectNoError(err, "failed to start test registry: %v", err)
+			image = reg.Ref(testImageOnCreate)
+			removeImage(ic, image)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+			removeImage(ic, image)
+			reg.Close()
+		})
+
+		It("runtime should create a container once its missing image is pulled", func() {
+			containerConfig := buildProcessContainerConfig("container-for-pull-on-create-test-", []string{"top"}, nil)
+			containerConfig.Image.Image = image
+
+			By("create container pulling the missing image")
+			containerID, err := framework.CreateContainerWithPullPolicy(rc, ic, containerConfig, podID, podConfig, true)
+			framework.ExpectNoError(err, "failed to create container: %v", err)
+			Expect(framework.ImageStatus(ic, image)).NotTo(BeNil(), "image should be pulled")
+			Expect(helpers.GetContainerStatus(rc, containerID).State).To(Equal(runtimeapi.ContainerState_CONTAINER_CREATED))
+		})
+
+		It("runtime should fail to create a container whose image is missing", func() {
+			containerConfig := buildProcessContainerConfig("container-for-missing-image-test-", []string{"top"}, nil)
+			containerConfig.Image.Image = image
+
+			By("create container without pulling the missing image")
+			_, err := framework.CreateContainerWithPullPolicy(rc, ic, containerConfig, podID, podConfig, false)
+			Expect(err).To(HaveOccurred(), "creating a container with a missing image should fail")
+			framework.Logf("Create container failed as expected: %v", err)
+			if s, ok := status.FromError(err); !ok || s.Code() != codes.NotFound {
+				Expect(strings.ToLower(err.Error())).To(ContainSubstring("not found"), "error should report the image not found")
+			}
+			Expect(framework.ImageStatus(ic, image)).To(BeNil(), "image should not be pulled")
+		})
+	})
+
+	Context("runtime should support container process configuration", func() {
+		var podID string
+		var podConfig *runtimeapi.PodSandboxConfig
+
+		BeforeEach(func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+		})
+
+		It("runtime should support setting environment variables [Conformance]", func() {
+			envs := []*runtimeapi.KeyValue{
+				{Key: "CRITEST_ENV", Value: "value"},
+				{Key: "CRITEST_ENV_SPACES", Value: "value with spaces"},
+				{Key: "CRITEST_ENV_EQUALS", Value: "key=value"},
+			}
+			containerConfig := buildProcessContainerConfig("container-for-env-test-", []string{"top"}, nil)
+			containerConfig.Envs = envs
+
+			By("create and start a container with environment variables")
+			containerID := framework.CreateContainer(rc, ic, containerConfig, podID, podConfig)
+			startContainer(rc, containerID)
+
+			By("check the environment variables via env")
+			stdout := execSyncContainer(rc, containerID, []string{"env"})
+			for _, env := range envs {
+				Expect(strings.Split(stdout, "\n")).To(ContainElement(env.Key+"="+env.Value), "env should contain %s", env.Key)
+			}
+		})
+
+		It("runtime should support setting the working directory [Conformance]", func() {
+			workingDir := "/tmp"
+			containerConfig := buildProcessContainerConfig("container-for-workdir-test-", []string{"top"}, nil)
+			containerConfig.WorkingDir = workingDir
+
+			By("create and start a container with a working directory")
+			containerID := framework.CreateContainer(rc, ic, containerConfig, podID, podConfig)
+			startContainer(rc, containerID)
+
+			By("check the working directory via pwd")
+			verifyExecSyncOutput(rc, containerID, []string{"pwd"}, workingDir+"\n")
+		})
+
+		It("runtime should append args to the command [Conformance]", func() {
+			// The script saves its arguments and keeps running, so that they
+			// can be read with execSync.
+			command := []string{"sh", "-c", "echo \"$@\" > /tmp/args; exec top", "sh"}
+			containerConfig := buildProcessContainerConfig("container-for-args-test-", command, []string{"hello", "world"})
+
+			By("create and start a container with command and args")
+			containerID := framework.CreateContainer(rc, ic, containerConfig, podID, podConfig)
+			startContainer(rc, containerID)
+
+			By("check the args are passed to the command")
+			Eventually(func() string {
+				return readContainerFile(rc, containerID, "/tmp/args")
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal("hello world\n"))
+		})
+
+		It("runtime should run args with the image entrypoint when command is not set [Conformance]", func() {
+			// The default image has no entrypoint, so args are run as is.
+			args := []string{"sh", "-c", "echo args > /tmp/args; exec top"}
+			containerConfig := buildProcessContainerConfig("container-for-entrypoint-test-", nil, args)
+
+			By("create and start a container with args only")
+			containerID := framework.CreateContainer(rc, ic, containerConfig, podID, podConfig)
+			startContainer(rc, containerID)
+
+			By("check the args are run")
+			Eventually(func() string {
+				return readContainerFile(rc, containerID, "/tmp/args")
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal("args\n"))
+		})
+	})
+
+	Context("runtime should support filtering containers", func() {
+		var podIDs []string
+
+		AfterEach(func() {
+			for _, podID := range podIDs {
+				By("stop PodSandbox")
+				rc.StopPodSandbox(podID)
+				By("delete PodSandbox")
+				rc.RemovePodSandbox(podID)
+			}
+			podIDs = nil
+		})
+
+		It("runtime should support listing containers with filters [Conformance]", func() {
+			// The filter label is unique to this spec, so that containers
+			// created concurrently by other specs are never listed.
+			filterValue := framework.NewUUID()
+			labels := func(group string) map[string]string {
+				return map[string]string{filterLabelKey: filterValue, "group": group}
+			}
+
+			By("run PodSandboxes")
+			pod1, pod1Config := framework.CreatePodSandboxForContainer(rc)
+			podIDs = append(podIDs, pod1)
+			pod2, pod2Config := framework.CreatePodSandboxForContainer(rc)
+			podIDs = append(podIDs, pod2)
+
+			By("create containers with labels")
+			container1 := createContainerWithLabels(rc, ic, pod1, pod1Config, "container-for-filter-1-", labels("a"))
+			container2 := createContainerWithLabels(rc, ic, pod1, pod1Config, "container-for-filter-2-", labels("b"))
+			container3 := createContainerWithLabels(rc, ic, pod2, pod2Config, "container-for-filter-3-", labels("a"))
+
+			By("start a container")
+			startContainer(rc, container1)
+
+			By("test filtering containers by label")
+			expectContainerIDs(listContainers(rc, &runtimeapi.ContainerFilter{
+				LabelSelector: labels("a"),
+			}), container1, container3)
+
+			By("test filtering containers by PodSandbox ID and label")
+			expectContainerIDs(listContainers(rc, &runtimeapi.ContainerFilter{
+				PodSandboxId:  pod1,
+				LabelSelector: map[string]string{filterLabelKey: filterValue},
+			}), container1, container2)
+			expectContainerIDs(listContainers(rc, &runtimeapi.ContainerFilter{
+				PodSandboxId:  pod2,
+				LabelSelector: labels("b"),
+			}))
+
+			By("test filtering containers by state and label")
+			expectContainerIDs(listContainers(rc, &runtimeapi.ContainerFilter{
+				State:         &runtimeapi.ContainerStateValue{State: runtimeapi.ContainerState_CONTAINER_RUNNING},
+				LabelSelector: map[string]string{filterLabelKey: filterValue},
+			}), container1)
+
+			By("test filtering containers by state, PodSandbox ID and label")
+			expectContainerIDs(listContainers(rc, &runtimeapi.ContainerFilter{
+				State:         &runtimeapi.ContainerStateValue{State: runtimeapi.ContainerState_CONTAINER_CREATED},
+				PodSandboxId:  pod1,
+				LabelSelector: map[string]string{filterLabelKey: filterValue},
+			}), container2)
+		})
+	})
+
+	Context("runtime should support labels and annotations", func() {
+		var podID string
+		var podConfig *runtimeapi.PodSandboxConfig
+
+		BeforeEach(func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+		})
+
+		It("runtime should preserve labels and annotations of container [Conformance]", func() {
+			filterValue := framework.NewUUID()
+			labels, annotations := buildLabelsAndAnnotations(filterValue)
+
+			By("create container with many labels and annotations")
+			containerConfig := &runtimeapi.ContainerConfig{
+				Metadata:    framework.BuildContainerMetadata("container-with-labels-and-annotations-"+framework.NewUUID(), framework.DefaultAttempt),
+				Image:       &runtimeapi.ImageSpec{Image: framework.DefaultContainerImage},
+				Command:     []string{"top"},
+				Labels:      labels,
+				Annotations: annotations,
+				Linux:       &runtimeapi.LinuxContainerConfig{},
+			}
+			containerID := framework.CreateContainer(rc, ic, containerConfig, podID, podConfig)
+
+			By("check labels and annotations in ContainerStatus")
+			status := helpers.GetContainerStatus(rc, containerID)
+			Expect(status.Labels).To(Equal(labels), "labels should be unchanged")
+			Expect(status.Annotations).To(Equal(annotations), "annotations should be unchanged")
+
+			By("check labels and annotations in ListContainers")
+			containers := listContainerForID(rc, containerID)
+			Expect(containers).To(HaveLen(1), "exactly one container should be listed")
+			Expect(containers[0].Labels).To(Equal(labels), "labels should be unchanged")
+			Expect(containers[0].Annotations).To(Equal(annotations), "annotations should be unchanged")
+
+			By("check that labels are used for filtering")
+			expectContainerIDs(listContainers(rc, &runtimeapi.ContainerFilter{
+				LabelSelector: labels,
+			}), containerID)
+
+			By("check that annotations are not used for filtering")
+			expectContainerIDs(listContainers(rc, &runtimeapi.ContainerFilter{
+				LabelSelector: map[string]string{annotationFilterKey: filterValue},
+			}))
+		})
 	})
 
 	Context("runtime should support adding volume and device", func() {
@@ -149,7 +517,7 @@ var _ = framework.KubeDescribe("Container", func() {
 
 		It("runtime should support starting container with volume [Conformance]", func() {
 			By("create host path and flag file")
-			hostPath, _ := createHostPath(podID)
+			hostPath, _ := helpers.CreateHostPath(podID)
 
 			defer os.RemoveAll(hostPath) // clean up the TempDir
 
@@ -167,7 +535,7 @@ var _ = framework.KubeDescribe("Container", func() {
 
 		It("runtime should support starting container with volume when host path is a symlink [Conformance]", func() {
 			By("create host path and flag file")
-			hostPath, _ := createHostPath(podID)
+			hostPath, _ := helpers.CreateHostPath(podID)
 			defer os.RemoveAll(hostPath) // clean up the TempDir
 
 			By("create symlink")
@@ -186,6 +554,33 @@ var _ = framework.KubeDescribe("Container", func() {
 			Expect(len(output)).NotTo(BeZero(), "len(output) should not be zero.")
 		})
 
+		It("runtime should support read-only volume [Conformance]", func() {
+			By("create host path and flag file")
+			hostPath, _ := helpers.CreateHostPath(podID)
+			defer os.RemoveAll(hostPath) // clean up the TempDir
+
+			By("create container with read-only volume")
+			containerID := createMountContainer(rc, ic, "container-with-readonly-volume-test-", podID, podConfig, &runtimeapi.Mount{
+				HostPath:      hostPath,
+				ContainerPath: hostPath,
+				Readonly:      true,
+			})
+
+			By("test start container with read-only volume")
+			testStartContainer(rc, containerID)
+
+			By("check the volume is readable in container")
+			command := []string{"ls", "-A", hostPath}
+			output := execSyncContainer(rc, containerID, command)
+			Expect(len(output)).NotTo(BeZero(), "len(output) should not be zero.")
+
+			By("check writing to the volume is rejected in container")
+			command = []string{"touch", filepath.Join(hostPath, "readonly-test.file")}
+			_, _, err := rc.ExecSync(containerID, command, framework.TestContext.ExecTimeout)
+			Expect(err).To(HaveOccurred(), "writing to a read-only volume should fail")
+			Expect(pathExists(filepath.Join(hostPath, "readonly-test.file"))).To(BeFalse(), "file should not be created on the host")
+		})
+
 		// TODO(random-liu): Decide whether to add host path not exist test when https://github.com/kubernetes/kubernetes/pull/61460
 		// is finalized.
 	})
@@ -209,14 +604,14 @@ var _ = framework.KubeDescribe("Container", func() {
 
 		It("runtime should support starting container with log [Conformance]", func() {
 			By("create container with log")
-			logPath, containerID := createLogContainer(rc, ic, "container-with-log-test-", podID, podConfig)
+			logPath, containerID := helpers.CreateLogContainer(rc, ic, "container-with-log-test-", podID, podConfig)
 
 			By("start container with log")
 			startContainer(rc, containerID)
 			// wait container exited and check the status.
 			Eventually(func() runtimeapi.ContainerState {
-				return getContainerStatus(rc, containerID).State
-			}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_EXITED))
+				return helpers.GetContainerStatus(rc, containerID).State
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_EXITED))
 
 			By("check the log context")
 			expectedLogMessage := defaultLog + "\n"
@@ -230,9 +625,8 @@ var _ = framework.KubeDescribe("Container", func() {
 			By("start container with log")
 			startContainer(rc, containerID)
 
-			Eventually(func() []logMessage {
-				return parseLogLine(podConfig, logPath)
-			}, time.Minute, time.Second).ShouldNot(BeEmpty(), "container log should be generated")
+			reader := newLogReader(podConfig, logPath)
+			Eventually(reader.read, framework.TestContext.StateTimeout, time.Second).ShouldNot(BeEmpty(), "container log should be generated")
 
 			By("rename container log")
 			newLogPath := logPath + ".new"
@@ -244,14 +638,105 @@ var _ = framework.KubeDescribe("Container", func() {
 
 			Expect(pathExists(filepath.Join(podConfig.LogDirectory, logPath))).To(
 				BeTrue(), "new container log file should be created")
-			Eventually(func() []logMessage {
-				return parseLogLine(podConfig, logPath)
-			}, time.Minute, time.Second).ShouldNot(BeEmpty(), "new container log should be generated")
-			oldLength := len(parseLogLine(podConfig, newLogPath))
+			// The reader notices that the log file was replaced.
+			Eventually(reader.read, framework.TestContext.StateTimeout, time.Second).ShouldNot(BeEmpty(), "new container log should be generated")
+			oldReader := newLogReader(podConfig, newLogPath)
+			oldLength := len(oldReader.read())
 			Consistently(func() int {
-				return len(parseLogLine(podConfig, newLogPath))
+				return len(oldReader.read())
 			}, 5*time.Second, time.Second).Should(Equal(oldLength), "old container log should not change")
 		})
+
+		It("runtime should merge stderr into stdout in the log of a tty container [Conformance]", func() {
+			By("create a tty container logging to stdout and stderr")
+			logPath, containerID := createTtyLogContainer(rc, ic, "container-tty-log-test-", podID, podConfig)
+
+			By("start container with log")
+			startContainer(rc, containerID)
+			helpers.WaitContainerExited(rc, containerID)
+
+			By("check the log context")
+			// The terminal translates line endings and both streams are
+			// written to it.
+			verifyLogContents(podConfig, logPath, "stdout\r\n", stdoutType)
+			verifyLogContents(podConfig, logPath, "stderr\r\n", stdoutType)
+			for _, msg := range parseLogLine(podConfig, logPath) {
+				Expect(msg.stream).To(Equal(stdoutType), "tty container should only log to stdout")
+			}
+		})
+
+		It("runtime should create the parent directories of the container log [Conformance]", func() {
+			By("create container with log in a subdirectory")
+			containerName := "container-nested-log-test-" + framework.NewUUID()
+			logPath := filepath.Join(containerName, "nested", "0.log")
+			metadata := framework.BuildContainerMetadata(containerName, framework.DefaultAttempt)
+			containerID := createLogPathContainer(rc, ic, podID, podConfig, metadata, logPath, defaultLog)
+
+			By("start container with log")
+			startContainer(rc, containerID)
+			helpers.WaitContainerExited(rc, containerID)
+
+			By("check the log context")
+			verifyLogContents(podConfig, logPath, defaultLog+"\n", stdoutType)
+		})
+
+		It("runtime should support reusing the log path of a removed container [Conformance]", func() {
+			containerName := "container-reuse-log-test-" + framework.NewUUID()
+			logPath := filepath.Join(containerName, "0.log")
+
+			By("create, start and remove the first attempt")
+			firstLog := "first attempt"
+			containerID := createLogPathContainer(rc, ic, podID, podConfig,
+				framework.BuildContainerMetadata(containerName, 0), logPath, firstLog)
+			startContainer(rc, containerID)
+			helpers.WaitContainerExited(rc, containerID)
+			verifyLogContents(podConfig, logPath, firstLog+"\n", stdoutType)
+			removeContainer(rc, containerID)
+
+			By("create and start the second attempt with the same log path")
+			secondLog := "second attempt"
+			containerID = createLogPathContainer(rc, ic, podID, podConfig,
+				framework.BuildContainerMetadata(containerName, 1), logPath, secondLog)
+			startContainer(rc, containerID)
+			helpers.WaitContainerExited(rc, containerID)
+
+			By("check the log context")
+			Expect(helpers.GetContainerStatus(rc, containerID).LogPath).To(Equal(filepath.Join(podConfig.LogDirectory, logPath)),
+				"the log path should be reported in ContainerStatus")
+			verifyLogContents(podConfig, logPath, secondLog+"\n", stdoutType)
+		})
+	})
+
+	Context("runtime should support log directory on a symlinked path", func() {
+		var podID, hostPath, podLogPath string
+		var podConfig *runtimeapi.PodSandboxConfig
+
+		BeforeEach(func() {
+			podID, podConfig, hostPath, podLogPath = createPodSandboxWithSymlinkLogDirectory(rc)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+			By("clean up the TempDir")
+			os.RemoveAll(hostPath)
+		})
+
+		It("runtime should write the container log through a symlinked log directory [Conformance]", func() {
+			By("create container with log")
+			logPath, containerID := helpers.CreateLogContainer(rc, ic, "container-symlink-log-test-", podID, podConfig)
+
+			By("start container with log")
+			startContainer(rc, containerID)
+			helpers.WaitContainerExited(rc, containerID)
+
+			By("check the log is written to the symlink target")
+			Expect(pathExists(filepath.Join(podLogPath, logPath))).To(BeTrue(),
+				"container log should be created in the symlink target")
+			verifyLogContents(podConfig, logPath, defaultLog+"\n", stdoutType)
+		})
 	})
 
 })
@@ -266,14 +751,6 @@ func containerFound(containers []*runtimeapi.Container, containerID string) bool
 	return false
 }
 
-// getContainerStatus gets ContainerState for containerID and fails if it gets error.
-func getContainerStatus(c internalapi.RuntimeService, containerID string) *runtimeapi.ContainerStatus {
-	By("Get container status for containerID: " + containerID)
-	status, err := c.ContainerStatus(containerID)
-	framework.ExpectNoError(err, "failed to get container %q status: %v", containerID, err)
-	return status
-}
-
 // createShellContainer creates a container to run /bin/sh.
 func createShellContainer(rc internalapi.RuntimeService, ic internalapi.ImageManagerService, podID string, podConfig *runtimeapi.PodSandboxConfig, prefix string) string {
 	containerName := prefix + framework.NewUUID()
@@ -294,8 +771,8 @@ func createShellContainer(rc internalapi.RuntimeService, ic internalapi.ImageMan
 func testCreateDefaultContainer(rc internalapi.RuntimeService, ic internalapi.ImageManagerService, podID string, podConfig *runtimeapi.PodSandboxConfig) string {
 	containerID := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-for-create-test-")
 	Eventually(func() runtimeapi.ContainerState {
-		return getContainerStatus(rc, containerID).State
-	}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_CREATED))
+		return helpers.GetContainerStatus(rc, containerID).State
+	}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_CREATED))
 	return containerID
 }
 
@@ -311,36 +788,16 @@ func startContainer(c internalapi.RuntimeService, containerID string) {
 func testStartContainer(rc internalapi.RuntimeService, containerID string) {
 	startContainer(rc, containerID)
 	Eventually(func() runtimeapi.ContainerState {
-		return getContainerStatus(rc, containerID).State
-	}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
-}
-
-// stopContainer stops the container for containerID.
-func stopContainer(c internalapi.RuntimeService, containerID string, timeout int64) {
-	By("Stop container for containerID: " + containerID)
-	stopped := make(chan bool, 1)
-
-	go func() {
-		defer GinkgoRecover()
-		err := c.StopContainer(containerID, timeout)
-		framework.ExpectNoError(err, "failed to stop container: %v", err)
-		stopped <- true
-	}()
-
-	select {
-	case <-time.After(time.Duration(timeout) * time.Second):
-		framework.Failf("stop container %q timeout.\n", containerID)
-	case <-stopped:
-		framework.Logf("Stopped container %q\n", containerID)
-	}
+		return helpers.GetContainerStatus(rc, containerID).State
+	}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
 }
 
 // testStopContainer stops the container for containerID and make sure it's exited.
 func testStopContainer(c internalapi.RuntimeService, containerID string) {
-	stopContainer(c, containerID, defaultStopContainerTimeout)
+	helpers.StopContainer(c, containerID, defaultStopContainerTimeout)
 	Eventually(func() runtimeapi.ContainerState {
-		return getContainerStatus(c, containerID).State
-	}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_EXITED))
+		return helpers.GetContainerStatus(c, containerID).State
+	}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_EXITED))
 }
 
 // removeContainer removes the container for containerID.
@@ -362,10 +819,87 @@ func listContainerForID(c internalapi.RuntimeService, containerID string) []*run
 	return containers
 }
 
+// createContainerWithMetadata creates a default container with metadata.
+func createContainerWithMetadata(rc internalapi.RuntimeService, ic internalapi.ImageManagerService, podID string, podConfig *runtimeapi.PodSandboxConfig, metadata *runtimeapi.ContainerMetadata) string {
+	containerConfig := &runtimeapi.ContainerConfig{
+		Metadata: metadata,
+		Image:    &runtimeapi.ImageSpec{Image: framework.DefaultContainerImage},
+		Command:  []string{"top"},
+		Linux:    &runtimeapi.LinuxContainerConfig{},
+	}
+	return framework.CreateContainer(rc, ic, containerConfig, podID, podConfig)
+}
+
+// verifyContainerMetadata checks the name and attempt of the container in
+// its status and in the container list.
+func verifyContainerMetadata(c internalapi.RuntimeService, containerID, name string, attempt uint32) {
+	status := helpers.GetContainerStatus(c, containerID)
+	Expect(status.GetMetadata().GetName()).To(Equal(name), "container status should have the name of its metadata")
+	Expect(status.GetMetadata().GetAttempt()).To(Equal(attempt), "container status should have the attempt of its metadata")
+
+	containers := listContainerForID(c, containerID)
+	Expect(containers).To(HaveLen(1), "container should be listed")
+	Expect(containers[0].GetMetadata().GetName()).To(Equal(name), "listed container should have the name of its metadata")
+	Expect(containers[0].GetMetadata().GetAttempt()).To(Equal(attempt), "listed container should have the attempt of its metadata")
+}
+
+// listContainers lists the containers matching filter.
+func listContainers(c internalapi.RuntimeService, filter *runtimeapi.ContainerFilter) []*runtimeapi.Container {
+	By("List containers")
+	containers, err := c.ListContainers(filter)
+	framework.ExpectNoError(err, "failed to list containers: %v", err)
+	return containers
+}
+
+// expectContainerIDs checks that containers are exactly the containers of
+// containerIDs.
+func expectContainerIDs(containers []*runtimeapi.Container, containerIDs ...string) {
+	ids := []string{}
+	for _, container := range containers {
+		ids = append(ids, container.Id)
+	}
+	Expect(ids).To(ConsistOf(containerIDs), "unexpected containers listed")
+}
+
+// createContainerWithLabels creates a default container with labels.
+func createContainerWithLabels(rc internalapi.RuntimeService, ic internalapi.ImageManagerService, podID string, podConfig *runtimeapi.PodSandboxConfig, prefix string, labels map[string]string) string {
+	containerName := prefix + framework.NewUUID()
+	containerConfig := &runtimeapi.ContainerConfig{
+		Metadata: framework.BuildContainerMetadata(containerName, framework.DefaultAttempt),
+		Image:    &runtimeapi.ImageSpec{Image: framework.DefaultContainerImage},
+		Command:  []string{"top"},
+		Labels:   labels,
+		Linux:    &runtimeapi.LinuxContainerConfig{},
+	}
+	return framework.CreateContainer(rc, ic, containerConfig, podID, podConfig)
+}
+
+// buildProcessContainerConfig returns the config of a container running command with args.
+func buildProcessContainerConfig(prefix string, command, args []string) *runtimeapi.ContainerConfig {
+	containerName := prefix + framework.NewUUID()
+	return &runtimeapi.ContainerConfig{
+		Metadata: framework.BuildContainerMetadata(containerName, framework.DefaultAttempt),
+		Image:    &runtimeapi.ImageSpec{Image: framework.DefaultContainerImage},
+		Command:  command,
+		Args:     args,
+		Linux:    &runtimeapi.LinuxContainerConfig{},
+	}
+}
+
+// readContainerFile returns the content of path in the container, or an
+// empty string if it can't be read yet.
+func readContainerFile(c internalapi.RuntimeService, containerID, path string) string {
+	stdout, _, err := c.ExecSync(containerID, []string{"cat", path}, framework.TestContext.ExecTimeout)
+	if err != nil {
+		return ""
+	}
+	return string(stdout)
+}
+
 // execSyncContainer test execSync for containerID and make sure the response is right.
 func execSyncContainer(c internalapi.RuntimeService, containerID string, command []string) string {
 	By("execSync for containerID: " + containerID)
-	stdout, stderr, err := c.ExecSync(containerID, command, time.Duration(defaultExecSyncTimeout)*time.Second)
+	stdout, stderr, err := c.ExecSync(containerID, command, framework.TestContext.ExecTimeout)
 	framework.ExpectNoError(err, "failed to execSync in container %q", containerID)
 	Expect(stderr).To(BeNil(), "The stderr should be nil.")
 	framework.Logf("Execsync succeed")
@@ -381,18 +915,6 @@ func verifyExecSyncOutput(c internalapi.RuntimeService, containerID string, comm
 	framework.Logf("verfiy Execsync output succeed")
 }
 
-// createHostPath creates the hostPath and flagFile for volume.
-func createHostPath(podID string) (string, string) {
-	hostPath, err := ioutil.TempDir("", "/test"+podID)
-	framework.ExpectNoError(err, "failed to create TempDir %q: %v", hostPath, err)
-
-	flagFile := "testVolume.file"
-	_, err = os.Create(filepath.Join(hostPath, flagFile))
-	framework.ExpectNoError(err, "failed to create volume file %q: %v", flagFile, err)
-
-	return hostPath, flagFile
-}
-
 // createSymlink creates a symlink of path.
 func createSymlink(path string) string {
 	symlinkPath := path + "-symlink"
@@ -420,18 +942,31 @@ func createVolumeContainer(rc internalapi.RuntimeService, ic internalapi.ImageMa
 	return framework.CreateContainer(rc, ic, containerConfig, podID, podConfig)
 }
 
-// createLogContainer creates a container with log and the prefix of containerName.
-func createLogContainer(rc internalapi.RuntimeService, ic internalapi.ImageManagerService, prefix string, podID string, podConfig *runtimeapi.PodSandboxConfig) (string, string) {
-	By("create a container with log and name")
+// createMountContainer creates a container with the given mount and the prefix of containerName and fails if it gets error.
+func createMountContainer(rc internalapi.RuntimeService, ic internalapi.ImageManagerService, prefix string, podID string, podConfig *runtimeapi.PodSandboxConfig, mount *runtimeapi.Mount) string {
+	By("create a container with mount and name")
 	containerName := prefix + framework.NewUUID()
-	path := fmt.Sprintf("%s.log", containerName)
 	containerConfig := &runtimeapi.ContainerConfig{
 		Metadata: framework.BuildContainerMetadata(containerName, framework.DefaultAttempt),
 		Image:    &runtimeapi.ImageSpec{Image: framework.DefaultContainerImage},
-		Command:  []string{"echo", defaultLog},
-		LogPath:  path,
+		Command:  []string{"sh", "-c", "top"},
+		Mounts:   []*runtimeapi.Mount{mount},
 	}
-	return containerConfig.LogPath, framework.CreateContainer(rc, ic, containerConfig, podID, podConfig)
+
+	return framework.CreateContainer(rc, ic, containerConfig, podID, podConfig)
+}
+
+// createLogPathContainer creates a container with metadata which logs msg to
+// logPath.
+func createLogPathContainer(rc internalapi.RuntimeService, ic internalapi.ImageManagerService, podID string, podConfig *runtimeapi.PodSandboxConfig, metadata *runtimeapi.ContainerMetadata, logPath, msg string) string {
+	By("create a container with log path " + logPath)
+	containerConfig := &runtimeapi.ContainerConfig{
+		Metadata: metadata,
+		Image:    &runtimeapi.ImageSpec{Image: framework.DefaultContainerImage},
+		Command:  []string{"echo", msg},
+		LogPath:  logPath,
+	}
+	return framework.CreateContainer(rc, ic, containerConfig, podID, podConfig)
 }
 
 // createKeepLoggingContainer creates a container keeps logging defaultLog to output.
@@ -448,6 +983,21 @@ func createKeepLoggingContainer(rc internalapi.RuntimeService, ic internalapi.Im
 	return containerConfig.LogPath, framework.CreateContainer(rc, ic, containerConfig, podID, podConfig)
 }
 
+// createTtyLogContainer creates a tty container which logs to stdout and stderr.
+func createTtyLogContainer(rc internalapi.RuntimeService, ic internalapi.ImageManagerService, prefix string, podID string, podConfig *runtimeapi.PodSandboxConfig) (string, string) {
+	By("create a tty container with log and name")
+	containerName := prefix + framework.NewUUID()
+	path := fmt.Sprintf("%s.log", containerName)
+	containerConfig := &runtimeapi.ContainerConfig{
+		Metadata: framework.BuildContainerMetadata(containerName, framework.DefaultAttempt),
+		Image:    &runtimeapi.ImageSpec{Image: framework.DefaultContainerImage},
+		Command:  []string{"sh", "-c", "echo stdout; echo stderr >&2"},
+		LogPath:  path,
+		Tty:      true,
+	}
+	return containerConfig.LogPath, framework.CreateContainer(rc, ic, containerConfig, podID, podConfig)
+}
+
 // pathExists check whether 'path' does exist or not
 func pathExists(path string) bool {
 	_, err := os.Stat(path)
@@ -498,35 +1048,63 @@ func parseCRILog(log string, msg *logMessage) {
 
 // parseLogLine parses log by row.
 func parseLogLine(podConfig *runtimeapi.PodSandboxConfig, logPath string) []logMessage {
-	path := filepath.Join(podConfig.LogDirectory, logPath)
-	f, err := os.Open(path)
+	return newLogReader(podConfig, logPath).read()
+}
+
+// logReader incrementally parses a container log. It remembers the offset of
+// the first line not parsed yet, so that polling a growing log only parses
+// the new lines instead of the whole file.
+type logReader struct {
+	path   string
+	file   os.FileInfo
+	offset int64
+	msgs   []logMessage
+}
+
+// newLogReader returns a reader of the container log at logPath.
+func newLogReader(podConfig *runtimeapi.PodSandboxConfig, logPath string) *logReader {
+	return &logReader{path: filepath.Join(podConfig.LogDirectory, logPath)}
+}
+
+// read parses the lines appended to the log since the previous call, and
+// returns all the messages parsed so far. The log is parsed from the start
+// again if it was replaced or truncated.
+func (r *logReader) read() []logMessage {
+	f, err := os.Open(r.path)
 	framework.ExpectNoError(err, "failed to open log file: %v", err)
-	framework.Logf("Open log file %s", path)
 	defer f.Close()
 
-	var msg logMessage
-	var msgLog []logMessage
+	info, err := f.Stat()
+	framework.ExpectNoError(err, "failed to stat log file: %v", err)
+	if r.file == nil || !os.SameFile(r.file, info) || info.Size() < r.offset {
+		r.offset = 0
+		r.msgs = nil
+	}
+	r.file = info
+
+	_, err = f.Seek(r.offset, io.SeekStart)
+	framework.ExpectNoError(err, "failed to seek log file: %v", err)
+	data, err := ioutil.ReadAll(f)
+	framework.ExpectNoError(err, "failed to read log file: %v", err)
 
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := scanner.Text()
+	// Lines which are still being written are parsed by the next call.
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		return r.msgs
+	}
+	r.offset += int64(end + 1)
 
+	for _, line := range strings.Split(string(data[:end]), "\n") {
+		var msg logMessage
 		// to determine whether the log is Docker format or CRI format.
 		if strings.HasPrefix(line, "{") {
 			parseDockerJSONLog([]byte(line), &msg)
 		} else {
 			parseCRILog(line, &msg)
 		}
-
-		msgLog = append(msgLog, msg)
-	}
-
-	if err := scanner.Err(); err != nil {
-		framework.ExpectNoError(err, "failed to read log by row: %v", err)
+		r.msgs = append(r.msgs, msg)
 	}
-	framework.Logf("Parse container log succeed")
-
-	return msgLog
+	return r.msgs
 }
 
 // verifyLogContents verifies the contents of container log.
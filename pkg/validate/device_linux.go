@@ -0,0 +1,157 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	"golang.org/x/sys/unix"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	// loopDeviceMajor is the major number of loop block devices.
+	loopDeviceMajor uint32 = 7
+	// testDeviceMinor is the minor number of the loop device created on
+	// the host, chosen high enough not to be in use.
+	testDeviceMinor uint32 = 242
+)
+
+var _ = framework.KubeDescribe("Container Devices", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	var rc internalapi.RuntimeService
+	var ic internalapi.ImageManagerService
+
+	BeforeEach(func() {
+		rc = f.CRIClient.CRIRuntimeClient
+		ic = f.CRIClient.CRIImageClient
+	})
+
+	Context("runtime should support devices", func() {
+		var podID string
+		var podConfig *runtimeapi.PodSandboxConfig
+
+		BeforeEach(func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+		})
+
+		It("runtime should support passing a device to the container [Conformance]", func() {
+			framework.Tolerate(rc, framework.BehaviorDevices)
+			hostPath := "/dev/null"
+			containerPath := "/dev/critest-null"
+			major, minor := deviceNumbers(hostPath)
+
+			By("create and start a container with a device")
+			containerID := createDeviceContainer(rc, ic, podID, podConfig, "container-for-device-test-", &runtimeapi.Device{
+				HostPath:      hostPath,
+				ContainerPath: containerPath,
+				Permissions:   "rwm",
+			})
+			testStartContainer(rc, containerID)
+
+			By("check the device numbers in the container")
+			verifyDeviceNumbers(rc, containerID, containerPath, major, minor)
+
+			By("check the device is writable")
+			execSyncContainer(rc, containerID, []string{"sh", "-c", "echo test > " + containerPath})
+		})
+
+		It("runtime should enforce the permissions of a device", func() {
+			framework.Tolerate(rc, framework.BehaviorDevices)
+			By("create a loop device on the host")
+			hostPath, clearHostPath := createLoopDevice(podID)
+			defer clearHostPath()
+			containerPath := "/dev/critest-loop"
+
+			By("create and start a container with a read-only device")
+			containerID := createDeviceContainer(rc, ic, podID, podConfig, "container-for-device-permissions-test-", &runtimeapi.Device{
+				HostPath:      hostPath,
+				ContainerPath: containerPath,
+				Permissions:   "r",
+			})
+			testStartContainer(rc, containerID)
+
+			By("check the device numbers in the container")
+			verifyDeviceNumbers(rc, containerID, containerPath, loopDeviceMajor, testDeviceMinor)
+
+			By("check the device can't be opened for writing")
+			_, stderr, err := rc.ExecSync(containerID, []string{"sh", "-c", ": > " + containerPath}, framework.TestContext.ExecTimeout)
+			Expect(err).To(HaveOccurred(), "opening a read-only device for writing should fail")
+			Expect(string(stderr)).To(ContainSubstring("Operation not permitted"), "opening a read-only device for writing should be denied")
+		})
+	})
+})
+
+// createDeviceContainer creates a container with the given device.
+func createDeviceContainer(rc internalapi.RuntimeService, ic internalapi.ImageManagerService, podID string, podConfig *runtimeapi.PodSandboxConfig, prefix string, device *runtimeapi.Device) string {
+	containerName := prefix + framework.NewUUID()
+	containerConfig := &runtimeapi.ContainerConfig{
+		Metadata: framework.BuildContainerMetadata(containerName, framework.DefaultAttempt),
+		Image:    &runtimeapi.ImageSpec{Image: framework.DefaultContainerImage},
+		Command:  []string{"top"},
+		Devices:  []*runtimeapi.Device{device},
+		Linux:    &runtimeapi.LinuxContainerConfig{},
+	}
+	return framework.CreateContainer(rc, ic, containerConfig, podID, podConfig)
+}
+
+// createLoopDevice creates a loop device node in a temporary directory and
+// returns its path along with a function cleaning it up.
+func createLoopDevice(podID string) (string, func()) {
+	dir, err := ioutil.TempDir("", "/test"+podID)
+	framework.ExpectNoError(err, "failed to create TempDir %q: %v", dir, err)
+
+	path := filepath.Join(dir, "loop")
+	err = unix.Mknod(path, unix.S_IFBLK|0660, int(unix.Mkdev(loopDeviceMajor, testDeviceMinor)))
+	framework.ExpectNoError(err, "failed to create device %q: %v", path, err)
+
+	return path, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+// deviceNumbers returns the major and minor numbers of the device at path.
+func deviceNumbers(path string) (uint32, uint32) {
+	var st unix.Stat_t
+	err := unix.Stat(path, &st)
+	framework.ExpectNoError(err, "failed to stat device %q: %v", path, err)
+	return unix.Major(uint64(st.Rdev)), unix.Minor(uint64(st.Rdev))
+}
+
+// verifyDeviceNumbers checks the major and minor numbers of the device at
+// path in the container.
+func verifyDeviceNumbers(c internalapi.RuntimeService, containerID, path string, major, minor uint32) {
+	// stat prints the device numbers in hexadecimal.
+	expected := fmt.Sprintf("%x %x\n", major, minor)
+	verifyExecSyncOutput(c, containerID, []string{"stat", "-c", "%t %T", path}, expected)
+}
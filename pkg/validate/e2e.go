@@ -33,6 +33,15 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+var _ = SynchronizedAfterSuite(func() {
+	framework.LogSkippedCapabilities()
+	framework.LogDeviations()
+}, func() {
+	// Only runs on the first node once all the nodes are done.
+	framework.CheckLeakedResources()
+	framework.ExpectNoError(framework.CloseCRIConnections(), "failed to close CRI connections")
+})
+
 // TestE2ECRI checks configuration parameters (specified through flags) and then runs
 // E2ECRI tests using the Ginkgo runner.
 // If a "report directory" is specified, one or more JUnit test reports will be
@@ -40,7 +49,7 @@ import (
 // This function is called on each Ginkgo node in parallel mode.
 func TestE2ECRI(t *testing.T) {
 	rand.Seed(time.Now().UTC().UnixNano())
-	RegisterFailHandler(Fail)
+	RegisterFailHandler(framework.FailWithArtifacts)
 	r := []Reporter{}
 	reportDir := framework.TestContext.ReportDir
 	if reportDir != "" {
@@ -0,0 +1,110 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+	utilexec "k8s.io/utils/exec"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	// concurrentExecCalls is the number of execSync calls issued by the
+	// concurrent execSync test, by concurrentExecWorkers goroutines.
+	concurrentExecCalls   = 200
+	concurrentExecWorkers = 20
+)
+
+var _ = framework.KubeDescribe("Container", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	var rc internalapi.RuntimeService
+	var ic internalapi.ImageManagerService
+
+	BeforeEach(func() {
+		rc = f.CRIClient.CRIRuntimeClient
+		ic = f.CRIClient.CRIImageClient
+	})
+
+	Context("runtime should support concurrent execSync", func() {
+		var podID string
+		var podConfig *runtimeapi.PodSandboxConfig
+
+		BeforeEach(func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+		})
+
+		It("runtime should return the output and exit code of each concurrent execSync [Conformance]", func() {
+			By("create container")
+			containerID := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-for-concurrent-execSync-test-")
+
+			By("start container")
+			startContainer(rc, containerID)
+
+			By("execSync commands concurrently")
+			calls := make(chan int, concurrentExecCalls)
+			for i := 0; i < concurrentExecCalls; i++ {
+				calls <- i
+			}
+			close(calls)
+
+			var wg sync.WaitGroup
+			for i := 0; i < concurrentExecWorkers; i++ {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					for call := range calls {
+						checkConcurrentExecSync(rc, containerID, call)
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	})
+})
+
+// checkConcurrentExecSync runs a command writing call to stdout and stderr
+// and exiting with a code derived from call, and checks its results.
+func checkConcurrentExecSync(c internalapi.RuntimeService, containerID string, call int) {
+	exitCode := call % 3
+	command := []string{"sh", "-c", fmt.Sprintf("echo stdout-%d; echo stderr-%d >&2; exit %d", call, call, exitCode)}
+	stdout, stderr, err := c.ExecSync(containerID, command, framework.TestContext.ExecTimeout)
+	if exitCode == 0 {
+		framework.ExpectNoError(err, "execSync %d failed: %v", call, err)
+	} else {
+		exitErr, ok := err.(utilexec.CodeExitError)
+		Expect(ok).To(BeTrue(), "execSync %d should fail with an exit code, got %v", call, err)
+		Expect(exitErr.ExitStatus()).To(Equal(exitCode), "unexpected exit code of execSync %d", call)
+	}
+	Expect(string(stdout)).To(Equal(fmt.Sprintf("stdout-%d\n", call)), "unexpected stdout of execSync %d", call)
+	Expect(string(stderr)).To(Equal(fmt.Sprintf("stderr-%d\n", call)), "unexpected stderr of execSync %d", call)
+}
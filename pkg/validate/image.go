@@ -17,10 +17,16 @@ limitations under the License.
 package validate
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework/registry"
+	"golang.org/x/net/context"
 	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
 	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 
@@ -46,8 +52,48 @@ const (
 	imageUserUIDGroup          = int64(1003)
 	testImageUserUsernameGroup = "gcr.io/cri-tools/test-image-user-username-group"
 	imageUserUsernameGroup     = "www-data"
+
+	// image served by the test registry
+	testImageWithAuth = "gcr.io/cri-tools/test-image-auth:latest"
+
+	// image whose manifest digest is resolved from the test registry
+	testImageDigestCheck = "gcr.io/cri-tools/test-image-digest-check:latest"
+
+	// credentials accepted by the test registry
+	testRegistryUsername      = "critest"
+	testRegistryPassword      = "critest-password"
+	testRegistryIdentityToken = "critest-identity-token"
+
+	// imageSizeChecks is the number of image status calls checking that
+	// the image size is stable.
+	imageSizeChecks = 3
+
+	// image served slowly by the test registry, so that its pull can be
+	// cancelled midway, or overlap with other pulls
+	testImageSlow = "gcr.io/cri-tools/test-image-slow:latest"
+	// slowImageLayerSize and slowImageBlobRate make the slow image take
+	// about 8 seconds to pull.
+	slowImageLayerSize = 64 * 1024 * 1024
+	slowImageBlobRate  = 8 * 1024 * 1024
+	// concurrentPulls is the number of identical pulls issued concurrently
+	// by the pull deduplication test.
+	concurrentPulls = 10
+	// imageGCTimeout is the time given to the runtime to reclaim the space of
+	// a removed image.
+	imageGCTimeout = 2 * time.Minute
+
+	// digestPattern matches the digests of the image layers.
+	digestPattern = `^sha256:[0-9a-f]{64}$`
 )
 
+// layerDigestKeys are the keys of the lists of layer digests in the verbose
+// image info: the diff IDs of the OCI image config, and the layer
+// descriptors of the manifest.
+var layerDigestKeys = map[string]bool{
+	"diff_ids": true,
+	"layers":   true,
+}
+
 var _ = framework.KubeDescribe("Image Manager", func() {
 	f := framework.NewDefaultCRIFramework()
 
@@ -59,23 +105,72 @@ var _ = framework.KubeDescribe("Image Manager", func() {
 
 	It("public image with tag should be pulled and removed [Conformance]", func() {
 		testPullPublicImage(c, testImageWithTag, func(s *runtimeapi.Image) {
-			Expect(s.RepoTags).To(Equal([]string{testImageWithTag}))
+			Expect(s.RepoTags).To(Equal([]string{framework.ResolveImage(testImageWithTag)}))
 		})
 	})
 
 	It("public image without tag should be pulled and removed [Conformance]", func() {
 		testPullPublicImage(c, testImageWithoutTag, func(s *runtimeapi.Image) {
-			Expect(s.RepoTags).To(Equal([]string{testImageWithoutTag + ":latest"}))
+			Expect(s.RepoTags).To(Equal([]string{framework.ResolveImage(testImageWithoutTag) + ":latest"}))
 		})
 	})
 
 	It("public image with digest should be pulled and removed [Conformance]", func() {
 		testPullPublicImage(c, testImageWithDigest, func(s *runtimeapi.Image) {
 			Expect(s.RepoTags).To(BeEmpty())
-			Expect(s.RepoDigests).To(Equal([]string{testImageWithDigest}))
+			Expect(s.RepoDigests).To(Equal([]string{framework.ResolveImage(testImageWithDigest)}))
 		})
 	})
 
+	It("image pulled by tag and by digest should resolve to the same image [Conformance]", func() {
+		// Make sure image does not exist before testing.
+		removeImage(c, testImageWithTag)
+
+		framework.PullPublicImage(c, testImageWithTag)
+		defer removeImage(c, testImageWithTag)
+
+		By("Get the digest of the image pulled by tag")
+		status := framework.ImageStatus(c, testImageWithTag)
+		Expect(status).NotTo(BeNil(), "Should have one image in list")
+		Expect(status.RepoDigests).NotTo(BeEmpty(), "RepoDigests should be populated for image pulled by tag")
+		digestRef := status.RepoDigests[0]
+
+		id := framework.PullPublicImage(c, digestRef)
+		Expect(id).To(Equal(status.Id), "Image pulled by digest should have the same ID as image pulled by tag")
+		digestStatus := framework.ImageStatus(c, digestRef)
+		Expect(digestStatus).NotTo(BeNil(), "Should find image by digest")
+		Expect(digestStatus.Id).To(Equal(status.Id), "Image found by digest should have the same ID as image found by tag")
+
+		By("Check image list to make sure RepoTags and RepoDigests are populated")
+		var found bool
+		for _, img := range framework.ListImage(c, &runtimeapi.ImageFilter{}) {
+			if img.Id == status.Id {
+				found = true
+				Expect(img.RepoTags).To(ContainElement(framework.ResolveImage(testImageWithTag)))
+				Expect(img.RepoDigests).To(ContainElement(digestRef))
+				break
+			}
+		}
+		Expect(found).To(BeTrue(), "Image should be in the image list")
+	})
+
+	It("public image with digest should be removed by digest [Conformance]", func() {
+		// Make sure image does not exist before testing.
+		removeImage(c, testImageWithDigest)
+
+		framework.PullPublicImage(c, testImageWithDigest)
+		defer removeImage(c, testImageWithDigest)
+
+		digestRef := framework.ResolveImage(testImageWithDigest)
+		By("Remove image by digest : " + digestRef)
+		err := c.RemoveImage(&runtimeapi.ImageSpec{Image: digestRef})
+		framework.ExpectNoError(err, "failed to remove image by digest: %v", err)
+
+		By("Check image list to make sure removing image by digest success")
+		status := framework.ImageStatus(c, testImageWithDigest)
+		Expect(status).To(BeNil(), "Should have none image in list")
+	})
+
 	It("image status get image fields should not have Uid|Username empty [Conformance]", func() {
 		for _, item := range []struct {
 			description string
@@ -139,7 +234,7 @@ var _ = framework.KubeDescribe("Image Manager", func() {
 			for _, img := range images {
 				if img.Id == id {
 					Expect(len(img.RepoTags)).To(Equal(1), "Should only have 1 repo tag")
-					Expect(img.RepoTags[0]).To(Equal(testImageList[i]), "Repo tag should be correct")
+					Expect(img.RepoTags[0]).To(Equal(framework.ResolveImage(testImageList[i])), "Repo tag should be correct")
 					break
 				}
 			}
@@ -164,14 +259,354 @@ var _ = framework.KubeDescribe("Image Manager", func() {
 
 		images := framework.ListImage(c, &runtimeapi.ImageFilter{})
 
-		sort.Strings(testImageList)
+		var expectedTags []string
+		for _, image := range testImageList {
+			expectedTags = append(expectedTags, framework.ResolveImage(image))
+		}
+		sort.Strings(expectedTags)
 		for _, img := range images {
 			if img.Id == ids[0] {
 				sort.Strings(img.RepoTags)
-				Expect(img.RepoTags).To(Equal(testImageList), "Should have 3 repoTags in single image")
+				Expect(img.RepoTags).To(Equal(expectedTags), "Should have 3 repoTags in single image")
+				break
+			}
+		}
+	})
+
+	It("listImage with image filter should only get the filtered image [Conformance]", func() {
+		testImageList := []string{
+			"gcr.io/cri-tools/test-image-1:latest",
+			"gcr.io/cri-tools/test-image-2:latest",
+		}
+
+		// Make sure test image does not exist.
+		removeImageList(c, testImageList)
+		ids := pullImageList(c, testImageList)
+		defer removeImageList(c, testImageList)
+
+		filter := &runtimeapi.ImageFilter{
+			Image: &runtimeapi.ImageSpec{Image: framework.ResolveImage(testImageList[0])},
+		}
+		images := framework.ListImage(c, filter)
+		Expect(len(images)).To(Equal(1), "Should only get 1 image with image filter")
+		Expect(images[0].Id).To(Equal(ids[0]), "Should get the filtered image")
+	})
+
+	It("listImage and imageStatus should return consistent image fields [Conformance]", func() {
+		testImageList := []string{
+			testImageUserUID,
+			testImageUserUsername,
+			"gcr.io/cri-tools/test-image-tags:1",
+			"gcr.io/cri-tools/test-image-tags:2",
+		}
+
+		// Make sure test image does not exist.
+		removeImageList(c, testImageList)
+		pullImageList(c, testImageList)
+		defer removeImageList(c, testImageList)
+
+		images := framework.ListImage(c, &runtimeapi.ImageFilter{})
+		for _, imageName := range testImageList {
+			status := framework.ImageStatus(c, imageName)
+			Expect(status).NotTo(BeNil(), "Should have image %q in list", imageName)
+
+			var listed *runtimeapi.Image
+			for _, img := range images {
+				if img.Id == status.Id {
+					listed = img
+					break
+				}
+			}
+			Expect(listed).NotTo(BeNil(), "Image %q should be in the image list", imageName)
+			Expect(listed.Size_).To(Equal(status.Size_), "Image %q size should be consistent", imageName)
+			Expect(listed.GetUid().GetValue()).To(Equal(status.GetUid().GetValue()), "Image %q uid should be consistent", imageName)
+			Expect(listed.Username).To(Equal(status.Username), "Image %q username should be consistent", imageName)
+			Expect(listed.RepoTags).To(ConsistOf(status.RepoTags), "Image %q repoTags should be consistent", imageName)
+			Expect(listed.RepoDigests).To(ConsistOf(status.RepoDigests), "Image %q repoDigests should be consistent", imageName)
+		}
+	})
+
+	It("image size should be non-zero and stable across calls [Conformance]", func() {
+		// Make sure image does not exist before testing.
+		removeImage(c, testImageWithTag)
+
+		framework.PullPublicImage(c, testImageWithTag)
+		defer removeImage(c, testImageWithTag)
+
+		status := framework.ImageStatus(c, testImageWithTag)
+		Expect(status).NotTo(BeNil(), "Should have one image in list")
+		Expect(status.Size_).NotTo(BeZero(), "Image size should not be zero")
+
+		By("Check the image size is stable across calls")
+		for i := 0; i < imageSizeChecks; i++ {
+			Expect(framework.ImageStatus(c, testImageWithTag).Size_).To(Equal(status.Size_), "Image size should be stable")
+		}
+
+		By("Check the image size is consistent with the image list")
+		var found bool
+		for _, img := range framework.ListImage(c, &runtimeapi.ImageFilter{}) {
+			if img.Id == status.Id {
+				found = true
+				Expect(img.Size_).To(Equal(status.Size_), "Image size should be consistent with the image list")
 				break
 			}
 		}
+		Expect(found).To(BeTrue(), "Image should be in the image list")
+	})
+
+	It("image verbose info should decode the layer digests", func() {
+		// Make sure image does not exist before testing.
+		removeImage(c, testImageWithTag)
+
+		framework.PullPublicImage(c, testImageWithTag)
+		defer removeImage(c, testImageWithTag)
+
+		resp := framework.ImageStatusVerbose(testImageWithTag)
+		Expect(resp.Image).NotTo(BeNil(), "Should have one image in list")
+		if len(resp.Info) == 0 {
+			Skip("runtime doesn't provide verbose image info")
+		}
+
+		By("Decode the layer digests in the verbose info")
+		var layers []string
+		for key, value := range resp.Info {
+			var info interface{}
+			if err := json.Unmarshal([]byte(value), &info); err != nil {
+				framework.Logf("Verbose info %q is not JSON: %v", key, err)
+				continue
+			}
+			layers = append(layers, findLayerDigests(info)...)
+		}
+		if len(layers) == 0 {
+			Skip("runtime doesn't provide the layer digests in the verbose image info")
+		}
+		framework.Logf("Image %q has %d layer(s): %v", testImageWithTag, len(layers), layers)
+		for _, layer := range layers {
+			Expect(layer).To(MatchRegexp(digestPattern), "Layer digest should be well formed")
+		}
+	})
+
+	Context("runtime should handle cancelled image pulls", func() {
+		var reg *registry.Registry
+		var image string
+
+		BeforeEach(func() {
+			var err error
+			reg, err = startTestRegistry(registry.Options{
+				Images:    []string{testImageSlow},
+				LayerSize: slowImageLayerSize,
+				BlobRate:  slowImageBlobRate,
+			})
+			framework.ExpectNoError(err, "failed to start test registry: %v", err)
+			image = reg.Ref(testImageSlow)
+		})
+
+		AfterEach(func() {
+			removeImage(c, image)
+			reg.Close()
+		})
+
+		It("image pull cancelled midway should not leave the image behind", func() {
+			removeImage(c, image)
+			usedBefore := imageFsUsedBytes(c)
+
+			By("Cancel the pull while the layers are downloaded")
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			errCh := make(chan error, 1)
+			go func() {
+				defer GinkgoRecover()
+				_, err := framework.PullImageWithContext(ctx, image)
+				errCh <- err
+			}()
+			// The config and the layer are requested once the manifest is
+			// resolved. They are polled more often than the containers, to
+			// cancel the pull well before it completes.
+			Eventually(reg.BlobRequests, framework.TestContext.StateTimeout, 100*time.Millisecond).Should(BeNumerically(">=", 2))
+			cancel()
+			var err error
+			Eventually(errCh, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Receive(&err))
+			Expect(err).To(HaveOccurred(), "cancelled pull should fail")
+			framework.Logf("Cancelled pull of %q failed as expected: %v", image, err)
+
+			By("Check the image is not listed")
+			Expect(framework.ImageStatus(c, image)).To(BeNil(), "cancelled image should not be found")
+			for _, img := range framework.ListImage(c, &runtimeapi.ImageFilter{}) {
+				Expect(img.RepoTags).NotTo(ContainElement(image), "cancelled image should not be listed")
+			}
+
+			By("Check the image can be pulled again")
+			framework.PullPublicImage(c, image)
+			Expect(framework.ImageStatus(c, image)).NotTo(BeNil(), "image should be pulled")
+
+			By("Check the space of the image is reclaimed once removed")
+			removeImage(c, image)
+			Eventually(func() uint64 {
+				return imageFsUsedBytes(c)
+			}, imageGCTimeout, framework.TestContext.PollInterval).Should(BeNumerically("<", usedBefore+slowImageLayerSize/2),
+				"image filesystem should not leak the space of the cancelled pull")
+		})
+	})
+
+	Context("runtime should deduplicate concurrent pulls of an image", func() {
+		var reg *registry.Registry
+		var image string
+
+		BeforeEach(func() {
+			var err error
+			reg, err = startTestRegistry(registry.Options{
+				Images:    []string{testImageSlow},
+				LayerSize: slowImageLayerSize,
+				BlobRate:  slowImageBlobRate,
+			})
+			framework.ExpectNoError(err, "failed to start test registry: %v", err)
+			image = reg.Ref(testImageSlow)
+		})
+
+		AfterEach(func() {
+			removeImage(c, image)
+			reg.Close()
+		})
+
+		It("concurrent pulls of the same image should share the download", func() {
+			removeImage(c, image)
+
+			By(fmt.Sprintf("Pull image %q from %d goroutines", image, concurrentPulls))
+			var wg sync.WaitGroup
+			ids := make([]string, concurrentPulls)
+			errs := make([]error, concurrentPulls)
+			start := time.Now()
+			for i := 0; i < concurrentPulls; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					ids[i], errs[i] = c.PullImage(&runtimeapi.ImageSpec{Image: image}, nil)
+				}(i)
+			}
+			wg.Wait()
+			elapsed := time.Since(start)
+			framework.Logf("%d concurrent pulls of %q took %v with %d blob requests", concurrentPulls, image, elapsed, reg.BlobRequests())
+
+			By("Check all the pulls succeed with the same image")
+			for i, err := range errs {
+				framework.ExpectNoError(err, "pull %d failed: %v", i, err)
+			}
+			Expect(removeDuplicates(ids)).To(HaveLen(1), "concurrent pulls should result in a single image")
+
+			By("Check the download is shared between the pulls")
+			// A single download of the layer takes slowImageLayerSize/slowImageBlobRate
+			// seconds, the pulls would take concurrentPulls times longer if
+			// the runtime downloaded the layer once per pull.
+			single := time.Duration(slowImageLayerSize/slowImageBlobRate) * time.Second
+			Expect(elapsed).To(BeNumerically("<", single*concurrentPulls/2), "concurrent pulls should not download the image once each")
+		})
+	})
+
+	Context("runtime should record the manifest digest of the registry", func() {
+		var reg *registry.Registry
+		var image string
+
+		BeforeEach(func() {
+			var err error
+			reg, err = startTestRegistry(registry.Options{
+				Images: []string{testImageDigestCheck},
+			})
+			framework.ExpectNoError(err, "failed to start test registry: %v", err)
+			image = reg.Ref(testImageDigestCheck)
+		})
+
+		AfterEach(func() {
+			removeImage(c, image)
+			reg.Close()
+		})
+
+		It("image pulled by digest should have the manifest digest of the registry", func() {
+			removeImage(c, image)
+
+			By("Resolve the manifest digest from the registry")
+			name, tag := splitImageTag(image)
+			digest, err := (&registry.Client{}).ManifestDigest(reg.URL(), strings.TrimPrefix(name, reg.Host()+"/"), tag)
+			framework.ExpectNoError(err, "failed to resolve the digest of %q: %v", image, err)
+			digestRef := name + "@" + digest
+			framework.Logf("Image %q has the manifest digest %s", image, digest)
+
+			id := framework.PullPublicImage(c, digestRef)
+
+			By("Check the image status records the digest")
+			status := framework.ImageStatus(c, digestRef)
+			Expect(status).NotTo(BeNil(), "Should find image by digest")
+			Expect(status.RepoDigests).To(ContainElement(digestRef), "RepoDigests should have the manifest digest of the registry")
+
+			By("Check the verbose image info records the digest")
+			resp := framework.ImageStatusVerbose(digestRef)
+			var infoDigests []string
+			for key, value := range resp.Info {
+				var info interface{}
+				if err := json.Unmarshal([]byte(value), &info); err != nil {
+					info = value
+				}
+				for _, ref := range findRepoDigests(info, name) {
+					framework.Logf("Verbose info %q has the digested reference %q", key, ref)
+					infoDigests = append(infoDigests, ref)
+				}
+			}
+			for _, ref := range infoDigests {
+				Expect(ref).To(Equal(digestRef), "Verbose info should have the manifest digest of the registry")
+			}
+
+			By("Check the image pulled by tag has the same digest")
+			Expect(framework.PullPublicImage(c, image)).To(Equal(id), "Image pulled by tag should be the image pulled by digest")
+			status = framework.ImageStatus(c, image)
+			Expect(status).NotTo(BeNil(), "Should find image by tag")
+			Expect(status.RepoDigests).To(ContainElement(digestRef), "RepoDigests of the image pulled by tag should have the manifest digest of the registry")
+		})
+	})
+
+	Context("runtime should support pulling image with registry credentials", func() {
+		var reg *registry.Registry
+		var image string
+
+		BeforeEach(func() {
+			var err error
+			reg, err = startTestRegistry(registry.Options{
+				Auth:          registry.AuthToken,
+				Username:      testRegistryUsername,
+				Password:      testRegistryPassword,
+				IdentityToken: testRegistryIdentityToken,
+				Images:        []string{testImageWithAuth},
+			})
+			framework.ExpectNoError(err, "failed to start test registry: %v", err)
+			image = reg.Ref(testImageWithAuth)
+		})
+
+		AfterEach(func() {
+			removeImage(c, image)
+			reg.Close()
+		})
+
+		It("image pull with username and password should succeed", func() {
+			testPullImageWithAuth(c, image, &runtimeapi.AuthConfig{
+				Username: testRegistryUsername,
+				Password: testRegistryPassword,
+			})
+		})
+
+		It("image pull with identity token should succeed", func() {
+			testPullImageWithAuth(c, image, &runtimeapi.AuthConfig{
+				IdentityToken: testRegistryIdentityToken,
+			})
+		})
+
+		It("image pull without credentials should fail with an auth error", func() {
+			testPullImageWithAuthFailure(c, image, nil)
+		})
+
+		It("image pull with wrong password should fail with an auth error", func() {
+			testPullImageWithAuthFailure(c, image, &runtimeapi.AuthConfig{
+				Username: testRegistryUsername,
+				Password: "wrong-password",
+			})
+		})
 	})
 })
 
@@ -204,6 +639,65 @@ func testPullPublicImage(c internalapi.ImageManagerService, imageName string, st
 	testRemoveImage(c, imageName)
 }
 
+// imageFsUsedBytes returns the bytes used by the image filesystems.
+func imageFsUsedBytes(c internalapi.ImageManagerService) uint64 {
+	usages, err := c.ImageFsInfo()
+	framework.ExpectNoError(err, "failed to get image filesystem info: %v", err)
+	var used uint64
+	for _, usage := range usages {
+		used += usage.GetUsedBytes().GetValue()
+	}
+	return used
+}
+
+// startTestRegistry starts a test registry with opts, serving images with the
+// content of the default container image.
+func startTestRegistry(opts registry.Options) (*registry.Registry, error) {
+	opts.Source = framework.ResolveImage(framework.DefaultContainerImage)
+	reg, err := registry.Start(opts)
+	if err != nil {
+		return nil, fmt.Errorf("%v; set a reachable mirror of %s with -test-images", err, framework.DefaultContainerImage)
+	}
+	return reg, nil
+}
+
+// pullImageWithAuth pulls the image named imageName with the given auth config.
+func pullImageWithAuth(c internalapi.ImageManagerService, imageName string, auth *runtimeapi.AuthConfig) (string, error) {
+	By("Pull image with auth : " + imageName)
+	return c.PullImage(&runtimeapi.ImageSpec{Image: imageName}, auth)
+}
+
+// testPullImageWithAuth pulls the image named imageName with auth and make sure it success.
+func testPullImageWithAuth(c internalapi.ImageManagerService, imageName string, auth *runtimeapi.AuthConfig) {
+	removeImage(c, imageName)
+
+	_, err := pullImageWithAuth(c, imageName, auth)
+	framework.ExpectNoError(err, "failed to pull image %q with auth: %v", imageName, err)
+
+	By("Check image list to make sure pulling image success : " + imageName)
+	status := framework.ImageStatus(c, imageName)
+	Expect(status).NotTo(BeNil(), "Should have one image in list")
+}
+
+// testPullImageWithAuthFailure pulls the image named imageName with auth and make sure it fails with an auth error.
+func testPullImageWithAuthFailure(c internalapi.ImageManagerService, imageName string, auth *runtimeapi.AuthConfig) {
+	removeImage(c, imageName)
+
+	_, err := pullImageWithAuth(c, imageName, auth)
+	Expect(err).To(HaveOccurred(), "pulling image %q should fail", imageName)
+	framework.Logf("Pull image %q failed as expected: %v", imageName, err)
+	Expect(strings.ToLower(err.Error())).To(Or(
+		ContainSubstring("unauthorized"),
+		ContainSubstring("authentication"),
+		ContainSubstring("authorization"),
+		ContainSubstring("401"),
+	), "pulling image %q should fail with an auth error", imageName)
+
+	By("Check image is not pulled : " + imageName)
+	status := framework.ImageStatus(c, imageName)
+	Expect(status).To(BeNil(), "Should have none image in list")
+}
+
 // pullImageList pulls the images listed in the imageList.
 func pullImageList(c internalapi.ImageManagerService, imageList []string) []string {
 	var ids []string
@@ -222,6 +716,7 @@ func removeImageList(c internalapi.ImageManagerService, imageList []string) {
 
 // removeImage removes the image named imagesName.
 func removeImage(c internalapi.ImageManagerService, imageName string) {
+	imageName = framework.ResolveImage(imageName)
 	By("Remove image : " + imageName)
 	image, err := c.ImageStatus(&runtimeapi.ImageSpec{Image: imageName})
 	framework.ExpectNoError(err, "failed to get image status: %v", err)
@@ -233,6 +728,64 @@ func removeImage(c internalapi.ImageManagerService, imageName string) {
 	}
 }
 
+// findLayerDigests returns the layer digests found in the decoded verbose
+// image info, under one of layerDigestKeys at any depth.
+func findLayerDigests(info interface{}) []string {
+	var digests []string
+	switch v := info.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if list, ok := value.([]interface{}); ok && layerDigestKeys[strings.ToLower(key)] {
+				for _, item := range list {
+					switch layer := item.(type) {
+					case string:
+						digests = append(digests, layer)
+					case map[string]interface{}:
+						// OCI descriptors carry the digest of the layer.
+						if digest, ok := layer["digest"].(string); ok {
+							digests = append(digests, digest)
+						}
+					}
+				}
+				continue
+			}
+			digests = append(digests, findLayerDigests(value)...)
+		}
+	case []interface{}:
+		for _, item := range v {
+			digests = append(digests, findLayerDigests(item)...)
+		}
+	}
+	return digests
+}
+
+// findRepoDigests returns the references of repository name with a digest,
+// e.g. name@sha256:..., found in the decoded verbose image info.
+func findRepoDigests(info interface{}, name string) []string {
+	var refs []string
+	switch v := info.(type) {
+	case string:
+		if strings.HasPrefix(v, name+"@") {
+			refs = append(refs, v)
+		}
+	case map[string]interface{}:
+		for _, value := range v {
+			refs = append(refs, findRepoDigests(value, name)...)
+		}
+	case []interface{}:
+		for _, item := range v {
+			refs = append(refs, findRepoDigests(item, name)...)
+		}
+	}
+	return refs
+}
+
+// splitImageTag splits an image reference with a tag into its name and tag.
+func splitImageTag(image string) (string, string) {
+	i := strings.LastIndex(image, ":")
+	return image[:i], image[i+1:]
+}
+
 // removeDuplicates remove duplicates strings from a list
 func removeDuplicates(ss []string) []string {
 	encountered := map[string]bool{}
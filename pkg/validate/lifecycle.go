@@ -0,0 +1,346 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"time"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework/helpers"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	// stopGracePeriod is the timeout given to StopContainer when checking
+	// that containers ignoring SIGTERM are killed.
+	stopGracePeriod int64 = 5
+	// stopKillSlack bounds the time taken to kill a container once its
+	// grace period is over.
+	stopKillSlack = 10 * time.Second
+	// exitCodeKilled is the exit code of a process killed by SIGKILL.
+	exitCodeKilled int32 = 137
+	// exitedContainerRetention is the time exited containers are checked to
+	// be kept by the runtime.
+	exitedContainerRetention = 10 * time.Second
+
+	// Termination reasons reported for exited containers.
+	reasonCompleted = "Completed"
+	reasonError     = "Error"
+	reasonOOMKilled = "OOMKilled"
+)
+
+var _ = framework.RequireCapabilities("Container runtime should not garbage collect containers by itself runtime should handle the stats of an exited container",
+	framework.CapabilityStats)
+
+var _ = framework.KubeDescribe("Container", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	var rc internalapi.RuntimeService
+	var ic internalapi.ImageManagerService
+
+	BeforeEach(func() {
+		rc = f.CRIClient.CRIRuntimeClient
+		ic = f.CRIClient.CRIImageClient
+	})
+
+	Context("runtime should support idempotent lifecycle operations", func() {
+		var podID string
+		var podConfig *runtimeapi.PodSandboxConfig
+
+		BeforeEach(func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+		})
+
+		It("runtime should support stopping an exited container [Conformance]", func() {
+			By("create and start a container which exits")
+			containerID := createCommandContainer(rc, ic, podID, podConfig, "container-for-stop-exited-test-", []string{"sh", "-c", "exit 0"})
+			startContainer(rc, containerID)
+			helpers.WaitContainerExited(rc, containerID)
+
+			By("stop the exited container")
+			err := rc.StopContainer(containerID, defaultStopContainerTimeout)
+			framework.ExpectNoError(err, "failed to stop exited container: %v", err)
+		})
+
+		It("runtime should consistently handle removing a nonexistent container [Conformance]", func() {
+			containerID := "nonexistent-container-" + framework.NewUUID()
+
+			By("remove a nonexistent container")
+			firstErr := rc.RemoveContainer(containerID)
+			Expect(isNilOrNotFound(firstErr)).To(BeTrue(), "removing a nonexistent container should succeed or return NotFound, got %v", firstErr)
+
+			By("remove it again")
+			secondErr := rc.RemoveContainer(containerID)
+			Expect(secondErr == nil).To(Equal(firstErr == nil), "removing a nonexistent container should return the same result, got %v then %v", firstErr, secondErr)
+		})
+
+		It("runtime should fail to start a removed container [Conformance]", func() {
+			By("create and remove a container")
+			containerID := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-for-start-removed-test-")
+			removeContainer(rc, containerID)
+
+			By("start the removed container")
+			err := rc.StartContainer(containerID)
+			Expect(err).To(HaveOccurred(), "starting a removed container should fail")
+		})
+
+		It("runtime should remove the containers of a removed PodSandbox [Conformance]", func() {
+			By("create and start a container")
+			containerID := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-for-remove-pod-test-")
+			startContainer(rc, containerID)
+
+			By("remove the PodSandbox without stopping it")
+			removePodSandbox(rc, podID)
+
+			By("check the container is removed")
+			containers := listContainers(rc, &runtimeapi.ContainerFilter{PodSandboxId: podID})
+			Expect(containers).To(BeEmpty(), "containers should be removed with their PodSandbox")
+			Expect(containerFound(listContainerForID(rc, containerID), containerID)).To(BeFalse(), "container should be removed with its PodSandbox")
+		})
+	})
+
+	Context("runtime should not garbage collect containers by itself", func() {
+		var podID string
+		var podConfig *runtimeapi.PodSandboxConfig
+
+		BeforeEach(func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+		})
+
+		It("runtime should keep an exited container with its status until it is removed [Conformance]", func() {
+			By("create and start a container which exits")
+			containerID := createCommandContainer(rc, ic, podID, podConfig, "container-for-exited-retention-test-", []string{"sh", "-c", "exit 0"})
+			startContainer(rc, containerID)
+			exited := helpers.WaitContainerExited(rc, containerID)
+
+			By("stop the PodSandbox of the exited container")
+			stopPodSandbox(rc, podID)
+
+			By("check the exited container is kept with its full status")
+			Consistently(func() []*runtimeapi.Container {
+				return listContainerForID(rc, containerID)
+			}, exitedContainerRetention, time.Second).Should(HaveLen(1), "exited container should be listed until it is removed")
+			containerStatus := helpers.GetContainerStatus(rc, containerID)
+			Expect(containerStatus.State).To(Equal(runtimeapi.ContainerState_CONTAINER_EXITED), "container should stay exited")
+			Expect(containerStatus.Metadata).To(Equal(exited.Metadata), "metadata should be kept")
+			Expect(containerStatus.Image).To(Equal(exited.Image), "image should be kept")
+			Expect(containerStatus.ImageRef).NotTo(BeEmpty(), "image ref should be kept")
+			Expect(containerStatus.CreatedAt).To(Equal(exited.CreatedAt), "CreatedAt should be kept")
+			Expect(containerStatus.StartedAt).To(Equal(exited.StartedAt), "StartedAt should be kept")
+			Expect(containerStatus.FinishedAt).To(Equal(exited.FinishedAt), "FinishedAt should be kept")
+			Expect(containerStatus.ExitCode).To(BeZero(), "exit code should be kept")
+			Expect(containerStatus.Reason).To(Equal(reasonCompleted), "termination reason should be kept")
+
+			By("remove the exited container")
+			removeContainer(rc, containerID)
+			Expect(listContainerForID(rc, containerID)).To(BeEmpty(), "removed container should not be listed")
+		})
+
+		It("runtime should handle the stats of an exited container", func() {
+			framework.SkipUnlessCapable(rc, framework.CapabilityStats)
+
+			By("create and start a container which exits")
+			containerID := createCommandContainer(rc, ic, podID, podConfig, "container-for-exited-stats-test-", []string{"sh", "-c", "exit 0"})
+			startContainer(rc, containerID)
+			helpers.WaitContainerExited(rc, containerID)
+
+			By("get the stats of the exited container")
+			_, err := rc.ContainerStats(containerID)
+			if err != nil {
+				s, ok := status.FromError(err)
+				Expect(ok).To(BeTrue(), "stats of an exited container should fail with a gRPC status, got %v", err)
+				Expect(s.Code() == codes.NotFound || s.Code() == codes.FailedPrecondition).To(BeTrue(), "stats of an exited container should be NotFound or FailedPrecondition, got %v", err)
+			}
+
+			By("list the container stats")
+			_, err = rc.ListContainerStats(&runtimeapi.ContainerStatsFilter{PodSandboxId: podID})
+			framework.ExpectNoError(err, "listing the stats should not fail because of an exited container: %v", err)
+
+			By("check the exited container is kept")
+			Expect(helpers.GetContainerStatus(rc, containerID).State).To(Equal(runtimeapi.ContainerState_CONTAINER_EXITED), "container should stay exited")
+		})
+
+		It("runtime should remove the records of all the containers of a removed PodSandbox [Conformance]", func() {
+			By("create an exited container and a created container")
+			exitedID := createCommandContainer(rc, ic, podID, podConfig, "container-for-remove-pod-exited-test-", []string{"sh", "-c", "exit 0"})
+			startContainer(rc, exitedID)
+			helpers.WaitContainerExited(rc, exitedID)
+			createdID := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-for-remove-pod-created-test-")
+
+			By("stop and remove the PodSandbox")
+			stopPodSandbox(rc, podID)
+			removePodSandbox(rc, podID)
+
+			By("check the containers are removed")
+			Expect(listContainers(rc, &runtimeapi.ContainerFilter{PodSandboxId: podID})).To(BeEmpty(), "containers should be removed with their PodSandbox")
+			for _, containerID := range []string{exitedID, createdID} {
+				Expect(listContainerForID(rc, containerID)).To(BeEmpty(), "container %q should be removed with its PodSandbox", containerID)
+				_, err := rc.ContainerStatus(containerID)
+				Expect(err).To(HaveOccurred(), "status of container %q should not be found once its PodSandbox is removed", containerID)
+			}
+		})
+	})
+
+	Context("runtime should support stop timeout", func() {
+		var podID string
+		var podConfig *runtimeapi.PodSandboxConfig
+
+		BeforeEach(func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+		})
+
+		It("runtime should kill a container ignoring SIGTERM once the timeout is over", func() {
+			By("create and start a container ignoring SIGTERM")
+			containerID := createIgnoreSigtermContainer(rc, ic, podID, podConfig, "container-for-stop-timeout-test-")
+
+			By("stop the container with a timeout")
+			elapsed := timeStopContainer(rc, containerID, stopGracePeriod)
+			grace := time.Duration(stopGracePeriod) * time.Second
+			Expect(elapsed).To(BeNumerically(">=", grace), "runtime should wait for the timeout before killing the container")
+			Expect(elapsed).To(BeNumerically("<", grace+stopKillSlack), "runtime should kill the container once the timeout is over")
+
+			By("check the container was killed")
+			containerStatus := helpers.WaitContainerExited(rc, containerID)
+			Expect(containerStatus.ExitCode).To(Equal(exitCodeKilled), "container should be killed by SIGKILL")
+		})
+
+		It("runtime should kill a container immediately with a zero timeout", func() {
+			By("create and start a container ignoring SIGTERM")
+			containerID := createIgnoreSigtermContainer(rc, ic, podID, podConfig, "container-for-stop-zero-timeout-test-")
+
+			By("stop the container without timeout")
+			elapsed := timeStopContainer(rc, containerID, 0)
+			Expect(elapsed).To(BeNumerically("<", stopKillSlack), "runtime should kill the container immediately")
+
+			By("check the container was killed")
+			containerStatus := helpers.WaitContainerExited(rc, containerID)
+			Expect(containerStatus.ExitCode).To(Equal(exitCodeKilled), "container should be killed by SIGKILL")
+		})
+	})
+
+	Context("runtime should report container termination", func() {
+		var podID string
+		var podConfig *runtimeapi.PodSandboxConfig
+
+		BeforeEach(func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+		})
+
+		for _, tc := range []struct {
+			desc     string
+			command  []string
+			exitCode int32
+			reason   string
+		}{
+			{"exit code 0", []string{"sh", "-c", "exit 0"}, 0, reasonCompleted},
+			{"exit code 1", []string{"sh", "-c", "exit 1"}, 1, reasonError},
+			// The shell is PID 1 and can't SIGKILL itself, so it runs a
+			// child which does and exits with its status. The exit after
+			// the child keeps the shell from exec'ing it as PID 1.
+			{"exit code 137 of a killed process", []string{"sh", "-c", `sh -c "kill -9 \$\$"; exit $?`}, exitCodeKilled, reasonError},
+		} {
+			tc := tc
+			It("runtime should report "+tc.desc+" [Conformance]", func() {
+				By("create and start a container which exits")
+				containerID := createCommandContainer(rc, ic, podID, podConfig, "container-for-exit-code-test-", tc.command)
+				startContainer(rc, containerID)
+
+				By("check the termination status")
+				containerStatus := helpers.WaitContainerExited(rc, containerID)
+				framework.Logf("Container %q exited with code %d, reason %q and message %q",
+					containerID, containerStatus.ExitCode, containerStatus.Reason, containerStatus.Message)
+				Expect(containerStatus.ExitCode).To(Equal(tc.exitCode), "unexpected exit code")
+				Expect(containerStatus.Reason).To(Equal(tc.reason), "unexpected termination reason")
+				Expect(containerStatus.StartedAt).NotTo(BeZero(), "StartedAt should be set")
+				Expect(containerStatus.FinishedAt).To(BeNumerically(">", containerStatus.StartedAt), "FinishedAt should be after StartedAt")
+			})
+		}
+	})
+})
+
+// createIgnoreSigtermContainer creates and starts a container which ignores
+// SIGTERM and never exits by itself.
+func createIgnoreSigtermContainer(rc internalapi.RuntimeService, ic internalapi.ImageManagerService, podID string, podConfig *runtimeapi.PodSandboxConfig, prefix string) string {
+	containerID := createCommandContainer(rc, ic, podID, podConfig, prefix, []string{"sh", "-c", "trap '' TERM; while true; do sleep 1; done"})
+	testStartContainer(rc, containerID)
+	return containerID
+}
+
+// timeStopContainer stops the container and returns how long it took.
+func timeStopContainer(c internalapi.RuntimeService, containerID string, timeout int64) time.Duration {
+	start := time.Now()
+	err := c.StopContainer(containerID, timeout)
+	elapsed := time.Since(start)
+	framework.ExpectNoError(err, "failed to stop container: %v", err)
+	framework.Logf("Stopped container %q with timeout %ds in %v", containerID, timeout, elapsed)
+	return elapsed
+}
+
+// createCommandContainer creates a container running command.
+func createCommandContainer(rc internalapi.RuntimeService, ic internalapi.ImageManagerService, podID string, podConfig *runtimeapi.PodSandboxConfig, prefix string, command []string) string {
+	containerName := prefix + framework.NewUUID()
+	containerConfig := &runtimeapi.ContainerConfig{
+		Metadata: framework.BuildContainerMetadata(containerName, framework.DefaultAttempt),
+		Image:    &runtimeapi.ImageSpec{Image: framework.DefaultContainerImage},
+		Command:  command,
+		Linux:    &runtimeapi.LinuxContainerConfig{},
+	}
+	return framework.CreateContainer(rc, ic, containerConfig, podID, podConfig)
+}
+
+// isNilOrNotFound returns whether err is nil or a gRPC NotFound error.
+func isNilOrNotFound(err error) bool {
+	if err == nil {
+		return true
+	}
+	s, ok := status.FromError(err)
+	return ok && s.Code() == codes.NotFound
+}
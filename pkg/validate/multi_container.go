@@ -0,0 +1,222 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework/helpers"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// sharedMemoryFile is written to the shared memory of a PodSandbox by one
+// container and read by another one.
+const sharedMemoryFile = "/dev/shm/critest-shared"
+
+var _ = framework.KubeDescribe("PodSandbox", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	var rc internalapi.RuntimeService
+	var ic internalapi.ImageManagerService
+
+	BeforeEach(func() {
+		rc = f.CRIClient.CRIRuntimeClient
+		ic = f.CRIClient.CRIImageClient
+	})
+
+	Context("runtime should support multiple containers in a PodSandbox", func() {
+		var podID string
+		var podConfig *runtimeapi.PodSandboxConfig
+
+		BeforeEach(func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+		})
+
+		It("runtime should start and stop containers of a PodSandbox independently [Conformance]", func() {
+			By("create and start two containers")
+			containerA := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-a-for-multi-container-test-")
+			startContainer(rc, containerA)
+			containerB := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-b-for-multi-container-test-")
+			startContainer(rc, containerB)
+
+			By("stop the first container")
+			testStopContainer(rc, containerA)
+			Expect(helpers.GetContainerStatus(rc, containerB).State).To(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING),
+				"the second container should still be running")
+
+			By("remove the first container")
+			removeContainer(rc, containerA)
+			Expect(helpers.GetContainerStatus(rc, containerB).State).To(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING),
+				"the second container should still be running")
+			expectContainerIDs(listContainers(rc, &runtimeapi.ContainerFilter{PodSandboxId: podID}), containerB)
+
+			By("check the PodSandbox is still ready")
+			Expect(getPodSandboxStatus(rc, podID).State).To(Equal(runtimeapi.PodSandboxState_SANDBOX_READY),
+				"the PodSandbox should still be ready")
+		})
+
+		It("runtime should share the network namespace between containers of a PodSandbox [Conformance]", func() {
+			By("create and start a TCP listener container")
+			serverID := createTCPListenerContainer(rc, ic, podID, podConfig, "container-for-localhost-server-")
+			startContainer(rc, serverID)
+
+			By("create and start a client container")
+			clientID := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-for-localhost-client-")
+			startContainer(rc, clientID)
+
+			checkTCPListenerFromContainer(rc, clientID, net.JoinHostPort("127.0.0.1", strconv.Itoa(int(tcpListenerPort))))
+		})
+
+		It("runtime should share the IPC namespace between containers of a PodSandbox [Conformance]", func() {
+			By("create and start two containers")
+			writerID := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-for-shm-writer-")
+			startContainer(rc, writerID)
+			readerID := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-for-shm-reader-")
+			startContainer(rc, readerID)
+
+			By("write to the shared memory in the first container")
+			content := framework.NewUUID()
+			execSyncContainer(rc, writerID, []string{"sh", "-c", "echo -n " + content + " > " + sharedMemoryFile})
+
+			By("read the shared memory in the second container")
+			Expect(readContainerFile(rc, readerID, sharedMemoryFile)).To(Equal(content),
+				"the shared memory should be visible to all containers of the PodSandbox")
+		})
+
+		It("runtime should remove all containers when removing a PodSandbox [Conformance]", func() {
+			By("create containers in various states")
+			created := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-created-for-pod-removal-")
+			running := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-running-for-pod-removal-")
+			startContainer(rc, running)
+			exited := createCommandContainer(rc, ic, podID, podConfig, "container-exited-for-pod-removal-", []string{"sh", "-c", "exit 0"})
+			startContainer(rc, exited)
+			helpers.WaitContainerExited(rc, exited)
+			expectContainerIDs(listContainers(rc, &runtimeapi.ContainerFilter{PodSandboxId: podID}), created, running, exited)
+
+			By("stop and remove the PodSandbox")
+			stopPodSandbox(rc, podID)
+			removePodSandbox(rc, podID)
+
+			By("check all containers are removed")
+			expectContainerIDs(listContainers(rc, &runtimeapi.ContainerFilter{PodSandboxId: podID}))
+			for _, containerID := range []string{created, running, exited} {
+				expectContainerNotFound(rc, containerID)
+			}
+		})
+	})
+
+	Context("runtime should handle stopped PodSandboxes", func() {
+		var podID string
+		var podConfig *runtimeapi.PodSandboxConfig
+
+		BeforeEach(func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+		})
+
+		It("runtime should fail to create a container in a stopped PodSandbox [Conformance]", func() {
+			By("stop the PodSandbox")
+			stopPodSandbox(rc, podID)
+
+			By("create a container in the stopped PodSandbox")
+			expectCreateContainerFailure(rc, ic, podID, podConfig, "container-for-stopped-pod-test-")
+		})
+
+		It("runtime should fail to create a container in a removed PodSandbox [Conformance]", func() {
+			By("stop and remove the PodSandbox")
+			stopPodSandbox(rc, podID)
+			removePodSandbox(rc, podID)
+
+			By("create a container in the removed PodSandbox")
+			expectCreateContainerFailure(rc, ic, podID, podConfig, "container-for-removed-pod-test-")
+		})
+
+		It("runtime should stop all running containers when stopping a PodSandbox [Conformance]", func() {
+			By("create and start two containers")
+			containerA := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-a-for-pod-stop-test-")
+			startContainer(rc, containerA)
+			containerB := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-b-for-pod-stop-test-")
+			startContainer(rc, containerB)
+
+			By("stop the PodSandbox")
+			stopPodSandbox(rc, podID)
+
+			By("check the containers exited")
+			for _, containerID := range []string{containerA, containerB} {
+				Expect(helpers.GetContainerStatus(rc, containerID).State).To(Equal(runtimeapi.ContainerState_CONTAINER_EXITED),
+					"container %q should exit with its PodSandbox", containerID)
+			}
+			Expect(getPodSandboxStatus(rc, podID).State).To(Equal(runtimeapi.PodSandboxState_SANDBOX_NOTREADY))
+		})
+
+		It("runtime should support stopping a PodSandbox twice [Conformance]", func() {
+			By("create and start a container")
+			containerID := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-for-pod-stop-twice-test-")
+			startContainer(rc, containerID)
+
+			By("stop the PodSandbox twice")
+			stopPodSandbox(rc, podID)
+			stopPodSandbox(rc, podID)
+
+			By("check the PodSandbox and its container are still stopped")
+			Expect(getPodSandboxStatus(rc, podID).State).To(Equal(runtimeapi.PodSandboxState_SANDBOX_NOTREADY))
+			Expect(helpers.GetContainerStatus(rc, containerID).State).To(Equal(runtimeapi.ContainerState_CONTAINER_EXITED))
+		})
+	})
+})
+
+// expectCreateContainerFailure checks that a container can't be created in
+// the PodSandbox podID, and that no container is left behind.
+func expectCreateContainerFailure(rc internalapi.RuntimeService, ic internalapi.ImageManagerService, podID string, podConfig *runtimeapi.PodSandboxConfig, prefix string) {
+	containerConfig := buildProcessContainerConfig(prefix, []string{"top"}, nil)
+	containerID, err := framework.CreateContainerWithError(rc, ic, containerConfig, podID, podConfig)
+	Expect(err).To(HaveOccurred(), "creating a container in PodSandbox %q should fail", podID)
+	framework.Logf("Create container failed as expected: %v", err)
+	Expect(err.Error()).NotTo(BeEmpty(), "error should explain the failure")
+	Expect(containerID).To(BeEmpty(), "no container should be created")
+	expectContainerIDs(listContainers(rc, &runtimeapi.ContainerFilter{PodSandboxId: podID}))
+}
+
+// expectContainerNotFound checks that the status of containerID can't be
+// found anymore.
+func expectContainerNotFound(c internalapi.RuntimeService, containerID string) {
+	_, err := c.ContainerStatus(containerID)
+	Expect(err).To(HaveOccurred(), "container %q should be removed", containerID)
+	s, ok := status.FromError(err)
+	Expect(ok && s.Code() == codes.NotFound).To(BeTrue(), "expected NotFound for container %q, got %v", containerID, err)
+}
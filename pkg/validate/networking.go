@@ -17,8 +17,13 @@ limitations under the License.
 package validate
 
 import (
+	"bufio"
+	"fmt"
+	"net"
 	"net/http"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
@@ -43,6 +48,13 @@ const (
 	nginxHostPortForHostNetPortFroward int32 = 12002
 	// The port used in hostNetNginxImage (See images/hostnet-nginx/)
 	nginxHostNetContainerPort int32 = 12003
+	// tcpListenerPort is the port served by the TCP listener containers.
+	tcpListenerPort int32 = 8080
+	// tcpListenerMessage is sent by the TCP listener containers to clients.
+	tcpListenerMessage string = "hello"
+	// externalURL is the address outside of the node reached by the
+	// external connectivity test.
+	externalURL string = "http://google.com"
 )
 
 var _ = framework.KubeDescribe("Networking", func() {
@@ -126,6 +138,139 @@ var _ = framework.KubeDescribe("Networking", func() {
 			By("check the port mapping with host port and container port")
 			checkNginxMainPage(rc, "", nginxHostPortForPortMapping)
 		})
+
+		It("runtime should assign a reachable IP to PodSandbox [Conformance]", func() {
+			By("run a PodSandbox")
+			var podConfig *runtimeapi.PodSandboxConfig
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+
+			By("check the PodSandbox IP")
+			podIP := getPodSandboxStatus(rc, podID).GetNetwork().GetIp()
+			ip := net.ParseIP(podIP)
+			Expect(ip).NotTo(BeNil(), "PodSandbox IP %q should be a valid IP", podIP)
+			Expect(ip.IsLoopback() || ip.IsUnspecified()).To(BeFalse(), "PodSandbox IP %q should be routable", podIP)
+
+			By("create a container listening on a TCP port")
+			containerID := createTCPListenerContainer(rc, ic, podID, podConfig, "container-for-pod-ip-test-")
+
+			By("start container")
+			startContainer(rc, containerID)
+
+			By("check the PodSandbox IP is reachable from the host")
+			checkTCPListener(net.JoinHostPort(podIP, strconv.Itoa(int(tcpListenerPort))))
+		})
+
+		It("runtime should support setting the hostname of PodSandbox [Conformance]", func() {
+			By("create a PodSandbox with hostname")
+			hostname := "critest-" + framework.NewUUID()[:8]
+			var podConfig *runtimeapi.PodSandboxConfig
+			podID, podConfig = createPodSandboxWithHostname(rc, hostname, false)
+
+			By("create container")
+			containerID := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-for-hostname-test-")
+
+			By("start container")
+			startContainer(rc, containerID)
+
+			By("check the hostname")
+			verifyExecSyncOutput(rc, containerID, []string{"hostname"}, hostname+"\n")
+		})
+
+		It("runtime should use the node hostname in host network PodSandbox [Conformance]", func() {
+			nodeHostname, err := os.Hostname()
+			framework.ExpectNoError(err, "failed to get the node hostname: %v", err)
+
+			By("create a host network PodSandbox")
+			var podConfig *runtimeapi.PodSandboxConfig
+			podID, podConfig = createPodSandboxWithHostname(rc, "", true)
+
+			By("create container")
+			containerID := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-for-host-network-hostname-test-")
+
+			By("start container")
+			startContainer(rc, containerID)
+
+			By("check the hostname")
+			verifyExecSyncOutput(rc, containerID, []string{"hostname"}, nodeHostname+"\n")
+		})
+
+		It("runtime should map the PodSandbox IP to its hostname in /etc/hosts", func() {
+			By("create a PodSandbox with hostname")
+			hostname := "critest-" + framework.NewUUID()[:8]
+			var podConfig *runtimeapi.PodSandboxConfig
+			podID, podConfig = createPodSandboxWithHostname(rc, hostname, false)
+			podIP := getPodSandboxStatus(rc, podID).GetNetwork().GetIp()
+			Expect(podIP).NotTo(BeEmpty(), "PodSandbox IP should be reported")
+
+			By("create container")
+			containerID := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-for-hosts-test-")
+
+			By("start container")
+			startContainer(rc, containerID)
+
+			By("check /etc/hosts")
+			checkHostsEntry(rc, containerID, podIP, hostname)
+		})
+	})
+
+	Context("runtime should support network connectivity", func() {
+		var podIDs []string
+
+		AfterEach(func() {
+			for _, podID := range podIDs {
+				By("stop PodSandbox")
+				rc.StopPodSandbox(podID)
+				By("delete PodSandbox")
+				rc.RemovePodSandbox(podID)
+			}
+			podIDs = nil
+		})
+
+		It("runtime should support connectivity between PodSandboxes [Conformance]", func() {
+			By("run a PodSandbox with a TCP listener")
+			serverAddress := runTCPListenerPodSandbox(rc, ic, &podIDs)
+
+			By("run a client PodSandbox")
+			clientPodID, clientPodConfig := framework.CreatePodSandboxForContainer(rc)
+			podIDs = append(podIDs, clientPodID)
+			clientID := framework.CreateDefaultContainer(rc, ic, clientPodID, clientPodConfig, "container-for-pod-connectivity-client-")
+			startContainer(rc, clientID)
+
+			By("check the listener is reachable from the client PodSandbox")
+			checkTCPListenerFromContainer(rc, clientID, serverAddress)
+		})
+
+		It("runtime should support connectivity from host network PodSandbox to PodSandbox [Conformance]", func() {
+			By("run a PodSandbox with a TCP listener")
+			serverAddress := runTCPListenerPodSandbox(rc, ic, &podIDs)
+
+			By("run a host network client PodSandbox")
+			clientPodID, clientPodConfig := createPodSandboxWithHostname(rc, "", true)
+			podIDs = append(podIDs, clientPodID)
+			clientID := framework.CreateDefaultContainer(rc, ic, clientPodID, clientPodConfig, "container-for-host-network-connectivity-client-")
+			startContainer(rc, clientID)
+
+			By("check the listener is reachable from the host network PodSandbox")
+			checkTCPListenerFromContainer(rc, clientID, serverAddress)
+		})
+
+		It("runtime should support connectivity to external addresses", func() {
+			if !framework.TestContext.ExternalConnectivity {
+				Skip("external connectivity tests are disabled, use --external-connectivity to enable them")
+			}
+
+			By("run a PodSandbox")
+			podID, podConfig := framework.CreatePodSandboxForContainer(rc)
+			podIDs = append(podIDs, podID)
+			containerID := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-for-external-connectivity-")
+			startContainer(rc, containerID)
+
+			By("check an external address is reachable")
+			Eventually(func() error {
+				_, _, err := rc.ExecSync(containerID, []string{"wget", "-q", "-O", "/dev/null", externalURL}, framework.TestContext.ExecTimeout)
+				return err
+			}, framework.TestContext.StateTimeout, time.Second).Should(Succeed(), "%s should be reachable from the container", externalURL)
+		})
 	})
 })
 
@@ -170,11 +315,111 @@ func createPodSandboxWithPortMapping(c internalapi.RuntimeService, portMappings
 	return podID, config
 }
 
+// createPodSandboxWithHostname creates a PodSandbox with hostname, in the
+// host network if hostNet is true.
+func createPodSandboxWithHostname(c internalapi.RuntimeService, hostname string, hostNet bool) (string, *runtimeapi.PodSandboxConfig) {
+	podSandboxName := "create-PodSandbox-with-hostname" + framework.NewUUID()
+	uid := framework.DefaultUIDPrefix + framework.NewUUID()
+	namespace := framework.DefaultNamespacePrefix + framework.NewUUID()
+	config := &runtimeapi.PodSandboxConfig{
+		Metadata: framework.BuildPodSandboxMetadata(podSandboxName, uid, namespace, framework.DefaultAttempt),
+		Hostname: hostname,
+		Linux:    &runtimeapi.LinuxPodSandboxConfig{},
+	}
+	if hostNet {
+		config.Linux.SecurityContext = &runtimeapi.LinuxSandboxSecurityContext{
+			NamespaceOptions: &runtimeapi.NamespaceOption{
+				Network: runtimeapi.NamespaceMode_NODE,
+			},
+		}
+	}
+
+	podID := framework.RunPodSandbox(c, config)
+	return podID, config
+}
+
+// checkHostsEntry checks whether /etc/hosts maps ip to hostname.
+func checkHostsEntry(c internalapi.RuntimeService, containerID, ip, hostname string) {
+	By("get the content of /etc/hosts via execSync")
+	stdout := execSyncContainer(c, containerID, []string{"cat", "/etc/hosts"})
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != ip {
+			continue
+		}
+		for _, name := range fields[1:] {
+			if name == hostname {
+				framework.Logf("check /etc/hosts succeed")
+				return
+			}
+		}
+	}
+	framework.Failf("/etc/hosts should map %s to %s, got %q", ip, hostname, stdout)
+}
+
+// createTCPListenerContainer creates a container sending tcpListenerMessage
+// to the clients of tcpListenerPort.
+func createTCPListenerContainer(rc internalapi.RuntimeService, ic internalapi.ImageManagerService, podID string, podConfig *runtimeapi.PodSandboxConfig, prefix string) string {
+	containerName := prefix + framework.NewUUID()
+	containerConfig := &runtimeapi.ContainerConfig{
+		Metadata: framework.BuildContainerMetadata(containerName, framework.DefaultAttempt),
+		Image:    &runtimeapi.ImageSpec{Image: framework.DefaultContainerImage},
+		Command: []string{"sh", "-c", fmt.Sprintf("while true; do echo %s | nc -l -p %d; done",
+			tcpListenerMessage, tcpListenerPort)},
+		Linux: &runtimeapi.LinuxContainerConfig{},
+	}
+	return framework.CreateContainer(rc, ic, containerConfig, podID, podConfig)
+}
+
+// runTCPListenerPodSandbox runs a PodSandbox with a TCP listener container,
+// appends it to podIDs and returns the address of the listener.
+func runTCPListenerPodSandbox(rc internalapi.RuntimeService, ic internalapi.ImageManagerService, podIDs *[]string) string {
+	podID, podConfig := framework.CreatePodSandboxForContainer(rc)
+	*podIDs = append(*podIDs, podID)
+	containerID := createTCPListenerContainer(rc, ic, podID, podConfig, "container-for-pod-connectivity-server-")
+	startContainer(rc, containerID)
+
+	podIP := getPodSandboxStatus(rc, podID).GetNetwork().GetIp()
+	Expect(podIP).NotTo(BeEmpty(), "PodSandbox IP should be reported")
+	return net.JoinHostPort(podIP, strconv.Itoa(int(tcpListenerPort)))
+}
+
+// checkTCPListenerFromContainer checks whether tcpListenerMessage is received
+// from address in the container.
+func checkTCPListenerFromContainer(c internalapi.RuntimeService, containerID, address string) {
+	By("connect to " + address + " from container " + containerID)
+	host, port, err := net.SplitHostPort(address)
+	framework.ExpectNoError(err, "failed to parse address %q: %v", address, err)
+	Eventually(func() string {
+		// The exit status of nc is ignored, it fails when the listener
+		// doesn't close the connection before the timeout.
+		stdout, _, _ := c.ExecSync(containerID, []string{"nc", "-w", "1", host, port}, framework.TestContext.ExecTimeout)
+		return strings.TrimSpace(string(stdout))
+	}, framework.TestContext.StateTimeout, time.Second).Should(Equal(tcpListenerMessage))
+	framework.Logf("check TCP connection to %s from container %q succeed", address, containerID)
+}
+
+// checkTCPListener checks whether tcpListenerMessage is received from address.
+func checkTCPListener(address string) {
+	By("connect to " + address)
+	Eventually(func() (string, error) {
+		conn, err := net.DialTimeout("tcp", address, time.Second)
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		return strings.TrimSpace(line), err
+	}, framework.TestContext.StateTimeout, time.Second).Should(Equal(tcpListenerMessage))
+	framework.Logf("check TCP connection to %s succeed", address)
+}
+
 // checkDNSConfig checks the content of /etc/resolv.conf.
 func checkDNSConfig(c internalapi.RuntimeService, containerID string, expectedContent []string) {
 	By("get the content of /etc/resolv.conf via execSync")
 	cmd := []string{"cat", resolvConfigPath}
-	stdout, stderr, err := c.ExecSync(containerID, cmd, time.Duration(defaultExecSyncTimeout)*time.Second)
+	stdout, stderr, err := c.ExecSync(containerID, cmd, framework.TestContext.ExecTimeout)
 	framework.ExpectNoError(err, "failed to execSync in container %q", containerID)
 	for _, content := range expectedContent {
 		Expect(string(stdout)).To(ContainSubstring(content), "The stdout output of execSync should contain %q", content)
@@ -227,7 +472,7 @@ func checkNginxMainPage(c internalapi.RuntimeService, podID string, hostPort int
 	Eventually(func() error {
 		resp, err = http.Get(url)
 		return err
-	}, time.Minute, time.Second).Should(BeNil())
+	}, framework.TestContext.StateTimeout, time.Second).Should(BeNil())
 
 	Expect(resp.StatusCode).To(Equal(200), "The status code of response should be 200.")
 	framework.Logf("check port mapping succeed")
@@ -17,11 +17,11 @@ limitations under the License.
 package validate
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
 	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
@@ -81,6 +81,117 @@ var _ = framework.KubeDescribe("PodSandbox", func() {
 		})
 	})
 
+	Context("runtime should support filtering PodSandbox", func() {
+		var podIDs []string
+
+		AfterEach(func() {
+			for _, podID := range podIDs {
+				By("stop PodSandbox")
+				rc.StopPodSandbox(podID)
+				By("delete PodSandbox")
+				rc.RemovePodSandbox(podID)
+			}
+			podIDs = nil
+		})
+
+		It("runtime should support listing PodSandbox with filters [Conformance]", func() {
+			// The filter label is unique to this spec, so that PodSandboxes
+			// created concurrently by other specs are never listed.
+			filterValue := framework.NewUUID()
+			labels := func(group string) map[string]string {
+				return map[string]string{filterLabelKey: filterValue, "group": group}
+			}
+
+			By("run PodSandboxes with labels")
+			podA, _ := runPodSandboxWithLabels(rc, "PodSandbox-for-filter-a-", labels("a"))
+			podIDs = append(podIDs, podA)
+			podB, _ := runPodSandboxWithLabels(rc, "PodSandbox-for-filter-b-", labels("b"))
+			podIDs = append(podIDs, podB)
+			podC, _ := runPodSandboxWithLabels(rc, "PodSandbox-for-filter-c-", labels("a"))
+			podIDs = append(podIDs, podC)
+
+			By("stop a PodSandbox")
+			stopPodSandbox(rc, podC)
+
+			By("test filtering PodSandbox by label")
+			expectPodSandboxIDs(listPodSandbox(rc, &runtimeapi.PodSandboxFilter{
+				LabelSelector: labels("a"),
+			}), podA, podC)
+			expectPodSandboxIDs(listPodSandbox(rc, &runtimeapi.PodSandboxFilter{
+				LabelSelector: labels("b"),
+			}), podB)
+
+			By("test filtering PodSandbox by state and label")
+			expectPodSandboxIDs(listPodSandbox(rc, &runtimeapi.PodSandboxFilter{
+				State:         &runtimeapi.PodSandboxStateValue{State: runtimeapi.PodSandboxState_SANDBOX_READY},
+				LabelSelector: map[string]string{filterLabelKey: filterValue},
+			}), podA, podB)
+			expectPodSandboxIDs(listPodSandbox(rc, &runtimeapi.PodSandboxFilter{
+				State:         &runtimeapi.PodSandboxStateValue{State: runtimeapi.PodSandboxState_SANDBOX_NOTREADY},
+				LabelSelector: map[string]string{filterLabelKey: filterValue},
+			}), podC)
+
+			By("test filtering PodSandbox by ID and label")
+			expectPodSandboxIDs(listPodSandbox(rc, &runtimeapi.PodSandboxFilter{
+				Id:            podA,
+				LabelSelector: labels("a"),
+			}), podA)
+			expectPodSandboxIDs(listPodSandbox(rc, &runtimeapi.PodSandboxFilter{
+				Id:            podA,
+				LabelSelector: labels("b"),
+			}))
+		})
+	})
+
+	Context("runtime should support labels and annotations", func() {
+		var podID string
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+		})
+
+		It("runtime should preserve labels and annotations of PodSandbox [Conformance]", func() {
+			filterValue := framework.NewUUID()
+			labels, annotations := buildLabelsAndAnnotations(filterValue)
+
+			By("run PodSandbox with many labels and annotations")
+			podSandboxName := "PodSandbox-with-labels-and-annotations-" + framework.NewUUID()
+			uid := framework.DefaultUIDPrefix + framework.NewUUID()
+			namespace := framework.DefaultNamespacePrefix + framework.NewUUID()
+			podConfig := &runtimeapi.PodSandboxConfig{
+				Metadata:    framework.BuildPodSandboxMetadata(podSandboxName, uid, namespace, framework.DefaultAttempt),
+				Labels:      labels,
+				Annotations: annotations,
+				Linux:       &runtimeapi.LinuxPodSandboxConfig{},
+			}
+			podID = framework.RunPodSandbox(rc, podConfig)
+
+			By("check labels and annotations in PodSandboxStatus")
+			status := getPodSandboxStatus(rc, podID)
+			Expect(status.Labels).To(Equal(labels), "labels should be unchanged")
+			Expect(status.Annotations).To(Equal(annotations), "annotations should be unchanged")
+
+			By("check labels and annotations in ListPodSandbox")
+			pods := listPodSanboxForID(rc, podID)
+			Expect(pods).To(HaveLen(1), "exactly one PodSandbox should be listed")
+			Expect(pods[0].Labels).To(Equal(labels), "labels should be unchanged")
+			Expect(pods[0].Annotations).To(Equal(annotations), "annotations should be unchanged")
+
+			By("check that labels are used for filtering")
+			expectPodSandboxIDs(listPodSandbox(rc, &runtimeapi.PodSandboxFilter{
+				LabelSelector: labels,
+			}), podID)
+
+			By("check that annotations are not used for filtering")
+			expectPodSandboxIDs(listPodSandbox(rc, &runtimeapi.PodSandboxFilter{
+				LabelSelector: map[string]string{annotationFilterKey: filterValue},
+			}))
+		})
+	})
+
 	Context("runtime should support sysctls", func() {
 		var podID string
 		var podConfig *runtimeapi.PodSandboxConfig
@@ -122,6 +233,54 @@ var _ = framework.KubeDescribe("PodSandbox", func() {
 			checkSetSysctls(rc, containerID, "/proc/sys/fs/mqueue/msg_max", "100")
 		})
 	})
+
+	Context("runtime should support cgroup parent", func() {
+		var podID string
+		var podConfig *runtimeapi.PodSandboxConfig
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+		})
+
+		// Only one of the forms is supported by a runtime, depending on
+		// its cgroup driver.
+		for _, tc := range []struct {
+			driver string
+			parent func(name string) (cgroupParent, expected string)
+		}{
+			{"cgroupfs", func(name string) (string, string) {
+				return "/" + name, "/" + name + "/"
+			}},
+			{"systemd", func(name string) (string, string) {
+				return name + ".slice", "/" + name + ".slice/"
+			}},
+		} {
+			tc := tc
+			It("should support "+tc.driver+" cgroup parent", func() {
+				// Dashes denote nested slices with systemd.
+				name := "critest" + strings.Replace(framework.NewUUID(), "-", "", -1)
+				cgroupParent, expected := tc.parent(name)
+
+				var err error
+				podID, podConfig, err = createSandboxWithCgroupParent(rc, cgroupParent)
+				if err != nil {
+					Skip(fmt.Sprintf("runtime doesn't support %s cgroup parent %q: %v", tc.driver, cgroupParent, err))
+				}
+
+				By("create a default container")
+				containerID := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-for-cgroup-parent-test-")
+
+				By("start container")
+				startContainer(rc, containerID)
+
+				By("check the container cgroups are under the cgroup parent")
+				checkCgroupParent(rc, containerID, expected)
+			})
+		}
+	})
 })
 
 // podSandboxFound returns whether PodSandbox is found.
@@ -202,6 +361,47 @@ func listPodSandbox(c internalapi.RuntimeService, filter *runtimeapi.PodSandboxF
 	return pods
 }
 
+// runPodSandboxWithLabels runs a PodSandbox with labels.
+func runPodSandboxWithLabels(c internalapi.RuntimeService, prefix string, labels map[string]string) (string, *runtimeapi.PodSandboxConfig) {
+	podSandboxName := prefix + framework.NewUUID()
+	uid := framework.DefaultUIDPrefix + framework.NewUUID()
+	namespace := framework.DefaultNamespacePrefix + framework.NewUUID()
+
+	podConfig := &runtimeapi.PodSandboxConfig{
+		Metadata: framework.BuildPodSandboxMetadata(podSandboxName, uid, namespace, framework.DefaultAttempt),
+		Labels:   labels,
+		Linux:    &runtimeapi.LinuxPodSandboxConfig{},
+	}
+	return framework.RunPodSandbox(c, podConfig), podConfig
+}
+
+// expectPodSandboxIDs checks that pods are exactly the PodSandboxes of podIDs.
+func expectPodSandboxIDs(pods []*runtimeapi.PodSandbox, podIDs ...string) {
+	ids := []string{}
+	for _, pod := range pods {
+		ids = append(ids, pod.Id)
+	}
+	Expect(ids).To(ConsistOf(podIDs), "unexpected PodSandboxes listed")
+}
+
+// buildLabelsAndAnnotations returns manyMetadataCount labels and annotations.
+// The labels include filterLabelKey set to filterValue, and the annotations
+// include annotationFilterKey set to filterValue, which must never match a
+// label selector. Some annotation values are largeAnnotationSize bytes long.
+func buildLabelsAndAnnotations(filterValue string) (map[string]string, map[string]string) {
+	labels := map[string]string{filterLabelKey: filterValue}
+	annotations := map[string]string{annotationFilterKey: filterValue}
+	for i := 0; i < manyMetadataCount; i++ {
+		labels[fmt.Sprintf("critest.label-%d", i)] = fmt.Sprintf("value-%d", i)
+		value := fmt.Sprintf("value-%d", i)
+		if i%10 == 0 {
+			value = strings.Repeat(value, largeAnnotationSize/len(value))
+		}
+		annotations[fmt.Sprintf("critest.annotation-%d", i)] = value
+	}
+	return labels, annotations
+}
+
 // createLogTempDir creates the log temp directory for podSandbox.
 func createLogTempDir(podSandboxName string) (string, string) {
 	hostPath, err := ioutil.TempDir("", "/podLogTest")
@@ -229,6 +429,23 @@ func createPodSandboxWithLogDirectory(c internalapi.RuntimeService) (string, *ru
 	return framework.RunPodSandbox(c, podConfig), podConfig, hostPath
 }
 
+// createPodSandboxWithSymlinkLogDirectory creates a PodSandbox whose log
+// directory is a symlink to podLogPath.
+func createPodSandboxWithSymlinkLogDirectory(c internalapi.RuntimeService) (string, *runtimeapi.PodSandboxConfig, string, string) {
+	By("create a PodSandbox with symlinked log directory")
+	podSandboxName := "PodSandbox-with-symlink-log-directory-" + framework.NewUUID()
+	uid := framework.DefaultUIDPrefix + framework.NewUUID()
+	namespace := framework.DefaultNamespacePrefix + framework.NewUUID()
+
+	hostPath, podLogPath := createLogTempDir(podSandboxName)
+
+	podConfig := &runtimeapi.PodSandboxConfig{
+		Metadata:     framework.BuildPodSandboxMetadata(podSandboxName, uid, namespace, framework.DefaultAttempt),
+		LogDirectory: createSymlink(podLogPath),
+	}
+	return framework.RunPodSandbox(c, podConfig), podConfig, hostPath, podLogPath
+}
+
 // createSandboxWithSysctls creates a PodSandbox with specified sysctls.
 func createSandboxWithSysctls(rc internalapi.RuntimeService, sysctls map[string]string) (string, *runtimeapi.PodSandboxConfig) {
 	By("create a PodSandbox with sysctls")
@@ -248,7 +465,52 @@ func createSandboxWithSysctls(rc internalapi.RuntimeService, sysctls map[string]
 // checkSetSysctls checks whether sysctl settings is equal to expected string.
 func checkSetSysctls(rc internalapi.RuntimeService, containerID, sysctlPath, expected string) {
 	cmd := []string{"cat", sysctlPath}
-	stdout, _, err := rc.ExecSync(containerID, cmd, time.Duration(defaultExecSyncTimeout)*time.Second)
+	stdout, _, err := rc.ExecSync(containerID, cmd, framework.TestContext.ExecTimeout)
 	Expect(err).NotTo(HaveOccurred())
 	Expect(strings.TrimSpace(string(stdout))).To(Equal(expected))
 }
+
+// createSandboxWithCgroupParent runs a PodSandbox with the given cgroup parent.
+func createSandboxWithCgroupParent(rc internalapi.RuntimeService, cgroupParent string) (string, *runtimeapi.PodSandboxConfig, error) {
+	By("create a PodSandbox with cgroup parent " + cgroupParent)
+	podSandboxName := "pod-sandbox-with-cgroup-parent-" + framework.NewUUID()
+	uid := framework.DefaultUIDPrefix + framework.NewUUID()
+	namespace := framework.DefaultNamespacePrefix + framework.NewUUID()
+
+	podConfig := &runtimeapi.PodSandboxConfig{
+		Metadata: framework.BuildPodSandboxMetadata(podSandboxName, uid, namespace, framework.DefaultAttempt),
+		Linux: &runtimeapi.LinuxPodSandboxConfig{
+			CgroupParent: cgroupParent,
+		},
+	}
+	podID, err := rc.RunPodSandbox(podConfig)
+	return podID, podConfig, err
+}
+
+// checkCgroupParent checks whether the cgroups of the container, read from
+// /proc/self/cgroup, are under expected.
+func checkCgroupParent(rc internalapi.RuntimeService, containerID, expected string) {
+	stdout := execSyncContainer(rc, containerID, []string{"cat", "/proc/self/cgroup"})
+	var paths []string
+	namespaced := true
+	for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+		// Each line is hierarchy-ID:controller-list:cgroup-path.
+		fields := strings.SplitN(line, ":", 3)
+		Expect(fields).To(HaveLen(3), "unexpected line %q in /proc/self/cgroup", line)
+		paths = append(paths, fields[2])
+		if fields[2] != "/" {
+			namespaced = false
+		}
+	}
+	if namespaced {
+		Skip("container cgroup paths are hidden by a cgroup namespace")
+	}
+	found := false
+	for _, path := range paths {
+		if strings.Contains(path+"/", expected) {
+			found = true
+			break
+		}
+	}
+	Expect(found).To(BeTrue(), "container cgroups %v should be under %q", paths, expected)
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework/helpers"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	// reopenLogLines and reopenLogLineSize are the number and the size of
+	// the lines logged while the container log is reopened.
+	reopenLogLines    = 400000
+	reopenLogLineSize = 128
+	// reopenLogTimes is the maximum number of times the log is rotated
+	// while the container is logging.
+	reopenLogTimes = 10
+	// reopenLogInterval is the interval between two rotations of the log.
+	reopenLogInterval = 50 * time.Millisecond
+)
+
+var _ = framework.KubeDescribe("Container", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	var rc internalapi.RuntimeService
+	var ic internalapi.ImageManagerService
+
+	BeforeEach(func() {
+		rc = f.CRIClient.CRIRuntimeClient
+		ic = f.CRIClient.CRIImageClient
+	})
+
+	Context("runtime should support reopening container log", func() {
+		var podID, hostPath string
+		var podConfig *runtimeapi.PodSandboxConfig
+
+		BeforeEach(func() {
+			podID, podConfig, hostPath = createPodSandboxWithLogDirectory(rc)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+			By("clean up the TempDir")
+			os.RemoveAll(hostPath)
+		})
+
+		It("runtime should not create a new log when reopening the log of a stopped container fails [Conformance]", func() {
+			By("create and start a container with log")
+			logPath, containerID := helpers.CreateLogContainer(rc, ic, "container-reopen-stopped-log-test-", podID, podConfig)
+			startContainer(rc, containerID)
+			helpers.WaitContainerExited(rc, containerID)
+
+			By("rotate the container log")
+			path := filepath.Join(podConfig.LogDirectory, logPath)
+			Expect(os.Rename(path, path+".old")).To(Succeed())
+
+			By("reopen the log of the stopped container")
+			// The runtime may either create a new log file and succeed, or
+			// fail without creating it.
+			if err := rc.ReopenContainerLog(containerID); err != nil {
+				framework.Logf("Reopening the log of stopped container %q failed: %v", containerID, err)
+				Expect(pathExists(path)).To(BeFalse(), "no log file should be created when reopening the log fails")
+			} else {
+				Expect(pathExists(path)).To(BeTrue(), "a new log file should be created when reopening the log succeeds")
+			}
+			checkRuntimeAlive(rc)
+		})
+
+		It("runtime should support reopening the log of a container without log path [Conformance]", func() {
+			By("create and start a container without log path")
+			containerID := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-reopen-no-log-test-")
+			startContainer(rc, containerID)
+
+			By("reopen the log of the container")
+			if err := rc.ReopenContainerLog(containerID); err != nil {
+				framework.Logf("Reopening the log of container %q without log path failed: %v", containerID, err)
+			}
+			checkRuntimeAlive(rc)
+			Expect(helpers.GetContainerStatus(rc, containerID).State).To(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING),
+				"the container should still be running")
+		})
+
+		It("runtime should return NotFound when reopening the log of an unknown container [Conformance]", func() {
+			err := rc.ReopenContainerLog("critest-unknown-container-" + framework.NewUUID())
+			Expect(err).To(HaveOccurred(), "reopening the log of an unknown container should fail")
+			s, ok := status.FromError(err)
+			Expect(ok && s.Code() == codes.NotFound).To(BeTrue(), "expected NotFound, got %v", err)
+			checkRuntimeAlive(rc)
+		})
+
+		It("runtime should not lose log lines when reopening the log of a container logging heavily [Conformance]", func() {
+			command, err := framework.LogSequenceCommand(reopenLogLines, reopenLogLineSize)
+			framework.ExpectNoError(err, "failed to build the logging command: %v", err)
+
+			By("create and start a container logging sequence-numbered lines")
+			containerName := "container-reopen-heavy-log-test-" + framework.NewUUID()
+			logPath := containerName + ".log"
+			containerID := framework.CreateContainer(rc, ic, &runtimeapi.ContainerConfig{
+				Metadata: framework.BuildContainerMetadata(containerName, framework.DefaultAttempt),
+				Image:    &runtimeapi.ImageSpec{Image: framework.DefaultContainerImage},
+				Command:  command,
+				LogPath:  logPath,
+				Linux:    &runtimeapi.LinuxContainerConfig{},
+			}, podID, podConfig)
+			startContainer(rc, containerID)
+
+			By("rotate and reopen the container log while the container is logging")
+			logPaths := rotateLogWhileRunning(rc, podConfig, containerID, logPath)
+			helpers.WaitContainerExited(rc, containerID)
+
+			By("check no line is lost in the rotated logs")
+			var report framework.LogSequenceReport
+			Eventually(func() bool {
+				report = checkLogSequenceFiles(podConfig, logPaths)
+				return report.OK()
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(BeTrue(),
+				"the rotated logs should hold all lines intact")
+			framework.Logf("Container log rotated %d times: %v", len(logPaths)-1, report)
+		})
+	})
+})
+
+// rotateLogWhileRunning renames and reopens the container log until
+// reopenLogTimes rotations are done or the container exits. It returns the
+// paths, relative to the log directory, of all the logs in writing order.
+func rotateLogWhileRunning(c internalapi.RuntimeService, podConfig *runtimeapi.PodSandboxConfig, containerID, logPath string) []string {
+	var logPaths []string
+	for i := 0; i < reopenLogTimes; i++ {
+		if helpers.GetContainerStatus(c, containerID).State != runtimeapi.ContainerState_CONTAINER_RUNNING {
+			break
+		}
+		rotatedPath := fmt.Sprintf("%s.%d", logPath, i)
+		Expect(os.Rename(filepath.Join(podConfig.LogDirectory, logPath),
+			filepath.Join(podConfig.LogDirectory, rotatedPath))).To(Succeed())
+		logPaths = append(logPaths, rotatedPath)
+
+		if err := c.ReopenContainerLog(containerID); err != nil {
+			// Reopening only fails if the container has exited meanwhile,
+			// and then the log file must not be created.
+			Expect(helpers.GetContainerStatus(c, containerID).State).To(Equal(runtimeapi.ContainerState_CONTAINER_EXITED),
+				"reopening the log of a running container should succeed: %v", err)
+			return logPaths
+		}
+		time.Sleep(reopenLogInterval)
+	}
+	Expect(logPaths).NotTo(BeEmpty(), "the container exited before its log was rotated")
+	return append(logPaths, logPath)
+}
+
+// checkLogSequenceFiles checks the lines logged by the heavy logging
+// container across the logs at logPaths.
+func checkLogSequenceFiles(podConfig *runtimeapi.PodSandboxConfig, logPaths []string) framework.LogSequenceReport {
+	var readers []io.Reader
+	for _, logPath := range logPaths {
+		f, err := os.Open(filepath.Join(podConfig.LogDirectory, logPath))
+		framework.ExpectNoError(err, "failed to open log file: %v", err)
+		defer f.Close()
+		readers = append(readers, f)
+	}
+	report, err := framework.CheckLogSequence(io.MultiReader(readers...), reopenLogLines, reopenLogLineSize)
+	framework.ExpectNoError(err, "failed to read log files: %v", err)
+	return report
+}
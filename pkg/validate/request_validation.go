@@ -0,0 +1,212 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"strings"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	// oversizedLabelKeyLength is far above the 63 characters allowed in the
+	// name of Kubernetes labels.
+	oversizedLabelKeyLength = 1024
+	// oversizedAnnotationsSize is above the 256KB allowed by Kubernetes for
+	// all the annotations of an object.
+	oversizedAnnotationsSize = 512 * 1024
+	// invalidUTF8 is not a valid UTF-8 string.
+	invalidUTF8 = "\xff\xfe"
+)
+
+var _ = framework.KubeDescribe("Request Validation", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	var rc internalapi.RuntimeService
+	var ic internalapi.ImageManagerService
+
+	BeforeEach(func() {
+		rc = f.CRIClient.CRIRuntimeClient
+		ic = f.CRIClient.CRIImageClient
+	})
+
+	Context("runtime should reject invalid PodSandbox configs", func() {
+		for _, tc := range []struct {
+			desc   string
+			mutate func(config *runtimeapi.PodSandboxConfig)
+		}{
+			{"without metadata", func(config *runtimeapi.PodSandboxConfig) {
+				config.Metadata = nil
+			}},
+			{"with an empty name", func(config *runtimeapi.PodSandboxConfig) {
+				config.Metadata.Name = ""
+			}},
+			{"with an oversized label key", func(config *runtimeapi.PodSandboxConfig) {
+				config.Labels = map[string]string{strings.Repeat("a", oversizedLabelKeyLength): "value"}
+			}},
+			{"with oversized annotations", func(config *runtimeapi.PodSandboxConfig) {
+				config.Annotations = map[string]string{"annotation": strings.Repeat("a", oversizedAnnotationsSize)}
+			}},
+			{"with an invalid UTF-8 label value", func(config *runtimeapi.PodSandboxConfig) {
+				config.Labels = map[string]string{"label": invalidUTF8}
+			}},
+		} {
+			tc := tc
+			It("should reject a PodSandbox "+tc.desc, func() {
+				config := buildPodSandboxConfig("PodSandbox-for-validation-test-")
+				uid := config.Metadata.Uid
+				tc.mutate(config)
+
+				By("run the invalid PodSandbox")
+				podID, err := rc.RunPodSandbox(config)
+				if err == nil {
+					rc.StopPodSandbox(podID)
+					rc.RemovePodSandbox(podID)
+				}
+				expectInvalidArgument(err)
+
+				By("check no PodSandbox was created")
+				for _, pod := range listPodSandbox(rc, nil) {
+					Expect(pod.Metadata).NotTo(BeNil(), "PodSandbox %q should have metadata", pod.Id)
+					Expect(pod.Metadata.Uid).NotTo(Equal(uid), "invalid PodSandbox %q should not be created", pod.Id)
+				}
+				checkRuntimeAlive(rc)
+			})
+		}
+	})
+
+	Context("runtime should reject invalid container configs", func() {
+		var podID string
+		var podConfig *runtimeapi.PodSandboxConfig
+		var image string
+
+		BeforeEach(func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+			image = framework.ResolveImage(framework.DefaultContainerImage)
+			if framework.ImageStatus(ic, image) == nil {
+				framework.PullPublicImage(ic, image)
+			}
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+		})
+
+		for _, tc := range []struct {
+			desc   string
+			mutate func(config *runtimeapi.ContainerConfig)
+		}{
+			{"without metadata", func(config *runtimeapi.ContainerConfig) {
+				config.Metadata = nil
+			}},
+			{"with an empty name", func(config *runtimeapi.ContainerConfig) {
+				config.Metadata.Name = ""
+			}},
+			{"without image", func(config *runtimeapi.ContainerConfig) {
+				config.Image = nil
+			}},
+			{"with an empty image", func(config *runtimeapi.ContainerConfig) {
+				config.Image.Image = ""
+			}},
+			{"with a negative memory limit", func(config *runtimeapi.ContainerConfig) {
+				config.Linux.Resources = &runtimeapi.LinuxContainerResources{MemoryLimitInBytes: -2}
+			}},
+			{"with a CPU period below the kernel minimum", func(config *runtimeapi.ContainerConfig) {
+				config.Linux.Resources = &runtimeapi.LinuxContainerResources{CpuPeriod: 1, CpuQuota: 1}
+			}},
+			{"with an out of range OOM score adjustment", func(config *runtimeapi.ContainerConfig) {
+				config.Linux.Resources = &runtimeapi.LinuxContainerResources{OomScoreAdj: 5000}
+			}},
+			{"with a relative mount path", func(config *runtimeapi.ContainerConfig) {
+				config.Mounts = []*runtimeapi.Mount{{ContainerPath: "relative/path", HostPath: "/tmp"}}
+			}},
+			{"with an empty mount path", func(config *runtimeapi.ContainerConfig) {
+				config.Mounts = []*runtimeapi.Mount{{ContainerPath: "", HostPath: "/tmp"}}
+			}},
+			{"with a NUL byte in a mount host path", func(config *runtimeapi.ContainerConfig) {
+				config.Mounts = []*runtimeapi.Mount{{ContainerPath: "/mnt", HostPath: "/tmp\x00invalid"}}
+			}},
+			{"with an oversized label key", func(config *runtimeapi.ContainerConfig) {
+				config.Labels = map[string]string{strings.Repeat("a", oversizedLabelKeyLength): "value"}
+			}},
+			{"with oversized annotations", func(config *runtimeapi.ContainerConfig) {
+				config.Annotations = map[string]string{"annotation": strings.Repeat("a", oversizedAnnotationsSize)}
+			}},
+			{"with an invalid UTF-8 env value", func(config *runtimeapi.ContainerConfig) {
+				config.Envs = []*runtimeapi.KeyValue{{Key: "INVALID", Value: invalidUTF8}}
+			}},
+		} {
+			tc := tc
+			It("should reject a container "+tc.desc, func() {
+				config := &runtimeapi.ContainerConfig{
+					Metadata: framework.BuildContainerMetadata("container-for-validation-test-"+framework.NewUUID(), framework.DefaultAttempt),
+					Image:    &runtimeapi.ImageSpec{Image: image},
+					Command:  []string{"top"},
+					Linux:    &runtimeapi.LinuxContainerConfig{},
+				}
+				tc.mutate(config)
+
+				By("create the invalid container")
+				containerID, err := rc.CreateContainer(podID, config, podConfig)
+				if err == nil {
+					rc.RemoveContainer(containerID)
+				}
+				expectInvalidArgument(err)
+
+				By("check no container was created")
+				containers := listContainers(rc, &runtimeapi.ContainerFilter{PodSandboxId: podID})
+				Expect(containers).To(BeEmpty(), "invalid container should not be created")
+				checkRuntimeAlive(rc)
+			})
+		}
+	})
+})
+
+// buildPodSandboxConfig builds a valid PodSandbox config named after prefix.
+func buildPodSandboxConfig(prefix string) *runtimeapi.PodSandboxConfig {
+	return &runtimeapi.PodSandboxConfig{
+		Metadata: framework.BuildPodSandboxMetadata(prefix+framework.NewUUID(), framework.DefaultUIDPrefix+framework.NewUUID(),
+			framework.DefaultNamespacePrefix+framework.NewUUID(), framework.DefaultAttempt),
+		Linux: &runtimeapi.LinuxPodSandboxConfig{},
+	}
+}
+
+// expectInvalidArgument checks that err is a gRPC InvalidArgument error.
+func expectInvalidArgument(err error) {
+	Expect(err).To(HaveOccurred(), "invalid request should be rejected")
+	s, ok := status.FromError(err)
+	Expect(ok).To(BeTrue(), "error should be a gRPC error: %v", err)
+	Expect(s.Code()).To(Equal(codes.InvalidArgument), "invalid request should be rejected with InvalidArgument: %v", err)
+}
+
+// checkRuntimeAlive checks that the runtime still answers after an invalid
+// request.
+func checkRuntimeAlive(c internalapi.RuntimeService) {
+	By("check the runtime is still running")
+	_, err := c.Status()
+	framework.ExpectNoError(err, "runtime should still answer after an invalid request")
+}
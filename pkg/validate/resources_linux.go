@@ -0,0 +1,220 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework/helpers"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	// testMemoryLimit is the memory limit checked in the container cgroup.
+	testMemoryLimit int64 = 64 * 1024 * 1024
+	// oomMemoryLimit is the memory limit of the containers expected to be
+	// OOM killed.
+	oomMemoryLimit int64 = 32 * 1024 * 1024
+
+	// CPU resources checked in the container cgroup.
+	testCPUPeriod  int64 = 100000
+	testCPUQuota   int64 = 50000
+	testCPUShares  int64 = 512
+	testCpusetCpus       = "0"
+	// cpuMeasurementWindow is the time during which the CPU usage of a busy
+	// loop is measured.
+	cpuMeasurementWindow = 10 * time.Second
+)
+
+var _ = framework.KubeDescribe("Container Resources", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	var rc internalapi.RuntimeService
+	var ic internalapi.ImageManagerService
+
+	BeforeEach(func() {
+		rc = f.CRIClient.CRIRuntimeClient
+		ic = f.CRIClient.CRIImageClient
+	})
+
+	Context("runtime should support memory limit", func() {
+		var podID string
+		var podConfig *runtimeapi.PodSandboxConfig
+
+		BeforeEach(func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+		})
+
+		It("runtime should set the memory limit in the container cgroup", func() {
+			By("create and start a container with a memory limit")
+			containerID := createResourcesContainer(rc, ic, podID, podConfig, "container-for-memory-limit-test-", []string{"top"},
+				&runtimeapi.LinuxContainerResources{MemoryLimitInBytes: testMemoryLimit})
+			testStartContainer(rc, containerID)
+
+			By("check the memory limit in the container cgroup")
+			path := "memory/memory.limit_in_bytes"
+			if isCgroupV2(rc, containerID) {
+				path = "memory.max"
+			}
+			Expect(readCgroupFile(rc, containerID, path)).To(Equal(strconv.FormatInt(testMemoryLimit, 10)))
+		})
+
+		It("runtime should OOM kill a container exceeding its memory limit", func() {
+			By("create and start a container using more memory than its limit")
+			// tail buffers /dev/zero until it finds a newline, which never happens.
+			containerID := createResourcesContainer(rc, ic, podID, podConfig, "container-for-oom-test-", []string{"tail", "/dev/zero"},
+				&runtimeapi.LinuxContainerResources{MemoryLimitInBytes: oomMemoryLimit})
+			startContainer(rc, containerID)
+
+			By("check the container is OOM killed")
+			containerStatus := helpers.WaitContainerExited(rc, containerID)
+			Expect(containerStatus.Reason).To(Equal(reasonOOMKilled), "container should be OOM killed")
+			Expect(containerStatus.ExitCode).To(Equal(exitCodeKilled), "container should be killed by SIGKILL")
+		})
+	})
+
+	Context("runtime should support CPU limits", func() {
+		var podID string
+		var podConfig *runtimeapi.PodSandboxConfig
+
+		BeforeEach(func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+		})
+
+		It("runtime should set the CPU limits in the container cgroup", func() {
+			By("create and start a container with CPU limits")
+			containerID := createResourcesContainer(rc, ic, podID, podConfig, "container-for-cpu-limits-test-", []string{"top"},
+				&runtimeapi.LinuxContainerResources{
+					CpuPeriod:  testCPUPeriod,
+					CpuQuota:   testCPUQuota,
+					CpuShares:  testCPUShares,
+					CpusetCpus: testCpusetCpus,
+				})
+			testStartContainer(rc, containerID)
+
+			By("check the CPU limits in the container cgroup")
+			if isCgroupV2(rc, containerID) {
+				Expect(readCgroupFile(rc, containerID, "cpu.max")).To(Equal(fmt.Sprintf("%d %d", testCPUQuota, testCPUPeriod)))
+				Expect(readCgroupFile(rc, containerID, "cpu.weight")).To(Equal(strconv.FormatInt(cpuSharesToWeight(testCPUShares), 10)))
+				Expect(readCgroupFile(rc, containerID, "cpuset.cpus")).To(Equal(testCpusetCpus))
+			} else {
+				Expect(readCgroupFile(rc, containerID, "cpu/cpu.cfs_quota_us")).To(Equal(strconv.FormatInt(testCPUQuota, 10)))
+				Expect(readCgroupFile(rc, containerID, "cpu/cpu.cfs_period_us")).To(Equal(strconv.FormatInt(testCPUPeriod, 10)))
+				Expect(readCgroupFile(rc, containerID, "cpu/cpu.shares")).To(Equal(strconv.FormatInt(testCPUShares, 10)))
+				Expect(readCgroupFile(rc, containerID, "cpuset/cpuset.cpus")).To(Equal(testCpusetCpus))
+			}
+		})
+
+		It("runtime should throttle a busy loop to the CPU quota", func() {
+			By("create and start a busy loop container with a CPU quota")
+			containerID := createResourcesContainer(rc, ic, podID, podConfig, "container-for-cpu-quota-test-", []string{"sh", "-c", "while true; do :; done"},
+				&runtimeapi.LinuxContainerResources{
+					CpuPeriod: testCPUPeriod,
+					CpuQuota:  testCPUQuota,
+				})
+			testStartContainer(rc, containerID)
+
+			By("measure the CPU usage of the busy loop")
+			v2 := isCgroupV2(rc, containerID)
+			start, startUsage := time.Now(), readCPUUsage(rc, containerID, v2)
+			time.Sleep(cpuMeasurementWindow)
+			elapsed, usage := time.Since(start), readCPUUsage(rc, containerID, v2)-startUsage
+			cores := float64(usage) / float64(elapsed)
+			framework.Logf("Container %q used %.2f cores over %v", containerID, cores, elapsed)
+
+			// The busy loop could use a full core without the quota. It is
+			// allowed to get less than its quota on loaded nodes.
+			quota := float64(testCPUQuota) / float64(testCPUPeriod)
+			Expect(cores).To(BeNumerically("<=", quota*1.2), "busy loop should be throttled to the CPU quota")
+			Expect(cores).To(BeNumerically(">=", quota*0.5), "busy loop should run")
+		})
+	})
+})
+
+// createResourcesContainer creates a container running command with resources.
+func createResourcesContainer(rc internalapi.RuntimeService, ic internalapi.ImageManagerService, podID string, podConfig *runtimeapi.PodSandboxConfig, prefix string, command []string, resources *runtimeapi.LinuxContainerResources) string {
+	containerName := prefix + framework.NewUUID()
+	containerConfig := &runtimeapi.ContainerConfig{
+		Metadata: framework.BuildContainerMetadata(containerName, framework.DefaultAttempt),
+		Image:    &runtimeapi.ImageSpec{Image: framework.DefaultContainerImage},
+		Command:  command,
+		Linux: &runtimeapi.LinuxContainerConfig{
+			Resources: resources,
+		},
+	}
+	return framework.CreateContainer(rc, ic, containerConfig, podID, podConfig)
+}
+
+// readCgroupFile reads a cgroup file of the container, relative to
+// /sys/fs/cgroup.
+func readCgroupFile(c internalapi.RuntimeService, containerID, path string) string {
+	return strings.TrimSpace(execSyncContainer(c, containerID, []string{"cat", "/sys/fs/cgroup/" + path}))
+}
+
+// isCgroupV2 returns whether the container uses the cgroup v2 unified
+// hierarchy.
+func isCgroupV2(c internalapi.RuntimeService, containerID string) bool {
+	_, _, err := c.ExecSync(containerID, []string{"test", "-f", "/sys/fs/cgroup/cgroup.controllers"}, framework.TestContext.ExecTimeout)
+	return err == nil
+}
+
+// readCPUUsage returns the CPU time used by the container.
+func readCPUUsage(c internalapi.RuntimeService, containerID string, v2 bool) time.Duration {
+	if !v2 {
+		usage, err := strconv.ParseInt(readCgroupFile(c, containerID, "cpuacct/cpuacct.usage"), 10, 64)
+		framework.ExpectNoError(err, "failed to parse cpuacct.usage: %v", err)
+		return time.Duration(usage)
+	}
+	for _, line := range strings.Split(readCgroupFile(c, containerID, "cpu.stat"), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "usage_usec" {
+			usage, err := strconv.ParseInt(fields[1], 10, 64)
+			framework.ExpectNoError(err, "failed to parse cpu.stat: %v", err)
+			return time.Duration(usage) * time.Microsecond
+		}
+	}
+	framework.Failf("usage_usec not found in cpu.stat of container %q", containerID)
+	return 0
+}
+
+// cpuSharesToWeight converts CPU shares to the cgroup v2 cpu.weight, the
+// same way as runc.
+func cpuSharesToWeight(shares int64) int64 {
+	return 1 + ((shares-2)*9999)/262142
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package validate
 
 import (
+	"regexp"
+
 	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
 	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
 	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
@@ -29,6 +31,13 @@ const (
 	defaultAPIVersion string = "0.1.0"
 )
 
+var (
+	// semverRegexp matches semantic versions, with an optional "v" prefix.
+	semverRegexp = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+	// apiVersionRegexp matches Kubernetes API versions, e.g. "v1alpha2".
+	apiVersionRegexp = regexp.MustCompile(`^v[1-9]\d*((alpha|beta)[1-9]\d*)?$`)
+)
+
 var _ = framework.KubeDescribe("Runtime info", func() {
 	f := framework.NewDefaultCRIFramework()
 
@@ -52,28 +61,28 @@ var _ = framework.KubeDescribe("Runtime info", func() {
 // TestGetVersion test if we can get runtime version info.
 func TestGetVersion(c internalapi.RuntimeService) {
 	version := getVersion(c)
-	Expect(version.Version).To(Not(BeNil()), "Version should not be nil")
-	Expect(version.RuntimeName).To(Not(BeNil()), "RuntimeName should not be nil")
-	Expect(version.RuntimeVersion).To(Not(BeNil()), "RuntimeVersion should not be nil")
-	Expect(version.RuntimeApiVersion).To(Not(BeNil()), "RuntimeApiVersion should not be nil")
-	framework.Logf("Get version info succeed")
+	Expect(version.Version).NotTo(BeEmpty(), "Version should not be empty")
+	Expect(version.RuntimeName).NotTo(BeEmpty(), "RuntimeName should not be empty")
+	Expect(version.RuntimeVersion).NotTo(BeEmpty(), "RuntimeVersion should not be empty")
+	Expect(version.RuntimeApiVersion).NotTo(BeEmpty(), "RuntimeApiVersion should not be empty")
+	Expect(semverRegexp.MatchString(version.Version)).To(BeTrue(),
+		"Version %q should be a semantic version", version.Version)
+	Expect(semverRegexp.MatchString(version.RuntimeApiVersion) || apiVersionRegexp.MatchString(version.RuntimeApiVersion)).To(BeTrue(),
+		"RuntimeApiVersion %q should be a semantic version or an API version like v1alpha2", version.RuntimeApiVersion)
+	framework.Logf("Get version info succeed: %s %s (API %s)", version.RuntimeName, version.RuntimeVersion, version.RuntimeApiVersion)
 }
 
 // TestGetRuntimeStatus test if we can get runtime status.
 func TestGetRuntimeStatus(c internalapi.RuntimeService) {
-	var count int
 	status, err := c.Status()
 	framework.ExpectNoError(err, "failed to get runtime conditions: %v", err)
 
-	for _, condition := range status.Conditions {
-		if condition.Type == "RuntimeReady" && condition.Status == true {
-			count = count + 1
-		}
-		if condition.Type == "NetworkReady" && condition.Status == true {
-			count = count + 1
-		}
+	for _, conditionType := range []string{runtimeapi.RuntimeReady, runtimeapi.NetworkReady} {
+		condition := framework.GetRuntimeCondition(status, conditionType)
+		Expect(condition).NotTo(BeNil(), "runtime should report the %s condition", conditionType)
+		Expect(condition.Status).To(BeTrue(), "runtime reports %s=false, reason: %q, message: %q",
+			conditionType, condition.Reason, condition.Message)
 	}
-	Expect(count >= 2).To(BeTrue(), "should return all the required runtime conditions")
 }
 
 // getVersion gets runtime version info.
@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework/helpers"
 	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
 	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
 
@@ -73,6 +74,7 @@ var _ = framework.KubeDescribe("Security Context", func() {
 		podSandboxName := "NamespaceOption-PodSandbox-" + framework.NewUUID()
 
 		It("runtime should support HostPID", func() {
+			framework.Tolerate(rc, framework.BehaviorHostNamespaces)
 			By("create podSandbox for security context HostPID")
 			namespaceOption := &runtimeapi.NamespaceOption{
 				Pid:     runtimeapi.NamespaceMode_NODE,
@@ -89,8 +91,8 @@ var _ = framework.KubeDescribe("Security Context", func() {
 			By("start container")
 			startContainer(rc, containerID)
 			Eventually(func() runtimeapi.ContainerState {
-				return getContainerStatus(rc, containerID).State
-			}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+				return helpers.GetContainerStatus(rc, containerID).State
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
 
 			By("get nginx container pid")
 			command := []string{"cat", "/var/run/nginx.pid"}
@@ -107,8 +109,8 @@ var _ = framework.KubeDescribe("Security Context", func() {
 			By("start container")
 			startContainer(rc, containerID)
 			Eventually(func() runtimeapi.ContainerState {
-				return getContainerStatus(rc, containerID).State
-			}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+				return helpers.GetContainerStatus(rc, containerID).State
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
 
 			By("should show its pid in the hostPID namespace container")
 			cmd := []string{"pidof", "nginx", "||", "true"}
@@ -127,6 +129,7 @@ var _ = framework.KubeDescribe("Security Context", func() {
 		})
 
 		It("runtime should support HostIpc is true", func() {
+			framework.Tolerate(rc, framework.BehaviorHostNamespaces)
 			By("create shared memory segment on the host")
 			out, err := exec.Command("ipcmk", "-M", "1048576").Output()
 			framework.ExpectNoError(err, "failed to execute ipcmk -M 1048576")
@@ -150,8 +153,8 @@ var _ = framework.KubeDescribe("Security Context", func() {
 			By("start container")
 			startContainer(rc, containerID)
 			Eventually(func() runtimeapi.ContainerState {
-				return getContainerStatus(rc, containerID).State
-			}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+				return helpers.GetContainerStatus(rc, containerID).State
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
 
 			By("check if the shared memory segment is included in the container")
 			command = []string{"ipcs", "-m"}
@@ -183,8 +186,8 @@ var _ = framework.KubeDescribe("Security Context", func() {
 			By("start container")
 			startContainer(rc, containerID)
 			Eventually(func() runtimeapi.ContainerState {
-				return getContainerStatus(rc, containerID).State
-			}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+				return helpers.GetContainerStatus(rc, containerID).State
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
 
 			By("check if the shared memory segment is not included in the container")
 			command = []string{"ipcs", "-m"}
@@ -208,8 +211,8 @@ var _ = framework.KubeDescribe("Security Context", func() {
 			By("start container")
 			startContainer(rc, containerID)
 			Eventually(func() runtimeapi.ContainerState {
-				return getContainerStatus(rc, containerID).State
-			}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+				return helpers.GetContainerStatus(rc, containerID).State
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
 
 			By("get nginx container pid")
 			command := []string{"cat", "/proc/1/cmdline"}
@@ -233,8 +236,8 @@ var _ = framework.KubeDescribe("Security Context", func() {
 			By("start container")
 			startContainer(rc, containerID)
 			Eventually(func() runtimeapi.ContainerState {
-				return getContainerStatus(rc, containerID).State
-			}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+				return helpers.GetContainerStatus(rc, containerID).State
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
 
 			By("get nginx container pid")
 			command := []string{"cat", "/proc/1/cmdline"}
@@ -243,6 +246,7 @@ var _ = framework.KubeDescribe("Security Context", func() {
 		})
 
 		It("runtime should support HostNetwork is true", func() {
+			framework.Tolerate(rc, framework.BehaviorHostNetwork)
 			srv, err := net.Listen("tcp", ":0")
 			if err != nil {
 				framework.Failf("Failed to listen a tcp port: %v", err)
@@ -308,8 +312,8 @@ var _ = framework.KubeDescribe("Security Context", func() {
 			By("start container")
 			startContainer(rc, containerID)
 			Eventually(func() runtimeapi.ContainerState {
-				return getContainerStatus(rc, containerID).State
-			}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+				return helpers.GetContainerStatus(rc, containerID).State
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
 
 			By("verify SupplementalGroups for container")
 			command := []string{"id", "-G"}
@@ -329,14 +333,37 @@ var _ = framework.KubeDescribe("Security Context", func() {
 			By("start container")
 			startContainer(rc, containerID)
 			Eventually(func() runtimeapi.ContainerState {
-				return getContainerStatus(rc, containerID).State
-			}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+				return helpers.GetContainerStatus(rc, containerID).State
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
 
 			By("verify RunAsUser for container")
 			command := []string{"id", "-u"}
 			verifyExecSyncOutput(rc, containerID, command, expectedLogMessage)
 		})
 
+		It("runtime should run exec as the RunAsUser and RunAsGroup of the container", func() {
+			By("create pod")
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+
+			By("create container for security context RunAsUser and RunAsGroup")
+			containerID := createRunAsUserAndGroupContainer(rc, ic, podID, podConfig, "container-with-exec-user-test-")
+
+			By("start container")
+			startContainer(rc, containerID)
+			Eventually(func() runtimeapi.ContainerState {
+				return helpers.GetContainerStatus(rc, containerID).State
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+
+			By("verify the user and group of exec")
+			verifyExecSyncOutput(rc, containerID, []string{"id", "-u"}, "1001\n")
+			verifyExecSyncOutput(rc, containerID, []string{"id", "-g"}, "1002\n")
+
+			By("verify exec can't write to a directory owned by root")
+			_, stderr, err := rc.ExecSync(containerID, []string{"touch", "/bin/critest-exec-user"}, framework.TestContext.ExecTimeout)
+			Expect(err).To(HaveOccurred(), "exec should not be able to write to a directory owned by root")
+			Expect(string(stderr)).To(ContainSubstring("Permission denied"), "exec should fail with permission denied")
+		})
+
 		It("runtime should support RunAsUserName", func() {
 			By("create pod")
 			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
@@ -347,8 +374,8 @@ var _ = framework.KubeDescribe("Security Context", func() {
 			By("start container")
 			startContainer(rc, containerID)
 			Eventually(func() runtimeapi.ContainerState {
-				return getContainerStatus(rc, containerID).State
-			}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+				return helpers.GetContainerStatus(rc, containerID).State
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
 
 			By("verify RunAsUserName for container")
 			command := []string{"id", "-nu"}
@@ -366,8 +393,8 @@ var _ = framework.KubeDescribe("Security Context", func() {
 			By("start container")
 			startContainer(rc, containerID)
 			Eventually(func() runtimeapi.ContainerState {
-				return getContainerStatus(rc, containerID).State
-			}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_EXITED))
+				return helpers.GetContainerStatus(rc, containerID).State
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_EXITED))
 
 			By("verify RunAsGroup for container")
 			matchContainerOutput(podConfig, containerName, expectedLogMessage)
@@ -408,8 +435,8 @@ var _ = framework.KubeDescribe("Security Context", func() {
 			By("start container")
 			startContainer(rc, containerID)
 			Eventually(func() runtimeapi.ContainerState {
-				return getContainerStatus(rc, containerID).State
-			}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_EXITED))
+				return helpers.GetContainerStatus(rc, containerID).State
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_EXITED))
 
 			By("Check whether rootfs is read-only")
 			checkRootfs(podConfig, logPath, readOnlyRootfs)
@@ -426,8 +453,8 @@ var _ = framework.KubeDescribe("Security Context", func() {
 			By("start container")
 			startContainer(rc, containerID)
 			Eventually(func() runtimeapi.ContainerState {
-				return getContainerStatus(rc, containerID).State
-			}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+				return helpers.GetContainerStatus(rc, containerID).State
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
 
 			By("check the Privileged container")
 			checkNetworkManagement(rc, containerID, isPrivileged)
@@ -444,8 +471,8 @@ var _ = framework.KubeDescribe("Security Context", func() {
 			By("start container")
 			startContainer(rc, containerID)
 			Eventually(func() runtimeapi.ContainerState {
-				return getContainerStatus(rc, containerID).State
-			}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+				return helpers.GetContainerStatus(rc, containerID).State
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
 
 			By("check the Privileged container")
 			checkNetworkManagement(rc, containerID, notPrivileged)
@@ -460,8 +487,8 @@ var _ = framework.KubeDescribe("Security Context", func() {
 
 			startContainer(rc, containerID)
 			Eventually(func() runtimeapi.ContainerState {
-				return getContainerStatus(rc, containerID).State
-			}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+				return helpers.GetContainerStatus(rc, containerID).State
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
 
 			checkNetworkManagement(rc, containerID, true)
 
@@ -470,8 +497,8 @@ var _ = framework.KubeDescribe("Security Context", func() {
 
 			startContainer(rc, containerID)
 			Eventually(func() runtimeapi.ContainerState {
-				return getContainerStatus(rc, containerID).State
-			}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+				return helpers.GetContainerStatus(rc, containerID).State
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
 
 			checkNetworkManagement(rc, containerID, false)
 		})
@@ -569,8 +596,8 @@ var _ = framework.KubeDescribe("Security Context", func() {
 				"unconfined", sysAdminCap, privileged, expectContainerCreateToPass)
 			startContainer(rc, containerID)
 			Eventually(func() runtimeapi.ContainerState {
-				return getContainerStatus(rc, containerID).State
-			}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+				return helpers.GetContainerStatus(rc, containerID).State
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
 			checkSetHostname(rc, containerID, true)
 		})
 
@@ -586,8 +613,8 @@ var _ = framework.KubeDescribe("Security Context", func() {
 				localhost+blockHostNameProfilePath, sysAdminCap, privileged, expectContainerCreateToPass)
 			startContainer(rc, containerID)
 			Eventually(func() runtimeapi.ContainerState {
-				return getContainerStatus(rc, containerID).State
-			}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+				return helpers.GetContainerStatus(rc, containerID).State
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
 			checkSetHostname(rc, containerID, false)
 		})
 
@@ -613,8 +640,8 @@ var _ = framework.KubeDescribe("Security Context", func() {
 				localhost+blockHostNameProfilePath, nil, privileged, expectContainerCreateToPass)
 			startContainer(rc, containerID)
 			Eventually(func() runtimeapi.ContainerState {
-				return getContainerStatus(rc, containerID).State
-			}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+				return helpers.GetContainerStatus(rc, containerID).State
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
 			checkSetHostname(rc, containerID, true)
 		})
 
@@ -640,8 +667,8 @@ var _ = framework.KubeDescribe("Security Context", func() {
 					"container-with-dockerdefault-seccomp-profile-test-", "docker/default", sysAdminCap, privileged, expectContainerCreateToPass)
 				startContainer(rc, containerID)
 				Eventually(func() runtimeapi.ContainerState {
-					return getContainerStatus(rc, containerID).State
-				}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+					return helpers.GetContainerStatus(rc, containerID).State
+				}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
 				checkSetHostname(rc, containerID, true)
 			})
 
@@ -655,8 +682,8 @@ var _ = framework.KubeDescribe("Security Context", func() {
 					"container-with-dockerdefault-seccomp-profile-test-", "docker/default", nil, privileged, expectContainerCreateToPass)
 				startContainer(rc, containerID)
 				Eventually(func() runtimeapi.ContainerState {
-					return getContainerStatus(rc, containerID).State
-				}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+					return helpers.GetContainerStatus(rc, containerID).State
+				}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
 				checkSetHostname(rc, containerID, false)
 			})
 		})
@@ -687,8 +714,8 @@ var _ = framework.KubeDescribe("Security Context", func() {
 			// wait container started and check the status.
 			startContainer(rc, containerID)
 			Eventually(func() runtimeapi.ContainerState {
-				return getContainerStatus(rc, containerID).State
-			}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_EXITED))
+				return helpers.GetContainerStatus(rc, containerID).State
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_EXITED))
 
 			return containerID
 		}
@@ -735,6 +762,25 @@ func createRunAsUserContainer(rc internalapi.RuntimeService, ic internalapi.Imag
 	return framework.CreateContainer(rc, ic, containerConfig, podID, podConfig), expectedLogMessage
 }
 
+// createRunAsUserAndGroupContainer creates a long running container with
+// RunAsUser 1001 and RunAsGroup 1002 in ContainerConfig.
+func createRunAsUserAndGroupContainer(rc internalapi.RuntimeService, ic internalapi.ImageManagerService, podID string, podConfig *runtimeapi.PodSandboxConfig, prefix string) string {
+	By("create a container with RunAsUser and RunAsGroup")
+	containerName := prefix + framework.NewUUID()
+	containerConfig := &runtimeapi.ContainerConfig{
+		Metadata: framework.BuildContainerMetadata(containerName, framework.DefaultAttempt),
+		Image:    &runtimeapi.ImageSpec{Image: framework.DefaultContainerImage},
+		Command:  []string{"sh", "-c", "top"},
+		Linux: &runtimeapi.LinuxContainerConfig{
+			SecurityContext: &runtimeapi.LinuxContainerSecurityContext{
+				RunAsUser:  &runtimeapi.Int64Value{Value: 1001},
+				RunAsGroup: &runtimeapi.Int64Value{Value: 1002},
+			},
+		},
+	}
+	return framework.CreateContainer(rc, ic, containerConfig, podID, podConfig)
+}
+
 // createRunAsUserNameContainer creates the container with specified RunAsUserName in ContainerConfig.
 func createRunAsUserNameContainer(rc internalapi.RuntimeService, ic internalapi.ImageManagerService, podID string, podConfig *runtimeapi.PodSandboxConfig, prefix string) (string, string) {
 	By("create RunAsUserName container")
@@ -912,7 +958,7 @@ func createPrivilegedContainer(rc internalapi.RuntimeService, ic internalapi.Ima
 func checkNetworkManagement(rc internalapi.RuntimeService, containerID string, manageable bool) {
 	cmd := []string{"brctl", "addbr", "foobar"}
 
-	stdout, stderr, err := rc.ExecSync(containerID, cmd, time.Duration(defaultExecSyncTimeout)*time.Second)
+	stdout, stderr, err := rc.ExecSync(containerID, cmd, framework.TestContext.ExecTimeout)
 	msg := fmt.Sprintf("cmd %v, stdout %q, stderr %q", cmd, stdout, stderr)
 
 	if manageable {
@@ -982,7 +1028,7 @@ func createAndCheckHostNetwork(rc internalapi.RuntimeService, ic internalapi.Ima
 			return fmt.Errorf("host port %s should be in container's port list", hostNetworkPort)
 		}
 		return nil
-	}, time.Minute, time.Second).Should(BeNil())
+	}, framework.TestContext.StateTimeout, time.Second).Should(BeNil())
 
 	return podID, podLogDir
 }
@@ -1040,14 +1086,14 @@ func seccompTestContainer(rc internalapi.RuntimeService, ic internalapi.ImageMan
 	By("start container")
 	startContainer(rc, containerID)
 	Eventually(func() runtimeapi.ContainerState {
-		return getContainerStatus(rc, containerID).State
-	}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+		return helpers.GetContainerStatus(rc, containerID).State
+	}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
 
 	return podID, containerID
 }
 
 func verifySeccomp(rc internalapi.RuntimeService, containerID string, command []string, expectError bool, output string) {
-	stdout, stderr, err := rc.ExecSync(containerID, command, time.Duration(defaultExecSyncTimeout)*time.Second)
+	stdout, stderr, err := rc.ExecSync(containerID, command, framework.TestContext.ExecTimeout)
 	msg := fmt.Sprintf("cmd %v, stdout %q, stderr %q, with err: %v", command, stdout, stderr, err)
 
 	if expectError {
@@ -1098,6 +1144,7 @@ func createContainerWithExpectation(rc internalapi.RuntimeService,
 	podConfig *runtimeapi.PodSandboxConfig,
 	expectContainerCreateToPass bool) string {
 	// Pull the image if it does not exist. (don't fail for inability to pull image)
+	config.Image.Image = framework.ResolveImage(config.Image.Image)
 	imageName := config.Image.Image
 	if !strings.Contains(imageName, ":") {
 		imageName = imageName + ":latest"
@@ -1124,7 +1171,7 @@ func checkSetHostname(rc internalapi.RuntimeService, containerID string, setable
 	By("set hostname in container to determine whether sethostname is blocked")
 
 	cmd := []string{"hostname", "ANewHostName"}
-	stdout, stderr, err := rc.ExecSync(containerID, cmd, time.Duration(defaultExecSyncTimeout)*time.Second)
+	stdout, stderr, err := rc.ExecSync(containerID, cmd, framework.TestContext.ExecTimeout)
 	msg := fmt.Sprintf("cmd %v, stdout %q, stderr %q", cmd, stdout, stderr)
 
 	if setable {
@@ -17,9 +17,12 @@ limitations under the License.
 package validate
 
 import (
-	"time"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework/helpers"
 	"github.com/opencontainers/selinux/go-selinux"
 	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
 	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
@@ -84,6 +87,31 @@ var _ = framework.KubeDescribe("SELinux", func() {
 				}
 				_ = createContainerWithSelinux(rc, ic, sandboxID, sandboxConfig, options, false, false)
 			})
+
+			It("should relabel volume with SelinuxRelabel set", func() {
+				By("create host path and flag file")
+				hostPath, _ := helpers.CreateHostPath(sandboxID)
+				defer os.RemoveAll(hostPath) // clean up the TempDir
+
+				By("create container with relabeled volume")
+				containerID := createMountContainer(rc, ic, "selinux-relabel-test-", sandboxID, sandboxConfig, &runtimeapi.Mount{
+					HostPath:       hostPath,
+					ContainerPath:  hostPath,
+					SelinuxRelabel: true,
+				})
+				testStartContainer(rc, containerID)
+
+				By("check the volume is writable in container")
+				execSyncContainer(rc, containerID, []string{"touch", filepath.Join(hostPath, "relabel-test.file")})
+
+				By("check the volume has the same selinux level as the container process")
+				processLabel := strings.TrimRight(execSyncContainer(rc, containerID, []string{"cat", "/proc/self/attr/current"}), "\x00\n")
+				fileLabel, err := selinux.FileLabel(hostPath)
+				framework.ExpectNoError(err, "failed to get selinux label of %q: %v", hostPath, err)
+				framework.Logf("Process label %q, file label %q", processLabel, fileLabel)
+				Expect(selinux.NewContext(fileLabel)["level"]).To(Equal(selinux.NewContext(processLabel)["level"]),
+					"the relabeled volume should have the selinux level of the container")
+			})
 		})
 	}
 })
@@ -119,8 +147,8 @@ func createContainerWithSelinux(rc internalapi.RuntimeService, ic internalapi.Im
 
 	// wait container exited and check the status.
 	Eventually(func() runtimeapi.ContainerState {
-		return getContainerStatus(rc, containerID).State
-	}, time.Minute, time.Second*4).Should(Equal(runtimeapi.ContainerState_CONTAINER_EXITED))
+		return helpers.GetContainerStatus(rc, containerID).State
+	}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(runtimeapi.ContainerState_CONTAINER_EXITED))
 
 	return containerID
 }
@@ -0,0 +1,286 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"fmt"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework/helpers"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// stateMatrixCPUShares are the CPU shares set by the UpdateContainerResources
+// calls of the state matrix.
+const stateMatrixCPUShares int64 = 512
+
+// containerLifecycleState is a state of the container state machine, which
+// adds the removed state to the CRI container states.
+type containerLifecycleState string
+
+const (
+	stateCreated containerLifecycleState = "created"
+	stateRunning containerLifecycleState = "running"
+	stateExited  containerLifecycleState = "exited"
+	stateRemoved containerLifecycleState = "removed"
+)
+
+// transitionExpectation is what the CRI contract expects from an RPC called
+// on a container in a given state.
+type transitionExpectation int
+
+const (
+	// transitionAllowed RPCs must succeed.
+	transitionAllowed transitionExpectation = iota
+	// transitionForbidden RPCs must fail.
+	transitionForbidden
+	// transitionIdempotent RPCs must succeed or return NotFound.
+	transitionIdempotent
+	// transitionUnspecified RPCs may succeed or fail, the result is only
+	// logged.
+	transitionUnspecified
+)
+
+// lifecycleRPC is a container RPC of the state matrix.
+type lifecycleRPC struct {
+	name string
+	call func(c internalapi.RuntimeService, containerID string) error
+	// expectations maps the states to the expected result of the RPC.
+	expectations map[containerLifecycleState]transitionExpectation
+	// next is the state the container must be in after the RPC succeeds,
+	// or an empty state if it depends on the runtime. The state doesn't
+	// change if next is missing.
+	next map[containerLifecycleState]containerLifecycleState
+}
+
+// lifecycleRPCs are the container RPCs tried from every state, with the
+// results expected by the CRI contract.
+var lifecycleRPCs = []lifecycleRPC{
+	{
+		name: "StartContainer",
+		call: func(c internalapi.RuntimeService, containerID string) error {
+			return c.StartContainer(containerID)
+		},
+		expectations: map[containerLifecycleState]transitionExpectation{
+			stateCreated: transitionAllowed,
+			stateRunning: transitionForbidden,
+			stateExited:  transitionForbidden,
+			stateRemoved: transitionForbidden,
+		},
+		next: map[containerLifecycleState]containerLifecycleState{
+			stateCreated: stateRunning,
+		},
+	},
+	{
+		name: "StopContainer",
+		call: func(c internalapi.RuntimeService, containerID string) error {
+			return c.StopContainer(containerID, 0)
+		},
+		expectations: map[containerLifecycleState]transitionExpectation{
+			stateCreated: transitionUnspecified,
+			stateRunning: transitionAllowed,
+			stateExited:  transitionAllowed,
+			stateRemoved: transitionIdempotent,
+		},
+		next: map[containerLifecycleState]containerLifecycleState{
+			// Runtimes either leave created containers as they are or
+			// mark them exited.
+			stateCreated: "",
+			stateRunning: stateExited,
+		},
+	},
+	{
+		name: "RemoveContainer",
+		call: func(c internalapi.RuntimeService, containerID string) error {
+			return c.RemoveContainer(containerID)
+		},
+		expectations: map[containerLifecycleState]transitionExpectation{
+			stateCreated: transitionAllowed,
+			// Running containers must be forcibly removed.
+			stateRunning: transitionAllowed,
+			stateExited:  transitionAllowed,
+			stateRemoved: transitionIdempotent,
+		},
+		next: map[containerLifecycleState]containerLifecycleState{
+			stateCreated: stateRemoved,
+			stateRunning: stateRemoved,
+			stateExited:  stateRemoved,
+		},
+	},
+	{
+		name: "ContainerStatus",
+		call: func(c internalapi.RuntimeService, containerID string) error {
+			_, err := c.ContainerStatus(containerID)
+			return err
+		},
+		expectations: map[containerLifecycleState]transitionExpectation{
+			stateCreated: transitionAllowed,
+			stateRunning: transitionAllowed,
+			stateExited:  transitionAllowed,
+			stateRemoved: transitionForbidden,
+		},
+	},
+	{
+		name: "ExecSync",
+		call: func(c internalapi.RuntimeService, containerID string) error {
+			_, _, err := c.ExecSync(containerID, []string{"true"}, framework.TestContext.ExecTimeout)
+			return err
+		},
+		expectations: map[containerLifecycleState]transitionExpectation{
+			stateCreated: transitionForbidden,
+			stateRunning: transitionAllowed,
+			stateExited:  transitionForbidden,
+			stateRemoved: transitionForbidden,
+		},
+	},
+	{
+		name: "UpdateContainerResources",
+		call: func(c internalapi.RuntimeService, containerID string) error {
+			return c.UpdateContainerResources(containerID, &runtimeapi.LinuxContainerResources{CpuShares: stateMatrixCPUShares})
+		},
+		expectations: map[containerLifecycleState]transitionExpectation{
+			stateCreated: transitionUnspecified,
+			stateRunning: transitionAllowed,
+			stateExited:  transitionUnspecified,
+			stateRemoved: transitionForbidden,
+		},
+	},
+	{
+		name: "ReopenContainerLog",
+		call: func(c internalapi.RuntimeService, containerID string) error {
+			return c.ReopenContainerLog(containerID)
+		},
+		expectations: map[containerLifecycleState]transitionExpectation{
+			stateCreated: transitionUnspecified,
+			// The containers of the matrix have no log path, running
+			// containers with one are covered by the ReopenContainerLog
+			// tests.
+			stateRunning: transitionUnspecified,
+			stateExited:  transitionUnspecified,
+			stateRemoved: transitionForbidden,
+		},
+	},
+}
+
+var _ = framework.KubeDescribe("Container", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	var rc internalapi.RuntimeService
+	var ic internalapi.ImageManagerService
+
+	BeforeEach(func() {
+		rc = f.CRIClient.CRIRuntimeClient
+		ic = f.CRIClient.CRIImageClient
+	})
+
+	Context("runtime should follow the container state machine", func() {
+		var podID string
+		var podConfig *runtimeapi.PodSandboxConfig
+
+		BeforeEach(func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+		})
+
+		for _, rpc := range lifecycleRPCs {
+			for _, state := range []containerLifecycleState{stateCreated, stateRunning, stateExited, stateRemoved} {
+				rpc, state := rpc, state
+				expectation := rpc.expectations[state]
+				It(fmt.Sprintf("runtime should handle %s on a %s container", rpc.name, state), func() {
+					By(fmt.Sprintf("create a %s container", state))
+					containerID := createContainerInState(rc, ic, podID, podConfig, state)
+
+					By(fmt.Sprintf("call %s on the %s container", rpc.name, state))
+					err := rpc.call(rc, containerID)
+					framework.Logf("%s on a %s container returned: %v", rpc.name, state, err)
+					switch expectation {
+					case transitionAllowed:
+						framework.ExpectNoError(err, "%s should succeed on a %s container: %v", rpc.name, state, err)
+					case transitionForbidden:
+						Expect(err).To(HaveOccurred(), "%s should fail on a %s container", rpc.name, state)
+					case transitionIdempotent:
+						Expect(isNilOrNotFound(err)).To(BeTrue(), "%s should succeed or return NotFound on a %s container, got %v", rpc.name, state, err)
+					case transitionUnspecified:
+						return
+					}
+					if err != nil {
+						return
+					}
+
+					next, ok := rpc.next[state]
+					if !ok {
+						next = state
+					}
+					if next == "" {
+						return
+					}
+					By(fmt.Sprintf("check the container is %s", next))
+					Eventually(func() containerLifecycleState {
+						return getContainerLifecycleState(rc, containerID)
+					}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(Equal(next))
+				})
+			}
+		}
+	})
+})
+
+// createContainerInState creates a container and brings it to state.
+func createContainerInState(rc internalapi.RuntimeService, ic internalapi.ImageManagerService, podID string, podConfig *runtimeapi.PodSandboxConfig, state containerLifecycleState) string {
+	containerID := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-for-state-matrix-test-")
+	switch state {
+	case stateRunning:
+		testStartContainer(rc, containerID)
+	case stateExited:
+		testStartContainer(rc, containerID)
+		helpers.StopContainer(rc, containerID, 0)
+		helpers.WaitContainerExited(rc, containerID)
+	case stateRemoved:
+		removeContainer(rc, containerID)
+	}
+	return containerID
+}
+
+// getContainerLifecycleState returns the state of the container, removed if
+// its status isn't found.
+func getContainerLifecycleState(c internalapi.RuntimeService, containerID string) containerLifecycleState {
+	containerStatus, err := c.ContainerStatus(containerID)
+	if s, ok := status.FromError(err); ok && s.Code() == codes.NotFound {
+		return stateRemoved
+	}
+	framework.ExpectNoError(err, "failed to get container %q status: %v", containerID, err)
+	switch containerStatus.State {
+	case runtimeapi.ContainerState_CONTAINER_CREATED:
+		return stateCreated
+	case runtimeapi.ContainerState_CONTAINER_RUNNING:
+		return stateRunning
+	case runtimeapi.ContainerState_CONTAINER_EXITED:
+		return stateExited
+	}
+	return containerLifecycleState(containerStatus.State.String())
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	// statsMemoryFill is the memory held by the container of the memory
+	// stats test, so that it can't be mistaken for an idle cgroup.
+	statsMemoryFill = 32 * 1024 * 1024
+	// statsMemoryTolerance is the absolute difference tolerated between the
+	// working set reported in the stats and computed from the cgroup, on
+	// top of statsRelativeTolerance.
+	statsMemoryTolerance = 8 * 1024 * 1024
+	// statsCPUTolerance is the absolute difference tolerated between the CPU
+	// usage reported in the stats and read from the cgroup, on top of
+	// statsRelativeTolerance.
+	statsCPUTolerance = 500 * time.Millisecond
+	// statsRelativeTolerance is the relative difference tolerated between
+	// the stats and the cgroup values, which are sampled at different times.
+	statsRelativeTolerance = 0.1
+)
+
+var _ = framework.RequireCapabilities("Container Stats", framework.CapabilityStats)
+
+var _ = framework.KubeDescribe("Container Stats", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	var rc internalapi.RuntimeService
+	var ic internalapi.ImageManagerService
+
+	BeforeEach(func() {
+		rc = f.CRIClient.CRIRuntimeClient
+		ic = f.CRIClient.CRIImageClient
+		framework.SkipUnlessCapable(rc, framework.CapabilityStats)
+	})
+
+	Context("runtime should report the stats of the container cgroup", func() {
+		var podID string
+		var podConfig *runtimeapi.PodSandboxConfig
+
+		BeforeEach(func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+		})
+
+		It("runtime should report the memory working set of the container cgroup", func() {
+			By("create and start a container holding memory")
+			command := []string{"sh", "-c", "dd if=/dev/zero of=/dev/shm/fill bs=1M count=" +
+				strconv.Itoa(statsMemoryFill/(1024*1024)) + " && top"}
+			containerID := createResourcesContainer(rc, ic, podID, podConfig, "container-for-memory-stats-test-", command, nil)
+			testStartContainer(rc, containerID)
+			Eventually(func() string {
+				return execSyncContainer(rc, containerID, []string{"ls", "/dev/shm"})
+			}, framework.TestContext.StateTimeout, framework.TestContext.PollInterval).Should(ContainSubstring("fill"))
+
+			By("compare the working set in the stats with the container cgroup")
+			v2 := isCgroupV2(rc, containerID)
+			before := readWorkingSet(rc, containerID, v2)
+			stats := getContainerStats(rc, containerID)
+			after := readWorkingSet(rc, containerID, v2)
+			Expect(stats.GetMemory().GetWorkingSetBytes()).NotTo(BeNil(), "memory working set should be reported")
+			workingSet := stats.GetMemory().GetWorkingSetBytes().GetValue()
+			framework.Logf("Container %q working set: stats %d, cgroup %d-%d", containerID, workingSet, before, after)
+
+			low, high := toleratedRange(float64(before), float64(after), statsMemoryTolerance)
+			Expect(float64(workingSet)).To(BeNumerically(">=", low), "working set should match the container cgroup")
+			Expect(float64(workingSet)).To(BeNumerically("<=", high), "working set should match the container cgroup")
+		})
+
+		It("runtime should report the CPU usage of the container cgroup", func() {
+			By("create and start a busy loop container")
+			containerID := createResourcesContainer(rc, ic, podID, podConfig, "container-for-cpu-stats-test-",
+				[]string{"sh", "-c", "while true; do :; done"}, nil)
+			testStartContainer(rc, containerID)
+			time.Sleep(time.Second)
+
+			By("compare the CPU usage in the stats with the container cgroup")
+			v2 := isCgroupV2(rc, containerID)
+			before := readCPUUsage(rc, containerID, v2)
+			stats := getContainerStats(rc, containerID)
+			after := readCPUUsage(rc, containerID, v2)
+			Expect(stats.GetCpu().GetUsageCoreNanoSeconds()).NotTo(BeNil(), "CPU usage should be reported")
+			usage := time.Duration(stats.GetCpu().GetUsageCoreNanoSeconds().GetValue())
+			framework.Logf("Container %q CPU usage: stats %v, cgroup %v-%v", containerID, usage, before, after)
+
+			low, high := toleratedRange(float64(before), float64(after), float64(statsCPUTolerance))
+			Expect(float64(usage)).To(BeNumerically(">=", low), "CPU usage should match the container cgroup")
+			Expect(float64(usage)).To(BeNumerically("<=", high), "CPU usage should match the container cgroup")
+		})
+	})
+})
+
+// getContainerStats gets the stats of the container.
+func getContainerStats(c internalapi.RuntimeService, containerID string) *runtimeapi.ContainerStats {
+	By("Get container stats for containerID: " + containerID)
+	stats, err := c.ContainerStats(containerID)
+	framework.ExpectNoError(err, "failed to get container %q stats: %v", containerID, err)
+	return stats
+}
+
+// readWorkingSet computes the memory working set of the container from its
+// cgroup the same way as cAdvisor: the usage minus the inactive file pages.
+func readWorkingSet(c internalapi.RuntimeService, containerID string, v2 bool) uint64 {
+	usageFile, statFile, inactiveKey := "memory/memory.usage_in_bytes", "memory/memory.stat", "total_inactive_file"
+	if v2 {
+		usageFile, statFile, inactiveKey = "memory.current", "memory.stat", "inactive_file"
+	}
+	usage, err := strconv.ParseUint(readCgroupFile(c, containerID, usageFile), 10, 64)
+	framework.ExpectNoError(err, "failed to parse %s: %v", usageFile, err)
+	for _, line := range strings.Split(readCgroupFile(c, containerID, statFile), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == inactiveKey {
+			inactive, err := strconv.ParseUint(fields[1], 10, 64)
+			framework.ExpectNoError(err, "failed to parse %s: %v", statFile, err)
+			if inactive > usage {
+				return 0
+			}
+			return usage - inactive
+		}
+	}
+	framework.Failf("%s not found in %s of container %q", inactiveKey, statFile, containerID)
+	return 0
+}
+
+// toleratedRange returns the range of values tolerated for a stat sampled
+// between two cgroup readings.
+func toleratedRange(before, after, tolerance float64) (float64, float64) {
+	low, high := before, after
+	if low > high {
+		low, high = high, low
+	}
+	return low*(1-statsRelativeTolerance) - tolerance, high*(1+statsRelativeTolerance) + tolerance
+}
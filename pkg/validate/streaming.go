@@ -27,6 +27,8 @@ import (
 	"time"
 
 	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework/helpers"
+	"github.com/kubernetes-sigs/cri-tools/pkg/streaming"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/portforward"
 	remoteclient "k8s.io/client-go/tools/remotecommand"
@@ -43,6 +45,8 @@ const (
 	defaultStreamServerScheme  string = "http"
 )
 
+var _ = framework.RequireCapabilities("Streaming", framework.CapabilityStreaming)
+
 var _ = framework.KubeDescribe("Streaming", func() {
 	f := framework.NewDefaultCRIFramework()
 
@@ -52,6 +56,7 @@ var _ = framework.KubeDescribe("Streaming", func() {
 	BeforeEach(func() {
 		rc = f.CRIClient.CRIRuntimeClient
 		ic = f.CRIClient.CRIImageClient
+		framework.SkipUnlessCapable(rc, framework.CapabilityStreaming)
 	})
 
 	Context("runtime should support streaming interfaces", func() {
@@ -123,6 +128,70 @@ var _ = framework.KubeDescribe("Streaming", func() {
 			checkAttach(rc, req)
 		})
 
+		It("runtime should close stdin after the first attach with stdinOnce [Conformance]", func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+
+			By("create a shell container with stdinOnce")
+			containerID := createShellContainer(rc, ic, podID, podConfig, "container-for-stdin-once-test")
+
+			By("start container")
+			startContainer(rc, containerID)
+
+			req := createDefaultAttach(rc, containerID)
+
+			By("run a command through the stdin of attach and detach")
+			checkAttach(rc, req)
+
+			By("check the container exits once its stdin is closed")
+			containerStatus := helpers.WaitContainerExited(rc, containerID)
+			Expect(containerStatus.ExitCode).To(BeZero(), "shell should exit successfully at the end of its input")
+		})
+
+		It("runtime should resize the tty of exec", func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+
+			By("create a default container")
+			containerID := framework.CreateDefaultContainer(rc, ic, podID, podConfig, "container-for-exec-resize-test")
+
+			By("start container")
+			startContainer(rc, containerID)
+
+			execReq := &runtimeapi.ExecRequest{
+				ContainerId: containerID,
+				Cmd:         []string{"sh"},
+				Stdout:      true,
+				Tty:         true,
+				Stdin:       true,
+			}
+			req := createExec(rc, execReq)
+
+			By("check the terminal size seen by exec follows the resizes")
+			checkTTYResize(rc, req)
+		})
+
+		It("runtime should resize the tty of attach", func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+
+			By("create a shell container with a tty")
+			containerID := createTTYShellContainer(rc, ic, podID, podConfig, "container-for-attach-resize-test")
+
+			By("start container")
+			startContainer(rc, containerID)
+
+			By("attach container: " + containerID)
+			resp, err := rc.Attach(&runtimeapi.AttachRequest{
+				ContainerId: containerID,
+				Stdin:       true,
+				Stdout:      true,
+				Tty:         true,
+			})
+			framework.ExpectNoError(err, "failed to attach in container %q", containerID)
+			framework.Logf("Get attach url: " + resp.Url)
+
+			By("check the terminal size seen by attach follows the resizes")
+			checkTTYResize(rc, resp.Url)
+		})
+
 		It("runtime should support portforward [Conformance]", func() {
 			By("create a PodSandbox with container port port mapping")
 			var podConfig *runtimeapi.PodSandboxConfig
@@ -200,7 +269,7 @@ func checkExec(c internalapi.RuntimeService, execServerURL, stdout string, isTty
 	// Only http is supported now.
 	// TODO: support streaming APIs via tls.
 	url := parseURL(c, execServerURL)
-	e, err := remoteclient.NewSPDYExecutor(&rest.Config{TLSClientConfig: rest.TLSClientConfig{Insecure: true}}, "POST", url)
+	e, err := newExecutor(url)
 	framework.ExpectNoError(err, "failed to create executor for %q", execServerURL)
 
 	streamOptions := remoteclient.StreamOptions{
@@ -220,6 +289,16 @@ func checkExec(c internalapi.RuntimeService, execServerURL, stdout string, isTty
 	framework.Logf("Check exec url %q succeed", execServerURL)
 }
 
+// newExecutor creates an executor for url using the streaming protocol
+// selected by --streaming-protocol.
+func newExecutor(url *url.URL) (remoteclient.Executor, error) {
+	protocol, err := streaming.ParseProtocol(framework.TestContext.StreamingProtocol)
+	if err != nil {
+		return nil, err
+	}
+	return streaming.NewExecutor(url, protocol)
+}
+
 func parseURL(c internalapi.RuntimeService, serverURL string) *url.URL {
 	url, err := url.Parse(serverURL)
 	framework.ExpectNoError(err, "failed to parse url:  %q", serverURL)
@@ -287,7 +366,7 @@ func checkAttach(c internalapi.RuntimeService, attachServerURL string) {
 		writer.Write([]byte("echo hello\n"))
 		Eventually(func() string {
 			return localOut.String()
-		}, time.Minute, time.Second).Should(Equal("hello\n"), "The stdout of attach should be hello")
+		}, framework.TestContext.StateTimeout, time.Second).Should(Equal("hello\n"), "The stdout of attach should be hello")
 		Consistently(func() string {
 			return localOut.String()
 		}, 3*time.Second, time.Second).Should(Equal("hello\n"), "The stdout of attach should not contain other things")
@@ -296,7 +375,7 @@ func checkAttach(c internalapi.RuntimeService, attachServerURL string) {
 	// Only http is supported now.
 	// TODO: support streaming APIs via tls.
 	url := parseURL(c, attachServerURL)
-	e, err := remoteclient.NewSPDYExecutor(&rest.Config{TLSClientConfig: rest.TLSClientConfig{Insecure: true}}, "POST", url)
+	e, err := newExecutor(url)
 	framework.ExpectNoError(err, "failed to create executor for %q", attachServerURL)
 
 	err = e.Stream(remoteclient.StreamOptions{
@@ -311,6 +390,103 @@ func checkAttach(c internalapi.RuntimeService, attachServerURL string) {
 	framework.Logf("Check attach url %q succeed", attachServerURL)
 }
 
+// ttyResizes are the terminal sizes sent by checkTTYResize, in order.
+var ttyResizes = []remoteclient.TerminalSize{
+	{Width: 100, Height: 30},
+	{Width: 132, Height: 43},
+}
+
+// terminalSizeQueue is a remoteclient.TerminalSizeQueue handing out the sizes
+// sent to it, until it is closed.
+type terminalSizeQueue chan remoteclient.TerminalSize
+
+// Next returns the next terminal size, nil once the queue is closed.
+func (q terminalSizeQueue) Next() *remoteclient.TerminalSize {
+	size, ok := <-q
+	if !ok {
+		return nil
+	}
+	return &size
+}
+
+// createTTYShellContainer creates a shell container with a tty, to be
+// attached to.
+func createTTYShellContainer(rc internalapi.RuntimeService, ic internalapi.ImageManagerService, podID string, podConfig *runtimeapi.PodSandboxConfig, prefix string) string {
+	containerName := prefix + framework.NewUUID()
+	containerConfig := &runtimeapi.ContainerConfig{
+		Metadata: framework.BuildContainerMetadata(containerName, framework.DefaultAttempt),
+		Image:    &runtimeapi.ImageSpec{Image: framework.DefaultContainerImage},
+		Command:  []string{"/bin/sh"},
+		Linux:    &runtimeapi.LinuxContainerConfig{},
+		Stdin:    true,
+		Tty:      true,
+	}
+
+	return framework.CreateContainer(rc, ic, containerConfig, podID, podConfig)
+}
+
+// checkTTYResize streams the interactive tty shell of serverURL, an exec or
+// attach url, and checks that `stty size` in the shell reports each of
+// ttyResizes once it was sent. The stream runs in the background, so that all
+// the checks are made in the spec goroutine.
+func checkTTYResize(c internalapi.RuntimeService, serverURL string) {
+	// Only http is supported now.
+	// TODO: support streaming APIs via tls.
+	url := parseURL(c, serverURL)
+	e, err := newExecutor(url)
+	framework.ExpectNoError(err, "failed to create executor for %q", serverURL)
+
+	localOut := &safeBuffer{buffer: bytes.Buffer{}}
+	reader, writer := io.Pipe()
+	defer reader.Close()
+	defer writer.Close()
+	sizes := make(terminalSizeQueue, 1)
+	defer close(sizes)
+	// done is closed once Stream returned streamErr. The reader is closed
+	// with it, so that writing to a stream which has ended doesn't block.
+	done := make(chan struct{})
+	var streamErr error
+	go func() {
+		defer close(done)
+		defer reader.Close()
+		streamErr = e.Stream(remoteclient.StreamOptions{
+			Stdin:             reader,
+			Stdout:            localOut,
+			Tty:               true,
+			TerminalSizeQueue: sizes,
+		})
+	}()
+	streamEnded := func() {
+		framework.ExpectNoError(streamErr, "failed to open streamer for %q", serverURL)
+		Fail(fmt.Sprintf("The stream of %q ended before the terminal was resized", serverURL))
+	}
+
+	for _, size := range ttyResizes {
+		select {
+		case sizes <- size:
+		case <-done:
+			streamEnded()
+		}
+		// The resize is applied asynchronously to the input of the shell, so
+		// stty is run until it reports the new size.
+		expected := fmt.Sprintf("%d %d", size.Height, size.Width)
+		Eventually(func() string {
+			select {
+			case <-done:
+				streamEnded()
+			default:
+			}
+			writer.Write([]byte("stty size\n"))
+			return localOut.String()
+		}, framework.TestContext.StateTimeout, time.Second).Should(ContainSubstring(expected), "The terminal size should be resized to %dx%d", size.Width, size.Height)
+	}
+	writer.Write([]byte("exit\n"))
+
+	Eventually(done, framework.TestContext.StateTimeout).Should(BeClosed(), "The stream of %q should end after exit", serverURL)
+	framework.ExpectNoError(streamErr, "failed to open streamer for %q", serverURL)
+	framework.Logf("Check tty resize of url %q succeed", serverURL)
+}
+
 func createDefaultPortForward(c internalapi.RuntimeService, podID string) string {
 	By("port forward PodSandbox: " + podID)
 	req := &runtimeapi.PortForwardRequest{
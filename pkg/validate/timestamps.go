@@ -0,0 +1,143 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"time"
+
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework"
+	"github.com/kubernetes-sigs/cri-tools/pkg/framework/helpers"
+	internalapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// timestampStatusCalls is the number of status calls checked to report the
+// same timestamps.
+const timestampStatusCalls = 3
+
+var _ = framework.KubeDescribe("Container", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	var rc internalapi.RuntimeService
+	var ic internalapi.ImageManagerService
+
+	BeforeEach(func() {
+		rc = f.CRIClient.CRIRuntimeClient
+		ic = f.CRIClient.CRIImageClient
+	})
+
+	Context("runtime should report the timestamps of containers", func() {
+		var podID string
+		var podConfig *runtimeapi.PodSandboxConfig
+
+		BeforeEach(func() {
+			podID, podConfig = framework.CreatePodSandboxForContainer(rc)
+		})
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+		})
+
+		It("runtime should report ordered timestamps of the container lifecycle [Conformance]", func() {
+			skew := framework.TestContext.MaxClockSkew
+			// The image is pulled first not to count the pull in the
+			// creation of the container.
+			framework.PullImageIfNotPresent(ic, framework.DefaultContainerImage)
+
+			By("create a container")
+			beforeCreate := time.Now()
+			containerID := createCommandContainer(rc, ic, podID, podConfig, "container-for-timestamps-test-", []string{"sh", "-c", "sleep 1"})
+			afterCreate := time.Now()
+			createdAt := framework.Timestamp{Name: "CreatedAt", Nanos: helpers.GetContainerStatus(rc, containerID).CreatedAt}
+			framework.ExpectNoError(framework.CheckTimestamp(createdAt, beforeCreate, afterCreate, skew), "invalid container timestamp")
+			Expect(helpers.GetContainerStatus(rc, containerID).StartedAt).To(BeZero(), "StartedAt should not be set before the container is started")
+
+			By("start the container")
+			startContainer(rc, containerID)
+			afterStart := time.Now()
+			startedAt := framework.Timestamp{Name: "StartedAt", Nanos: helpers.GetContainerStatus(rc, containerID).StartedAt}
+			framework.ExpectNoError(framework.CheckTimestamp(startedAt, afterCreate, afterStart, skew), "invalid container timestamp")
+
+			By("wait for the container to exit")
+			containerStatus := helpers.WaitContainerExited(rc, containerID)
+			afterExit := time.Now()
+			finishedAt := framework.Timestamp{Name: "FinishedAt", Nanos: containerStatus.FinishedAt}
+			framework.ExpectNoError(framework.CheckTimestamp(finishedAt, afterStart, afterExit, skew), "invalid container timestamp")
+
+			By("check the timestamps are ordered and stable")
+			createdAt.Nanos = containerStatus.CreatedAt
+			startedAt.Nanos = containerStatus.StartedAt
+			framework.ExpectNoError(framework.CheckTimestampOrder(createdAt, startedAt, finishedAt), "container timestamps should be ordered")
+			for i := 0; i < timestampStatusCalls; i++ {
+				status := helpers.GetContainerStatus(rc, containerID)
+				Expect([]int64{status.CreatedAt, status.StartedAt, status.FinishedAt}).To(Equal([]int64{createdAt.Nanos, startedAt.Nanos, finishedAt.Nanos}),
+					"container timestamps should not change once the container exited")
+			}
+			containers := listContainerForID(rc, containerID)
+			Expect(containers).To(HaveLen(1), "container should be listed")
+			Expect(containers[0].CreatedAt).To(Equal(createdAt.Nanos), "listed CreatedAt should be the one of the status")
+		})
+	})
+})
+
+var _ = framework.KubeDescribe("PodSandbox", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	var rc internalapi.RuntimeService
+
+	BeforeEach(func() {
+		rc = f.CRIClient.CRIRuntimeClient
+	})
+
+	Context("runtime should report the timestamps of PodSandbox", func() {
+		var podID string
+
+		AfterEach(func() {
+			By("stop PodSandbox")
+			rc.StopPodSandbox(podID)
+			By("delete PodSandbox")
+			rc.RemovePodSandbox(podID)
+		})
+
+		It("runtime should report a stable creation timestamp of PodSandbox [Conformance]", func() {
+			By("run a PodSandbox")
+			beforeRun := time.Now()
+			podID = framework.RunDefaultPodSandbox(rc, "PodSandbox-for-timestamps-test-")
+			afterRun := time.Now()
+			createdAt := framework.Timestamp{Name: "CreatedAt", Nanos: getPodSandboxStatus(rc, podID).CreatedAt}
+			framework.ExpectNoError(framework.CheckTimestamp(createdAt, beforeRun, afterRun, framework.TestContext.MaxClockSkew), "invalid PodSandbox timestamp")
+
+			By("check the creation timestamp is stable")
+			for i := 0; i < timestampStatusCalls; i++ {
+				Expect(getPodSandboxStatus(rc, podID).CreatedAt).To(Equal(createdAt.Nanos), "PodSandbox CreatedAt should not change")
+			}
+			pods := listPodSanboxForID(rc, podID)
+			Expect(pods).To(HaveLen(1), "PodSandbox should be listed")
+			Expect(pods[0].CreatedAt).To(Equal(createdAt.Nanos), "listed CreatedAt should be the one of the status")
+
+			By("check the creation timestamp is kept once the PodSandbox is stopped")
+			stopPodSandbox(rc, podID)
+			Expect(getPodSandboxStatus(rc, podID).CreatedAt).To(Equal(createdAt.Nanos), "PodSandbox CreatedAt should not change once stopped")
+		})
+	})
+})